@@ -1,61 +1,269 @@
 package minstance
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/graingo/maltose/internal/intlog"
+)
+
+// IShutdown is implemented by the instances shut down with a context, like servers, see RemoveE.
+type IShutdown interface {
+	Shutdown(ctx context.Context) error
+}
 
 // Container is a singleton container.
+// It is safe for concurrent use: the instances are spread over shards locked separately,
+// so that the accesses to different instances do not contend, and the reads of an instance
+// only take a read lock.
 type Container struct {
-	instances map[string]any
-	mu        sync.RWMutex
+	shards    [shardCount]*shard
+	sliding   bool     // whether accessing an entry refreshes its TTL, see WithSlidingExpiration
+	janitor   *janitor // evicting the expired entries, see StartJanitor
+	janitorMu sync.Mutex
 }
 
-func New() *Container {
-	return &Container{
-		instances: make(map[string]any),
+// flight is the creation of an instance, whose result is shared by the concurrent callers.
+type flight struct {
+	done     chan struct{}
+	instance any
+	err      error
+}
+
+// Option is an option of the container, see New.
+type Option func(c *Container)
+
+// New creates a container, see GetOrSet to get its instances with their type.
+func New(options ...Option) *Container {
+	c := &Container{shards: newShards()}
+	for _, option := range options {
+		option(c)
 	}
+	return c
 }
 
 // Get gets the existing instance.
 func (c *Container) Get(name string) any {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.instances[name]
+	instance, _ := c.get(name)
+	return instance
 }
 
 // Set sets the instance.
 func (c *Container) Set(name string, instance any) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.instances[name] = instance
+	c.SetWithTTL(name, instance, 0)
 }
 
 // GetOrSetFunc gets the instance, if it does not exist, it will be created by the function.
 func (c *Container) GetOrSetFunc(name string, fn func() any) any {
+	instance, _ := c.GetOrSetFuncE(name, func() (any, error) {
+		return fn(), nil
+	})
+	return instance
+}
+
+// GetOrSetFuncE is GetOrSetFunc for the constructors which can fail, like those opening files:
+// if `fn` returns an error, nothing is stored and the error is returned, so the next call creates
+// the instance again. Concurrent callers of a failing creation get an error, never a nil instance.
+//
+// An instance is created once: the concurrent callers for the same name wait for the creation
+// and get its result, while those for other names are not blocked, so `fn` can get the other
+// instances of the container, but not the instance `name` itself. The changes of the instance `name`,
+// like Set, Replace or Remove, wait for its creation, so they are ordered with it.
+func (c *Container) GetOrSetFuncE(name string, fn func() (any, error)) (any, error) {
+	return c.GetOrSetFuncTTL(name, 0, fn)
+}
+
+// GetOrSetFuncTTL is GetOrSetFuncE for the instances expiring `ttl` after their creation, see SetWithTTL.
+// An expired instance is evicted and created again like a missing one, once for the concurrent callers.
+func (c *Container) GetOrSetFuncTTL(name string, ttl time.Duration, fn func() (any, error)) (any, error) {
 	// try to get the instance
-	c.mu.RLock()
-	if instance, ok := c.instances[name]; ok {
-		c.mu.RUnlock()
-		return instance
+	if instance, ok := c.get(name); ok {
+		return instance, nil
 	}
-	c.mu.RUnlock()
 
-	// add write lock to create
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	// double check, and wait for the creation in progress if any
+	s := c.shard(name)
+	s.mu.Lock()
+	var expired *entry
+	if e, ok := s.instances[name]; ok {
+		if !e.expired() {
+			s.mu.Unlock()
+			return e.access(c.sliding), nil
+		}
+		expired = e
+		delete(s.instances, name)
+	}
+	if f, ok := s.flights[name]; ok {
+		s.mu.Unlock()
+		<-f.done
+		return f.instance, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	s.flights[name] = f
+	s.mu.Unlock()
+	if expired != nil {
+		evict(name, expired.instance)
+	}
 
-	// double check
-	if instance, ok := c.instances[name]; ok {
-		return instance
+	// create new instance outside of the lock
+	created := false
+	defer func() {
+		s.mu.Lock()
+		if !created {
+			f.err = fmt.Errorf(`minstance: creation of instance "%s" panicked`, name)
+		}
+		if f.err == nil {
+			s.instances[name] = newEntry(f.instance, ttl)
+		}
+		delete(s.flights, name)
+		s.mu.Unlock()
+		close(f.done)
+	}()
+	instance, err := fn()
+	created = true
+	if err != nil {
+		instance = nil
 	}
+	f.instance, f.err = instance, err
+	return instance, err
+}
 
-	// create new instance
-	instance := fn()
-	c.instances[name] = instance
+// Range calls `fn` for each instance in the order of their names, until `fn` returns false.
+// It ranges over a snapshot of the instances taken when it is called: `fn` can use the container,
+// like Get, Set or Remove, whose changes are not seen by the range. The expired instances are skipped.
+// The snapshot is taken shard by shard, so the concurrent changes of different instances may be
+// partially seen, like an instance set after another one seen without it.
+func (c *Container) Range(fn func(name string, instance any) bool) {
+	instances := c.snapshot()
+	for _, name := range sortedNames(instances) {
+		if !fn(name, instances[name].instance) {
+			return
+		}
+	}
+}
+
+// Keys returns the names of the instances, sorted.
+func (c *Container) Keys() []string {
+	var names []string
+	c.Range(func(name string, _ any) bool {
+		names = append(names, name)
+		return true
+	})
+	return names
+}
+
+// Size returns the number of instances.
+func (c *Container) Size() int {
+	size := 0
+	for _, s := range c.shards {
+		s.mu.RLock()
+		for _, e := range s.instances {
+			if !e.expired() {
+				size++
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return size
+}
+
+// Remove removes the instance `name` and returns it, or nil if it does not exist.
+// The instance is released if it holds resources: it is shut down if it implements IShutdown,
+// with a background context, or closed if it implements io.Closer. The error of the release is
+// logged, use RemoveE to get it. The instance is released outside of the lock of the container,
+// so it can use the container.
+func (c *Container) Remove(name string) any {
+	instance, err := c.RemoveE(name)
+	if err != nil {
+		intlog.Errorf(context.Background(), "%v", err)
+	}
 	return instance
 }
 
-// Remove removes the instance.
-func (c *Container) Remove(name string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.instances, name)
+// RemoveE is Remove returning the error of the release of the instance instead of logging it.
+func (c *Container) RemoveE(name string) (any, error) {
+	s, expired := c.lockSettled(name)
+	e, ok := s.instances[name]
+	delete(s.instances, name)
+	s.mu.Unlock()
+	expired.evict(name)
+	if !ok {
+		return nil, nil
+	}
+	if err := release(e.instance); err != nil {
+		return e.instance, fmt.Errorf(`release instance "%s": %w`, name, err)
+	}
+	return e.instance, nil
+}
+
+// Clear removes all the instances and releases them like RemoveE, in the order of their names.
+// It returns the errors of all the instances joined, see errors.Join. The shards are cleared one by one,
+// so an instance set concurrently may be kept.
+func (c *Container) Clear() error {
+	instances := make(map[string]*entry)
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for name, e := range s.instances {
+			instances[name] = e
+		}
+		s.instances = make(map[string]*entry)
+		s.mu.Unlock()
+	}
+
+	var errs []error
+	for _, name := range sortedNames(instances) {
+		if err := release(instances[name].instance); err != nil {
+			errs = append(errs, fmt.Errorf(`release instance "%s": %w`, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// get returns the instance `name` if it exists and is not expired, the expired instance is evicted.
+func (c *Container) get(name string) (any, bool) {
+	s := c.shard(name)
+	s.mu.RLock()
+	e, ok := s.instances[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if e.expired() {
+		c.evictExpiredName(name)
+		return nil, false
+	}
+	return e.access(c.sliding), true
+}
+
+// sortedNames returns the names of `instances`, sorted.
+func sortedNames(instances map[string]*entry) []string {
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// release shuts down or closes `instance` if it implements IShutdown or io.Closer.
+func release(instance any) error {
+	switch v := instance.(type) {
+	case IShutdown:
+		return v.Shutdown(context.Background())
+	case io.Closer:
+		return v.Close()
+	}
+	return nil
+}
+
+// evict releases the expired instance `name`, logging the error as there is no caller to return it to.
+func evict(name string, instance any) {
+	if err := release(instance); err != nil {
+		intlog.Errorf(context.Background(), `release expired instance "%s" failed: %v`, name, err)
+	}
 }
@@ -0,0 +1,51 @@
+package minstance
+
+import "sync"
+
+// shardCount is the number of shards of a container. The instances are spread over the shards by the hash
+// of their name, so that the accesses to different instances, like the loggers and the configs of
+// a service on every request, do not contend for the same lock, see BenchmarkGetOrSet_Parallel.
+const shardCount = 32
+
+// shard holds the instances and the creations in progress of a part of the names.
+type shard struct {
+	mu        sync.RWMutex
+	instances map[string]*entry
+	flights   map[string]*flight // creations in progress by name
+}
+
+// newShards creates the shards of a container.
+func newShards() (shards [shardCount]*shard) {
+	for i := range shards {
+		shards[i] = &shard{
+			instances: make(map[string]*entry),
+			flights:   make(map[string]*flight),
+		}
+	}
+	return shards
+}
+
+// shard returns the shard of the instance `name`, by the FNV-1a hash of the name.
+func (c *Container) shard(name string) *shard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		hash ^= uint32(name[i])
+		hash *= 16777619
+	}
+	return c.shards[hash%shardCount]
+}
+
+// snapshot returns the instances which are not expired, shard by shard.
+func (c *Container) snapshot() map[string]*entry {
+	instances := make(map[string]*entry)
+	for _, s := range c.shards {
+		s.mu.RLock()
+		for name, e := range s.instances {
+			if !e.expired() {
+				instances[name] = e
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return instances
+}
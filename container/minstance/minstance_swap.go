@@ -0,0 +1,72 @@
+package minstance
+
+// SetIfNotExist sets the instance `name` if it does not exist, and reports whether it is set.
+// An expired instance does not exist, it is evicted, see SetWithTTL.
+func (c *Container) SetIfNotExist(name string, instance any) bool {
+	s, expired := c.lockSettled(name)
+	_, ok := s.instances[name]
+	if !ok {
+		s.instances[name] = newEntry(instance, 0)
+	}
+	s.mu.Unlock()
+	expired.evict(name)
+	return !ok
+}
+
+// Replace sets the instance `name` if it exists, and returns the replaced instance and whether it existed.
+// The replaced instance is not released, it is up to the caller. The new instance keeps the TTL
+// of the replaced one, starting over, see SetWithTTL. An expired instance does not exist.
+func (c *Container) Replace(name string, instance any) (old any, existed bool) {
+	s, expired := c.lockSettled(name)
+	e, ok := s.instances[name]
+	if ok {
+		s.instances[name] = newEntry(instance, e.ttl)
+		old = e.instance
+	}
+	s.mu.Unlock()
+	expired.evict(name)
+	return old, ok
+}
+
+// CompareAndSwap sets the instance `name` to `new` if it is `old`, and reports whether it is swapped.
+// The instances are compared with ==, so `old` must be comparable, like sync.Map.CompareAndSwap.
+// The new instance keeps the TTL of the old one, starting over, like Replace.
+func (c *Container) CompareAndSwap(name string, old, new any) bool {
+	s, expired := c.lockSettled(name)
+	e, ok := s.instances[name]
+	swapped := ok && e.instance == old
+	if swapped {
+		s.instances[name] = newEntry(new, e.ttl)
+	}
+	s.mu.Unlock()
+	expired.evict(name)
+	return swapped
+}
+
+// lockSettled locks the shard of the instance `name` once it is not being created, so that the changes
+// of the instance are ordered with its creation, and it removes the instance if it is expired and returns it.
+// The caller unlocks the shard, then evicts the expired instance.
+func (c *Container) lockSettled(name string) (*shard, *entry) {
+	s := c.shard(name)
+	for {
+		s.mu.Lock()
+		f, ok := s.flights[name]
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		<-f.done
+	}
+	if e, ok := s.instances[name]; ok && e.expired() {
+		delete(s.instances, name)
+		return s, e
+	}
+	return s, nil
+}
+
+// evict releases the instance of the expired entry `e` if it is not nil.
+func (e *entry) evict(name string) {
+	if e != nil {
+		evict(name, e.instance)
+	}
+}
@@ -0,0 +1,136 @@
+package minstance
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// entry is an instance of the container, expiring at `expires` if its TTL is positive.
+type entry struct {
+	instance any
+	ttl      time.Duration
+	expires  atomic.Int64 // unix nano, refreshed by the accesses with sliding expiration
+}
+
+// newEntry creates the entry of `instance` expiring `ttl` after now, or never if `ttl` is not positive.
+func newEntry(instance any, ttl time.Duration) *entry {
+	e := &entry{instance: instance, ttl: ttl}
+	if ttl > 0 {
+		e.expires.Store(time.Now().Add(ttl).UnixNano())
+	}
+	return e
+}
+
+// expired reports whether the entry is expired, the clock is only read for the entries with a TTL.
+func (e *entry) expired() bool {
+	return e.ttl > 0 && time.Now().UnixNano() >= e.expires.Load()
+}
+
+// access returns the instance of the entry, refreshing its TTL if `sliding` is true.
+func (e *entry) access(sliding bool) any {
+	if sliding && e.ttl > 0 {
+		e.expires.Store(time.Now().Add(e.ttl).UnixNano())
+	}
+	return e.instance
+}
+
+// WithSlidingExpiration makes the accesses to the instances with a TTL, like Get and GetOrSetFunc,
+// refresh their TTL, so that they expire after `ttl` of inactivity instead of `ttl` after their creation:
+//
+//	tenants := minstance.New(minstance.WithSlidingExpiration())
+func WithSlidingExpiration() Option {
+	return func(c *Container) {
+		c.sliding = true
+	}
+}
+
+// SetWithTTL sets the instance `name` expiring after `ttl`, or never if `ttl` is not positive,
+// like the clients of the tenants which should not hold their connections once idle.
+// The expired instances are not returned, and they are released like by Remove when they are evicted:
+// on the next access by Get or GetOrSetFunc, or by the janitor, see StartJanitor.
+//
+// Like the other changes of an instance, it waits for the creation of the instance `name` in progress
+// by GetOrSetFunc, if any, so it is not overwritten by the created instance.
+func (c *Container) SetWithTTL(name string, instance any, ttl time.Duration) {
+	s, expired := c.lockSettled(name)
+	s.instances[name] = newEntry(instance, ttl)
+	s.mu.Unlock()
+	expired.evict(name)
+}
+
+// janitor evicts the expired instances periodically.
+type janitor struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartJanitor starts a goroutine evicting the expired instances every `interval`, so that the instances
+// not accessed anymore are released too. It restarts the janitor if it is running, see StopJanitor.
+func (c *Container) StartJanitor(interval time.Duration) {
+	c.janitorMu.Lock()
+	defer c.janitorMu.Unlock()
+	c.stopJanitor()
+	j := &janitor{stop: make(chan struct{}), done: make(chan struct{})}
+	c.janitor = j
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.evictExpired()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor stops the janitor started by StartJanitor, and waits for it to return.
+func (c *Container) StopJanitor() {
+	c.janitorMu.Lock()
+	defer c.janitorMu.Unlock()
+	c.stopJanitor()
+}
+
+// stopJanitor stops the janitor if it is running, it must be called with janitorMu held.
+func (c *Container) stopJanitor() {
+	if c.janitor != nil {
+		close(c.janitor.stop)
+		<-c.janitor.done
+		c.janitor = nil
+	}
+}
+
+// evictExpired removes the expired instances and releases them.
+func (c *Container) evictExpired() {
+	expired := make(map[string]*entry)
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for name, e := range s.instances {
+			if e.expired() {
+				expired[name] = e
+				delete(s.instances, name)
+			}
+		}
+		s.mu.Unlock()
+	}
+	for _, name := range sortedNames(expired) {
+		evict(name, expired[name].instance)
+	}
+}
+
+// evictExpiredName removes the instance `name` and releases it if it is expired.
+func (c *Container) evictExpiredName(name string) {
+	s := c.shard(name)
+	s.mu.Lock()
+	e, ok := s.instances[name]
+	if !ok || !e.expired() {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.instances, name)
+	s.mu.Unlock()
+	evict(name, e.instance)
+}
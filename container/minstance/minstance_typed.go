@@ -0,0 +1,47 @@
+package minstance
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GetOrSet is the typed GetOrSetFunc: it gets the instance `name` of `c`, creating it by `fn` if it does not exist.
+//
+//	logger := minstance.GetOrSet(instances, name, func() *Logger { return New() })
+//
+// It panics if the instance `name` exists with another type than T, which is a programming error
+// of two callers sharing a name in the same container.
+func GetOrSet[T any](c *Container, name string, fn func() T) T {
+	return assertType[T](name, c.GetOrSetFunc(name, func() any { return fn() }))
+}
+
+// GetOrSetE is the typed GetOrSetFuncE: it gets the instance `name` of `c`, creating it by `fn`
+// if it does not exist, and nothing is stored if `fn` fails. It panics like GetOrSet.
+func GetOrSetE[T any](c *Container, name string, fn func() (T, error)) (T, error) {
+	instance, err := c.GetOrSetFuncE(name, func() (any, error) { return fn() })
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return assertType[T](name, instance), nil
+}
+
+// Get is the typed Get: it gets the instance `name` of `c` and reports whether it exists.
+// It panics if the instance `name` exists with another type than T, see GetOrSet.
+func Get[T any](c *Container, name string) (T, bool) {
+	instance, ok := c.get(name)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return assertType[T](name, instance), true
+}
+
+// assertType returns `instance` as a T, it panics with the name and the types if it is not a T.
+func assertType[T any](name string, instance any) T {
+	v, ok := instance.(T)
+	if !ok {
+		panic(fmt.Sprintf(`minstance: instance "%s" is a %T, not a %s`, name, instance, reflect.TypeFor[T]()))
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package minstance_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/graingo/maltose/container/minstance"
+)
+
+// benchNames is the number of instances of the benchmarks, like the loggers and clients of a service
+const benchNames = 64
+
+var benchKeys = func() []string {
+	keys := make([]string, benchNames)
+	for i := range keys {
+		keys[i] = "instance." + strconv.Itoa(i)
+	}
+	return keys
+}()
+
+// newBenchContainer returns a container with the instances of benchKeys
+func newBenchContainer() *minstance.Container {
+	c := minstance.New()
+	for _, key := range benchKeys {
+		c.Set(key, &service{name: key})
+	}
+	return c
+}
+
+// BenchmarkGet benchmarks the reads of a single goroutine
+func BenchmarkGet(b *testing.B) {
+	c := newBenchContainer()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.Get(benchKeys[i%benchNames])
+	}
+}
+
+// BenchmarkGetOrSet_Parallel benchmarks the hot path of mlog.Instance and mcfg.Instance: the parallel reads
+// of existing instances
+func BenchmarkGetOrSet_Parallel(b *testing.B) {
+	c := newBenchContainer()
+	create := func() *service { return &service{} }
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = minstance.GetOrSet(c, benchKeys[i%benchNames], create)
+			i++
+		}
+	})
+}
+
+// BenchmarkSet_Parallel benchmarks the parallel writes
+func BenchmarkSet_Parallel(b *testing.B) {
+	c := newBenchContainer()
+	instance := &service{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Set(benchKeys[i%benchNames], instance)
+			i++
+		}
+	})
+}
+
+// BenchmarkMixed_Parallel benchmarks parallel reads with one write out of ten
+func BenchmarkMixed_Parallel(b *testing.B) {
+	c := newBenchContainer()
+	instance := &service{}
+	var seed atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(seed.Add(7))
+		for pb.Next() {
+			key := benchKeys[i%benchNames]
+			if i%10 == 0 {
+				c.Set(key, instance)
+			} else {
+				_ = c.Get(key)
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,510 @@
+package minstance_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/container/minstance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type service struct {
+	name string
+}
+
+// closer is an instance holding resources
+type closer struct {
+	closed atomic.Int32
+	err    error
+}
+
+func (c *closer) Close() error {
+	c.closed.Add(1)
+	return c.err
+}
+
+// server is an instance shut down with a context
+type server struct {
+	ctx context.Context
+}
+
+func (s *server) Shutdown(ctx context.Context) error {
+	s.ctx = ctx
+	return nil
+}
+
+func TestGetOrSet(t *testing.T) {
+	c := minstance.New()
+	calls := 0
+	create := func() *service {
+		calls++
+		return &service{name: "orders"}
+	}
+	s := minstance.GetOrSet(c, "orders", create)
+	assert.Equal(t, "orders", s.name)
+	assert.Same(t, s, minstance.GetOrSet(c, "orders", create))
+	assert.Equal(t, 1, calls)
+
+	got, ok := minstance.Get[*service](c, "orders")
+	assert.True(t, ok)
+	assert.Same(t, s, got)
+	got, ok = minstance.Get[*service](c, "missing")
+	assert.False(t, ok)
+	assert.Nil(t, got)
+}
+
+func TestGetOrSet_TypeMismatch(t *testing.T) {
+	c := minstance.New()
+	c.Set("orders", "not a service")
+	assert.PanicsWithValue(t, `minstance: instance "orders" is a string, not a *minstance_test.service`, func() {
+		minstance.GetOrSet(c, "orders", func() *service { return &service{} })
+	})
+	assert.PanicsWithValue(t, `minstance: instance "orders" is a string, not a *minstance_test.service`, func() {
+		minstance.Get[*service](c, "orders")
+	})
+}
+
+func TestRemove(t *testing.T) {
+	c := minstance.New()
+	assert.Nil(t, c.Remove("missing"))
+
+	// the error of the release is logged
+	file := &closer{err: errors.New("sync failed")}
+	c.Set("file", file)
+	assert.Same(t, file, c.Remove("file"))
+	assert.Equal(t, int32(1), file.closed.Load())
+	assert.Nil(t, c.Get("file"))
+
+	srv := &server{}
+	c.Set("server", srv)
+	assert.Same(t, srv, c.Remove("server"))
+	assert.NotNil(t, srv.ctx)
+
+	// instances without resources are just removed
+	c.Set("service", &service{})
+	assert.NotNil(t, c.Remove("service"))
+	assert.Nil(t, c.Get("service"))
+}
+
+func TestRemoveE(t *testing.T) {
+	c := minstance.New()
+	instance, err := c.RemoveE("missing")
+	assert.Nil(t, instance)
+	assert.NoError(t, err)
+
+	file := &closer{err: errors.New("sync failed")}
+	c.Set("file", file)
+	instance, err = c.RemoveE("file")
+	assert.Same(t, file, instance)
+	assert.EqualError(t, err, `release instance "file": sync failed`)
+	assert.Equal(t, int32(1), file.closed.Load())
+
+	srv := &server{}
+	c.Set("server", srv)
+	_, err = c.RemoveE("server")
+	assert.NoError(t, err)
+	assert.NotNil(t, srv.ctx)
+}
+
+func TestClear(t *testing.T) {
+	c := minstance.New()
+	a, b, ok := &closer{err: errors.New("a failed")}, &closer{err: errors.New("b failed")}, &closer{}
+	c.Set("b", b)
+	c.Set("a", a)
+	c.Set("ok", ok)
+	c.Set("service", &service{})
+
+	err := c.Clear()
+	assert.EqualError(t, err, "release instance \"a\": a failed\nrelease instance \"b\": b failed")
+	for _, instance := range []*closer{a, b, ok} {
+		assert.Equal(t, int32(1), instance.closed.Load())
+	}
+	assert.Nil(t, c.Get("service"))
+	assert.NoError(t, c.Clear())
+}
+
+// countingCloser counts the closes of all its instances
+type countingCloser struct {
+	closed *atomic.Int32
+}
+
+func (c countingCloser) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+// TestRemove_Concurrent tests removing instances while they are created, run it with -race
+func TestRemove_Concurrent(t *testing.T) {
+	c := minstance.New()
+	var (
+		wg      sync.WaitGroup
+		created atomic.Int32
+		closed  atomic.Int32
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				minstance.GetOrSet(c, fmt.Sprint(j%4), func() countingCloser {
+					created.Add(1)
+					return countingCloser{closed: &closed}
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Remove(fmt.Sprint(j % 4))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, c.Clear())
+	// every created instance is closed once, by Remove or Clear
+	assert.Equal(t, created.Load(), closed.Load())
+}
+
+func TestRange(t *testing.T) {
+	c := minstance.New()
+	assert.Equal(t, 0, c.Size())
+	assert.Empty(t, c.Keys())
+	for _, name := range []string{"c", "a", "b"} {
+		c.Set(name, name)
+	}
+	assert.Equal(t, 3, c.Size())
+	assert.Equal(t, []string{"a", "b", "c"}, c.Keys())
+
+	var names []string
+	c.Range(func(name string, instance any) bool {
+		assert.Equal(t, name, instance)
+		names = append(names, name)
+		return name != "b"
+	})
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	// the callback can use the container, the range is over a snapshot
+	names = nil
+	c.Range(func(name string, instance any) bool {
+		names = append(names, name)
+		c.Remove("c")
+		c.Set(name+"2", c.Get(name))
+		return true
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Equal(t, []string{"a", "a2", "b", "b2", "c2"}, c.Keys())
+}
+
+func TestGetOrSetFuncE(t *testing.T) {
+	c := minstance.New()
+	failure := errors.New("open app.log: permission denied")
+	calls := 0
+	create := func() (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, failure
+		}
+		return &service{name: "logger"}, nil
+	}
+
+	instance, err := c.GetOrSetFuncE("logger", create)
+	assert.ErrorIs(t, err, failure)
+	assert.Nil(t, instance)
+	assert.Equal(t, 0, c.Size())
+
+	// the failure is not cached, the next call creates the instance
+	instance, err = c.GetOrSetFuncE("logger", create)
+	assert.NoError(t, err)
+	assert.Equal(t, "logger", instance.(*service).name)
+	again, err := c.GetOrSetFuncE("logger", create)
+	assert.NoError(t, err)
+	assert.Same(t, instance, again)
+	assert.Equal(t, 2, calls)
+
+	s, err := minstance.GetOrSetE(c, "typed", func() (*service, error) { return nil, failure })
+	assert.ErrorIs(t, err, failure)
+	assert.Nil(t, s)
+	s, err = minstance.GetOrSetE(c, "typed", func() (*service, error) { return &service{name: "typed"}, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "typed", s.name)
+}
+
+// TestGetOrSetFuncE_Concurrent tests that the concurrent callers of a failing creation get an error
+func TestGetOrSetFuncE_Concurrent(t *testing.T) {
+	c := minstance.New()
+	failure := errors.New("invalid base URL")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance, err := c.GetOrSetFuncE("client", func() (any, error) {
+				return nil, failure
+			})
+			assert.ErrorIs(t, err, failure)
+			assert.Nil(t, instance)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, c.Size())
+}
+
+// TestGetOrSetFunc_SingleFlight tests that concurrent callers create an instance once, run it with -race
+func TestGetOrSetFunc_SingleFlight(t *testing.T) {
+	c := minstance.New()
+	var (
+		wg    sync.WaitGroup
+		calls atomic.Int32
+		got   = make([]*service, 100)
+	)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = minstance.GetOrSet(c, "logger", func() *service {
+				calls.Add(1)
+				time.Sleep(20 * time.Millisecond)
+				return &service{name: "logger"}
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	for _, s := range got {
+		assert.Same(t, got[0], s)
+	}
+}
+
+// TestGetOrSetFunc_OtherNames tests that a creation does not block the other names
+func TestGetOrSetFunc_OtherNames(t *testing.T) {
+	c := minstance.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	go minstance.GetOrSet(c, "slow", func() *service {
+		close(started)
+		<-release
+		return &service{}
+	})
+	<-started
+	// the constructors can create the other instances of the container
+	parent := minstance.GetOrSet(c, "app.db", func() *service {
+		return &service{name: minstance.GetOrSet(c, "app", func() *service { return &service{name: "app"} }).name + ".db"}
+	})
+	assert.Equal(t, "app.db", parent.name)
+	close(release)
+}
+
+// TestGetOrSetFunc_Panic tests that the waiters of a panicking creation get an error
+func TestGetOrSetFunc_Panic(t *testing.T) {
+	c := minstance.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer func() { _ = recover() }()
+		_, _ = c.GetOrSetFuncE("broken", func() (any, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+	result := make(chan error)
+	go func() {
+		_, err := c.GetOrSetFuncE("broken", func() (any, error) { return &service{}, nil })
+		result <- err
+	}()
+	// let the second caller wait for the creation before it panics
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	// the second caller creates the instance itself if it did not wait in time
+	err := <-result
+	if err != nil {
+		assert.EqualError(t, err, `minstance: creation of instance "broken" panicked`)
+	}
+	// the next call creates the instance again
+	instance, err := c.GetOrSetFuncE("broken", func() (any, error) { return &service{}, nil })
+	assert.NoError(t, err)
+	assert.NotNil(t, instance)
+}
+
+func TestSetWithTTL(t *testing.T) {
+	c := minstance.New()
+	client := &closer{}
+	c.SetWithTTL("tenant", client, 30*time.Millisecond)
+	c.SetWithTTL("forever", &closer{}, 0)
+	assert.Same(t, client, c.Get("tenant"))
+	assert.Equal(t, []string{"forever", "tenant"}, c.Keys())
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, c.Size())
+	assert.Equal(t, []string{"forever"}, c.Keys())
+	// the expired instance is evicted on access
+	assert.Equal(t, int32(0), client.closed.Load())
+	assert.Nil(t, c.Get("tenant"))
+	assert.Equal(t, int32(1), client.closed.Load())
+	assert.NotNil(t, c.Get("forever"))
+}
+
+func TestGetOrSetFuncTTL(t *testing.T) {
+	c := minstance.New()
+	var created []*closer
+	create := func() (any, error) {
+		client := &closer{}
+		created = append(created, client)
+		return client, nil
+	}
+	first, err := c.GetOrSetFuncTTL("tenant", 30*time.Millisecond, create)
+	assert.NoError(t, err)
+	again, _ := c.GetOrSetFuncTTL("tenant", 30*time.Millisecond, create)
+	assert.Same(t, first, again)
+
+	time.Sleep(50 * time.Millisecond)
+	second, err := c.GetOrSetFuncTTL("tenant", 30*time.Millisecond, create)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, second)
+	require.Len(t, created, 2)
+	assert.Equal(t, int32(1), created[0].closed.Load())
+	assert.Equal(t, int32(0), created[1].closed.Load())
+}
+
+// TestGetOrSetFuncTTL_SingleFlight tests that an expired instance is created again once, run it with -race
+func TestGetOrSetFuncTTL_SingleFlight(t *testing.T) {
+	c := minstance.New()
+	var calls atomic.Int32
+	create := func() (any, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return &closer{}, nil
+	}
+	_, _ = c.GetOrSetFuncTTL("tenant", 20*time.Millisecond, create)
+	time.Sleep(30 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetOrSetFuncTTL("tenant", time.Minute, create)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWithSlidingExpiration(t *testing.T) {
+	c := minstance.New(minstance.WithSlidingExpiration())
+	c.SetWithTTL("tenant", &service{}, 80*time.Millisecond)
+	// the accesses keep the instance alive
+	for i := 0; i < 4; i++ {
+		time.Sleep(30 * time.Millisecond)
+		assert.NotNil(t, c.Get("tenant"), "access %d", i)
+	}
+	time.Sleep(120 * time.Millisecond)
+	assert.Nil(t, c.Get("tenant"))
+}
+
+func TestJanitor(t *testing.T) {
+	c := minstance.New()
+	client := &closer{}
+	c.SetWithTTL("tenant", client, 10*time.Millisecond)
+	c.StartJanitor(5 * time.Millisecond)
+	defer c.StopJanitor()
+
+	assert.Eventually(t, func() bool {
+		return client.closed.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, c.Size())
+
+	// the janitor can be restarted and stopped more than once
+	c.StartJanitor(time.Millisecond)
+	c.StopJanitor()
+	c.StopJanitor()
+}
+
+func TestSetIfNotExist(t *testing.T) {
+	c := minstance.New()
+	first, second := &service{name: "first"}, &service{name: "second"}
+	assert.True(t, c.SetIfNotExist("client", first))
+	assert.False(t, c.SetIfNotExist("client", second))
+	assert.Same(t, first, c.Get("client"))
+
+	// an expired instance does not exist, it is evicted
+	expired := &closer{}
+	c.SetWithTTL("tenant", expired, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, c.SetIfNotExist("tenant", second))
+	assert.Equal(t, int32(1), expired.closed.Load())
+	assert.Same(t, second, c.Get("tenant"))
+}
+
+func TestReplace(t *testing.T) {
+	c := minstance.New()
+	first, second := &closer{}, &closer{}
+	old, existed := c.Replace("client", first)
+	assert.Nil(t, old)
+	assert.False(t, existed)
+	assert.Nil(t, c.Get("client"))
+
+	c.Set("client", first)
+	old, existed = c.Replace("client", second)
+	assert.True(t, existed)
+	assert.Same(t, first, old)
+	assert.Same(t, second, c.Get("client"))
+	// the replaced instance is not released
+	assert.Equal(t, int32(0), first.closed.Load())
+
+	// the new instance keeps the TTL
+	c.SetWithTTL("tenant", first, 30*time.Millisecond)
+	_, existed = c.Replace("tenant", second)
+	assert.True(t, existed)
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, c.Get("tenant"))
+	_, existed = c.Replace("tenant", first)
+	assert.False(t, existed)
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	c := minstance.New()
+	first, second := &service{name: "first"}, &service{name: "second"}
+	assert.False(t, c.CompareAndSwap("client", nil, first))
+	c.Set("client", first)
+	assert.False(t, c.CompareAndSwap("client", second, second))
+	assert.True(t, c.CompareAndSwap("client", first, second))
+	assert.Same(t, second, c.Get("client"))
+
+	c.SetWithTTL("tenant", first, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.False(t, c.CompareAndSwap("tenant", first, second))
+}
+
+// TestReplace_Creation tests that the changes of an instance wait for its creation in progress
+func TestReplace_Creation(t *testing.T) {
+	c := minstance.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	created := &service{name: "created"}
+	go minstance.GetOrSet(c, "client", func() *service {
+		close(started)
+		<-release
+		return created
+	})
+	<-started
+
+	replaced := make(chan any)
+	go func() {
+		old, _ := c.Replace("client", &service{name: "replacement"})
+		replaced <- old
+	}()
+	select {
+	case <-replaced:
+		t.Fatal("Replace did not wait for the creation")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	assert.Same(t, created, <-replaced)
+	assert.Equal(t, "replacement", c.Get("client").(*service).name)
+}
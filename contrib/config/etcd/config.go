@@ -0,0 +1,87 @@
+package etcd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const (
+	// ConfigKey is the key of the etcd connection settings in the bootstrap configuration.
+	ConfigKey = "etcd"
+	// EnvPrefix is the prefix of the environment variables overriding the etcd connection settings,
+	// like MALTOSE_ETCD_ENDPOINTS.
+	EnvPrefix = "MALTOSE_ETCD_"
+)
+
+// LoadConfig loads the etcd connection settings from the key ConfigKey of the bootstrap
+// configuration `bootstrap`, like a local file, and the environment variables with EnvPrefix:
+//
+//	etcd:
+//	  endpoints: ["localhost:2379"]
+//	  prefix: /config/app/
+//	  username: app
+//	  fail_fast: true
+//
+// The environment variables are the upper case keys with EnvPrefix, like MALTOSE_ETCD_PASSWORD,
+// and MALTOSE_ETCD_ENDPOINTS separates the endpoints with commas. They override the bootstrap
+// configuration, which is optional if `bootstrap` is nil.
+func LoadConfig(ctx context.Context, bootstrap *mcfg.Config) (config Config, err error) {
+	if bootstrap != nil {
+		if err = bootstrap.UnmarshalKey(ctx, ConfigKey, &config); err != nil {
+			return Config{}, err
+		}
+	}
+	if err = config.loadEnv(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// loadEnv overrides the settings with the environment variables.
+func (c *Config) loadEnv() error {
+	if value, ok := os.LookupEnv(EnvPrefix + "ENDPOINTS"); ok {
+		c.Endpoints = nil
+		for _, endpoint := range strings.Split(value, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				c.Endpoints = append(c.Endpoints, endpoint)
+			}
+		}
+	}
+	for name, field := range map[string]*string{
+		"PREFIX":    &c.Prefix,
+		"USERNAME":  &c.Username,
+		"PASSWORD":  &c.Password,
+		"CERT_FILE": &c.CertFile,
+		"KEY_FILE":  &c.KeyFile,
+		"CA_FILE":   &c.CAFile,
+	} {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			*field = value
+		}
+	}
+	for name, field := range map[string]*time.Duration{
+		"DIAL_TIMEOUT":       &c.DialTimeout,
+		"RETRY_INTERVAL":     &c.RetryInterval,
+		"MAX_RETRY_INTERVAL": &c.MaxRetryInterval,
+	} {
+		value, ok := os.LookupEnv(EnvPrefix + name)
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `invalid environment variable %s%s`, EnvPrefix, name)
+		}
+		*field = duration
+	}
+	if value, ok := os.LookupEnv(EnvPrefix + "FAIL_FAST"); ok {
+		c.FailFast = value == "true" || value == "1"
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/frame/m"
+	"github.com/graingo/maltose/internal/intlog"
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/tidwall/gjson"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultDialTimeout      = 5 * time.Second
+	defaultRetryInterval    = time.Second
+	defaultMaxRetryInterval = 30 * time.Second
+)
+
+// Config is the configuration object for etcd client.
+type Config struct {
+	Endpoints        []string      `json:"endpoints"`          // Endpoints of the etcd cluster, like "localhost:2379".
+	Prefix           string        `json:"prefix"`             // Prefix of the keys loaded into configuration, like "/config/app/".
+	Username         string        `json:"username"`           // Username of the etcd authentication.
+	Password         string        `json:"password"`           // Password of the etcd authentication.
+	CertFile         string        `json:"cert_file"`          // Client certificate file of TLS.
+	KeyFile          string        `json:"key_file"`           // Client key file of TLS.
+	CAFile           string        `json:"ca_file"`            // CA certificate file of TLS to verify the server.
+	DialTimeout      time.Duration `json:"dial_timeout"`       // Timeout of connecting and loading, 5s by default.
+	FailFast         bool          `json:"fail_fast"`          // FailFast makes New fail if the configuration cannot be loaded from etcd.
+	RetryInterval    time.Duration `json:"retry_interval"`     // Initial interval of reconnecting the watch, 1s by default.
+	MaxRetryInterval time.Duration `json:"max_retry_interval"` // Maximum interval of reconnecting the watch, 30s by default.
+}
+
+var _ mcfg.WatchAdapter = (*Client)(nil)
+
+// Client implements mcfg.WatchAdapter implementing using etcd service.
+type Client struct {
+	config  Config           // Config object when created.
+	client  *clientv3.Client // Etcd client, closed by Close.
+	kv      clientv3.KV      // Etcd key-value API loading the configuration.
+	watcher clientv3.Watcher // Etcd watch API watching the configuration.
+	value   *m.Var           // Configmap content cached. It is json string.
+}
+
+// New creates and returns mcfg.WatchAdapter implementing using etcd service, which should be closed
+// by Close after use.
+//
+// The keys under Config.Prefix are loaded into the configuration by their path, so that the key
+// "/config/app/server/address" with the prefix "/config/app/" is the configuration "server.address".
+// The values are decoded as YAML, so that a key may hold a document merged at its path,
+// like the key "/config/app/server" holding "address: :8000".
+//
+// If the configuration cannot be loaded, New fails with Config.FailFast, otherwise the
+// configuration is loaded again on access.
+func New(ctx context.Context, config Config) (client *Client, err error) {
+	if len(config.Endpoints) == 0 {
+		return nil, merror.NewCode(mcode.CodeMissingConfiguration, `etcd endpoints are required`)
+	}
+	client = newClient(config, nil, nil)
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	client.client, err = clientv3.New(clientv3.Config{
+		Endpoints:   config.Endpoints,
+		Username:    config.Username,
+		Password:    config.Password,
+		TLS:         tlsConfig,
+		DialTimeout: client.config.DialTimeout,
+		// The client lives after the context of its creation, like the init context.
+		Context: context.WithoutCancel(ctx),
+	})
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeOperationFailed, `create etcd client failed with endpoints: %v`, config.Endpoints)
+	}
+	client.kv, client.watcher = client.client, client.client
+	if _, err = client.updateLocalValue(ctx); err != nil {
+		if config.FailFast {
+			_ = client.Close()
+			return nil, err
+		}
+		intlog.Errorf(ctx, `%+v`, err)
+	}
+	return client, nil
+}
+
+// newClient creates and returns the adapter using the etcd APIs `kv` and `watcher`.
+func newClient(config Config, kv clientv3.KV, watcher clientv3.Watcher) *Client {
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = defaultDialTimeout
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+	if config.MaxRetryInterval < config.RetryInterval {
+		config.MaxRetryInterval = max(defaultMaxRetryInterval, config.RetryInterval)
+	}
+	return &Client{
+		config:  config,
+		kv:      kv,
+		watcher: watcher,
+		value:   m.NewVar(nil, true),
+	}
+}
+
+// Available checks and returns the backend configuration service is available.
+// The optional parameter `resource` specifies certain configuration resource.
+//
+// Note that this function does not return error as it just does simply check for
+// backend configuration service.
+func (c *Client) Available(ctx context.Context, resource ...string) (ok bool) {
+	if len(resource) == 0 && !c.value.IsNil() {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.DialTimeout)
+	defer cancel()
+	_, err := c.kv.Get(ctx, c.config.Prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	return err == nil
+}
+
+// Get retrieves and returns value by specified `pattern` in current resource.
+// Pattern like:
+// "x.y.z" for map item.
+// "x.0.y" for slice item.
+func (c *Client) Get(ctx context.Context, pattern string) (value any, err error) {
+	if c.value.IsNil() {
+		if _, err = c.updateLocalValue(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return gjson.Get(c.value.String(), pattern).Value(), nil
+}
+
+// Data retrieves and returns all configuration data in current resource as map.
+// Note that this function may lead lots of memory usage if configuration data is too large,
+// you can implement this function if necessary.
+func (c *Client) Data(ctx context.Context) (data map[string]any, err error) {
+	if c.value.IsNil() {
+		if _, err = c.updateLocalValue(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return c.data(), nil
+}
+
+// Watch watches the keys under Config.Prefix until `ctx` is canceled, reloading the configuration
+// on their changes, see mcfg.Config.Watch.
+//
+// If the watch is disconnected, `fn` is called with the error and the watch is reconnected with
+// an exponential backoff from Config.RetryInterval to Config.MaxRetryInterval, keeping the last
+// loaded configuration meanwhile.
+func (c *Client) Watch(ctx context.Context, fn func(old, new map[string]any, err error)) error {
+	go c.watch(ctx, fn)
+	return nil
+}
+
+// Close closes the etcd client.
+func (c *Client) Close() error {
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Close()
+}
+
+func (c *Client) watch(ctx context.Context, fn func(old, new map[string]any, err error)) {
+	retryInterval := c.config.RetryInterval
+	for {
+		// The configuration is reloaded to catch the changes while disconnected.
+		old := c.data()
+		revision, err := c.updateLocalValue(ctx)
+		if err == nil {
+			fn(old, c.data(), nil)
+			retryInterval = c.config.RetryInterval
+			err = c.watchRevision(ctx, revision+1, fn)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		fn(nil, nil, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+		retryInterval = min(retryInterval*2, c.config.MaxRetryInterval)
+	}
+}
+
+// watchRevision watches the changes from `revision` until the watch fails.
+func (c *Client) watchRevision(ctx context.Context, revision int64, fn func(old, new map[string]any, err error)) error {
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	watchChan := c.watcher.Watch(ctx, c.config.Prefix, clientv3.WithPrefix(), clientv3.WithRev(revision))
+	for response := range watchChan {
+		if err := response.Err(); err != nil {
+			return merror.WrapCode(err, mcode.CodeOperationFailed, `watch config from etcd failed`)
+		}
+		old := c.data()
+		if _, err := c.updateLocalValue(ctx); err != nil {
+			return err
+		}
+		fn(old, c.data(), nil)
+	}
+	return merror.NewCode(mcode.CodeOperationFailed, `watch config from etcd closed`)
+}
+
+func (c *Client) data() map[string]any {
+	if c.value.IsNil() {
+		return nil
+	}
+	data, _ := gjson.Parse(c.value.String()).Value().(map[string]any)
+	return data
+}
+
+// updateLocalValue loads the configuration and returns the revision it is loaded at.
+func (c *Client) updateLocalValue(ctx context.Context) (revision int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.DialTimeout)
+	defer cancel()
+	response, err := c.kv.Get(ctx, c.config.Prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, merror.WrapCodef(err, mcode.CodeOperationFailed, `retrieve config from etcd prefix "%s" failed`, c.config.Prefix)
+	}
+	data := map[string]any{}
+	for _, kv := range response.Kvs {
+		var value any
+		if err = yaml.Unmarshal(kv.Value, &value); err != nil {
+			return 0, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `parse config of etcd key "%s" failed`, kv.Key)
+		}
+		var path []string
+		for _, part := range strings.Split(strings.TrimPrefix(string(kv.Key), c.config.Prefix), "/") {
+			if part != "" {
+				path = append(path, strings.ToLower(part))
+			}
+		}
+		merge(data, path, lowerKeys(value))
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return 0, merror.WrapCode(err, mcode.CodeInvalidConfiguration, `encode config from etcd failed`)
+	}
+	c.value.Set(string(content))
+	return response.Header.Revision, nil
+}
+
+// merge sets `value` at `path` of `data`, merging it with the existing map at the path.
+func merge(data map[string]any, path []string, value any) {
+	if len(path) == 0 {
+		if m, ok := value.(map[string]any); ok {
+			for k, v := range m {
+				merge(data, []string{k}, v)
+			}
+		}
+		return
+	}
+	key := path[0]
+	if len(path) == 1 {
+		existing, isMap := data[key].(map[string]any)
+		if m, ok := value.(map[string]any); ok && isMap {
+			merge(existing, nil, m)
+			return
+		}
+		data[key] = value
+		return
+	}
+	child, ok := data[key].(map[string]any)
+	if !ok {
+		child = map[string]any{}
+		data[key] = child
+	}
+	merge(child, path[1:], value)
+}
+
+// lowerKeys returns `value` with its map keys in lower case, like the keys of the configuration files.
+func lowerKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[strings.ToLower(key)] = lowerKeys(item)
+		}
+		return result
+	case []any:
+		for i, item := range v {
+			v[i] = lowerKeys(item)
+		}
+	}
+	return value
+}
+
+// tlsConfig returns the TLS configuration of the client, or nil without TLS files.
+func (c Config) tlsConfig() (*tls.Config, error) {
+	if c.CertFile == "" && c.KeyFile == "" && c.CAFile == "" {
+		return nil, nil
+	}
+	config := &tls.Config{}
+	if c.CertFile != "" || c.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, merror.WrapCode(err, mcode.CodeInvalidConfiguration, `load etcd client certificate failed`)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `read etcd CA file "%s" failed`, c.CAFile)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, merror.NewCodef(mcode.CodeInvalidConfiguration, `invalid etcd CA file "%s"`, c.CAFile)
+		}
+	}
+	return config, nil
+}
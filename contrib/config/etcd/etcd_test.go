@@ -0,0 +1,225 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var ctx = context.Background()
+
+// fakeEtcd implements the etcd APIs used by the adapter in memory.
+type fakeEtcd struct {
+	clientv3.KV
+	clientv3.Watcher
+
+	mu       sync.Mutex
+	data     map[string]string
+	revision int64
+	err      error                            // error of Get
+	watches  chan chan clientv3.WatchResponse // channels of the started watches
+}
+
+func newFakeEtcd(data map[string]string) *fakeEtcd {
+	return &fakeEtcd{
+		data:     data,
+		revision: 1,
+		watches:  make(chan chan clientv3.WatchResponse, 10),
+	}
+}
+
+func (f *fakeEtcd) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = value
+	f.revision++
+}
+
+func (f *fakeEtcd) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeEtcd) Get(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	response := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.revision}}
+	for k, v := range f.data {
+		if strings.HasPrefix(k, key) {
+			response.Kvs = append(response.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+	return response, nil
+}
+
+func (f *fakeEtcd) Watch(_ context.Context, _ string, _ ...clientv3.OpOption) clientv3.WatchChan {
+	watch := make(chan clientv3.WatchResponse, 1)
+	f.watches <- watch
+	return watch
+}
+
+func TestClient_Data(t *testing.T) {
+	client := newClient(Config{Prefix: "/config/app/"}, newFakeEtcd(map[string]string{
+		"/config/app/server/address": ":8000",
+		"/config/app/server":         "port: 8080\nTLS:\n  enable: true",
+		"/config/app/name":           "demo",
+		"/config/other/name":         "other",
+	}), nil)
+
+	value, err := client.Get(ctx, "server.address")
+	require.NoError(t, err)
+	assert.Equal(t, ":8000", value)
+	value, _ = client.Get(ctx, "server.port")
+	assert.EqualValues(t, 8080, value)
+	value, _ = client.Get(ctx, "server.tls.enable")
+	assert.Equal(t, true, value)
+
+	data, err := client.Data(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "demo", data["name"])
+	assert.Len(t, data, 2)
+	assert.True(t, client.Available(ctx))
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	etcd := newFakeEtcd(map[string]string{})
+	etcd.setErr(errors.New("connection refused"))
+	client := newClient(Config{}, etcd, nil)
+
+	assert.False(t, client.Available(ctx))
+	_, err := client.Get(ctx, "name")
+	assert.Error(t, err)
+
+	// the configuration is loaded again on access
+	etcd.setErr(nil)
+	etcd.put("name", "demo")
+	value, err := client.Get(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "demo", value)
+}
+
+func TestClient_Watch(t *testing.T) {
+	etcd := newFakeEtcd(map[string]string{"/app/name": "demo"})
+	c := mcfg.NewWithAdapter(newClient(Config{
+		Prefix:        "/app/",
+		RetryInterval: 10 * time.Millisecond,
+	}, etcd, etcd))
+	var (
+		changes = make(chan map[string]any, 10)
+		errs    = make(chan error, 10)
+	)
+	c.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+	c.OnChangeError(func(err error) {
+		errs <- err
+	})
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	require.NoError(t, c.Watch(watchCtx))
+	watch := <-etcd.watches
+	// the first load of the watch is the loaded configuration
+	<-changes
+
+	etcd.put("/app/name", "changed")
+	watch <- clientv3.WatchResponse{}
+	select {
+	case data := <-changes:
+		assert.Equal(t, "changed", data["name"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called")
+	}
+
+	// the disconnected watch is reconnected and reloads the changes meanwhile
+	close(watch)
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "watch config from etcd closed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChangeError() not called")
+	}
+	etcd.put("/app/version", "1.0.0")
+	select {
+	case <-etcd.watches:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch not reconnected")
+	}
+	select {
+	case data := <-changes:
+		assert.Equal(t, "1.0.0", data["version"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called after reconnecting")
+	}
+	value, err := c.Get(ctx, "version")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", value.String())
+}
+
+func TestNew(t *testing.T) {
+	config := Config{
+		Endpoints:   []string{"127.0.0.1:1"},
+		DialTimeout: 100 * time.Millisecond,
+		FailFast:    true,
+	}
+	_, err := New(ctx, config)
+	assert.Error(t, err)
+
+	config.FailFast = false
+	client, err := New(ctx, config)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.False(t, client.Available(ctx))
+
+	_, err = New(ctx, Config{})
+	assert.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "config.yaml"), []byte(`
+etcd:
+  endpoints: ["localhost:2379"]
+  prefix: /config/app/
+  username: app
+  dial_timeout: 3s
+  fail_fast: true
+`), 0o644))
+	bootstrap, err := mcfg.New()
+	require.NoError(t, err)
+
+	t.Setenv("MALTOSE_ETCD_ENDPOINTS", "etcd-0:2379, etcd-1:2379")
+	t.Setenv("MALTOSE_ETCD_PASSWORD", "secret")
+	config, err := LoadConfig(ctx, bootstrap)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etcd-0:2379", "etcd-1:2379"}, config.Endpoints)
+	assert.Equal(t, "/config/app/", config.Prefix)
+	assert.Equal(t, "app", config.Username)
+	assert.Equal(t, "secret", config.Password)
+	assert.Equal(t, 3*time.Second, config.DialTimeout)
+	assert.True(t, config.FailFast)
+
+	t.Setenv("MALTOSE_ETCD_DIAL_TIMEOUT", "invalid")
+	_, err = LoadConfig(ctx, nil)
+	assert.Error(t, err)
+}
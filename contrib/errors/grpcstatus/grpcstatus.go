@@ -0,0 +1,179 @@
+// Package grpcstatus converts the errors of merror to and from gRPC statuses, so that errors
+// cross the boundary of gRPC services without losing their codes, messages and details.
+// It is a separate module so that services without gRPC do not depend on it.
+package grpcstatus
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// domain marks the status detail carrying the code, message and details of an error.
+const domain = "maltose"
+
+// defaultGRPCCode maps the codes whose gRPC code is not derived from their HTTP status.
+var defaultGRPCCode = map[int]codes.Code{
+	mcode.CodeNil.Code():     codes.Unknown,
+	mcode.CodeUnknown.Code(): codes.Unknown,
+}
+
+// httpGRPCCode maps HTTP statuses to canonical gRPC codes.
+var httpGRPCCode = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	499:                            codes.Canceled,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// grpcMCode maps gRPC codes to the codes of statuses without the detail of an error, see FromGRPCStatus.
+var grpcMCode = map[codes.Code]mcode.Code{
+	codes.Canceled:           mcode.CodeOperationFailed,
+	codes.InvalidArgument:    mcode.CodeInvalidParameter,
+	codes.DeadlineExceeded:   mcode.CodeServerBusy,
+	codes.NotFound:           mcode.CodeNotFound,
+	codes.AlreadyExists:      mcode.CodeInvalidOperation,
+	codes.PermissionDenied:   mcode.CodeForbidden,
+	codes.ResourceExhausted:  mcode.CodeServerBusy,
+	codes.FailedPrecondition: mcode.CodeInvalidOperation,
+	codes.Aborted:            mcode.CodeOperationFailed,
+	codes.OutOfRange:         mcode.CodeInvalidParameter,
+	codes.Unimplemented:      mcode.CodeNotImplemented,
+	codes.Internal:           mcode.CodeInternalError,
+	codes.Unavailable:        mcode.CodeServerBusy,
+	codes.DataLoss:           mcode.CodeInternalError,
+	codes.Unauthenticated:    mcode.CodeNotAuthorized,
+}
+
+var (
+	// grpcCodesMu protects grpcCodes.
+	grpcCodesMu sync.RWMutex
+	// grpcCodes holds the gRPC codes set by SetGRPCCode by code number.
+	grpcCodes = map[int]codes.Code{}
+)
+
+// SetGRPCCode sets the gRPC code of `code`, which overrides its default gRPC code, see GRPCCode.
+func SetGRPCCode(code mcode.Code, grpcCode codes.Code) {
+	grpcCodesMu.Lock()
+	defer grpcCodesMu.Unlock()
+	grpcCodes[code.Code()] = grpcCode
+}
+
+// GRPCCode returns the canonical gRPC code of `code`, for example codes.NotFound for mcode.CodeNotFound.
+// The gRPC code set by SetGRPCCode takes precedence, otherwise it is derived from the HTTP status
+// of the code, see mcode.HTTPStatus. It returns codes.Unknown for nil, mcode.CodeNil and mcode.CodeUnknown.
+func GRPCCode(code mcode.Code) codes.Code {
+	if code == nil {
+		return codes.Unknown
+	}
+	grpcCodesMu.RLock()
+	grpcCode, ok := grpcCodes[code.Code()]
+	grpcCodesMu.RUnlock()
+	if ok {
+		return grpcCode
+	}
+	if grpcCode, ok = defaultGRPCCode[code.Code()]; ok {
+		return grpcCode
+	}
+	httpStatus := mcode.HTTPStatus(code)
+	if grpcCode, ok = httpGRPCCode[httpStatus]; ok {
+		return grpcCode
+	}
+	if httpStatus >= http.StatusBadRequest && httpStatus < http.StatusInternalServerError {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// ToGRPCStatus returns the gRPC status of `err`, whose gRPC code is the GRPCCode of the code of `err`
+// and whose message is the message of `err`. The code, message and details of `err`, see merror.ToJSON,
+// are embedded into the status details, so that FromGRPCStatus reconstructs the error on the other side.
+// An error without code carrying a gRPC status, like the errors of gRPC clients, returns that status.
+// It returns nil if `err` is nil.
+func ToGRPCStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	code := merror.Code(err)
+	if mcode.Equal(code, mcode.CodeNil) {
+		var se interface{ GRPCStatus() *status.Status }
+		if errors.As(err, &se) {
+			return se.GRPCStatus()
+		}
+	}
+	st := status.New(GRPCCode(code), err.Error())
+	detail, e := errorDetail(err)
+	if e != nil {
+		return st
+	}
+	if withDetail, e := st.WithDetails(detail); e == nil {
+		return withDetail
+	}
+	return st
+}
+
+// FromGRPCStatus returns the error of the status `st`, reconstructing the code, message and details
+// embedded by ToGRPCStatus. The registered code of the code number is used if there is one,
+// see mcode.Lookup. A status without the embedded error, like those of other services,
+// has the code mapped from its gRPC code. It returns nil if `st` is nil or OK.
+func FromGRPCStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	for _, detail := range st.Details() {
+		s, ok := detail.(*structpb.Struct)
+		if !ok || s.GetFields()["domain"].GetStringValue() != domain {
+			continue
+		}
+		data, err := protojson.Marshal(s)
+		if err != nil {
+			break
+		}
+		var e merror.Error
+		if err = json.Unmarshal(data, &e); err != nil {
+			break
+		}
+		return &e
+	}
+	code, ok := grpcMCode[st.Code()]
+	if !ok {
+		code = mcode.CodeUnknown
+	}
+	return merror.NewCode(code, st.Message())
+}
+
+// errorDetail returns the status detail carrying the code, message and details of `err`.
+func errorDetail(err error) (*structpb.Struct, error) {
+	v := merror.ToJSON(err)
+	data, e := json.Marshal(struct {
+		Domain  string `json:"domain"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Details any    `json:"details,omitempty"`
+	}{domain, v.Code, v.Message, v.Details})
+	if e != nil {
+		return nil, e
+	}
+	detail := &structpb.Struct{}
+	if e = protojson.Unmarshal(data, detail); e != nil {
+		return nil, e
+	}
+	return detail, nil
+}
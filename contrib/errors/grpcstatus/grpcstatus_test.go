@@ -0,0 +1,127 @@
+package grpcstatus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var codeOrderNotFound = mcode.New(46101, "Order Not Found", nil)
+
+// roundTrip sends the status of `err` over the wire and returns the error of the received status.
+func roundTrip(t *testing.T, err error) (*status.Status, error) {
+	data, e := proto.Marshal(ToGRPCStatus(err).Proto())
+	require.NoError(t, e)
+	var received spb.Status
+	require.NoError(t, proto.Unmarshal(data, &received))
+	st := status.FromProto(&received)
+	return st, FromGRPCStatus(st)
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Run("registered code", func(t *testing.T) {
+		err := merror.Wrap(merror.NewCode(codeOrderNotFound, "order 42 not found"), "load order")
+		err = merror.WithDetail(err, "order_id", 42)
+		err = merror.WithDetail(err, "tags", []string{"a", "b"})
+
+		st, got := roundTrip(t, err)
+		assert.Equal(t, codes.Internal, st.Code())
+		assert.Equal(t, "load order: order 42 not found", st.Message())
+		require.Error(t, got)
+		assert.Equal(t, err.Error(), got.Error())
+		assert.Equal(t, 46101, merror.Code(got).Code())
+		assert.Equal(t, "Order Not Found", merror.Code(got).Message())
+		assert.Equal(t, map[string]any{"order_id": float64(42), "tags": []any{"a", "b"}}, merror.Code(got).Detail())
+	})
+
+	t.Run("built-in code", func(t *testing.T) {
+		err := merror.NewCode(mcode.WithCode(mcode.CodeNotFound, map[string]any{"id": "u1"}), "user not found")
+		st, got := roundTrip(t, err)
+		assert.Equal(t, codes.NotFound, st.Code())
+		assert.Equal(t, "user not found", got.Error())
+		assert.Equal(t, mcode.CodeNotFound.Code(), merror.Code(got).Code())
+		assert.Equal(t, map[string]any{"id": "u1"}, merror.Code(got).Detail())
+	})
+
+	t.Run("unregistered code", func(t *testing.T) {
+		err := merror.NewCode(mcode.WithCode(localCode(99461), nil), "remote failure")
+		_, got := roundTrip(t, err)
+		assert.Equal(t, 99461, merror.Code(got).Code())
+		assert.Equal(t, "remote failure", got.Error())
+	})
+
+	t.Run("without code", func(t *testing.T) {
+		st, got := roundTrip(t, fmt.Errorf("boom"))
+		assert.Equal(t, codes.Unknown, st.Code())
+		assert.Equal(t, "boom", got.Error())
+		assert.Equal(t, mcode.CodeNil, merror.Code(got))
+	})
+}
+
+func TestToGRPCStatus(t *testing.T) {
+	assert.Nil(t, ToGRPCStatus(nil))
+
+	remote := status.Error(codes.Unavailable, "connection refused")
+	st := ToGRPCStatus(merror.Wrap(remote, "call inventory"))
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "connection refused", st.Message())
+
+	st = ToGRPCStatus(merror.WrapCode(remote, mcode.CodeServerBusy))
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "Server Busy: rpc error: code = Unavailable desc = connection refused", st.Message())
+}
+
+func TestFromGRPCStatus(t *testing.T) {
+	assert.Nil(t, FromGRPCStatus(nil))
+	assert.Nil(t, FromGRPCStatus(status.New(codes.OK, "")))
+
+	err := FromGRPCStatus(status.New(codes.PermissionDenied, "no access"))
+	assert.Equal(t, "no access", err.Error())
+	assert.Equal(t, mcode.CodeForbidden, merror.Code(err))
+
+	err = FromGRPCStatus(status.New(codes.Code(99), "custom"))
+	assert.Equal(t, mcode.CodeUnknown, merror.Code(err))
+}
+
+func TestGRPCCode(t *testing.T) {
+	tests := []struct {
+		code mcode.Code
+		want codes.Code
+	}{
+		{nil, codes.Unknown},
+		{mcode.CodeNil, codes.Unknown},
+		{mcode.CodeUnknown, codes.Unknown},
+		{mcode.CodeValidationFailed, codes.InvalidArgument},
+		{mcode.CodeNotFound, codes.NotFound},
+		{mcode.CodeNotAuthorized, codes.Unauthenticated},
+		{mcode.CodeForbidden, codes.PermissionDenied},
+		{mcode.CodeInternalError, codes.Internal},
+		{mcode.CodeServerBusy, codes.Unavailable},
+		{mcode.CodeNotImplemented, codes.Unimplemented},
+		{mcode.CodeBusinessValidationFailed, codes.FailedPrecondition},
+		{mcode.WithCode(mcode.CodeNotFound, "detail"), codes.NotFound},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, GRPCCode(tt.code), "code %v", tt.code)
+	}
+
+	SetGRPCCode(codeOrderNotFound, codes.NotFound)
+	defer SetGRPCCode(codeOrderNotFound, codes.Internal)
+	assert.Equal(t, codes.NotFound, GRPCCode(codeOrderNotFound))
+	assert.Equal(t, codes.NotFound, ToGRPCStatus(merror.NewCode(codeOrderNotFound)).Code())
+}
+
+// localCode is a code which is not registered.
+type localCode int
+
+func (c localCode) Code() int       { return int(c) }
+func (c localCode) Message() string { return "" }
+func (c localCode) Detail() any     { return nil }
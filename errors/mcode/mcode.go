@@ -1,13 +1,25 @@
 package mcode
 
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/graingo/maltose/internal/intlog"
+)
+
 // Code is the error code interface.
+// Codes are identified by their number: a code derived by WithMessage or WithDetail is the same code,
+// compare codes with Equal. The built-in codes are comparable values, so they can also be compared with
+// == or in switch statements, as long as the code is not derived.
 type Code interface {
 	Code() int
 	Message() string
 	Detail() any
 }
 
-// Predefined error codes.
+// Predefined error codes, which are registered in the framework range from -1 to 999, see FrameworkRange.
+// Their string representation is "number: message", like "104: Not Found".
 var (
 	CodeNil                      = localCode{-1, "", nil}
 	CodeOK                       = localCode{0, "OK", nil}
@@ -33,20 +45,67 @@ var (
 	CodeBusinessValidationFailed = localCode{500, "Business Validation Failed", nil}
 )
 
-// New creates a new error code.
+// New creates and registers a new error code, which should be in a range reserved by RegisterRange:
+//
+//	var CodeUserNotFound = mcode.New(10001, "User Not Found", nil)
+//
+// The codes which cannot be registered, see Register, are programming errors: New panics in debug mode,
+// see SetDebug, so they are found at startup in development. Otherwise the error is logged and the code
+// is returned anyway, registered if it is only out of the registered ranges. Use Register to get the error.
 func New(code int, message string, detail any) Code {
-	return localCode{
-		code:    code,
-		message: message,
-		detail:  detail,
+	c, err := Register(code, message, detail)
+	if err == nil {
+		return c
+	}
+	if debug.Load() {
+		panic(err)
 	}
+	intlog.Errorf(context.Background(), "%v", err)
+	if errors.Is(err, ErrOutOfRange) {
+		if c, err = defaultRegistry.register(code, message, detail, false); err == nil {
+			return c
+		}
+	}
+	return localCode{code: code, message: message, detail: detail}
+}
+
+// debug reports whether New panics on the codes which cannot be registered.
+var debug atomic.Bool
+
+// SetDebug sets whether New panics on the codes which cannot be registered, like duplicate numbers,
+// instead of logging them, which is meant for development and tests.
+func SetDebug(enabled bool) {
+	debug.Store(enabled)
 }
 
-// WithCode creates a new error code, using the given error code and detail.
+// NewWithDetail creates an error code without registering it, like the codes of other services
+// received in their responses. Use New to define the codes of an application.
+func NewWithDetail(code int, message string, detail any) Code {
+	return localCode{code: code, message: message, detail: detail}
+}
+
+// WithCode creates a new error code, using the given error code and detail, see WithDetail.
 func WithCode(code Code, detail any) Code {
-	return localCode{
-		code:    code.Code(),
-		message: code.Message(),
-		detail:  detail,
+	return WithDetail(code, detail)
+}
+
+// WithMessage derives a code from `code` with the message `message`, keeping its number and detail:
+//
+//	mcode.WithMessage(mcode.CodeNotFound, "user not found") // 104: user not found
+func WithMessage(code Code, message string) Code {
+	return localCode{code: code.Code(), message: message, detail: code.Detail()}
+}
+
+// WithDetail derives a code from `code` with the detail `detail`, keeping its number and message.
+func WithDetail(code Code, detail any) Code {
+	return localCode{code: code.Code(), message: code.Message(), detail: detail}
+}
+
+// Equal reports whether `a` and `b` are the same code, which is whether they have the same number,
+// whatever their message and detail. Nil codes are only equal to each other.
+func Equal(a, b Code) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
 	}
+	return a.Code() == b.Code()
 }
@@ -0,0 +1,105 @@
+package mcode
+
+import (
+	"net/http"
+	"sync"
+)
+
+// IHTTPStatus is the interface of codes carrying their own HTTP status, see HTTPStatus.
+type IHTTPStatus interface {
+	HTTPStatus() int
+}
+
+// defaultHTTPStatus maps the built-in codes to their HTTP status.
+var defaultHTTPStatus = map[int]int{
+	CodeOK.Code():                       http.StatusOK,
+	CodeUnknown.Code():                  http.StatusInternalServerError,
+	CodeInvalidRequest.Code():           http.StatusBadRequest,
+	CodeInvalidParameter.Code():         http.StatusBadRequest,
+	CodeMissingParameter.Code():         http.StatusBadRequest,
+	CodeValidationFailed.Code():         http.StatusBadRequest,
+	CodeNotFound.Code():                 http.StatusNotFound,
+	CodeNotAuthorized.Code():            http.StatusUnauthorized,
+	CodeForbidden.Code():                http.StatusForbidden,
+	CodeInternalError.Code():            http.StatusInternalServerError,
+	CodeDbOperationError.Code():         http.StatusInternalServerError,
+	CodeInternalPanic.Code():            http.StatusInternalServerError,
+	CodeServerBusy.Code():               http.StatusServiceUnavailable,
+	CodeInvalidOperation.Code():         http.StatusBadRequest,
+	CodeInvalidConfiguration.Code():     http.StatusInternalServerError,
+	CodeMissingConfiguration.Code():     http.StatusInternalServerError,
+	CodeNotImplemented.Code():           http.StatusNotImplemented,
+	CodeNotSupported.Code():             http.StatusBadRequest,
+	CodeOperationFailed.Code():          http.StatusInternalServerError,
+	CodeSecurityReason.Code():           http.StatusForbidden,
+	CodeBusinessValidationFailed.Code(): http.StatusUnprocessableEntity,
+}
+
+var (
+	// httpStatusMu protects httpStatus.
+	httpStatusMu sync.RWMutex
+	// httpStatus holds the HTTP status set by SetHTTPStatus by code number.
+	httpStatus = map[int]int{}
+)
+
+// SetHTTPStatus sets the HTTP status of `code`, which overrides the status of the code itself
+// and the default status of the built-in codes, see HTTPStatus.
+func SetHTTPStatus(code Code, status int) {
+	httpStatusMu.Lock()
+	defer httpStatusMu.Unlock()
+	httpStatus[code.Code()] = status
+}
+
+// HTTPStatus returns the HTTP status of `code`, for example 404 for CodeNotFound and 400 for CodeValidationFailed.
+// The status set by SetHTTPStatus takes precedence, then the status of a code implementing IHTTPStatus,
+// then the default status of the built-in codes. It returns 500 for other codes, including nil and CodeNil.
+func HTTPStatus(code Code) int {
+	if code == nil {
+		return http.StatusInternalServerError
+	}
+	httpStatusMu.RLock()
+	status, ok := httpStatus[code.Code()]
+	httpStatusMu.RUnlock()
+	if ok {
+		return status
+	}
+	if c, ok := code.(IHTTPStatus); ok {
+		if status = c.HTTPStatus(); status != 0 {
+			return status
+		}
+	}
+	if status, ok = defaultHTTPStatus[code.Code()]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromHTTPStatus returns the built-in code of the HTTP status `status`, for example CodeNotFound for 404,
+// which is the reverse of HTTPStatus for the common statuses. Other 4xx statuses return CodeInvalidRequest,
+// other 5xx statuses return CodeInternalError, and the statuses below 400 return CodeOK.
+func FromHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeNotAuthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusUnprocessableEntity:
+		return CodeBusinessValidationFailed
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return CodeServerBusy
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	}
+	switch {
+	case status < http.StatusBadRequest:
+		return CodeOK
+	case status < http.StatusInternalServerError:
+		return CodeInvalidRequest
+	default:
+		return CodeInternalError
+	}
+}
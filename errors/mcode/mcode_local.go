@@ -24,13 +24,11 @@ func (c localCode) Detail() any {
 	return c.detail
 }
 
-// String returns the string representation of the error code.
+// String returns the string representation of the error code, like "10001: user not found".
+// The detail is not included.
 func (c localCode) String() string {
-	if c.detail != nil {
-		return fmt.Sprintf(`%d:%s %v`, c.code, c.message, c.detail)
-	}
 	if c.message != "" {
-		return fmt.Sprintf(`%d:%s`, c.code, c.message)
+		return fmt.Sprintf(`%d: %s`, c.code, c.message)
 	}
 	return fmt.Sprintf(`%d`, c.code)
 }
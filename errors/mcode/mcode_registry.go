@@ -0,0 +1,165 @@
+package mcode
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	// FrameworkRange is the name of the code range reserved for the built-in codes of the framework,
+	// from FrameworkRangeMin to FrameworkRangeMax. Applications register their codes outside of it.
+	FrameworkRange = "maltose"
+	// FrameworkRangeMin is the lowest code of the framework range, which is CodeNil.
+	FrameworkRangeMin = -1
+	// FrameworkRangeMax is the highest code of the framework range.
+	FrameworkRangeMax = 999
+)
+
+var (
+	// ErrDuplicateCode is returned by Register when the code number is already registered.
+	ErrDuplicateCode = errors.New("duplicate error code")
+	// ErrRangeConflict is returned by RegisterRange when the range overlaps a registered range,
+	// and by Register when the code belongs to the framework range.
+	ErrRangeConflict = errors.New("error code range conflict")
+	// ErrOutOfRange is returned by Register when the code is not in a range registered by RegisterRange.
+	ErrOutOfRange = errors.New("error code out of registered ranges")
+)
+
+// Range is a range of code numbers reserved by a package or service, see RegisterRange.
+type Range struct {
+	Name string `json:"name"`
+	Min  int    `json:"min"`
+	Max  int    `json:"max"`
+}
+
+// Contains reports whether `code` is in the range.
+func (r Range) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// registry is the registry of the code ranges and codes.
+type registry struct {
+	mu     sync.RWMutex
+	ranges []Range
+	codes  map[int]Code
+}
+
+// defaultRegistry holds the framework range and the built-in codes.
+var defaultRegistry = newRegistry()
+
+// newRegistry creates a registry with the framework range and the built-in codes.
+func newRegistry() *registry {
+	r := &registry{
+		ranges: []Range{{Name: FrameworkRange, Min: FrameworkRangeMin, Max: FrameworkRangeMax}},
+		codes:  make(map[int]Code),
+	}
+	for _, code := range []Code{
+		CodeNil, CodeOK, CodeUnknown, CodeInvalidRequest, CodeInvalidParameter, CodeMissingParameter,
+		CodeValidationFailed, CodeNotFound, CodeNotAuthorized, CodeForbidden, CodeInternalError,
+		CodeDbOperationError, CodeInternalPanic, CodeServerBusy, CodeInvalidOperation,
+		CodeInvalidConfiguration, CodeMissingConfiguration, CodeNotImplemented, CodeNotSupported,
+		CodeOperationFailed, CodeSecurityReason, CodeBusinessValidationFailed,
+	} {
+		r.codes[code.Code()] = code
+	}
+	return r
+}
+
+// RegisterRange reserves the codes from `min` to `max` for `name`, for example a service:
+//
+//	mcode.RegisterRange("user-service", 10000, 10999)
+//
+// It returns an error wrapping ErrRangeConflict if the range overlaps a registered range or
+// `name` is already registered.
+func RegisterRange(name string, min, max int) error {
+	if min > max {
+		return fmt.Errorf(`invalid error code range "%s" [%d, %d]`, name, min, max)
+	}
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	for _, r := range defaultRegistry.ranges {
+		if r.Name == name {
+			return fmt.Errorf(`%w: range "%s" is already registered`, ErrRangeConflict, name)
+		}
+		if min <= r.Max && max >= r.Min {
+			return fmt.Errorf(`%w: range "%s" [%d, %d] overlaps "%s" [%d, %d]`,
+				ErrRangeConflict, name, min, max, r.Name, r.Min, r.Max)
+		}
+	}
+	defaultRegistry.ranges = append(defaultRegistry.ranges, Range{Name: name, Min: min, Max: max})
+	return nil
+}
+
+// Register creates and registers a new error code, see New. Nothing is registered if it returns
+// an error wrapping ErrDuplicateCode if the code number is already registered, ErrRangeConflict if it
+// belongs to the framework range, or ErrOutOfRange if it is not in a range registered by RegisterRange.
+func Register(code int, message string, detail any) (Code, error) {
+	return defaultRegistry.register(code, message, detail, true)
+}
+
+// register registers the code, checking that it is in a registered range if `inRange` is true.
+func (r *registry) register(code int, message string, detail any, inRange bool) (Code, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.codes[code]; ok {
+		return nil, fmt.Errorf(`%w: code %d "%s" is already registered as "%s"`,
+			ErrDuplicateCode, code, message, existing.Message())
+	}
+	if r.ranges[0].Contains(code) {
+		return nil, fmt.Errorf(`%w: code %d "%s" is reserved for the framework`, ErrRangeConflict, code, message)
+	}
+	if inRange && !slices.ContainsFunc(r.ranges, func(rg Range) bool { return rg.Contains(code) }) {
+		return nil, fmt.Errorf(`%w: code %d "%s" is not in a range registered by RegisterRange`, ErrOutOfRange, code, message)
+	}
+	c := localCode{code: code, message: message, detail: detail}
+	r.codes[code] = c
+	return c, nil
+}
+
+// Lookup returns the registered code with the number `code`.
+func Lookup(code int) (Code, bool) {
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	c, ok := defaultRegistry.codes[code]
+	return c, ok
+}
+
+// Codes returns all registered codes sorted by number, including the built-in codes,
+// for example to generate the documentation of the codes.
+func Codes() []Code {
+	defaultRegistry.mu.RLock()
+	codes := make([]Code, 0, len(defaultRegistry.codes))
+	for _, c := range defaultRegistry.codes {
+		codes = append(codes, c)
+	}
+	defaultRegistry.mu.RUnlock()
+	slices.SortFunc(codes, func(a, b Code) int {
+		return a.Code() - b.Code()
+	})
+	return codes
+}
+
+// Ranges returns all registered ranges sorted by their lowest code, including the framework range.
+func Ranges() []Range {
+	defaultRegistry.mu.RLock()
+	ranges := slices.Clone(defaultRegistry.ranges)
+	defaultRegistry.mu.RUnlock()
+	slices.SortFunc(ranges, func(a, b Range) int {
+		return a.Min - b.Min
+	})
+	return ranges
+}
+
+// RangeOf returns the registered range containing `code`.
+func RangeOf(code int) (Range, bool) {
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	for _, r := range defaultRegistry.ranges {
+		if r.Contains(code) {
+			return r, true
+		}
+	}
+	return Range{}, false
+}
@@ -0,0 +1,78 @@
+package mcode_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewWithDetail tests creating codes without registering them
+func TestNewWithDetail(t *testing.T) {
+	code := mcode.NewWithDetail(30001, "user not found", map[string]any{"id": 1})
+	assert.Equal(t, 30001, code.Code())
+	assert.Equal(t, "user not found", code.Message())
+	assert.Equal(t, map[string]any{"id": 1}, code.Detail())
+	_, ok := mcode.Lookup(30001)
+	assert.False(t, ok)
+}
+
+// TestDerive tests that derived codes keep the number of their code
+func TestDerive(t *testing.T) {
+	withMessage := mcode.WithMessage(mcode.WithDetail(mcode.CodeNotFound, "users"), "user not found")
+	assert.Equal(t, mcode.CodeNotFound.Code(), withMessage.Code())
+	assert.Equal(t, "user not found", withMessage.Message())
+	assert.Equal(t, "users", withMessage.Detail())
+
+	withDetail := mcode.WithDetail(withMessage, []int{1})
+	assert.Equal(t, "user not found", withDetail.Message())
+	assert.Equal(t, []int{1}, withDetail.Detail())
+	assert.Equal(t, "Not Found", mcode.WithCode(mcode.CodeNotFound, nil).Message())
+
+	// the built-in codes are not changed
+	assert.Equal(t, "Not Found", mcode.CodeNotFound.Message())
+	assert.Nil(t, mcode.CodeNotFound.Detail())
+}
+
+// TestEqual tests comparing codes by number
+func TestEqual(t *testing.T) {
+	detail := map[string]any{"field": "email"}
+	assert.True(t, mcode.Equal(mcode.CodeNotFound, mcode.WithMessage(mcode.CodeNotFound, "user not found")))
+	// uncomparable details do not panic
+	assert.True(t, mcode.Equal(mcode.WithDetail(mcode.CodeValidationFailed, detail), mcode.WithDetail(mcode.CodeValidationFailed, detail)))
+	assert.True(t, mcode.Equal(mcode.NewWithDetail(104, "", nil), mcode.CodeNotFound))
+	assert.False(t, mcode.Equal(mcode.CodeNotFound, mcode.CodeForbidden))
+	assert.False(t, mcode.Equal(mcode.CodeNil, nil))
+	assert.True(t, mcode.Equal(nil, nil))
+}
+
+// TestSwitch tests that the built-in codes can be compared in switch statements
+func TestSwitch(t *testing.T) {
+	name := func(code mcode.Code) string {
+		switch code {
+		case mcode.CodeNotFound:
+			return "not found"
+		case mcode.CodeForbidden:
+			return "forbidden"
+		default:
+			return "other"
+		}
+	}
+	assert.Equal(t, "not found", name(mcode.CodeNotFound))
+	assert.Equal(t, "forbidden", name(mcode.CodeForbidden))
+	assert.Equal(t, "other", name(mcode.CodeInternalError))
+	// derived codes are matched by number
+	switch code := mcode.WithMessage(mcode.CodeNotFound, "user not found"); code.Code() {
+	case mcode.CodeNotFound.Code():
+	default:
+		t.Errorf("unexpected code %v", code)
+	}
+}
+
+// TestString tests the string representation of the codes
+func TestString(t *testing.T) {
+	assert.Equal(t, "104: Not Found", fmt.Sprint(mcode.CodeNotFound))
+	assert.Equal(t, "10001: user not found", fmt.Sprint(mcode.NewWithDetail(10001, "user not found", map[string]any{"id": 1})))
+	assert.Equal(t, "-1", fmt.Sprint(mcode.CodeNil))
+}
@@ -0,0 +1,80 @@
+package mcode_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/stretchr/testify/assert"
+)
+
+// baseCode is embedded by statusCode to promote the methods of mcode.Code
+type baseCode = mcode.Code
+
+// statusCode is a code carrying its own HTTP status
+type statusCode struct {
+	baseCode
+	status int
+}
+
+func (c statusCode) HTTPStatus() int {
+	return c.status
+}
+
+// the codes are registered at package level, so that the tests can run several times
+var (
+	_                   = mcode.RegisterRange("mcode-http-test", 20000, 20099)
+	codeQuotaExceeded   = mcode.New(20001, "Quota Exceeded", nil)
+	codePaymentRequired = mcode.New(20002, "Payment Required", nil)
+	codeRateLimited     = mcode.New(20003, "Rate Limited", nil)
+	codeLegalReasons    = mcode.New(20004, "Legal Reasons", nil)
+)
+
+// TestHTTPStatus tests the default HTTP status of the codes and the override precedence
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusOK, mcode.HTTPStatus(mcode.CodeOK))
+	assert.Equal(t, http.StatusNotFound, mcode.HTTPStatus(mcode.CodeNotFound))
+	assert.Equal(t, http.StatusBadRequest, mcode.HTTPStatus(mcode.CodeValidationFailed))
+	assert.Equal(t, http.StatusInternalServerError, mcode.HTTPStatus(mcode.CodeNil))
+	assert.Equal(t, http.StatusInternalServerError, mcode.HTTPStatus(nil))
+
+	// unknown codes are 500 unless they carry their own status
+	assert.Equal(t, http.StatusInternalServerError, mcode.HTTPStatus(codeQuotaExceeded))
+	own := statusCode{baseCode: codePaymentRequired, status: http.StatusPaymentRequired}
+	assert.Equal(t, http.StatusPaymentRequired, mcode.HTTPStatus(own))
+
+	// SetHTTPStatus overrides the status of the code itself and the defaults
+	overridden := statusCode{baseCode: codeLegalReasons, status: http.StatusPaymentRequired}
+	mcode.SetHTTPStatus(codeRateLimited, http.StatusTooManyRequests)
+	mcode.SetHTTPStatus(overridden, http.StatusUnavailableForLegalReasons)
+	mcode.SetHTTPStatus(mcode.CodeNotSupported, http.StatusNotImplemented)
+	t.Cleanup(func() { mcode.SetHTTPStatus(mcode.CodeNotSupported, http.StatusBadRequest) })
+	assert.Equal(t, http.StatusTooManyRequests, mcode.HTTPStatus(codeRateLimited))
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, mcode.HTTPStatus(overridden))
+	assert.Equal(t, http.StatusNotImplemented, mcode.HTTPStatus(mcode.CodeNotSupported))
+	// codes are matched by number
+	assert.Equal(t, http.StatusNotImplemented, mcode.HTTPStatus(mcode.WithCode(mcode.CodeNotSupported, "detail")))
+}
+
+// TestFromHTTPStatus tests mapping HTTP status to codes
+func TestFromHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		code   mcode.Code
+	}{
+		{http.StatusOK, mcode.CodeOK},
+		{http.StatusNoContent, mcode.CodeOK},
+		{http.StatusBadRequest, mcode.CodeInvalidRequest},
+		{http.StatusUnauthorized, mcode.CodeNotAuthorized},
+		{http.StatusForbidden, mcode.CodeForbidden},
+		{http.StatusNotFound, mcode.CodeNotFound},
+		{http.StatusConflict, mcode.CodeInvalidRequest},
+		{http.StatusTooManyRequests, mcode.CodeServerBusy},
+		{http.StatusNotImplemented, mcode.CodeNotImplemented},
+		{http.StatusServiceUnavailable, mcode.CodeServerBusy},
+		{http.StatusBadGateway, mcode.CodeInternalError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, mcode.FromHTTPStatus(tt.status), "status %d", tt.status)
+	}
+}
@@ -0,0 +1,125 @@
+package mcode_test
+
+import (
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the registrations are at package level, so that the tests can run several times
+var (
+	errTestRange     = mcode.RegisterRange("mcode-test", 70000, 70999)
+	codeTestNotFound = mcode.New(70001, "Test Not Found", "users")
+)
+
+// TestRegisterRange tests reserving ranges
+func TestRegisterRange(t *testing.T) {
+	require.NoError(t, errTestRange)
+	assert.ErrorIs(t, mcode.RegisterRange("mcode-test", 71000, 71999), mcode.ErrRangeConflict)
+	assert.ErrorIs(t, mcode.RegisterRange("mcode-overlap", 70999, 71999), mcode.ErrRangeConflict)
+	assert.ErrorIs(t, mcode.RegisterRange("mcode-framework", 500, 1500), mcode.ErrRangeConflict)
+	err := mcode.RegisterRange("mcode-invalid", 71999, 71000)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, mcode.ErrRangeConflict)
+
+	ranges := mcode.Ranges()
+	assert.Equal(t, mcode.Range{Name: mcode.FrameworkRange, Min: mcode.FrameworkRangeMin, Max: mcode.FrameworkRangeMax}, ranges[0])
+	assert.Contains(t, ranges, mcode.Range{Name: "mcode-test", Min: 70000, Max: 70999})
+	for _, name := range []string{"mcode-overlap", "mcode-framework", "mcode-invalid"} {
+		assert.NotContains(t, ranges, mcode.Range{Name: name})
+	}
+	for i := 1; i < len(ranges); i++ {
+		assert.Less(t, ranges[i-1].Min, ranges[i].Min)
+	}
+}
+
+// TestRangeOf tests finding the range of a code
+func TestRangeOf(t *testing.T) {
+	r, ok := mcode.RangeOf(70500)
+	assert.True(t, ok)
+	assert.Equal(t, "mcode-test", r.Name)
+	r, ok = mcode.RangeOf(mcode.CodeNil.Code())
+	assert.True(t, ok)
+	assert.Equal(t, mcode.FrameworkRange, r.Name)
+	_, ok = mcode.RangeOf(90003)
+	assert.False(t, ok)
+}
+
+// TestRegister tests the errors of the registration, which register nothing
+func TestRegister(t *testing.T) {
+	_, err := mcode.Register(70001, "Other", nil)
+	assert.ErrorIs(t, err, mcode.ErrDuplicateCode)
+	_, err = mcode.Register(150, "Framework", nil)
+	assert.ErrorIs(t, err, mcode.ErrRangeConflict)
+	_, err = mcode.Register(90003, "Out Of Range", nil)
+	assert.ErrorIs(t, err, mcode.ErrOutOfRange)
+
+	code, ok := mcode.Lookup(70001)
+	assert.True(t, ok)
+	assert.Equal(t, codeTestNotFound, code)
+	_, ok = mcode.Lookup(150)
+	assert.False(t, ok)
+	_, ok = mcode.Lookup(90003)
+	assert.False(t, ok)
+}
+
+// TestLookup tests looking up the built-in and the registered codes
+func TestLookup(t *testing.T) {
+	code, ok := mcode.Lookup(104)
+	assert.True(t, ok)
+	assert.Equal(t, mcode.CodeNotFound, code)
+	code, ok = mcode.Lookup(70001)
+	assert.True(t, ok)
+	assert.Equal(t, "Test Not Found", code.Message())
+	assert.Equal(t, "users", code.Detail())
+	_, ok = mcode.Lookup(70002)
+	assert.False(t, ok)
+}
+
+// TestCodes tests listing the codes sorted by number
+func TestCodes(t *testing.T) {
+	codes := mcode.Codes()
+	assert.Equal(t, mcode.CodeNil, codes[0])
+	assert.Contains(t, codes, mcode.CodeNotFound)
+	assert.Contains(t, codes, codeTestNotFound)
+	for i := 1; i < len(codes); i++ {
+		assert.Less(t, codes[i-1].Code(), codes[i].Code())
+	}
+}
+
+// TestNew tests that New logs the registration errors and returns the code outside of debug mode
+func TestNew(t *testing.T) {
+	duplicate := mcode.New(70001, "Other", nil)
+	assert.Equal(t, 70001, duplicate.Code())
+	assert.Equal(t, "Other", duplicate.Message())
+	code, _ := mcode.Lookup(70001)
+	assert.Equal(t, "Test Not Found", code.Message(), "the registered code is kept")
+
+	framework := mcode.New(150, "Framework", nil)
+	assert.Equal(t, 150, framework.Code())
+	_, ok := mcode.Lookup(150)
+	assert.False(t, ok)
+
+	// the codes out of the registered ranges are registered anyway
+	outOfRange := mcode.New(90001, "Out Of Range", nil)
+	assert.Equal(t, 90001, outOfRange.Code())
+	code, ok = mcode.Lookup(90001)
+	assert.True(t, ok)
+	assert.Equal(t, "Out Of Range", code.Message())
+}
+
+// TestNew_Debug tests that New panics on the registration errors in debug mode
+func TestNew_Debug(t *testing.T) {
+	mcode.SetDebug(true)
+	t.Cleanup(func() { mcode.SetDebug(false) })
+
+	assert.PanicsWithError(t, `duplicate error code: code 70001 "Other" is already registered as "Test Not Found"`, func() {
+		mcode.New(70001, "Other", nil)
+	})
+	assert.Panics(t, func() { mcode.New(150, "Framework", nil) })
+	assert.Panics(t, func() { mcode.New(90002, "Out Of Range", nil) })
+	_, ok := mcode.Lookup(90002)
+	assert.False(t, ok)
+}
@@ -26,9 +26,13 @@ func Newf(format string, a ...any) error {
 	}
 }
 
-// Wrap wraps an error.
+// Wrap wraps an error with the text, keeping `err` as the next error of the chain,
+// so errors.Is, errors.As and Code see through it. It returns nil if `err` is nil.
 // Example: err := merror.Wrap(err, "username cannot be empty")
 func Wrap(err error, text string) error {
+	if err == nil {
+		return nil
+	}
 	return &Error{
 		stack: callers(),
 		text:  text,
@@ -37,9 +41,12 @@ func Wrap(err error, text string) error {
 	}
 }
 
-// Wrapf wraps an error.
+// Wrapf wraps an error with the formatted text, see Wrap. It returns nil if `err` is nil.
 // Example: err := merror.Wrapf(err, "username %s cannot be empty", admin)
 func Wrapf(err error, format string, a ...any) error {
+	if err == nil {
+		return nil
+	}
 	return &Error{
 		stack: callers(),
 		text:  fmt.Sprintf(format, a...),
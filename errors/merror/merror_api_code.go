@@ -27,7 +27,8 @@ func NewCodef(code mcode.Code, format string, args ...any) error {
 	}
 }
 
-// WrapCode wraps an error and appends the specified error code.
+// WrapCode wraps an error and appends the specified error code, which overrides the code of `err`.
+// It returns nil if `err` is nil.
 // Example: err := merror.WrapCode(err, mcode.ValidationError)
 func WrapCode(err error, code mcode.Code, text ...string) error {
 	if err == nil {
@@ -41,7 +42,7 @@ func WrapCode(err error, code mcode.Code, text ...string) error {
 	}
 }
 
-// WrapCodef wraps an error and appends the specified error code and formatted text.
+// WrapCodef wraps an error and appends the specified error code and formatted text, see WrapCode.
 // Example: err := merror.WrapCodef(err, mcode.ValidationError, "username %s cannot be empty", admin)
 func WrapCodef(err error, code mcode.Code, format string, args ...any) error {
 	if err == nil {
@@ -55,17 +56,83 @@ func WrapCodef(err error, code mcode.Code, format string, args ...any) error {
 	}
 }
 
-// Code gets the error code of the error.
-// If there is no error code, and the error does not implement the ICode interface, it returns CodeNil.
+// Code gets the error code of the error, which is the outermost code set in the chain of `err`:
+// errors wrapped by Wrap or fmt.Errorf with %w inherit the code of the error they wrap,
+// and WrapCode overrides it. Errors joined by Join have the code of the first error having one.
+// If no error of the chain has a code, it returns CodeNil.
 func Code(err error) mcode.Code {
-	if err == nil {
-		return mcode.CodeNil
+	for err != nil {
+		if e, ok := err.(ICode); ok {
+			if code := e.Code(); code != nil && !mcode.Equal(code, mcode.CodeNil) {
+				return code
+			}
+		}
+		if e, ok := err.(IUnwrapMulti); ok {
+			for _, joined := range e.Unwrap() {
+				if code := Code(joined); !mcode.Equal(code, mcode.CodeNil) {
+					return code
+				}
+			}
+			return mcode.CodeNil
+		}
+		err = Unwrap(err)
 	}
-	if e, ok := err.(ICode); ok {
-		return e.Code()
+	return mcode.CodeNil
+}
+
+// HasCode reports whether any error in the chain of `err` has `code`, including the inner errors whose code
+// is overridden by WrapCode and the errors joined by Join. Codes are compared by number,
+// so a code with a different detail, see mcode.WithCode, is the same code.
+func HasCode(err error, code mcode.Code) bool {
+	if code == nil {
+		return false
 	}
-	if e, ok := err.(IUnwrap); ok {
-		return Code(e.Unwrap())
+	for err != nil {
+		if e, ok := err.(ICode); ok {
+			if c := e.Code(); c != nil && !mcode.Equal(c, mcode.CodeNil) && mcode.Equal(c, code) {
+				return true
+			}
+		}
+		if e, ok := err.(IUnwrapMulti); ok {
+			for _, joined := range e.Unwrap() {
+				if HasCode(joined, code) {
+					return true
+				}
+			}
+			return false
+		}
+		err = Unwrap(err)
 	}
-	return mcode.CodeNil
+	return false
+}
+
+// IsCode reports whether the code of `err` is `code`, which is the outermost code set in its chain, see Code.
+// Codes are compared by number like HasCode, so it suits asserting the errors of tests:
+//
+//	require.True(t, merror.IsCode(err, mcode.CodeNotFound))
+func IsCode(err error, code mcode.Code) bool {
+	if code == nil {
+		return false
+	}
+	return Code(err).Code() == code.Code()
+}
+
+// MessageContains reports whether the message of any error in the chain of `err` contains `substr`,
+// including the errors joined by Join and the errors whose message does not include the errors they wrap.
+func MessageContains(err error, substr string) bool {
+	for err != nil {
+		if strings.Contains(err.Error(), substr) {
+			return true
+		}
+		if e, ok := err.(IUnwrapMulti); ok {
+			for _, joined := range e.Unwrap() {
+				if MessageContains(joined, substr) {
+					return true
+				}
+			}
+			return false
+		}
+		err = Unwrap(err)
+	}
+	return false
 }
@@ -0,0 +1,83 @@
+package merror
+
+import (
+	"fmt"
+	"io"
+)
+
+// detailError is the error of WithDetail, it adds a detail to the error it wraps without changing its message.
+type detailError struct {
+	error
+	key   string
+	value any
+}
+
+// WithDetail returns `err` with the machine-readable detail `key` and `value`, for example the offending field,
+// a resource ID or a retry-after hint. The message, code and stack of `err` are unchanged, see Details.
+// It returns nil if `err` is nil.
+// Example: err = merror.WithDetail(err, "user_id", id)
+func WithDetail(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+	return &detailError{error: err, key: key, value: value}
+}
+
+// Details returns the details added by WithDetail in the chain of `err`, including those added before
+// wrapping it by Wrap or WrapCode. A detail added by an outer error wins over the same key of an inner error.
+// It returns nil if `err` has no detail.
+func Details(err error) map[string]any {
+	var details map[string]any
+	for ; err != nil; err = Unwrap(err) {
+		e, ok := err.(*detailError)
+		if !ok {
+			continue
+		}
+		if details == nil {
+			details = make(map[string]any)
+		}
+		if _, exists := details[e.key]; !exists {
+			details[e.key] = e.value
+		}
+	}
+	return details
+}
+
+// Unwrap returns the error with the detail.
+func (e *detailError) Unwrap() error {
+	return e.error
+}
+
+// Stack returns the stack of the error with the detail.
+func (e *detailError) Stack() string {
+	return Stack(e.error)
+}
+
+// Format implements the fmt.Formatter interface, it formats the error with the detail.
+func (e *detailError) Format(s fmt.State, verb rune) {
+	if f, ok := e.error.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
+
+// errorDetails returns the details of `err` merged into the code detail of `err` when it is a map,
+// which are the details of the JSON of `err` and the parameters of its message templates.
+func errorDetails(err error, detail any) any {
+	details := Details(err)
+	if details == nil {
+		return detail
+	}
+	if params := templateParams(detail); params != nil {
+		merged := make(map[string]any, len(params)+len(details))
+		for key, value := range params {
+			merged[key] = value
+		}
+		for key, value := range details {
+			merged[key] = value
+		}
+		return merged
+	}
+	return details
+}
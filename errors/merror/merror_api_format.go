@@ -0,0 +1,176 @@
+package merror
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// FormatOptions are the options of Format.
+type FormatOptions struct {
+	// MaxFrames is the maximum number of frames of each stack, 0 for no limit.
+	MaxFrames int
+}
+
+// Chain is the structured representation of an error and the stacks of its chain, see Format.
+type Chain struct {
+	// Message is the message of the whole chain, like "load order: query user: user not found".
+	Message string
+	// Stacks are the unique stacks of the chain, starting with the stack where the error originated.
+	Stacks []ChainStack
+}
+
+// ChainStack is a stack of the chain of an error, shared by the errors wrapping it from its frames.
+type ChainStack struct {
+	// Error is the message of the error whose stack it is.
+	Error string
+	// Frames are the frames of the stack, filtered like Stack, see SetStackFilter.
+	Frames []ChainFrame
+	// More is the number of frames dropped by FormatOptions.MaxFrames.
+	More int
+}
+
+// ChainFrame is a frame of a stack of the chain of an error.
+type ChainFrame struct {
+	Function string
+	File     string
+	Line     int
+	// Wraps are the errors of the chain wrapping the error of the stack in this frame, innermost first.
+	Wraps []ChainWrap
+}
+
+// ChainWrap is the point where an error of the chain wraps the errors below it.
+type ChainWrap struct {
+	Message string
+	File    string
+	Line    int
+}
+
+// Format returns the structured representation of `err` and the stacks of the errors of its chain.
+// The stack of an error wrapping another one is merged into the stack of the wrapped error when it is
+// called from it, like in the same goroutine, so the chain is described by one stack per unique stack
+// whose frames carry the points where errors are wrapped. This is the representation of %+v, see Chain.String.
+// It returns nil if `err` is nil.
+//
+//	for _, s := range merror.Format(err, merror.FormatOptions{MaxFrames: 32}).Stacks {
+//		...
+//	}
+func Format(err error, opts FormatOptions) *Chain {
+	if err == nil {
+		return nil
+	}
+	var levels []*Error
+	for e := error(err); e != nil; e = Unwrap(e) {
+		if level, ok := e.(*Error); ok && level != nil && len(level.stack) > 0 {
+			levels = append(levels, level)
+		}
+	}
+
+	chain := &Chain{Message: err.Error()}
+	for i := len(levels) - 1; i >= 0; i-- {
+		frames := levels[i].stack.frames()
+		if len(frames) == 0 {
+			continue
+		}
+		if !chain.merge(levels[i], frames) {
+			stack := ChainStack{Error: levels[i].Error(), Frames: make([]ChainFrame, len(frames))}
+			for j, frame := range frames {
+				stack.Frames[j] = ChainFrame{Function: frame.Function, File: frame.File, Line: frame.Line}
+			}
+			chain.Stacks = append(chain.Stacks, stack)
+		}
+	}
+	if opts.MaxFrames > 0 {
+		for i := range chain.Stacks {
+			if s := &chain.Stacks[i]; len(s.Frames) > opts.MaxFrames {
+				s.More = len(s.Frames) - opts.MaxFrames
+				s.Frames = s.Frames[:opts.MaxFrames]
+			}
+		}
+	}
+	return chain
+}
+
+// merge annotates the frame of a stack of `c` where `err`, whose stack is `frames`, wraps the errors below it.
+// The first frame of `frames` is the wrap point, the others must be the frames following it in the stack.
+// It reports false if no stack of `c` is the one of `err`.
+func (c *Chain) merge(err *Error, frames []runtime.Frame) bool {
+	for i := range c.Stacks {
+		s := &c.Stacks[i]
+		for at := range s.Frames {
+			if s.Frames[at].Function != frames[0].Function || !s.follows(at, frames[1:]) {
+				continue
+			}
+			// the stack of the wrapped error may be cut by the stack depth
+			for _, frame := range frames[min(len(frames), len(s.Frames)-at):] {
+				s.Frames = append(s.Frames, ChainFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			}
+			message := err.message()
+			if message == "" {
+				message = err.Error()
+			}
+			s.Frames[at].Wraps = append(s.Frames[at].Wraps, ChainWrap{
+				Message: message,
+				File:    frames[0].File,
+				Line:    frames[0].Line,
+			})
+			return true
+		}
+	}
+	return false
+}
+
+// follows reports whether `frames` are the frames following the frame `at` of `s`, as far as both go.
+func (s *ChainStack) follows(at int, frames []runtime.Frame) bool {
+	for i, frame := range frames {
+		if at+1+i >= len(s.Frames) {
+			return true
+		}
+		f := s.Frames[at+1+i]
+		if f.Function != frame.Function || f.File != frame.File || f.Line != frame.Line {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the human readable representation of `c`, which is the message of the chain
+// followed by its stacks. The frames of the stacks are written like those of Error.Stack,
+// with a line `wrapped "message" at file:line` for each error wrapped in the frame.
+func (c *Chain) String() string {
+	if c == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(c.Message)
+	b.WriteByte('\n')
+	for _, s := range c.Stacks {
+		b.WriteString("error: ")
+		b.WriteString(s.Error)
+		b.WriteString("\nstack:\n")
+		for _, frame := range s.Frames {
+			b.WriteString("  ")
+			b.WriteString(frame.Function)
+			b.WriteString("\n    ")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteByte('\n')
+			for _, wrap := range frame.Wraps {
+				b.WriteString("    wrapped ")
+				b.WriteString(strconv.Quote(wrap.Message))
+				b.WriteString(" at ")
+				b.WriteString(wrap.File)
+				b.WriteByte(':')
+				b.WriteString(strconv.Itoa(wrap.Line))
+				b.WriteByte('\n')
+			}
+		}
+		if s.More > 0 {
+			b.WriteString("  ... ")
+			b.WriteString(strconv.Itoa(s.More))
+			b.WriteString(" more frames\n")
+		}
+	}
+	return b.String()
+}
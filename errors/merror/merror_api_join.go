@@ -0,0 +1,68 @@
+package merror
+
+import (
+	"strings"
+)
+
+// IUnwrapMulti defines the interface of errors joining several errors, like the errors of Join and errors.Join.
+type IUnwrapMulti interface {
+	Error() string
+	Unwrap() []error
+}
+
+// joinError is the error of Join.
+type joinError struct {
+	errs []error
+}
+
+// Join returns an error joining the non-nil errors `errs`, for example the failures of a batch operation,
+// or nil if there is none. Like errors.Join, errors.Is and errors.As match any of the errors,
+// its code is the code of the first error having one, and its message lists the messages of all errors.
+func Join(errs ...error) error {
+	var joined []error
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	if len(joined) == 0 {
+		return nil
+	}
+	return &joinError{errs: joined}
+}
+
+// Split returns the errors joined by Join or errors.Join in the chain of `err`,
+// or `err` itself if it does not join errors. It returns nil if `err` is nil.
+func Split(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if errs, ok := joined(err); ok {
+		return append([]error(nil), errs...)
+	}
+	return []error{err}
+}
+
+// joined returns the errors joined by the first error joining errors in the chain of `err`.
+func joined(err error) ([]error, bool) {
+	for ; err != nil; err = Unwrap(err) {
+		if e, ok := err.(IUnwrapMulti); ok {
+			return e.Unwrap(), true
+		}
+	}
+	return nil, false
+}
+
+// Error implements the error interface, it returns the messages of the errors separated by "; ".
+func (e *joinError) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the joined errors, which is for errors.Is and errors.As.
+func (e *joinError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,78 @@
+package merror
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// lazyText is the text of an error created by the lazy constructors, which is formatted on first use.
+type lazyText struct {
+	once   sync.Once
+	format string
+	args   []any
+	text   string
+}
+
+// LazyNewf creates a new error like Newf, but formats its text only when the message is first used,
+// for example by Error or fmt, and caches it. Errors checked by Code, Is or As and then dropped never
+// pay the formatting cost, which suits hot paths. The stack is still captured when the error is created.
+// Note that `a` is kept until the text is formatted, so its values should not be modified afterwards.
+// Example: err := merror.LazyNewf("user %d not found", id)
+func LazyNewf(format string, a ...any) error {
+	return &Error{
+		stack: callers(),
+		lazy:  &lazyText{format: format, args: a},
+		code:  mcode.CodeNil,
+	}
+}
+
+// LazyNewCodef creates a new error with the specified error code like NewCodef, formatting its text lazily, see LazyNewf.
+// Example: err := merror.LazyNewCodef(mcode.CodeNotFound, "user %d not found", id)
+func LazyNewCodef(code mcode.Code, format string, args ...any) error {
+	return &Error{
+		stack: callers(),
+		lazy:  &lazyText{format: format, args: args},
+		code:  code,
+	}
+}
+
+// LazyWrapf wraps an error with the formatted text like Wrapf, formatting it lazily, see LazyNewf.
+// It returns nil if `err` is nil.
+// Example: err := merror.LazyWrapf(err, "load user %d", id)
+func LazyWrapf(err error, format string, a ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		stack: callers(),
+		lazy:  &lazyText{format: format, args: a},
+		error: err,
+		code:  mcode.CodeNil,
+	}
+}
+
+// LazyWrapCodef wraps an error and appends the specified error code and formatted text like WrapCodef,
+// formatting it lazily, see LazyNewf. It returns nil if `err` is nil.
+// Example: err := merror.LazyWrapCodef(err, mcode.CodeDbOperationError, "load user %d", id)
+func LazyWrapCodef(err error, code mcode.Code, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		error: err,
+		stack: callers(),
+		lazy:  &lazyText{format: format, args: args},
+		code:  code,
+	}
+}
+
+// String returns the formatted text, formatting it on first call.
+func (t *lazyText) String() string {
+	t.once.Do(func() {
+		t.text = fmt.Sprintf(t.format, t.args...)
+		t.args = nil
+	})
+	return t.text
+}
@@ -0,0 +1,151 @@
+package merror
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// messageTemplate is a parsed message template, the parts at odd indexes are parameter names.
+type messageTemplate []string
+
+var (
+	// messagesMu protects messages.
+	messagesMu sync.RWMutex
+	// messages holds the message templates by lower case locale and code.
+	messages = map[string]map[int]messageTemplate{}
+)
+
+// RegisterMessages registers the message templates of the codes for `locale`, see Localize:
+//
+//	merror.RegisterMessages("zh-CN", map[int]string{10001: "用户不存在: {name}"})
+//
+// A template refers to the parameters of the error by name in braces, and "{{" and "}}" write literal braces.
+// The templates are merged into the registered templates of the locale, replacing those of the same codes.
+// It panics if a template is invalid, for example with an unclosed brace, which is found at startup.
+func RegisterMessages(locale string, templates map[int]string) {
+	parsed := make(map[int]messageTemplate, len(templates))
+	for code, text := range templates {
+		tpl, err := parseTemplate(text)
+		if err != nil {
+			panic(Wrapf(err, `invalid message template of code %d for locale "%s"`, code, locale))
+		}
+		parsed[code] = tpl
+	}
+	locale = normalizeLocale(locale)
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	if messages[locale] == nil {
+		messages[locale] = make(map[int]messageTemplate, len(parsed))
+	}
+	for code, tpl := range parsed {
+		messages[locale][code] = tpl
+	}
+}
+
+// Localize returns the message of `err` for `locale`, rendering the template registered by RegisterMessages
+// for the code of `err` with the parameters of `err`, which are the details added by WithDetail and the entries
+// of the code detail when it is a map, for example `merror.WithDetail(merror.NewCode(CodeUserNotFound), "name", name)`.
+// A locale like "zh-CN" falls back to the templates of "zh". It returns the message of `err`
+// if there is no template, and keeps the parameters missing from `err` as they are written in the template.
+func Localize(err error, locale string) string {
+	if err == nil {
+		return ""
+	}
+	code := Code(err)
+	tpl, ok := lookupTemplate(locale, code.Code())
+	if !ok {
+		return err.Error()
+	}
+	return tpl.render(templateParams(errorDetails(err, code.Detail())))
+}
+
+// lookupTemplate returns the message template of `code` for `locale` or its language.
+func lookupTemplate(locale string, code int) (messageTemplate, bool) {
+	locale = normalizeLocale(locale)
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	for locale != "" {
+		if tpl, ok := messages[locale][code]; ok {
+			return tpl, true
+		}
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return nil, false
+}
+
+// normalizeLocale returns the lower case `locale` with "-" as the separator, for example "zh-cn" for "zh_CN".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// templateParams returns the parameters in the code detail `detail`.
+func templateParams(detail any) map[string]any {
+	switch v := detail.(type) {
+	case map[string]any:
+		return v
+	case map[string]string:
+		params := make(map[string]any, len(v))
+		for key, value := range v {
+			params[key] = value
+		}
+		return params
+	}
+	return nil
+}
+
+// parseTemplate parses the message template `text`.
+func parseTemplate(text string) (messageTemplate, error) {
+	var (
+		tpl     = messageTemplate{""}
+		literal strings.Builder
+	)
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '{' && i+1 < len(text) && text[i+1] == '{',
+			c == '}' && i+1 < len(text) && text[i+1] == '}':
+			literal.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(text[i+1:], '}')
+			if end < 0 {
+				return nil, Newf(`unclosed "{" at offset %d`, i)
+			}
+			name := strings.TrimSpace(text[i+1 : i+1+end])
+			if name == "" || strings.ContainsAny(name, "{") {
+				return nil, Newf(`invalid parameter name "%s" at offset %d`, name, i)
+			}
+			tpl[len(tpl)-1] = literal.String()
+			literal.Reset()
+			tpl = append(tpl, name, "")
+			i += end + 1
+		case c == '}':
+			return nil, Newf(`unexpected "}" at offset %d`, i)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	tpl[len(tpl)-1] = literal.String()
+	return tpl, nil
+}
+
+// render returns the message with the parameters `params`.
+func (tpl messageTemplate) render(params map[string]any) string {
+	var b strings.Builder
+	for i, part := range tpl {
+		if i%2 == 0 {
+			b.WriteString(part)
+			continue
+		}
+		if value, ok := params[part]; ok {
+			fmt.Fprint(&b, value)
+		} else {
+			b.WriteString("{" + part + "}")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package merror
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// FromPanic returns the error of the value `recovered` by recover, with the code mcode.CodeInternalError
+// and the message "panic: " followed by the value. A recovered error is wrapped, so errors.Is and errors.As
+// see through it. When it is called while panicking, like in the deferred function calling recover,
+// the stack starts at the function that panicked instead of the recovery. The error is marked as
+// not worth retrying, see MarkPermanent. It returns nil if `recovered` is nil.
+//
+//	defer func() {
+//		if v := recover(); v != nil {
+//			err = merror.FromPanic(v)
+//		}
+//	}()
+func FromPanic(recovered any) error {
+	if recovered == nil {
+		return nil
+	}
+	err := &Error{
+		stack: panicStack(callers()),
+		text:  "panic",
+		code:  mcode.CodeInternalError,
+	}
+	if e, ok := recovered.(error); ok {
+		err.error = e
+	} else {
+		err.text = fmt.Sprintf("panic: %v", recovered)
+	}
+	return MarkPermanent(err)
+}
+
+// Recover calls `fn` and returns its error, or the error of its panic if it panics, see FromPanic.
+// Example: err := merror.Recover(func() error { return job.Run(ctx) })
+func Recover(fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = FromPanic(recovered)
+		}
+	}()
+	return fn()
+}
+
+// panicStack returns the part of `s` from the function that panicked,
+// dropping the recovery plumbing, or `s` itself if it is not captured while panicking.
+func panicStack(s stack) stack {
+	for i, pc := range s {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			return s[i+1:]
+		}
+	}
+	return s
+}
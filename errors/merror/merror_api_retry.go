@@ -0,0 +1,105 @@
+package merror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// IRetryable defines the interface of errors classifying themselves as worth retrying or not, see IsRetryable.
+type IRetryable interface {
+	Error() string
+	Retryable() bool
+}
+
+// markError is the error of MarkRetryable and MarkPermanent, it marks the error it wraps without changing its message.
+type markError struct {
+	error
+	retryable bool
+}
+
+var (
+	// retryableCodesMu protects retryableCodes.
+	retryableCodesMu sync.RWMutex
+	// retryableCodes holds whether the errors of the codes are retryable by code number, see SetRetryable.
+	retryableCodes = map[int]bool{
+		mcode.CodeServerBusy.Code(): true,
+	}
+)
+
+// MarkRetryable returns `err` marked as worth retrying, like a conflict resolved by retrying the operation.
+// The mark survives wrapping and takes precedence over the default of the code, see IsRetryable.
+// It returns nil if `err` is nil.
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &markError{error: err, retryable: true}
+}
+
+// MarkPermanent returns `err` marked as not worth retrying, like a timeout of a request that must not be repeated.
+// The mark survives wrapping and takes precedence over the default of the code, see IsRetryable.
+// It returns nil if `err` is nil.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &markError{error: err, retryable: false}
+}
+
+// SetRetryable sets whether the errors of `code` are retryable by default, see IsRetryable.
+// The errors of mcode.CodeServerBusy are retryable unless it is set otherwise.
+func SetRetryable(code mcode.Code, retryable bool) {
+	retryableCodesMu.Lock()
+	defer retryableCodesMu.Unlock()
+	retryableCodes[code.Code()] = retryable
+}
+
+// IsRetryable reports whether `err` is worth retrying. The outermost error of the chain implementing
+// IRetryable decides first, like the marks of MarkRetryable and MarkPermanent, then the default
+// set by SetRetryable for the code of `err`, see Code. Otherwise `err` is retryable if an error
+// of its chain is a timeout, like net.Error and context.DeadlineExceeded. It returns false if `err` is nil.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r IRetryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	retryableCodesMu.RLock()
+	retryable, ok := retryableCodes[Code(err).Code()]
+	retryableCodesMu.RUnlock()
+	if ok {
+		return retryable
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// Retryable reports whether the marked error is retryable.
+func (e *markError) Retryable() bool {
+	return e.retryable
+}
+
+// Unwrap returns the marked error.
+func (e *markError) Unwrap() error {
+	return e.error
+}
+
+// Stack returns the stack of the marked error.
+func (e *markError) Stack() string {
+	return Stack(e.error)
+}
+
+// Format implements the fmt.Formatter interface, it formats the marked error.
+func (e *markError) Format(s fmt.State, verb rune) {
+	if f, ok := e.error.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+}
@@ -9,22 +9,35 @@ import (
 type stack []uintptr
 
 const (
-	// maxStackDepth marks the maximum stack depth.
+	// maxStackDepth marks the default maximum stack depth, see SetStackDepth.
 	maxStackDepth = 64
 )
 
-// Cause returns the root cause of `err`.
+// Cause returns the root cause of `err`, which is the deepest error of its chain that does not wrap
+// another error, for example sql.ErrNoRows in `merror.Wrap(fmt.Errorf("query: %w", sql.ErrNoRows), "load user")`.
+// The chain is followed through Unwrap, and through Cause for errors like those of github.com/pkg/errors
+// that do not implement Unwrap. Errors joining several errors like those of Join have no single cause,
+// so they are returned as they are. It returns nil if `err` is nil.
 func Cause(err error) error {
-	if err == nil {
-		return nil
-	}
-	if e, ok := err.(ICause); ok {
-		return e.Cause()
-	}
-	if e, ok := err.(IUnwrap); ok {
-		return Cause(e.Unwrap())
+	for err != nil {
+		if _, ok := err.(IUnwrapMulti); ok {
+			return err
+		}
+		if next := Unwrap(err); next != nil {
+			err = next
+			continue
+		}
+		if _, ok := err.(IUnwrap); !ok {
+			if e, ok := err.(ICause); ok {
+				if next := e.Cause(); next != nil {
+					err = next
+					continue
+				}
+			}
+		}
+		return err
 	}
-	return err
+	return nil
 }
 
 // Stack returns the string of the stack caller information.
@@ -39,6 +52,34 @@ func Stack(err error) string {
 	return err.Error()
 }
 
+// Frames returns the stack frames of the deepest error carrying a stack in the chain of `err`,
+// which is where the error originated, filtered like Stack, see SetStackFilter.
+// It returns nil if no error of the chain carries a stack.
+func Frames(err error) []runtime.Frame {
+	var deepest *Error
+	for err != nil {
+		if e, ok := err.(*Error); ok && e != nil && len(e.stack) > 0 {
+			deepest = e
+		}
+		err = Unwrap(err)
+	}
+	if deepest == nil {
+		return nil
+	}
+	return deepest.stack.frames()
+}
+
+// Caller returns the file, line and function of the first frame of the stack of the deepest error carrying
+// a stack in the chain of `err`, which is where the error was created, filtered like Stack, see Frames.
+// It reports false if no error of the chain carries a stack.
+func Caller(err error) (file string, line int, fn string, ok bool) {
+	frames := Frames(err)
+	if len(frames) == 0 {
+		return "", 0, "", false
+	}
+	return frames[0].File, frames[0].Line, frames[0].Function, true
+}
+
 // Current creates and returns the current level error.
 // If the current level error is nil, it returns nil.
 func Current(err error) error {
@@ -110,12 +151,21 @@ func As(err error, target any) bool {
 // callers returns the stack caller.
 // Note that this only retrieves the caller memory address array, not the caller information.
 func callers(skip ...int) stack {
-	var (
-		pcs [maxStackDepth]uintptr
-		n   = 3
-	)
+	n := 1
 	if len(skip) > 0 {
 		n += skip[0]
 	}
-	return pcs[:runtime.Callers(n, pcs[:])]
+	return capture(n, 0)
+}
+
+// capture returns the stack of the caller of the function calling capture, skipping `skip` more callers.
+// It captures at most `depth` frames, or the depth set by SetStackDepth if `depth` is 0.
+func capture(skip, depth int) stack {
+	if depth <= 0 {
+		if depth = int(stackDepth.Load()); depth == 0 {
+			return nil
+		}
+	}
+	pcs := make(stack, depth)
+	return pcs[:runtime.Callers(3+skip, pcs)]
 }
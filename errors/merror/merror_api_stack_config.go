@@ -0,0 +1,74 @@
+package merror
+
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// stackDepth is the maximum number of frames captured for the stacks of errors, 0 disables the capture.
+	stackDepth atomic.Int32
+	// stackFilter is the filter of the frames of the stacks of errors, nil for DefaultStackFilter.
+	stackFilter atomic.Pointer[func(frame runtime.Frame) bool]
+)
+
+func init() {
+	stackDepth.Store(maxStackDepth)
+}
+
+// SetStackDepth sets the maximum number of frames captured for the stacks of errors created afterwards,
+// which is 64 by default. The frames are counted before filtering, see SetStackFilter.
+// A depth less than or equal to 0 disables the capture, see DisableStack.
+func SetStackDepth(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	stackDepth.Store(int32(depth))
+}
+
+// DisableStack disables the capture of stacks for errors created afterwards, which saves its cost on hot paths.
+// Their Stack has no frame and Frames returns nil. Call SetStackDepth to enable it again.
+func DisableStack() {
+	SetStackDepth(0)
+}
+
+// SetStackFilter sets the filter of the frames printed by Stack and returned by Frames,
+// which reports whether `frame` is kept. It applies to the errors created before as well,
+// as frames are only resolved when printed. A nil filter restores DefaultStackFilter, and a
+// custom filter may call DefaultStackFilter to drop the frames it drops as well.
+func SetStackFilter(filter func(frame runtime.Frame) bool) {
+	if filter == nil {
+		stackFilter.Store(nil)
+		return
+	}
+	stackFilter.Store(&filter)
+}
+
+// DefaultStackFilter is the default filter of the frames of stacks, see SetStackFilter.
+// It drops the frames of the standard library and runtime, of reflect calls,
+// and of the middleware chains of maltose and gin, which are the same for every request.
+func DefaultStackFilter(frame runtime.Frame) bool {
+	if goroot := runtime.GOROOT(); goroot != "" && strings.HasPrefix(frame.File, goroot) {
+		return false
+	}
+	name := frame.Function
+	switch {
+	case strings.HasPrefix(name, "reflect."),
+		strings.HasPrefix(name, "github.com/gin-gonic/gin."):
+		return false
+	case strings.HasPrefix(name, "github.com/graingo/maltose/net/"):
+		return !strings.Contains(name, "Middleware") &&
+			!strings.Contains(name, ".bindRoutes.") &&
+			!strings.HasSuffix(name, ".Next")
+	}
+	return true
+}
+
+// keepFrame reports whether `frame` is kept by the filter set by SetStackFilter.
+func keepFrame(frame runtime.Frame) bool {
+	if filter := stackFilter.Load(); filter != nil {
+		return (*filter)(frame)
+	}
+	return DefaultStackFilter(frame)
+}
@@ -0,0 +1,115 @@
+package merror
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// StackCapture creates errors with a custom capture of their stacks, see WithStack.
+type StackCapture struct {
+	skip  int
+	depth int
+}
+
+// WithStack returns a StackCapture creating errors whose stacks skip `skip` more callers,
+// like the helper functions creating them, and capture at most `depth` frames.
+// A depth less than or equal to 0 uses the depth set by SetStackDepth, and a positive depth
+// captures the stack even if it is disabled by DisableStack.
+// Example: return merror.WithStack(1, 0).NewCode(mcode.CodeNotFound, "user not found")
+func WithStack(skip, depth int) StackCapture {
+	if skip < 0 {
+		skip = 0
+	}
+	return StackCapture{skip: skip, depth: depth}
+}
+
+// New creates a new error, see New.
+func (c StackCapture) New(text string) error {
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  text,
+		code:  mcode.CodeNil,
+	}
+}
+
+// Newf creates a new error, see Newf.
+func (c StackCapture) Newf(format string, a ...any) error {
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  fmt.Sprintf(format, a...),
+		code:  mcode.CodeNil,
+	}
+}
+
+// Wrap wraps an error with the text, see Wrap. It returns nil if `err` is nil.
+func (c StackCapture) Wrap(err error, text string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  text,
+		error: err,
+		code:  mcode.CodeNil,
+	}
+}
+
+// Wrapf wraps an error with the formatted text, see Wrapf. It returns nil if `err` is nil.
+func (c StackCapture) Wrapf(err error, format string, a ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  fmt.Sprintf(format, a...),
+		error: err,
+		code:  mcode.CodeNil,
+	}
+}
+
+// NewCode creates a new error with the specified error code, see NewCode.
+func (c StackCapture) NewCode(code mcode.Code, text ...string) error {
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  strings.Join(text, commaSeparatorSpace),
+		code:  code,
+	}
+}
+
+// NewCodef creates a new error with the specified error code, see NewCodef.
+func (c StackCapture) NewCodef(code mcode.Code, format string, args ...any) error {
+	return &Error{
+		stack: capture(c.skip, c.depth),
+		text:  fmt.Sprintf(format, args...),
+		code:  code,
+	}
+}
+
+// WrapCode wraps an error and appends the specified error code, see WrapCode. It returns nil if `err` is nil.
+func (c StackCapture) WrapCode(err error, code mcode.Code, text ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		error: err,
+		stack: capture(c.skip, c.depth),
+		text:  strings.Join(text, commaSeparatorSpace),
+		code:  code,
+	}
+}
+
+// WrapCodef wraps an error and appends the specified error code and formatted text, see WrapCodef.
+// It returns nil if `err` is nil.
+func (c StackCapture) WrapCodef(err error, code mcode.Code, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		error: err,
+		stack: capture(c.skip, c.depth),
+		text:  fmt.Sprintf(format, args...),
+		code:  code,
+	}
+}
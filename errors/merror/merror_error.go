@@ -1,7 +1,6 @@
 package merror
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/graingo/maltose/errors/mcode"
@@ -11,6 +10,7 @@ import (
 type Error struct {
 	error error
 	text  string
+	lazy  *lazyText
 	code  mcode.Code
 	stack stack
 }
@@ -20,7 +20,7 @@ func (err *Error) Error() string {
 	if err == nil {
 		return ""
 	}
-	errStr := err.text
+	errStr := err.message()
 	if errStr == "" && err.code != nil {
 		errStr = err.code.Message()
 	}
@@ -33,31 +33,12 @@ func (err *Error) Error() string {
 	return errStr
 }
 
-// Cause returns the root error.
+// Cause returns the root error, see the Cause function.
 func (err *Error) Cause() error {
 	if err == nil {
 		return nil
 	}
-	loop := err
-	for loop != nil {
-		if loop.error != nil {
-			if e, ok := loop.error.(*Error); ok {
-				// Internal Error struct.
-				loop = e
-			} else if e, ok := loop.error.(ICause); ok {
-				// Other Error that implements ApiCause interface.
-				return e.Cause()
-			} else {
-				return loop.error
-			}
-		} else {
-			// return loop
-			//
-			// To be compatible with Case of https://github.com/pkg/errors.
-			return errors.New(loop.text)
-		}
-	}
-	return nil
+	return Cause(err)
 }
 
 // Current creates and returns the current error.
@@ -70,6 +51,7 @@ func (err *Error) Current() error {
 		error: nil,
 		stack: err.stack,
 		text:  err.text,
+		lazy:  err.lazy,
 		code:  err.code,
 	}
 }
@@ -95,8 +77,16 @@ func (err *Error) Equal(target error) bool {
 		return false
 	}
 	// Text should be the same.
-	if err.text != fmt.Sprintf(`%-s`, target) {
+	if err.message() != fmt.Sprintf(`%-s`, target) {
 		return false
 	}
 	return true
 }
+
+// message returns the text of the error, formatting it on first use if it is created by the lazy constructors.
+func (err *Error) message() string {
+	if err.lazy != nil {
+		return err.lazy.String()
+	}
+	return err.text
+}
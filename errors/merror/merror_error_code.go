@@ -10,7 +10,7 @@ func (err *Error) Code() mcode.Code {
 	if err == nil {
 		return mcode.CodeNil
 	}
-	if err.code == mcode.CodeNil {
+	if mcode.Equal(err.code, mcode.CodeNil) {
 		return Code(err.Unwrap())
 	}
 	return err.code
@@ -10,14 +10,15 @@ import (
 // Format specifiers:
 //
 //	%s: error information
-//	+v: error information and stack information
+//	%+s: stack information of the error, see Error.Stack
+//	%+v: error information and the stacks of its chain, see Format
 func (err *Error) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 's', 'v':
 		switch {
 		case s.Flag('-'):
-			if err.text != "" {
-				_, _ = io.WriteString(s, err.text)
+			if text := err.message(); text != "" {
+				_, _ = io.WriteString(s, text)
 			} else {
 				_, _ = io.WriteString(s, err.Error())
 			}
@@ -25,7 +26,7 @@ func (err *Error) Format(s fmt.State, verb rune) {
 			if verb == 's' {
 				_, _ = io.WriteString(s, err.Stack())
 			} else {
-				_, _ = io.WriteString(s, err.Error()+"\n"+err.Stack())
+				_, _ = io.WriteString(s, Format(err, FormatOptions{}).String())
 			}
 		default:
 			_, _ = io.WriteString(s, err.Error())
@@ -1,6 +1,108 @@
 package merror
 
-// MarshalJSON implements the json.Marshaler interface.
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// JSON is the JSON representation of an error for API responses, see Error.MarshalJSON.
+// The caller, stack and cause chain are only included in debug mode, see SetDebug.
+type JSON struct {
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Details any      `json:"details,omitempty"`
+	Caller  string   `json:"caller,omitempty"`
+	Stack   []string `json:"stack,omitempty"`
+	Cause   *JSON    `json:"cause,omitempty"`
+}
+
+// debug reports whether the JSON of errors includes the stack and cause chain.
+var debug atomic.Bool
+
+// SetDebug sets whether the JSON of errors includes the caller where the error was created, see Caller,
+// the stack and the chain of wrapped causes,
+// which is for internal tooling and should be disabled for responses to clients.
+func SetDebug(enabled bool) {
+	debug.Store(enabled)
+}
+
+// ToJSON returns the JSON representation of `err`, with the code resolved from its chain like Code.
+// The details are those added by WithDetail, merged into the code detail when it is a map. The details of errors joined by Join are the list of the JSON of the joined errors.
+// It returns nil if `err` is nil.
+func ToJSON(err error) *JSON {
+	return newJSON(err, debug.Load())
+}
+
+// MarshalWithStack returns the JSON of `err` including the caller, stack and cause chain regardless of SetDebug.
+func MarshalWithStack(err error) ([]byte, error) {
+	return json.Marshal(newJSON(err, true))
+}
+
+// MarshalJSON implements the json.Marshaler interface, see ToJSON.
 func (err Error) MarshalJSON() ([]byte, error) {
-	return []byte(`"` + err.Error() + `"`), nil
+	return json.Marshal(newJSON(&err, debug.Load()))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, it reconstructs the code and message of
+// an error marshaled by MarshalJSON, but not its stack and causes. The registered code of the number
+// is used if there is one, see mcode.Lookup.
+func (err *Error) UnmarshalJSON(data []byte) error {
+	var v JSON
+	if e := json.Unmarshal(data, &v); e != nil {
+		return e
+	}
+	code, ok := mcode.Lookup(v.Code)
+	switch {
+	case !ok:
+		code = mcode.NewWithDetail(v.Code, v.Message, v.Details)
+	case v.Details != nil:
+		code = mcode.WithCode(code, v.Details)
+	}
+	*err = Error{text: v.Message, code: code}
+	return nil
+}
+
+// newJSON returns the JSON representation of `err`, including the caller of `err` if `withStack` is true.
+func newJSON(err error, withStack bool) *JSON {
+	v := toJSON(err, withStack)
+	if v == nil || !withStack {
+		return v
+	}
+	if file, line, fn, ok := Caller(err); ok {
+		v.Caller = fmt.Sprintf("%s %s:%d", fn, file, line)
+	}
+	return v
+}
+
+// toJSON returns the JSON representation of `err`, including the stack and cause chain if `withStack` is true.
+func toJSON(err error, withStack bool) *JSON {
+	if err == nil {
+		return nil
+	}
+	code := Code(err)
+	v := &JSON{
+		Code:    code.Code(),
+		Message: err.Error(),
+		Details: errorDetails(err, code.Detail()),
+	}
+	if errs, ok := joined(err); ok {
+		details := make([]*JSON, len(errs))
+		for i, e := range errs {
+			details[i] = toJSON(e, withStack)
+		}
+		v.Details = details
+	}
+	if !withStack {
+		return v
+	}
+	if e, ok := err.(*Error); ok && e != nil {
+		for _, frame := range e.stack.frames() {
+			v.Stack = append(v.Stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+	}
+	v.Cause = toJSON(Unwrap(err), true)
+	return v
 }
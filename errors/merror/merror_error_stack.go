@@ -4,49 +4,49 @@ import (
 	"bytes"
 	"fmt"
 	"runtime"
-	"strings"
 )
 
-// Stack returns the stack information of the error.
+// Stack returns the stack information of the error, captured where it was created.
 func (err *Error) Stack() string {
 	if err == nil {
 		return ""
 	}
 
-	var (
-		buffer bytes.Buffer
-		pcs    [maxStackDepth]uintptr
-		n      = runtime.Callers(3, pcs[:]) // Skip the first 3 stack frames
-	)
+	var buffer bytes.Buffer
 
 	// Write error information
 	buffer.WriteString(fmt.Sprintf("error: %s\n", err.Error()))
 	buffer.WriteString("stack:\n")
 
-	// Get the stack information
-	frames := runtime.CallersFrames(pcs[:n])
-	for {
-		frame, more := frames.Next()
-
-		// Skip the calls to the standard library and runtime
-		if strings.HasPrefix(frame.File, runtime.GOROOT()) {
-			if !more {
-				break
-			}
-			continue
-		}
-
-		// Format the stack information
+	// Format the stack information
+	for _, frame := range err.stack.frames() {
 		buffer.WriteString(fmt.Sprintf("  %s\n    %s:%d\n",
 			frame.Function,
 			frame.File,
 			frame.Line,
 		))
+	}
 
+	return buffer.String()
+}
+
+// frames returns the frames of the stack kept by the filter set by SetStackFilter.
+func (s stack) frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	var (
+		result []runtime.Frame
+		frames = runtime.CallersFrames(s)
+	)
+	for {
+		frame, more := frames.Next()
+		if keepFrame(frame) {
+			result = append(result, frame)
+		}
 		if !more {
 			break
 		}
 	}
-
-	return buffer.String()
+	return result
 }
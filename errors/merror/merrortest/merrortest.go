@@ -0,0 +1,100 @@
+// Package merrortest provides assertions of the errors of merror for tests.
+// The failures describe the expected and actual values with the full %+v rendering of the error,
+// including its chain and stack, instead of comparing messages.
+package merrortest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// AssertCode asserts that the code of `err` is `code`, see merror.IsCode. It reports whether the assertion passed.
+//
+//	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+func AssertCode(t testing.TB, err error, code mcode.Code) bool {
+	t.Helper()
+	if merror.IsCode(err, code) {
+		return true
+	}
+	t.Errorf("unexpected error code\nwant: %s\ngot:  %s\nerror:\n%s", describeCode(code), describeCode(merror.Code(err)), render(err))
+	return false
+}
+
+// AssertDetail asserts that the detail `key` of `err` is `want`, which is a detail added by merror.WithDetail
+// or an entry of the code detail when it is a map. Values are compared by reflect.DeepEqual.
+// It reports whether the assertion passed.
+//
+//	merrortest.AssertDetail(t, err, "field", "email")
+func AssertDetail(t testing.TB, err error, key string, want any) bool {
+	t.Helper()
+	details := details(err)
+	got, ok := details[key]
+	if ok && reflect.DeepEqual(got, want) {
+		return true
+	}
+	gotText := "<missing>"
+	if ok {
+		gotText = fmt.Sprintf("%#v", got)
+	}
+	t.Errorf("unexpected error detail %q\nwant: %#v\ngot:  %s\ndetails: %s\nerror:\n%s", key, want, gotText, describeDetails(details), render(err))
+	return false
+}
+
+// details returns the details of `err` merged into its code detail when it is a map.
+func details(err error) map[string]any {
+	merged := make(map[string]any)
+	switch detail := merror.Code(err).Detail().(type) {
+	case map[string]any:
+		for key, value := range detail {
+			merged[key] = value
+		}
+	case map[string]string:
+		for key, value := range detail {
+			merged[key] = value
+		}
+	}
+	for key, value := range merror.Details(err) {
+		merged[key] = value
+	}
+	return merged
+}
+
+// describeCode returns the description of `code` for failures.
+func describeCode(code mcode.Code) string {
+	if code == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d %q", code.Code(), code.Message())
+}
+
+// describeDetails returns the description of `details` for failures, sorted by key.
+func describeDetails(details map[string]any) string {
+	if len(details) == 0 {
+		return "<none>"
+	}
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%#v", key, details[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+// render returns the %+v rendering of `err` indented for failures.
+func render(err error) string {
+	if err == nil {
+		return "  <nil>"
+	}
+	text := strings.TrimRight(fmt.Sprintf("%+v", err), "\n")
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
@@ -0,0 +1,64 @@
+package merrortest_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the failures of assertions
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertCode(t *testing.T) {
+	err := merror.Wrap(merror.NewCode(mcode.CodeNotFound, "user not found"), "load user")
+
+	r := &recorder{TB: t}
+	assert.True(t, merrortest.AssertCode(r, err, mcode.CodeNotFound))
+	assert.True(t, merrortest.AssertCode(r, err, mcode.WithCode(mcode.CodeNotFound, "detail")))
+	assert.True(t, merrortest.AssertCode(r, io.EOF, mcode.CodeNil))
+	assert.Empty(t, r.failures)
+
+	assert.False(t, merrortest.AssertCode(r, err, mcode.CodeForbidden))
+	assert.False(t, merrortest.AssertCode(r, nil, mcode.CodeNotFound))
+	assert.Len(t, r.failures, 2)
+	assert.Contains(t, r.failures[0], `want: 106 "Forbidden"`)
+	assert.Contains(t, r.failures[0], `got:  104 "Not Found"`)
+	assert.Contains(t, r.failures[0], "  load user: user not found\n  error: user not found\n  stack:\n")
+	assert.Contains(t, r.failures[0], ".TestAssertCode")
+	assert.Contains(t, r.failures[0], `wrapped "load user" at `)
+	assert.Contains(t, r.failures[1], "error:\n  <nil>")
+}
+
+func TestAssertDetail(t *testing.T) {
+	err := merror.NewCode(mcode.WithCode(mcode.CodeValidationFailed, map[string]any{"rule": "email"}), "invalid email")
+	err = merror.WithDetail(err, "field", "email")
+	err = merror.WithDetail(err, "ids", []int{1, 2})
+
+	r := &recorder{TB: t}
+	assert.True(t, merrortest.AssertDetail(r, err, "field", "email"))
+	assert.True(t, merrortest.AssertDetail(r, err, "rule", "email"))
+	assert.True(t, merrortest.AssertDetail(r, err, "ids", []int{1, 2}))
+	assert.Empty(t, r.failures)
+
+	assert.False(t, merrortest.AssertDetail(r, err, "field", "name"))
+	assert.False(t, merrortest.AssertDetail(r, err, "missing", 1))
+	assert.Len(t, r.failures, 2)
+	assert.Contains(t, r.failures[0], `want: "name"`)
+	assert.Contains(t, r.failures[0], `got:  "email"`)
+	assert.Contains(t, r.failures[0], `details: field="email" ids=[]int{1, 2} rule="email"`)
+	assert.Contains(t, r.failures[1], "got:  <missing>")
+}
@@ -0,0 +1,159 @@
+package merror_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+// causeError is a wrapping error implementing Cause but not Unwrap, like the errors of github.com/pkg/errors
+type causeError struct {
+	cause error
+}
+
+func (e causeError) Error() string { return "cause: " + e.cause.Error() }
+func (e causeError) Cause() error  { return e.cause }
+
+// opaqueError is a wrapping error whose message does not include the error it wraps
+type opaqueError struct {
+	err error
+}
+
+func (e opaqueError) Error() string { return "request failed" }
+func (e opaqueError) Unwrap() error { return e.err }
+
+// TestCause tests finding the root cause of mixed chains
+func TestCause(t *testing.T) {
+	leaf := merror.NewCode(mcode.CodeNotFound, "user not found")
+	joined := merror.Join(io.EOF, sql.ErrNoRows)
+	tests := []struct {
+		name  string
+		err   error
+		cause error
+	}{
+		{"nil", nil, nil},
+		{"foreign", io.EOF, io.EOF},
+		{"merror leaf", leaf, leaf},
+		{"merror wrap", merror.Wrap(merror.Wrapf(io.EOF, "read %s", "body"), "decode"), io.EOF},
+		{"fmt wrap", fmt.Errorf("query: %w", sql.ErrNoRows), sql.ErrNoRows},
+		{"mixed", merror.WrapCode(fmt.Errorf("query: %w", merror.Wrap(sql.ErrNoRows, "scan")), mcode.CodeDbOperationError), sql.ErrNoRows},
+		{"mixed merror leaf", fmt.Errorf("handler: %w", merror.Wrap(leaf, "load")), leaf},
+		{"detail", merror.WithDetail(merror.Wrap(io.EOF, "read"), "path", "/tmp"), io.EOF},
+		{"cause", merror.Wrap(causeError{cause: fmt.Errorf("x: %w", io.EOF)}, "wrap"), io.EOF},
+		{"join", merror.Wrap(joined, "batch"), joined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.cause, merror.Cause(tt.err))
+		})
+	}
+
+	stdJoined := errors.Join(io.EOF, sql.ErrNoRows)
+	cause := merror.Cause(fmt.Errorf("batch: %w", stdJoined))
+	assert.Equal(t, stdJoined.Error(), cause.Error())
+	assert.Equal(t, []error{io.EOF, sql.ErrNoRows}, merror.Split(cause))
+	var e *merror.Error
+	assert.Nil(t, e.Cause())
+	assert.Equal(t, io.EOF, merror.Wrap(io.EOF, "read").(*merror.Error).Cause())
+}
+
+// TestCode tests resolving the code of mixed chains from the outermost error inwards
+func TestCode(t *testing.T) {
+	notFound := merror.NewCode(mcode.CodeNotFound, "user not found")
+	tests := []struct {
+		name string
+		err  error
+		code mcode.Code
+	}{
+		{"nil", nil, mcode.CodeNil},
+		{"foreign", io.EOF, mcode.CodeNil},
+		{"no code", merror.Wrap(io.EOF, "read"), mcode.CodeNil},
+		{"leaf", notFound, mcode.CodeNotFound},
+		{"inherited", merror.Wrap(fmt.Errorf("handler: %w", notFound), "request"), mcode.CodeNotFound},
+		{"overridden", merror.WrapCode(notFound, mcode.CodeInternalError), mcode.CodeInternalError},
+		{"outermost wins", fmt.Errorf("x: %w", merror.WrapCode(fmt.Errorf("y: %w", notFound), mcode.CodeForbidden)), mcode.CodeForbidden},
+		{"detail", merror.WithDetail(notFound, "id", 1), mcode.CodeNotFound},
+		{"join", merror.Join(io.EOF, notFound, merror.NewCode(mcode.CodeForbidden)), mcode.CodeNotFound},
+		{"std join", fmt.Errorf("batch: %w", errors.Join(io.EOF, merror.NewCode(mcode.CodeForbidden))), mcode.CodeForbidden},
+		{"join overridden", merror.WrapCode(merror.Join(notFound), mcode.CodeValidationFailed), mcode.CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, merror.Code(tt.err))
+		})
+	}
+}
+
+// TestHasCode tests finding a code anywhere in mixed chains
+func TestHasCode(t *testing.T) {
+	notFound := merror.NewCode(mcode.CodeNotFound, "user not found")
+	overridden := fmt.Errorf("handler: %w", merror.WrapCode(notFound, mcode.CodeInternalError))
+
+	assert.False(t, merror.HasCode(nil, mcode.CodeNotFound))
+	assert.False(t, merror.HasCode(io.EOF, mcode.CodeNotFound))
+	assert.False(t, merror.HasCode(notFound, nil))
+	assert.False(t, merror.HasCode(merror.Wrap(io.EOF, "read"), mcode.CodeNil))
+	assert.True(t, merror.HasCode(notFound, mcode.CodeNotFound))
+	assert.True(t, merror.HasCode(overridden, mcode.CodeInternalError))
+	assert.True(t, merror.HasCode(overridden, mcode.CodeNotFound))
+	assert.False(t, merror.HasCode(overridden, mcode.CodeForbidden))
+	assert.True(t, merror.HasCode(overridden, mcode.WithCode(mcode.CodeNotFound, "detail")))
+	assert.True(t, merror.HasCode(merror.Join(io.EOF, merror.Wrap(notFound, "x")), mcode.CodeNotFound))
+	assert.True(t, merror.HasCode(errors.Join(io.EOF, overridden), mcode.CodeNotFound))
+	assert.True(t, merror.HasCode(merror.WithDetail(overridden, "id", 1), mcode.CodeNotFound))
+	assert.True(t, merror.HasCode(overridden, mcode.WithMessage(mcode.CodeNotFound, "user not found")))
+}
+
+// TestIsCode tests matching the code of mixed chains
+func TestIsCode(t *testing.T) {
+	inner := merror.NewCode(mcode.CodeNotFound, "user not found")
+	err := merror.WrapCode(fmt.Errorf("query users: %w", inner), mcode.CodeInternalError, "load user")
+
+	assert.True(t, merror.IsCode(err, mcode.CodeInternalError))
+	assert.True(t, merror.IsCode(err, mcode.WithCode(mcode.CodeInternalError, "detail")))
+	assert.False(t, merror.IsCode(err, mcode.CodeNotFound))
+	assert.True(t, merror.IsCode(inner, mcode.CodeNotFound))
+	assert.False(t, merror.IsCode(err, nil))
+	assert.True(t, merror.IsCode(nil, mcode.CodeNil))
+	assert.True(t, merror.IsCode(io.EOF, mcode.CodeNil))
+
+	// a derived nil code is no code, so the code of the wrapped error is used
+	derived := merror.WrapCode(inner, mcode.WithMessage(mcode.CodeNil, "none"))
+	assert.True(t, merror.IsCode(derived, mcode.CodeNotFound))
+	detailed := merror.NewCode(mcode.WithDetail(mcode.CodeNotFound, map[string]any{"id": 1}), "user not found")
+	assert.True(t, merror.IsCode(detailed, mcode.WithDetail(mcode.CodeNotFound, map[string]any{"id": 2})))
+}
+
+// TestMessageContains tests matching the messages of mixed chains
+func TestMessageContains(t *testing.T) {
+	err := merror.Wrap(opaqueError{err: fmt.Errorf("query users: %w", sql.ErrNoRows)}, "load user")
+
+	assert.Equal(t, "load user: request failed", err.Error())
+	assert.True(t, merror.MessageContains(err, "load user"))
+	assert.True(t, merror.MessageContains(err, "query users"))
+	assert.True(t, merror.MessageContains(err, "no rows"))
+	assert.True(t, merror.MessageContains(merror.Join(io.EOF, err), "no rows"))
+	assert.True(t, merror.MessageContains(errors.Join(io.EOF, err), "no rows"))
+	assert.False(t, merror.MessageContains(err, "timeout"))
+	assert.False(t, merror.MessageContains(nil, ""))
+}
+
+// TestIsAs tests that errors.Is and errors.As see through mixed chains
+func TestIsAs(t *testing.T) {
+	target := &causeError{cause: io.EOF}
+	err := merror.Wrap(merror.Join(fmt.Errorf("a: %w", sql.ErrNoRows), merror.WithDetail(target, "k", "v")), "batch")
+
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.False(t, errors.Is(err, io.ErrClosedPipe))
+	var e *causeError
+	assert.True(t, errors.As(err, &e))
+	assert.Same(t, target, e)
+	var me *merror.Error
+	assert.True(t, errors.As(err, &me))
+}
@@ -0,0 +1,130 @@
+package merror_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The errors of the golden files, keep them at the top of the file so that their lines do not move
+
+func findUser() error {
+	return merror.NewCode(mcode.CodeNotFound, "user not found")
+}
+
+func loadUser() error {
+	return merror.Wrap(findUser(), "query user")
+}
+
+func loadOrder() error {
+	return merror.Wrapf(loadUser(), "load order %d", 7)
+}
+
+func loadOrderForeign() error {
+	return merror.Wrap(fmt.Errorf("query user: %w", findUser()), "load order")
+}
+
+func readConfig() error {
+	return merror.Wrap(merror.Wrap(io.ErrUnexpectedEOF, "read app.yaml"), "load config")
+}
+
+func loadAsync() error {
+	result := make(chan error)
+	go func() { result <- findUser() }()
+	return merror.Wrap(<-result, "load async")
+}
+
+// update rewrites the golden files with the current output: go test -run TestFormat_Golden -update
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestFormat_Golden locks the %+v rendering of error chains, see testdata/*.golden
+func TestFormat_Golden(t *testing.T) {
+	resetStack(t)
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file)
+
+	tests := []struct {
+		name  string
+		build func() error
+	}{
+		{"single", findUser},
+		{"chain", loadOrder},
+		{"foreign_wrapper", loadOrderForeign},
+		{"foreign_cause", readConfig},
+		{"goroutine", loadAsync},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the paths depend on where the module is
+			got := strings.ReplaceAll(fmt.Sprintf("%+v", tt.build()), dir, ".")
+			golden := filepath.Join("testdata", "format_"+tt.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+// TestFormat tests the structured representation of error chains
+func TestFormat(t *testing.T) {
+	resetStack(t)
+	assert.Nil(t, merror.Format(nil, merror.FormatOptions{}))
+	assert.Equal(t, &merror.Chain{Message: "plain"}, merror.Format(fmt.Errorf("plain"), merror.FormatOptions{}))
+
+	chain := merror.Format(loadOrder(), merror.FormatOptions{})
+	assert.Equal(t, "load order 7: query user: user not found", chain.Message)
+	require.Len(t, chain.Stacks, 1)
+	stack := chain.Stacks[0]
+	assert.Equal(t, "user not found", stack.Error)
+	require.Greater(t, len(stack.Frames), 3)
+	assert.True(t, strings.HasSuffix(stack.Frames[0].Function, ".findUser"))
+	assert.Empty(t, stack.Frames[0].Wraps)
+	require.Len(t, stack.Frames[1].Wraps, 1)
+	assert.Equal(t, "query user", stack.Frames[1].Wraps[0].Message)
+	assert.Equal(t, stack.Frames[1].File, stack.Frames[1].Wraps[0].File)
+	require.Len(t, stack.Frames[2].Wraps, 1)
+	assert.Equal(t, "load order 7", stack.Frames[2].Wraps[0].Message)
+	assert.Equal(t, merror.Frames(loadOrder())[0].Function, stack.Frames[0].Function)
+
+	chain = merror.Format(loadAsync(), merror.FormatOptions{})
+	require.Len(t, chain.Stacks, 2)
+	assert.Equal(t, "user not found", chain.Stacks[0].Error)
+	assert.Equal(t, "load async: user not found", chain.Stacks[1].Error)
+}
+
+// TestFormat_MaxFrames tests limiting the frames of each stack
+func TestFormat_MaxFrames(t *testing.T) {
+	resetStack(t)
+	err := loadOrder()
+	all := merror.Format(err, merror.FormatOptions{}).Stacks[0].Frames
+
+	stack := merror.Format(err, merror.FormatOptions{MaxFrames: 2}).Stacks[0]
+	assert.Equal(t, all[:2], stack.Frames)
+	assert.Equal(t, len(all)-2, stack.More)
+	assert.Contains(t, merror.Format(err, merror.FormatOptions{MaxFrames: 2}).String(), fmt.Sprintf("  ... %d more frames\n", len(all)-2))
+}
+
+// TestFormat_StackDepth tests that the wrap points are kept when the stacks are cut by the stack depth
+func TestFormat_StackDepth(t *testing.T) {
+	resetStack(t)
+	merror.SetStackDepth(2)
+	stack := merror.Format(loadOrder(), merror.FormatOptions{}).Stacks[0]
+	// the stack of the wrapping errors continue the one of the origin
+	require.Len(t, stack.Frames, 4)
+	assert.True(t, strings.HasSuffix(stack.Frames[3].Function, ".TestFormat_StackDepth"))
+	assert.Len(t, stack.Frames[1].Wraps, 1)
+	assert.Len(t, stack.Frames[2].Wraps, 1)
+}
@@ -0,0 +1,118 @@
+package merror_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingArg counts how many times it is formatted
+type countingArg struct {
+	calls atomic.Int32
+}
+
+func (a *countingArg) String() string {
+	a.calls.Add(1)
+	return "42"
+}
+
+// TestLazy_NotFormatted tests that Code, Is and As do not format the text of lazy errors
+func TestLazy_NotFormatted(t *testing.T) {
+	arg := &countingArg{}
+	err := merror.LazyWrapCodef(io.EOF, mcode.CodeNotFound, "user %s not found", arg)
+	err = merror.LazyWrapf(err, "load user %s", arg)
+
+	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+	assert.True(t, merror.HasCode(err, mcode.CodeNotFound))
+	assert.True(t, errors.Is(err, io.EOF))
+	var e *merror.Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, io.EOF, merror.Cause(err))
+	assert.NotEmpty(t, merror.Frames(err))
+	assert.Equal(t, int32(0), arg.calls.Load())
+
+	assert.Equal(t, "load user 42: user 42 not found: EOF", err.Error())
+	assert.Equal(t, int32(2), arg.calls.Load())
+	assert.Equal(t, "load user 42: user 42 not found: EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, "load user 42", fmt.Sprintf("%-v", err))
+	assert.Equal(t, int32(2), arg.calls.Load())
+}
+
+// TestLazy_Constructors tests the messages, codes and stacks of the lazy constructors
+func TestLazy_Constructors(t *testing.T) {
+	err := merror.LazyNewf("user %d not found", 1)
+	assert.Equal(t, merror.Newf("user %d not found", 1).Error(), err.Error())
+	merrortest.AssertCode(t, err, mcode.CodeNil)
+	assert.True(t, merror.Equal(err, merror.New("user 1 not found")))
+
+	err = merror.LazyNewCodef(mcode.CodeNotFound, "user %d not found", 1)
+	assert.Equal(t, "user 1 not found", err.Error())
+	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+	assert.Equal(t, "user 1 not found", merror.Current(err).Error())
+
+	assert.Nil(t, merror.LazyWrapf(nil, "load user %d", 1))
+	assert.Nil(t, merror.LazyWrapCodef(nil, mcode.CodeNotFound, "load user %d", 1))
+
+	// the stack is captured by the constructor, not where the text is formatted
+	err = newLazyNotFound()
+	var text string
+	func() { text = err.Error() }()
+	assert.Equal(t, "user 7 not found", text)
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".newLazyNotFound"))
+}
+
+// TestLazy_Concurrent tests formatting the text of a lazy error concurrently
+func TestLazy_Concurrent(t *testing.T) {
+	arg := &countingArg{}
+	err := merror.LazyNewf("user %s not found", arg)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "user 42 not found", err.Error())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), arg.calls.Load())
+}
+
+func newLazyNotFound() error {
+	return merror.LazyNewCodef(mcode.CodeNotFound, "user %d not found", 7)
+}
+
+// BenchmarkNewCodef benchmarks creating errors with an eagerly formatted text and checking their codes
+func BenchmarkNewCodef(b *testing.B) {
+	merror.DisableStack()
+	defer merror.SetStackDepth(64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if merror.Code(merror.NewCodef(mcode.CodeNotFound, "user %d not found in %s", i, "db")) != mcode.CodeNotFound {
+			b.Fatal("unexpected code")
+		}
+	}
+}
+
+// BenchmarkLazyNewCodef benchmarks creating errors with a lazily formatted text and checking their codes
+func BenchmarkLazyNewCodef(b *testing.B) {
+	merror.DisableStack()
+	defer merror.SetStackDepth(64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if merror.Code(merror.LazyNewCodef(mcode.CodeNotFound, "user %d not found in %s", i, "db")) != mcode.CodeNotFound {
+			b.Fatal("unexpected code")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package merror_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicValue struct {
+	ID   int
+	Name string
+}
+
+// recoverPanic runs `fn` and returns the error of its panic like a recovery middleware
+func recoverPanic(fn func()) (err error) {
+	defer func() {
+		err = merror.FromPanic(recover())
+	}()
+	fn()
+	return nil
+}
+
+// panicking panics with `v`
+func panicking(v any) {
+	panic(v)
+}
+
+// TestFromPanic tests the errors of the recovered panic values
+func TestFromPanic(t *testing.T) {
+	assert.Nil(t, merror.FromPanic(nil))
+
+	tests := []struct {
+		name    string
+		value   any
+		message string
+		origin  string
+	}{
+		{"string", "boom", "panic: boom", ".panicking"},
+		{"error", io.ErrUnexpectedEOF, "panic: unexpected EOF", ".panicking"},
+		// the stack of a panicking merror is where it was created, see Frames
+		{"merror", merror.NewCode(mcode.CodeNotFound, "user not found"), "panic: user not found", ".TestFromPanic"},
+		{"struct", panicValue{ID: 1, Name: "job"}, "panic: {1 job}", ".panicking"},
+		{"int", 42, "panic: 42", ".panicking"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := recoverPanic(func() { panicking(tt.value) })
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+			merrortest.AssertCode(t, err, mcode.CodeInternalError)
+			assert.False(t, merror.IsRetryable(err))
+			if e, ok := tt.value.(error); ok {
+				assert.True(t, errors.Is(err, e))
+			}
+
+			frames := merror.Frames(err)
+			require.NotEmpty(t, frames)
+			assert.True(t, strings.HasSuffix(frames[0].Function, tt.origin), "got %s", frames[0].Function)
+			assert.NotContains(t, merror.Stack(err), ".recoverPanic.func1")
+		})
+	}
+}
+
+// TestFromPanic_Runtime tests the error of a runtime panic
+func TestFromPanic_Runtime(t *testing.T) {
+	var m map[string]int
+	err := recoverPanic(func() { m["key"] = 1 })
+	assert.True(t, strings.HasPrefix(err.Error(), "panic: assignment to entry in nil map"))
+	var re runtime.Error
+	assert.True(t, errors.As(err, &re))
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestFromPanic_Runtime.func1"), "got %s", frames[0].Function)
+}
+
+// TestFromPanic_NotPanicking tests the stack of an error created outside of a panic
+func TestFromPanic_NotPanicking(t *testing.T) {
+	err := merror.FromPanic("stored value")
+	assert.Equal(t, "panic: stored value", err.Error())
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestFromPanic_NotPanicking"))
+}
+
+// TestRecover tests running a function converting its panic
+func TestRecover(t *testing.T) {
+	assert.NoError(t, merror.Recover(func() error { return nil }))
+	assert.Equal(t, io.EOF, merror.Recover(func() error { return io.EOF }))
+
+	err := merror.Recover(func() error {
+		panicking(fmt.Sprintf("job %d failed", 7))
+		return nil
+	})
+	assert.Equal(t, "panic: job 7 failed", err.Error())
+	merrortest.AssertCode(t, err, mcode.CodeInternalError)
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".panicking"))
+}
@@ -0,0 +1,78 @@
+package merror_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/stretchr/testify/assert"
+)
+
+// the codes are registered at package level, so that the tests can run several times
+var (
+	_                = mcode.RegisterRange("merror-test", 46300, 46399)
+	codeConflict     = mcode.New(46301, "Version Conflict", nil)
+	codeInvalidToken = mcode.New(46302, "Invalid Token", nil)
+)
+
+// TestIsRetryable tests the classification of errors as retryable
+func TestIsRetryable(t *testing.T) {
+	merror.SetRetryable(codeConflict, true)
+	merror.SetRetryable(codeInvalidToken, false)
+	timeout := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{IsTimeout: true}}
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"plain", io.EOF, false},
+		{"merror", merror.New("invalid signature"), false},
+		{"marked retryable", merror.MarkRetryable(io.EOF), true},
+		{"marked permanent", merror.MarkPermanent(timeout), false},
+		{"mark survives wrapping", fmt.Errorf("call: %w", merror.Wrap(merror.MarkRetryable(io.EOF), "send")), true},
+		{"outermost mark wins", merror.MarkPermanent(merror.Wrap(merror.MarkRetryable(io.EOF), "send")), false},
+		{"mark over code", merror.MarkPermanent(merror.NewCode(mcode.CodeServerBusy)), false},
+		{"default code", merror.Wrap(merror.NewCode(mcode.CodeServerBusy), "call"), true},
+		{"registered code", merror.NewCode(codeConflict), true},
+		{"code over timeout", merror.WrapCode(timeout, codeInvalidToken), false},
+		{"unclassified code", merror.WrapCode(timeout, mcode.CodeNotFound), true},
+		{"net timeout", merror.Wrap(timeout, "dial"), true},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{"canceled", merror.Wrap(context.Canceled, "query"), false},
+		{"joined mark", merror.Join(io.EOF, merror.MarkRetryable(io.ErrUnexpectedEOF)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, merror.IsRetryable(tt.err))
+		})
+	}
+
+	merror.SetRetryable(mcode.CodeServerBusy, false)
+	defer merror.SetRetryable(mcode.CodeServerBusy, true)
+	assert.False(t, merror.IsRetryable(merror.NewCode(mcode.CodeServerBusy)))
+}
+
+// TestMark tests that marks do not change the errors they mark
+func TestMark(t *testing.T) {
+	assert.Nil(t, merror.MarkRetryable(nil))
+	assert.Nil(t, merror.MarkPermanent(nil))
+
+	inner := merror.NewCode(mcode.CodeNotFound, "user not found")
+	err := merror.MarkRetryable(merror.WithDetail(inner, "id", 1))
+	assert.Equal(t, "user not found", err.Error())
+	assert.Equal(t, "user not found", fmt.Sprintf("%v", err))
+	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+	merrortest.AssertDetail(t, err, "id", 1)
+	assert.Equal(t, merror.Stack(inner), merror.Stack(err))
+	assert.True(t, errors.Is(err, inner))
+	var r merror.IRetryable
+	assert.True(t, errors.As(err, &r))
+}
@@ -0,0 +1,170 @@
+package merror_test
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stackPattern is the format of Error.Stack, which log parsers rely on
+var stackPattern = regexp.MustCompile(`^error: .*\nstack:\n(  \S+\n    \S+:\d+\n)*$`)
+
+// resetStack restores the default stack configuration after a test
+func resetStack(t *testing.T) {
+	t.Cleanup(func() {
+		merror.SetStackDepth(64)
+		merror.SetStackFilter(nil)
+	})
+}
+
+// newNotFound is a helper creating errors, which should not appear in their stacks
+func newNotFound() error {
+	return merror.WithStack(1, 0).NewCode(mcode.CodeNotFound, "user not found")
+}
+
+// TestStack_Default tests the stack captured with the default configuration
+func TestStack_Default(t *testing.T) {
+	err := merror.New("failed")
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestStack_Default"))
+	for _, frame := range frames {
+		assert.NotContains(t, frame.File, runtime.GOROOT())
+	}
+	assert.Regexp(t, stackPattern, merror.Stack(err))
+	assert.Equal(t, "failed\n"+merror.Stack(err), fmt.Sprintf("%+v", err))
+}
+
+// TestSetStackDepth tests limiting the depth of the stacks
+func TestSetStackDepth(t *testing.T) {
+	resetStack(t)
+	merror.SetStackDepth(1)
+	frames := merror.Frames(merror.New("failed"))
+	require.Len(t, frames, 1)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestSetStackDepth"))
+}
+
+// TestDisableStack tests that errors have no stack when the capture is disabled
+func TestDisableStack(t *testing.T) {
+	resetStack(t)
+	merror.DisableStack()
+	err := merror.Wrap(merror.NewCode(mcode.CodeNotFound, "user not found"), "load user")
+	assert.Nil(t, merror.Frames(err))
+	assert.Equal(t, "error: load user: user not found\nstack:\n", merror.Stack(err))
+	assert.Regexp(t, stackPattern, merror.Stack(err))
+	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+
+	merror.SetStackDepth(64)
+	assert.NotEmpty(t, merror.Frames(merror.New("failed")))
+}
+
+// TestSetStackFilter tests filtering the frames of the stacks
+func TestSetStackFilter(t *testing.T) {
+	resetStack(t)
+	err := merror.New("failed")
+	merror.SetStackFilter(func(frame runtime.Frame) bool {
+		return merror.DefaultStackFilter(frame) && !strings.HasSuffix(frame.Function, ".TestSetStackFilter")
+	})
+	for _, frame := range merror.Frames(err) {
+		assert.False(t, strings.HasSuffix(frame.Function, ".TestSetStackFilter"))
+	}
+	assert.NotContains(t, merror.Stack(err), ".TestSetStackFilter")
+	assert.Regexp(t, stackPattern, merror.Stack(err))
+
+	merror.SetStackFilter(nil)
+	assert.Contains(t, merror.Stack(err), ".TestSetStackFilter")
+}
+
+// TestDefaultStackFilter tests the frames dropped by the default filter
+func TestDefaultStackFilter(t *testing.T) {
+	tests := []struct {
+		function string
+		keep     bool
+	}{
+		{"main.handler", true},
+		{"github.com/graingo/maltose/net/mhttp.(*Server).Run", true},
+		{"github.com/graingo/maltose/net/mhttp.handleRequest", true},
+		{"reflect.Value.Call", false},
+		{"github.com/gin-gonic/gin.(*Context).Next", false},
+		{"github.com/graingo/maltose/net/mhttp.MiddlewareResponse.func1", false},
+		{"github.com/graingo/maltose/net/mhttp.internalMiddlewareRecovery.func1", false},
+		{"github.com/graingo/maltose/net/mhttp.(*Request).Next", false},
+		{"github.com/graingo/maltose/net/mhttp.(*Server).bindRoutes.func1", false},
+		{"github.com/graingo/maltose/net/mclient.internalMiddlewareTrace.func1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			assert.Equal(t, tt.keep, merror.DefaultStackFilter(runtime.Frame{Function: tt.function, File: "/app/main.go"}))
+		})
+	}
+	assert.False(t, merror.DefaultStackFilter(runtime.Frame{Function: "main.main", File: runtime.GOROOT() + "/src/runtime/proc.go"}))
+}
+
+// TestWithStack tests the stack override of a single error
+func TestWithStack(t *testing.T) {
+	resetStack(t)
+	err := newNotFound()
+	frames := merror.Frames(err)
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestWithStack"))
+	merrortest.AssertCode(t, err, mcode.CodeNotFound)
+
+	frames = merror.Frames(merror.WithStack(0, 1).Wrap(fmt.Errorf("query failed"), "load user"))
+	assert.Len(t, frames, 1)
+
+	merror.DisableStack()
+	assert.Nil(t, merror.Frames(merror.WithStack(0, 0).New("failed")))
+	frames = merror.Frames(merror.WithStack(0, 2).Newf("failed %d", 1))
+	require.NotEmpty(t, frames)
+	assert.True(t, strings.HasSuffix(frames[0].Function, ".TestWithStack"))
+	assert.Nil(t, merror.WithStack(0, 2).WrapCode(nil, mcode.CodeNotFound))
+}
+
+// TestCaller tests the creation site of errors and its JSON in debug mode
+func TestCaller(t *testing.T) {
+	resetStack(t)
+	_, _, _, ok := merror.Caller(fmt.Errorf("plain"))
+	assert.False(t, ok)
+
+	err := merror.Wrapf(newNotFound(), "load user %d", 1)
+	file, line, fn, ok := merror.Caller(err)
+	require.True(t, ok)
+	assert.True(t, strings.HasSuffix(fn, ".TestCaller"), fn)
+	assert.True(t, strings.HasSuffix(file, "/z_merror_unit_stack_test.go"), file)
+	assert.Greater(t, line, 0)
+
+	data, marshalErr := merror.MarshalWithStack(err)
+	require.NoError(t, marshalErr)
+	assert.Contains(t, string(data), fmt.Sprintf(`"caller":"%s %s:%d"`, fn, file, line))
+	assert.Empty(t, merror.ToJSON(err).Caller)
+
+	merror.DisableStack()
+	_, _, _, ok = merror.Caller(merror.New("failed"))
+	assert.False(t, ok)
+}
+
+// BenchmarkNew benchmarks creating errors with their stacks captured
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = merror.New("failed")
+	}
+}
+
+// BenchmarkNew_DisableStack benchmarks creating errors with the capture of stacks disabled
+func BenchmarkNew_DisableStack(b *testing.B) {
+	merror.DisableStack()
+	defer merror.SetStackDepth(64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = merror.New("failed")
+	}
+}
@@ -0,0 +1,43 @@
+package merror_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrap_Nil tests that wrapping nil returns an untyped nil, so `if err != nil` holds after wrapping
+func TestWrap_Nil(t *testing.T) {
+	assert.True(t, merror.Wrap(nil, "read") == nil)
+	assert.True(t, merror.Wrapf(nil, "read %s", "file") == nil)
+	assert.True(t, merror.WrapCode(nil, mcode.CodeNotFound) == nil)
+	assert.True(t, merror.WrapCodef(nil, mcode.CodeNotFound, "user %d", 1) == nil)
+}
+
+// TestWrap tests that the wrapped error is the next error of the chain
+func TestWrap(t *testing.T) {
+	err := merror.Wrapf(merror.Wrap(io.EOF, "read"), "load %s", "config")
+	assert.Equal(t, "load config: read: EOF", err.Error())
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.Equal(t, io.EOF, merror.Cause(err))
+}
+
+// TestCode_OutermostWins tests that the outermost code of the chain wins over the inner ones,
+// and that the errors without code, or with CodeNil, inherit the code of the error they wrap
+func TestCode_OutermostWins(t *testing.T) {
+	notFound := merror.NewCode(mcode.CodeNotFound, "user not found")
+
+	assert.Equal(t, mcode.CodeNotFound, merror.Code(merror.Wrap(notFound, "load")))
+	assert.Equal(t, mcode.CodeNotFound, merror.Code(fmt.Errorf("load: %w", notFound)))
+	assert.Equal(t, mcode.CodeNotFound, merror.Code(merror.WrapCode(notFound, mcode.CodeNil)))
+
+	forbidden := merror.WrapCode(merror.Wrap(notFound, "load"), mcode.CodeForbidden)
+	assert.Equal(t, mcode.CodeForbidden, merror.Code(forbidden))
+	assert.Equal(t, mcode.CodeForbidden, merror.Code(merror.Wrap(forbidden, "handle")))
+	assert.Equal(t, mcode.CodeInternalError, merror.Code(merror.WrapCodef(forbidden, mcode.CodeInternalError, "retry %d", 3)))
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // Server 返回指定名称的 HTTP 服务器实例
-func Server(name ...interface{}) *mhttp.Server {
+func Server(name ...string) *mhttp.Server {
 	return mins.Server(name...)
 }
 
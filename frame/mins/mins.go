@@ -1,9 +1,14 @@
 package mins
 
-import "github.com/graingo/maltose/container/minstance"
+import (
+	"errors"
+	"strings"
+
+	"github.com/graingo/maltose/container/minstance"
+	"github.com/graingo/maltose/os/mlog"
+)
 
 const (
-	frameCoreNameLogger = "maltose.logger"
 	frameCoreNameRedis  = "maltose.redis"
 	frameCoreNameServer = "maltose.server"
 )
@@ -11,3 +16,19 @@ const (
 var (
 	globalInstances = minstance.New()
 )
+
+// Shutdown removes all the instances of the framework and releases their resources, see
+// minstance.Container.RemoveE. The servers are shut down first, see mhttp.Server.Shutdown, so that
+// their Run returns once they stop listening, then the loggers of Log are closed, see mlog.CloseAll,
+// so that the entries of the servers are written. It is meant for the shutdown of the process.
+func Shutdown() error {
+	var errs []error
+	globalInstances.Range(func(name string, _ any) bool {
+		if strings.HasPrefix(name, frameCoreNameServer+".") {
+			_, err := globalInstances.RemoveE(name)
+			errs = append(errs, err)
+		}
+		return true
+	})
+	return errors.Join(append(errs, globalInstances.Clear(), mlog.CloseAll())...)
+}
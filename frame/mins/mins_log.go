@@ -1,9 +1,6 @@
 package mins
 
 import (
-	"context"
-	"fmt"
-
 	"github.com/graingo/maltose/os/mlog"
 )
 
@@ -11,42 +8,10 @@ const (
 	configNodeNameLogger = "logger" // config node name for logger
 )
 
-// Log returns a glog.Logger instance
-// The parameter name is the instance name
+// Log returns the logger instance with the specified name, "default" by default.
+// It is mlog.Instance: the instances are shared with the packages using mlog directly,
+// and the config node "logger.{name}" of the default config, or "logger", is applied on creation.
+// The servers of Server log with Log() unless their config node has a logger node of its own.
 func Log(name ...string) *mlog.Logger {
-	var (
-		ctx          = context.Background()
-		instanceName = mlog.DefaultName
-	)
-	if len(name) > 0 && name[0] != "" {
-		instanceName = name[0]
-	}
-
-	instanceKey := fmt.Sprintf("%s.%s", frameCoreNameLogger, instanceName)
-	v := globalInstances.GetOrSetFunc(instanceKey, func() any {
-		// create logger instance
-		logger := mlog.Instance(instanceName)
-		// try to get logger config
-		var configMap map[string]any
-		// try to get logger config with certain name
-		certainLoggerNodeName := fmt.Sprintf(`%s.%s`, configNodeNameLogger, instanceName)
-		if v, _ := Config().Get(ctx, certainLoggerNodeName); v.IsNil() {
-			configMap = v.Map()
-		}
-		// if certain config not exists, use global logger config
-		if len(configMap) == 0 {
-			if v, _ := Config().Get(ctx, configNodeNameLogger); !v.IsEmpty() {
-				configMap = v.Map()
-			}
-		}
-		// if config exists, set to logger instance
-		if len(configMap) > 0 {
-			if err := logger.SetConfigWithMap(configMap); err != nil {
-				panic(err)
-			}
-		}
-		return logger
-	})
-
-	return v.(*mlog.Logger)
+	return mlog.Instance(name...)
 }
@@ -4,77 +4,45 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/graingo/maltose/container/minstance"
+	"github.com/graingo/maltose/internal/intlog"
 	"github.com/graingo/maltose/net/mhttp"
-	"github.com/graingo/mconv"
+	"github.com/graingo/maltose/os/mlog"
 )
 
 const (
 	configNodeNameServer = "server" // config node name for server
 )
 
-func Server(name ...interface{}) *mhttp.Server {
-	var (
-		ctx          = context.Background()
-		instanceName = mhttp.DefaultServerName
-		instanceKey  = fmt.Sprintf("%s.%v", frameCoreNameServer, name)
-	)
-
+// Server returns the HTTP server instance with the specified name, "default" by default.
+// The server is created on the first call and the same instance is returned for the name afterward,
+// so the routes can be registered from anywhere, like init functions of the packages, and it is
+// safe for concurrent use.
+//
+// On creation, the config node "server.{name}" of the default config is applied to the server,
+// or "server.default", or the node "server" itself for a flat config, like mcfg.Instance.
+// The server and its middleware log with Log(), or with a logger of their own configured by
+// the node "logger" of the server node if it exists.
+func Server(name ...string) *mhttp.Server {
+	instanceName := mhttp.DefaultServerName
 	if len(name) > 0 && name[0] != "" {
-		instanceName = mconv.ToString(name[0])
+		instanceName = name[0]
 	}
 
-	return globalInstances.GetOrSetFunc(instanceKey, func() interface{} {
+	instanceKey := fmt.Sprintf("%s.%s", frameCoreNameServer, instanceName)
+	return minstance.GetOrSet(globalInstances, instanceKey, func() *mhttp.Server {
+		ctx := context.Background()
 		server := mhttp.New()
+		if serverConfigMap := loadServerConfig(ctx, instanceName); len(serverConfigMap) > 0 {
+			server.SetConfigWithMap(serverConfigMap)
 
-		if Config().Available(ctx) {
-			var (
-				configMap             map[string]any
-				serverConfigMap       map[string]any
-				loggerServerConfigMap map[string]any
-			)
-
-			// get global config
-			configMap, err := Config().Data(ctx)
-			if err != nil {
-				panic(fmt.Errorf("retrieve config data map failed: %+v", err))
-			}
-
-			if configMap[configNodeNameServer] != "" {
-				serverConfigMap = make(map[string]any)
-
-				// try to get instance specific config
-				if instanceConfig, ok := configMap[configNodeNameServer].(map[string]any)[instanceName]; ok {
-					serverConfigMap = instanceConfig.(map[string]any)
-				} else if defaultConfig, ok := configMap[configNodeNameServer].(map[string]any)["default"]; ok {
-					// try to get default instance config
-					serverConfigMap = defaultConfig.(map[string]any)
-				} else {
-					// use flat structure config
-					serverConfigMap = configMap[configNodeNameServer].(map[string]any)
-				}
-
-				// apply server config
-				if len(serverConfigMap) > 0 {
-					// basic config
-					server.SetConfigWithMap(serverConfigMap)
-					// logger config processing
-					loggerServerConfigMap = make(map[string]any)
-
-					// check current config for logger node
-					if cfg, ok := serverConfigMap[configNodeNameLogger].(map[string]any); ok {
-						loggerServerConfigMap = cfg
-					} else {
-						// try to get global logger config
-						if cfg, ok := configMap["logger"].(map[string]any); ok {
-							loggerServerConfigMap = cfg
-						}
-					}
-					if len(loggerServerConfigMap) > 0 {
-						if err := server.Logger().SetConfigWithMap(loggerServerConfigMap); err != nil {
-							panic(err)
-						}
-					}
+			// the server logs with Log() unless it has a logger of its own
+			if loggerConfigMap, _ := serverConfigMap[configNodeNameLogger].(map[string]any); len(loggerConfigMap) > 0 {
+				logger := mlog.New()
+				if err := logger.SetConfigWithMap(loggerConfigMap); err != nil {
+					intlog.Errorf(ctx, `configure the logger of server "%s" failed: %v`, instanceName, err)
 				}
+				server.SetLogger(logger)
 			}
 		}
 		// set server name
@@ -82,5 +50,41 @@ func Server(name ...interface{}) *mhttp.Server {
 			server.SetServerName(instanceName)
 		}
 		return server
-	}).(*mhttp.Server)
+	})
+}
+
+// loadServerConfig returns the config node of the server `name`: "server.{name}", "server.default"
+// or "server", the first which exists. The node "server" is only used if it is a flat config,
+// not the nodes of named servers like {"admin": {...}}.
+func loadServerConfig(ctx context.Context, name string) map[string]any {
+	cfg := Config()
+	if !cfg.Available(ctx) {
+		return nil
+	}
+	for _, node := range []string{
+		configNodeNameServer + "." + name,
+		configNodeNameServer + "." + mhttp.DefaultServerName,
+		configNodeNameServer,
+	} {
+		v, err := cfg.Get(ctx, node)
+		if err != nil {
+			intlog.Errorf(ctx, `retrieve the config of server "%s" failed: %v`, name, err)
+			return nil
+		}
+		if m := v.Map(); len(m) > 0 && (node != configNodeNameServer || isFlatServerConfig(m)) {
+			return m
+		}
+	}
+	return nil
+}
+
+// isFlatServerConfig reports whether the node "server" is the config of a server, and not the nodes
+// of named servers: it has no nested node but the logger node.
+func isFlatServerConfig(m map[string]any) bool {
+	for k, v := range m {
+		if _, ok := v.(map[string]any); ok && k != configNodeNameLogger {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,37 @@
+package mins_test
+
+import (
+	"testing"
+
+	"github.com/graingo/maltose/frame/mins"
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/graingo/maltose/os/mlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	assert.NoError(t, mcfg.Instance().LoadFromBytes([]byte(`
+logger:
+  test_mins:
+    level: warn
+    stdout: false
+server:
+  test_logged:
+    address: ":8001"
+    logger:
+      level: debug
+      stdout: false
+`), "yaml"))
+	t.Cleanup(func() { _ = mcfg.Instance().ClearContent() })
+
+	// one registry with mlog
+	assert.Same(t, mlog.Instance(), mins.Log())
+	assert.Same(t, mlog.Instance("test_mins"), mins.Log("test_mins"))
+	assert.Equal(t, mlog.WarnLevel, mins.Log("test_mins").GetLevel())
+
+	// the servers log with Log() unless they have a logger node
+	assert.Same(t, mins.Log(), mins.Server("test_plain").Logger())
+	logged := mins.Server("test_logged").Logger()
+	assert.NotSame(t, mins.Log(), logged)
+	assert.Equal(t, mlog.DebugLevel, logged.GetLevel())
+}
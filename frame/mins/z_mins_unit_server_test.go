@@ -0,0 +1,84 @@
+package mins_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/graingo/maltose/frame/mins"
+	"github.com/graingo/maltose/net/mhttp"
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/graingo/maltose/os/mlog"
+	"github.com/stretchr/testify/assert"
+)
+
+// serverRuns numbers the runs of the tests, the servers are process-wide so each run uses its own names
+var serverRuns atomic.Int32
+
+func TestServer(t *testing.T) {
+	assert.Same(t, mins.Server(), mins.Server(mhttp.DefaultServerName))
+
+	// the routes registered with either handle are on the same server
+	name := fmt.Sprintf("api-%d", serverRuns.Add(1))
+	users, orders := mins.Server(name), mins.Server(name)
+	assert.Same(t, users, orders)
+	users.GET("/users", func(r *mhttp.Request) {})
+	orders.GET("/orders", func(r *mhttp.Request) {})
+	var paths []string
+	for _, route := range mins.Server(name).Routes() {
+		paths = append(paths, route.Path)
+	}
+	assert.Equal(t, []string{"/users", "/orders"}, paths)
+
+	assert.NotSame(t, users, mins.Server("admin"))
+}
+
+func TestServer_Config(t *testing.T) {
+	t.Cleanup(func() { _ = mcfg.Instance().ClearContent() })
+
+	// the node "server" of named servers is not the config of the other servers
+	assert.NoError(t, mcfg.Instance().LoadFromBytes([]byte(`
+server:
+  test_named:
+    address: ":8101"
+    logger:
+      level: debug
+      stdout: false
+  logger:
+    level: debug
+    stdout: false
+`), "yaml"))
+	assert.Equal(t, mlog.DebugLevel, mins.Server("test_named").Logger().GetLevel())
+	assert.Same(t, mins.Log(), mins.Server("test_unnamed").Logger())
+
+	// a flat node "server" is the config of all the servers
+	assert.NoError(t, mcfg.Instance().LoadFromBytes([]byte(`
+server:
+  address: ":8102"
+  logger:
+    level: debug
+    stdout: false
+`), "yaml"))
+	flat := mins.Server("test_flat").Logger()
+	assert.NotSame(t, mins.Log(), flat)
+	assert.Equal(t, mlog.DebugLevel, flat.GetLevel())
+}
+
+func TestServer_Concurrent(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		servers = make([]*mhttp.Server, 20)
+	)
+	for i := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			servers[i] = mins.Server("concurrent")
+		}()
+	}
+	wg.Wait()
+	for _, server := range servers {
+		assert.Same(t, servers[0], server)
+	}
+}
@@ -0,0 +1,67 @@
+package mins_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/frame/mins"
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/graingo/maltose/os/mlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Cleanup(func() { _ = mcfg.Instance().ClearContent() })
+
+	// reserve a free port for the server
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	name := fmt.Sprintf("test_shutdown_%d", serverRuns.Add(1))
+	require.NoError(t, mcfg.Instance().LoadFromBytes([]byte(fmt.Sprintf(`
+server:
+  %s:
+    address: "%s"
+    graceful_wait_time: 10ms
+    logger:
+      level: info
+      stdout: false
+`, name, address)), "yaml"))
+	server := mins.Server(name)
+	logger := server.Logger()
+	assert.NotSame(t, mins.Log(), logger)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Run()
+	}()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, mins.Shutdown())
+
+	// Run returns and the listener is closed
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Shutdown")
+	}
+	_, err = net.Dial("tcp", address)
+	assert.Error(t, err)
+
+	// the logger of the server and the shared loggers are closed
+	assert.False(t, logger.IsLevelEnabled(mlog.ErrorLevel))
+	assert.False(t, mins.Log().IsLevelEnabled(mlog.ErrorLevel))
+	assert.NotSame(t, server, mins.Server(name))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -20,12 +21,29 @@ var (
 	// Debug controls whether logging is enabled.
 	// It's true in development environments and false in production by default.
 	Debug = true
+
+	// handler receives the logs instead of printing them, see SetHandler.
+	handler atomic.Pointer[Handler]
 )
 
+// Handler handles an internal log instead of printing it. The component is the package
+// of the logging call, like "mclient". It returns false to print the log as usual.
+type Handler func(ctx context.Context, component string, content string, isError bool) bool
+
+// SetHandler sets the handler receiving the internal logs, even if Debug is false,
+// like a bridge to a logger. A nil handler restores printing them.
+func SetHandler(h Handler) {
+	if h == nil {
+		handler.Store(nil)
+		return
+	}
+	handler.Store(&h)
+}
+
 // Print prints `v` with newline using fmt.Println.
 // The parameter `v` can be multiple variables.
 func Print(ctx context.Context, v ...interface{}) {
-	if !Debug {
+	if !enabled() {
 		return
 	}
 	doPrint(ctx, fmt.Sprint(v...), false)
@@ -34,7 +52,7 @@ func Print(ctx context.Context, v ...interface{}) {
 // Printf prints `v` with format `format` using fmt.Printf.
 // The parameter `v` can be multiple variables.
 func Printf(ctx context.Context, format string, v ...interface{}) {
-	if !Debug {
+	if !enabled() {
 		return
 	}
 	doPrint(ctx, fmt.Sprintf(format, v...), false)
@@ -43,7 +61,7 @@ func Printf(ctx context.Context, format string, v ...interface{}) {
 // Error prints `v` with newline using fmt.Println.
 // The parameter `v` can be multiple variables.
 func Error(ctx context.Context, v ...interface{}) {
-	if !Debug {
+	if !enabled() {
 		return
 	}
 	doPrint(ctx, fmt.Sprint(v...), true)
@@ -51,13 +69,21 @@ func Error(ctx context.Context, v ...interface{}) {
 
 // Errorf prints `v` with format `format` using fmt.Printf.
 func Errorf(ctx context.Context, format string, v ...interface{}) {
-	if !Debug {
+	if !enabled() {
 		return
 	}
 	doPrint(ctx, fmt.Sprintf(format, v...), true)
 }
 
+// enabled reports whether the logs are printed or handled.
+func enabled() bool {
+	return Debug || handler.Load() != nil
+}
+
 func doPrint(ctx context.Context, content string, stack bool) {
+	if h := handler.Load(); h != nil && (*h)(ctx, component(), content, stack) {
+		return
+	}
 	if !Debug {
 		return
 	}
@@ -103,6 +129,15 @@ func file() string {
 	return "unknown:0"
 }
 
+// component returns the package name of the caller, like "mclient".
+func component() string {
+	_, file, _, ok := runtime.Caller(3) // Skip doPrint, Error/Print and the actual caller
+	if ok {
+		return filepath.Base(filepath.Dir(file))
+	}
+	return "unknown"
+}
+
 // getCallerStack returns the stack trace excluding this package.
 func getCallerStack() string {
 	stackBuf := bytes.NewBuffer(nil)
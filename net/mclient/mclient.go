@@ -3,14 +3,17 @@ package mclient
 import (
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
 // Client is an HTTP client with enhanced features.
 type Client struct {
-	client      *http.Client     // HTTP client for the request.
-	config      ClientConfig     // Default configuration for the client.
-	middlewares []MiddlewareFunc // Middleware functions.
+	client      *http.Client        // HTTP client for the request.
+	mu          sync.RWMutex        // Protects config, which can change while requests are running, see WatchConfig.
+	config      ClientConfig        // Default configuration for the client.
+	middlewares []MiddlewareFunc    // Middleware functions.
+	limiter     *TokenBucketLimiter // Rate limiter of SetRateLimit.
 }
 
 // New creates and returns a new HTTP client object.
@@ -62,7 +65,7 @@ func (c *Client) Clone() *Client {
 		Transport: c.client.Transport,
 		Timeout:   c.client.Timeout,
 	}
-	newClient.config = c.config
+	newClient.config = c.getConfig()
 	newClient.middlewares = append(newClient.middlewares, c.middlewares...)
 	return newClient
 }
@@ -74,11 +77,12 @@ func (c *Client) do(req *http.Request) (*http.Response, error) {
 	reqCopy := req.Clone(req.Context())
 
 	// Apply client configuration
-	if c.config.Header != nil && reqCopy.Header == nil {
+	config := c.getConfig()
+	if config.Header != nil && reqCopy.Header == nil {
 		reqCopy.Header = make(http.Header)
 	}
 
-	for k, v := range c.config.Header {
+	for k, v := range config.Header {
 		if reqCopy.Header.Get(k) == "" && len(v) > 0 {
 			reqCopy.Header.Set(k, v[0])
 		}
@@ -96,13 +100,17 @@ func (c *Client) GetClient() *http.Client {
 // SetTransport sets the client transport.
 func (c *Client) SetTransport(transport http.RoundTripper) *Client {
 	c.client.Transport = transport
+	c.mu.Lock()
 	c.config.Transport = transport
+	c.mu.Unlock()
 	return c
 }
 
 // SetConfig sets the client configuration.
 func (c *Client) SetConfig(config ClientConfig) *Client {
+	c.mu.Lock()
 	c.config = config
+	c.mu.Unlock()
 
 	// Apply configuration to HTTP client
 	if config.Timeout > 0 {
@@ -115,18 +123,31 @@ func (c *Client) SetConfig(config ClientConfig) *Client {
 	return c
 }
 
-// NewRequest creates and returns a new request object.
+// NewRequest creates and returns a new request object,
+// with the default retry configuration of the client if any.
 func (c *Client) NewRequest() *Request {
-	return &Request{
+	r := &Request{
 		client:      c,
 		middlewares: make([]MiddlewareFunc, 0),
 		queryParams: make(url.Values),
 		formParams:  make(url.Values),
 		response:    &Response{},
 	}
+	if retry := c.getConfig().Retry; retry.Count > 0 {
+		r.SetRetry(retry)
+	}
+	return r
 }
 
 // R returns a new request object bound to this client for chain calls.
 func (c *Client) R() *Request {
 	return c.NewRequest()
 }
+
+// getConfig returns the current configuration of the client.
+// The header of the returned configuration must not be modified.
+func (c *Client) getConfig() ClientConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 	"time"
 
 	"github.com/graingo/maltose/errors/merror"
@@ -20,6 +21,8 @@ type ClientConfig struct {
 	Header http.Header
 	// BaseURL specifies the base URL for all requests.
 	BaseURL string
+	// Retry specifies the default retry configuration for requests, no retry if Count is 0.
+	Retry RetryConfig
 }
 
 // SetBrowserMode enables browser mode of the client.
@@ -35,21 +38,22 @@ func (c *Client) SetBrowserMode(enabled bool) *Client {
 
 // SetHeader sets a custom HTTP header pair for the client.
 func (c *Client) SetHeader(key, value string) *Client {
-	if c.config.Header == nil {
-		c.config.Header = make(http.Header)
-	}
-	c.config.Header.Set(key, value)
-	return c
+	return c.SetHeaderMap(map[string]string{key: value})
 }
 
 // SetHeaderMap sets custom HTTP headers with map.
 func (c *Client) SetHeaderMap(m map[string]string) *Client {
-	if c.config.Header == nil {
-		c.config.Header = make(http.Header)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// copy on write, as the header may be in use by running requests
+	header := c.config.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
 	}
 	for k, v := range m {
-		c.config.Header.Set(k, v)
+		header.Set(k, v)
 	}
+	c.config.Header = header
 	return c
 }
 
@@ -87,14 +91,26 @@ func (c *Client) SetCookieMap(m map[string]string) *Client {
 
 // SetBaseURL sets the base URL for all requests.
 func (c *Client) SetBaseURL(baseURL string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.config.BaseURL = baseURL
 	return c
 }
 
+// SetRetry sets the default retry configuration for requests, see Request.SetRetry.
+func (c *Client) SetRetry(config RetryConfig) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.Retry = config
+	return c
+}
+
 // SetTimeout sets the request timeout for the client.
 func (c *Client) SetTimeout(t time.Duration) *Client {
 	c.client.Timeout = t
+	c.mu.Lock()
 	c.config.Timeout = t
+	c.mu.Unlock()
 	return c
 }
 
@@ -141,3 +157,45 @@ func (c *Client) SetBasicAuth(username, password string) *Client {
 	auth := username + ":" + password
 	return c.SetHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
 }
+
+// SetProxy sets the proxy URL for the client, like "http://proxy.example.com:3128".
+func (c *Client) SetProxy(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return merror.Wrapf(err, "invalid proxy URL %s", proxyURL)
+	}
+	transport, err := c.httpTransport()
+	if err != nil {
+		return err
+	}
+	transport.Proxy = http.ProxyURL(u)
+	return nil
+}
+
+// SetTLSSkipVerify sets whether the client skips verifying the certificate of the server.
+// It should only be enabled for testing.
+func (c *Client) SetTLSSkipVerify(skip bool) error {
+	transport, err := c.httpTransport()
+	if err != nil {
+		return err
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	} else {
+		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = skip
+	return nil
+}
+
+// httpTransport returns the *http.Transport of the client,
+// cloning http.DefaultTransport so that the shared default transport is not modified.
+func (c *Client) httpTransport() (*http.Transport, error) {
+	if c.client.Transport == http.DefaultTransport {
+		c.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if transport, ok := c.client.Transport.(*http.Transport); ok {
+		return transport, nil
+	}
+	return nil, merror.New("cannot set the transport options for custom Transport of the client")
+}
@@ -0,0 +1,154 @@
+package mclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/graingo/maltose/container/minstance"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/internal/intlog"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const (
+	// DefaultClientName is the default instance name of Instance.
+	DefaultClientName = "default"
+	// configNodeNameClient is the config node name of the clients.
+	configNodeNameClient = "client"
+)
+
+// instances caches the clients of Instance by name.
+var instances = minstance.New()
+
+// clientConfig is the config section of a client, see NewFromConfig.
+type clientConfig struct {
+	BaseURL       string
+	Timeout       time.Duration
+	RetryCount    int
+	RetryInterval time.Duration
+	Proxy         string
+	TLSSkipVerify bool
+	Header        map[string]string
+	RateLimit     float64 // requests per second, no limit if 0
+	RateBurst     int
+}
+
+// Instance returns the client configured by the `client.{name}` section of the default config,
+// creating it on the first call, see NewFromConfig. The clients are cached by name and are
+// safe for concurrent use. The client adjusts itself when the section changes, see WatchConfig.
+// If the section is missing or invalid, the error is logged and a client with the valid settings is returned.
+func Instance(name ...string) *Client {
+	instanceName := DefaultClientName
+	if len(name) > 0 && name[0] != "" {
+		instanceName = name[0]
+	}
+
+	return minstance.GetOrSet(instances, instanceName, func() *Client {
+		config := mcfg.Instance()
+		c, err := NewFromConfig(context.Background(), config, instanceName)
+		if err != nil {
+			intlog.Errorf(context.Background(), "%+v", merror.Wrapf(err, `create client instance "%s" failed`, instanceName))
+		}
+		c.WatchConfig(config, instanceName)
+		return c
+	})
+}
+
+// NewFromConfig creates a client configured by the `client.{name}` section of `config`:
+//
+//	client:
+//	  payment:
+//	    baseURL: https://pay.example.com
+//	    timeout: 5s
+//	    retryCount: 2
+//	    retryInterval: 200ms
+//	    proxy: http://proxy.example.com:3128
+//	    tlsSkipVerify: false
+//	    header:
+//	      X-Caller: order-service
+//	    rateLimit: 50 # requests per second
+//	    rateBurst: 10
+//
+// Keys match ignoring case and "_", see mcfg.Config.UnmarshalKey. The returned client is
+// never nil, the error reports a missing or invalid section.
+func NewFromConfig(ctx context.Context, config *mcfg.Config, name string) (*Client, error) {
+	c := New()
+	key := configNodeNameClient + "." + name
+	if !config.IsSet(ctx, key) {
+		return c, merror.NewCodef(mcode.CodeMissingConfiguration, `config "%s" not found`, key)
+	}
+	section, err := readClientConfig(ctx, config, key)
+	if err != nil {
+		return c, err
+	}
+	if section.Timeout > 0 {
+		c.SetTimeout(section.Timeout)
+	}
+	if section.Proxy != "" {
+		if err = c.SetProxy(section.Proxy); err != nil {
+			return c, err
+		}
+	}
+	if section.TLSSkipVerify {
+		if err = c.SetTLSSkipVerify(true); err != nil {
+			return c, err
+		}
+	}
+	if section.RateLimit > 0 {
+		c.SetRateLimit(section.RateLimit, section.RateBurst)
+	}
+	c.applyConfig(section)
+	return c, nil
+}
+
+// WatchConfig adjusts the client when the `client.{name}` section of `config` changes,
+// see mcfg.Config.OnKeyChange and mcfg.Config.Watch. The base URL, header, retry and
+// rate limit are applied to the running client: headers are merged into the current header,
+// and a rate limit can be changed but not added or removed. The timeout, proxy and TLS
+// settings take effect on a new client.
+func (c *Client) WatchConfig(config *mcfg.Config, name string) {
+	key := configNodeNameClient + "." + name
+	config.OnKeyChange(key, func(_ []mcfg.Change) {
+		ctx := context.Background()
+		section, err := readClientConfig(ctx, config, key)
+		if err != nil {
+			intlog.Errorf(ctx, "%+v", merror.Wrapf(err, `apply config "%s" to client failed`, key))
+			return
+		}
+		c.applyConfig(section)
+		c.mu.RLock()
+		limited := c.limiter != nil
+		c.mu.RUnlock()
+		if limited && section.RateLimit > 0 {
+			c.SetRateLimit(section.RateLimit, section.RateBurst)
+		}
+	})
+}
+
+// readClientConfig reads the client config section `key` of `config`.
+func readClientConfig(ctx context.Context, config *mcfg.Config, key string) (clientConfig, error) {
+	var section clientConfig
+	err := config.UnmarshalKey(ctx, key, &section)
+	return section, err
+}
+
+// applyConfig applies the base URL, header and retry settings of `section`,
+// which can change while requests are running. The header is merged into the current header.
+func (c *Client) applyConfig(section clientConfig) {
+	c.mu.Lock()
+	c.config.BaseURL = section.BaseURL
+	c.config.Retry = RetryConfig{}
+	if section.RetryCount > 0 {
+		c.config.Retry = DefaultRetryConfig()
+		c.config.Retry.Count = section.RetryCount
+		if section.RetryInterval > 0 {
+			c.config.Retry.BaseInterval = section.RetryInterval
+		}
+	}
+	c.mu.Unlock()
+
+	if len(section.Header) > 0 {
+		c.SetHeaderMap(section.Header)
+	}
+}
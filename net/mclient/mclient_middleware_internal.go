@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/graingo/maltose/errors/merror"
 	"github.com/graingo/maltose/net/mtrace"
 	"github.com/graingo/mconv"
 	"go.opentelemetry.io/otel"
@@ -31,19 +32,15 @@ type contextKey string
 // internalMiddlewareRecovery internal error recovery middleware
 func internalMiddlewareRecovery() MiddlewareFunc {
 	return func(next HandlerFunc) HandlerFunc {
-		return func(req *Request) (*Response, error) {
-			var resp *Response
-			var err error
-
+		return func(req *Request) (resp *Response, err error) {
 			defer func() {
 				if r := recover(); r != nil {
 					// Handle panic
-					err = fmt.Errorf("client panic: %v", r)
+					resp, err = nil, merror.Wrap(merror.FromPanic(r), "client")
 				}
 			}()
 
-			resp, err = next(req)
-			return resp, err
+			return next(req)
 		}
 	}
 }
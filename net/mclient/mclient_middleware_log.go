@@ -27,21 +27,21 @@ func MiddlewareLog(logger mlog.ILogger) MiddlewareFunc {
 			} else {
 				urlStr = "<no url>"
 			}
-			logger.Infof(ctx, "Request: %s %s", req.Method, urlStr)
+			logger.Infow(ctx, "Request", "method", req.Method, "url", urlStr)
 
 			// Execute request
 			resp, err := next(req)
 
 			// Log response or error
 			if err != nil {
-				logger.Errorf(ctx, "Request failed: %v, Duration: %v", err, time.Since(start))
+				logger.Errorw(ctx, "Request failed", mlog.Err(err), "duration", time.Since(start))
 				return resp, err
 			}
 
 			if resp != nil {
-				logger.Infof(ctx, "Response: %d, Duration: %v", resp.StatusCode, time.Since(start))
+				logger.Infow(ctx, "Response", "status", resp.StatusCode, "duration", time.Since(start))
 			} else {
-				logger.Infof(ctx, "Response: nil, Duration: %v", time.Since(start))
+				logger.Infow(ctx, "Response", "status", nil, "duration", time.Since(start))
 			}
 
 			return resp, nil
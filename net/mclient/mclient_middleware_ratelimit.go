@@ -42,6 +42,20 @@ func NewTokenBucketLimiter(rate float64, bucketSize int) *TokenBucketLimiter {
 	}
 }
 
+// SetRate changes the rate in requests per second and the maximum burst size of the limiter,
+// for example when the configuration changes.
+func (l *TokenBucketLimiter) SetRate(rate float64, bucketSize int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	l.rate = rate
+	l.bucketSize = bucketSize
+	if l.tokens > float64(bucketSize) {
+		l.tokens = float64(bucketSize)
+	}
+}
+
 // refill adds tokens to the bucket based on elapsed time.
 func (l *TokenBucketLimiter) refill() {
 	now := time.Now()
@@ -136,8 +150,11 @@ func MiddlewareRateLimit(config RateLimitConfig) MiddlewareFunc {
 	}
 
 	// Create a token bucket limiter
-	limiter := NewTokenBucketLimiter(rps, burst)
+	return rateLimitMiddleware(NewTokenBucketLimiter(rps, burst), config)
+}
 
+// rateLimitMiddleware returns a middleware that limits the rate of requests with `limiter`.
+func rateLimitMiddleware(limiter RateLimiter, config RateLimitConfig) MiddlewareFunc {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(req *Request) (*Response, error) {
 			ctx := req.Context()
@@ -171,6 +188,30 @@ func MiddlewareRateLimit(config RateLimitConfig) MiddlewareFunc {
 	}
 }
 
+// SetRateLimit limits the rate of all requests from this client to `rps` requests per second
+// with bursts of `burst` requests. Unlike WithGlobalRateLimit, calling it again changes the
+// rate of the existing limiter, so it can be adjusted while requests are running.
+func (c *Client) SetRateLimit(rps float64, burst int) *Client {
+	if rps <= 0 {
+		rps = 100
+	}
+	if burst <= 0 {
+		burst = 10
+	}
+	c.mu.Lock()
+	limiter, created := c.limiter, c.limiter == nil
+	if created {
+		limiter = NewTokenBucketLimiter(rps, burst)
+		c.limiter = limiter
+	}
+	c.mu.Unlock()
+	if !created {
+		limiter.SetRate(rps, burst)
+		return c
+	}
+	return c.Use(rateLimitMiddleware(limiter, RateLimitConfig{}))
+}
+
 // WithGlobalRateLimit applies rate limiting to all requests from this client.
 func (c *Client) WithGlobalRateLimit(rps float64, burst int) *Client {
 	c.Use(MiddlewareRateLimit(RateLimitConfig{
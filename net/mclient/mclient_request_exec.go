@@ -44,8 +44,12 @@ func (r *Request) doRequest(ctx context.Context, method string, urlPath string)
 		// Create a new request for each attempt
 		resp, err = r.attemptRequest(ctx, method, urlPath)
 
-		// Break if we shouldn't retry
-		if !r.shouldRetry(resp.Response, err) || attempts >= maxAttempts {
+		// Break if we shouldn't retry, the response is nil if the attempt failed
+		var httpResp *http.Response
+		if resp != nil {
+			httpResp = resp.Response
+		}
+		if !r.shouldRetry(httpResp, err) || attempts >= maxAttempts {
 			break
 		}
 
@@ -95,8 +99,7 @@ func (r *Request) attemptRequest(ctx context.Context, method string, urlPath str
 
 	// Prepare the request URL
 	fullURL := urlPath
-	if r.client.config.BaseURL != "" && !strings.HasPrefix(urlPath, "http://") && !strings.HasPrefix(urlPath, "https://") {
-		baseURL := r.client.config.BaseURL
+	if baseURL := r.client.getConfig().BaseURL; baseURL != "" && !strings.HasPrefix(urlPath, "http://") && !strings.HasPrefix(urlPath, "https://") {
 
 		// Ensure there's a single slash between baseURL and urlPath
 		if !strings.HasSuffix(baseURL, "/") && !strings.HasPrefix(urlPath, "/") {
@@ -148,8 +151,8 @@ func (r *Request) attemptRequest(ctx context.Context, method string, urlPath str
 	}
 
 	// Set headers from the client config
-	if r.client.config.Header != nil {
-		for k, v := range r.client.config.Header {
+	if header := r.client.getConfig().Header; header != nil {
+		for k, v := range header {
 			if len(v) > 0 {
 				req.Header.Set(k, v[0])
 			}
@@ -1,9 +1,12 @@
 package mclient
 
 import (
+	"errors"
 	"math/rand"
 	"net/http"
 	"time"
+
+	"github.com/graingo/maltose/errors/merror"
 )
 
 // -----------------------------------------------------------------------------
@@ -95,6 +98,10 @@ func (r *Request) shouldRetry(resp *http.Response, err error) bool {
 
 	// Default retry condition
 	if err != nil {
+		// Classify the errors of merror, like those returned by middlewares
+		if isMError(err) {
+			return merror.IsRetryable(err)
+		}
 		// Retry on network/connection errors
 		return true
 	}
@@ -107,6 +114,16 @@ func (r *Request) shouldRetry(resp *http.Response, err error) bool {
 	return false
 }
 
+// isMError reports whether the chain of `err` contains errors of merror or errors classifying themselves,
+// which are classified by merror.IsRetryable.
+func isMError(err error) bool {
+	var (
+		e *merror.Error
+		r merror.IRetryable
+	)
+	return errors.As(err, &e) || errors.As(err, &r)
+}
+
 // calculateRetryDelay calculates the delay for the next retry attempt.
 func (r *Request) calculateRetryDelay(attempt int) time.Duration {
 	// If no retry config, use simple interval
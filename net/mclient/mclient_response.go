@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
 	"github.com/graingo/maltose/internal/intlog"
 )
 
@@ -108,6 +110,26 @@ func (r *Response) IsSuccess() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
 }
 
+// Err returns nil if the response status code is in the 2xx range, otherwise an error
+// carrying the code mapped from the status code, for example mcode.CodeNotFound for 404,
+// see mcode.FromHTTPStatus.
+func (r *Response) Err() error {
+	if r == nil || r.Response == nil {
+		return merror.NewCode(mcode.CodeInternalError, "response is nil")
+	}
+	if r.IsSuccess() {
+		return nil
+	}
+	code := mcode.FromHTTPStatus(r.StatusCode)
+	if mcode.Equal(code, mcode.CodeOK) {
+		code = mcode.CodeUnknown
+	}
+	if r.Request == nil {
+		return merror.NewCodef(code, "unexpected status %s", r.Status)
+	}
+	return merror.NewCodef(code, "%s %s: unexpected status %s", r.Request.Method, r.Request.URL, r.Status)
+}
+
 // SetBodyContent overwrites response content with custom one.
 func (r *Response) SetBodyContent(content []byte) {
 	buffer := bytes.NewBuffer(content)
@@ -0,0 +1,109 @@
+package mclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/net/mclient"
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig(t *testing.T, content string) *mcfg.Config {
+	adapter, err := mcfg.NewAdapterFile()
+	require.NoError(t, err)
+	config := mcfg.NewWithAdapter(adapter)
+	require.NoError(t, config.LoadFromBytes([]byte(content), "yaml"))
+	return config
+}
+
+// TestNewFromConfig tests creating a client from the client config section
+func TestNewFromConfig(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Caller")))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	config := newTestConfig(t, fmt.Sprintf(`
+client:
+  payment:
+    baseURL: %s/v1
+    timeout: 5s
+    retryCount: 2
+    retry_interval: 1ms
+    tlsSkipVerify: true
+    header:
+      X-Caller: order-service
+    rateLimit: 1000
+    rateBurst: 10
+`, server.URL))
+
+	client, err := mclient.NewFromConfig(ctx, config, "payment")
+	require.NoError(t, err)
+	assert.Equal(t, "5s", client.GetClient().Timeout.String())
+
+	resp, err := client.R().GET("/charges")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/v1/charges order-service", resp.ReadAllString())
+	assert.Equal(t, int32(2), attempts.Load(), "Expected the configured retry")
+
+	// adjust the running client when the section changes
+	client.WatchConfig(config, "payment")
+	require.NoError(t, config.Set(ctx, "client.payment.baseURL", server.URL+"/v2"))
+	resp, err = client.R().GET("/charges")
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/charges order-service", resp.ReadAllString())
+
+	_, err = mclient.NewFromConfig(ctx, config, "search")
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+}
+
+// TestClientConcurrentReconfigure tests adjusting a client while requests are running
+func TestClientConcurrentReconfigure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := mclient.New().SetBaseURL(server.URL).SetRateLimit(10000, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				resp, err := client.R().GET("/ping")
+				if assert.NoError(t, err) {
+					resp.Close()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		client.SetHeader("X-Version", fmt.Sprint(i))
+		client.SetBaseURL(server.URL)
+		client.SetRateLimit(float64(10000+i), 100)
+	}
+	wg.Wait()
+}
+
+// TestInstance tests that clients are cached by name
+func TestInstance(t *testing.T) {
+	assert.Same(t, mclient.Instance("instance-test"), mclient.Instance("instance-test"))
+	assert.NotSame(t, mclient.Instance("instance-test"), mclient.Instance())
+}
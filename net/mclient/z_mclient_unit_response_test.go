@@ -0,0 +1,78 @@
+package mclient_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/net/mclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResponseErr tests mapping non-2xx responses to coded errors
+func TestResponseErr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/denied":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	client := mclient.New()
+	tests := []struct {
+		path string
+		code mcode.Code
+	}{
+		{"/missing", mcode.CodeNotFound},
+		{"/denied", mcode.CodeNotAuthorized},
+		{"/busy", mcode.CodeServerBusy},
+		{"/gateway", mcode.CodeInternalError},
+	}
+
+	resp, err := client.R().GET(server.URL + "/ok")
+	require.NoError(t, err)
+	assert.NoError(t, resp.Err())
+
+	for _, tt := range tests {
+		resp, err = client.R().GET(server.URL + tt.path)
+		require.NoError(t, err)
+		err = resp.Err()
+		require.Error(t, err, tt.path)
+		merrortest.AssertCode(t, err, tt.code)
+		assert.True(t, merror.MessageContains(err, tt.path), tt.path)
+	}
+}
+
+// TestResponseErrorDecoding tests decoding an error body into merror.Error
+func TestResponseErrorDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := json.Marshal(merror.NewCode(mcode.WithCode(mcode.CodeNotFound, map[string]any{"id": "42"}), "order not found"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	var apiErr *merror.Error
+	resp, err := mclient.New().R().SetError(&apiErr).GET(server.URL)
+	require.NoError(t, err)
+	assert.False(t, resp.IsSuccess())
+	require.NotNil(t, apiErr)
+	assert.Equal(t, "order not found", apiErr.Error())
+	assert.Equal(t, mcode.CodeNotFound.Code(), apiErr.Code().Code())
+	assert.Equal(t, map[string]any{"id": "42"}, apiErr.Code().Detail())
+}
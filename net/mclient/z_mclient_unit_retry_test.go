@@ -0,0 +1,78 @@
+package mclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/net/mclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRetryClassification tests that the default retry condition classifies the errors of merror
+func TestRetryClassification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"plain error", errors.New("connection reset"), 3},
+		{"merror", merror.New("invalid signature"), 1},
+		{"retryable code", merror.NewCode(mcode.CodeServerBusy), 3},
+		{"marked retryable", merror.MarkRetryable(merror.New("version conflict")), 3},
+		{"marked permanent", merror.MarkPermanent(fmt.Errorf("send: %w", context.DeadlineExceeded)), 1},
+		{"wrapped timeout", merror.Wrap(context.DeadlineExceeded, "sign request"), 3},
+	}
+	client := mclient.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			_, err := client.R().
+				SetRetrySimple(2, time.Millisecond).
+				Use(func(next mclient.HandlerFunc) mclient.HandlerFunc {
+					return func(r *mclient.Request) (*mclient.Response, error) {
+						attempts++
+						return nil, tt.err
+					}
+				}).
+				GET(server.URL)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
+
+// TestRecoveryPanic tests that panics of middlewares are returned as permanent internal errors
+func TestRecoveryPanic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	attempts := 0
+	resp, err := mclient.New().R().
+		SetRetrySimple(2, time.Millisecond).
+		Use(func(next mclient.HandlerFunc) mclient.HandlerFunc {
+			return func(r *mclient.Request) (*mclient.Response, error) {
+				attempts++
+				panic("boom")
+			}
+		}).
+		GET(server.URL)
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "client: panic: boom")
+	merrortest.AssertCode(t, err, mcode.CodeInternalError)
+	assert.Equal(t, 1, attempts)
+}
@@ -2,6 +2,9 @@ package mhttp
 
 import (
 	"io"
+	"net/http"
+	"net/netip"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	ut "github.com/go-playground/universal-translator"
@@ -20,9 +23,15 @@ type Server struct {
 	engine       *gin.Engine
 	config       ServerConfig
 	routes       []Route
+	routeInfos   map[string]*RouteInfo
 	openapi      *OpenAPI
 	preBindItems []preBindItem
 	translator   ut.Translator
+	encoders     map[string]ResponseEncoder
+	proxies      []netip.Prefix
+	mu           sync.Mutex   // guards httpServer and shutdown
+	httpServer   *http.Server // the server started by Run, see Shutdown
+	shutdown     bool         // whether Shutdown was called
 }
 
 // New creates a new HTTP server.
@@ -34,11 +43,14 @@ func New() *Server {
 	gin.SetMode(gin.ReleaseMode)
 
 	engine := gin.New()
+	// trailing slash redirects are handled by the server itself, see handleNoRoute
+	engine.RedirectTrailingSlash = false
 
 	s := &Server{
 		engine:       engine,
 		config:       NewConfig(),
 		preBindItems: make([]preBindItem, 0),
+		encoders:     defaultResponseEncoders(),
 	}
 
 	// initialize root RouterGroup
@@ -48,6 +60,9 @@ func New() *Server {
 		ginGroup: engine.Group("/"),
 	}
 
+	// handle path normalization for unmatched routes
+	engine.NoRoute(s.handleNoRoute)
+
 	// add default middlewares
 	s.Use(
 		internalMiddlewareRecovery(),
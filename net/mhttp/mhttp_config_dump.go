@@ -0,0 +1,25 @@
+package mhttp
+
+import (
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const (
+	defaultConfigDumpPattern = "/debug/config"
+)
+
+// EnableConfigDump enables the endpoint dumping the effective configuration of `config`, with the
+// sensitive values redacted, see mcfg.Config.Handler. The query parameter `key` explains the value
+// of a single key and its source, like "/debug/config?key=server.address".
+//
+// The endpoint exposes the configuration of the service, so it should only be served internally.
+func (s *Server) EnableConfigDump(config *mcfg.Config, pattern ...string) {
+	p := defaultConfigDumpPattern
+	if len(pattern) > 0 && pattern[0] != "" {
+		p = pattern[0]
+	}
+	handler := config.Handler()
+	s.GET(p, func(r *Request) {
+		handler.ServeHTTP(r.Writer, r.Request)
+	})
+}
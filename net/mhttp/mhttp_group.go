@@ -182,6 +182,7 @@ func (rg *RouterGroup) bindObject(object any) *RouterGroup {
 			ControllerMethod: method,
 			ReqType:          reqType,
 			RespType:         method.Type.Out(0),
+			Meta:             mmeta.Data(reqInstance),
 		})
 
 		// add to pre-bind list
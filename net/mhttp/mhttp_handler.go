@@ -17,15 +17,9 @@ type HandlerFunc func(*Request)
 // handleRequest handles the request and returns the result.
 func handleRequest(r *Request, method reflect.Method, val reflect.Value, req interface{}) error {
 	// parameter binding
-	if err := r.ShouldBind(req); err != nil {
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			var errMsgs []string
-			for _, e := range validationErrors.Translate(r.GetTranslator()) {
-				errMsgs = append(errMsgs, e)
-			}
-			if len(errMsgs) > 0 {
-				return merror.NewCode(mcode.CodeValidationFailed, errMsgs[0])
-			}
+	if err := r.bindRequest(req); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok && len(validationErrors) > 0 {
+			return r.validationError(validationErrors)
 		}
 		return err
 	}
@@ -49,6 +43,24 @@ func handleRequest(r *Request, method reflect.Method, val reflect.Value, req int
 	return nil
 }
 
+// validationError returns the error of the field errors `errs`, which is the error of the first field,
+// or all field errors joined if ServerConfig.ValidateAllErrors is enabled, see merror.Join.
+// The error of a field has the field name in its "field" detail, see merror.Details.
+func (r *Request) validationError(errs validator.ValidationErrors) error {
+	if !r.server.config.ValidateAllErrors {
+		errs = errs[:1]
+	}
+	fieldErrs := make([]error, len(errs))
+	for i, e := range errs {
+		err := merror.NewCode(mcode.CodeValidationFailed, e.Translate(r.GetTranslator()))
+		fieldErrs[i] = merror.WithDetail(err, "field", e.Field())
+	}
+	if len(fieldErrs) == 1 {
+		return fieldErrs[0]
+	}
+	return merror.Join(fieldErrs...)
+}
+
 // checkMethodSignature checks the method signature.
 func checkMethodSignature(typ reflect.Type) error {
 	// check parameter number and return value number
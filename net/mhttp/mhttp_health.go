@@ -0,0 +1,36 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	defaultHealthPattern = "/health"
+)
+
+// EnableHealth mounts a health check on `pattern`, "/health" by default,
+// responding 200 "ok" to load balancers and liveness probes while the server is running.
+func (s *Server) EnableHealth(pattern ...string) {
+	p := defaultHealthPattern
+	if len(pattern) > 0 && pattern[0] != "" {
+		p = pattern[0]
+	}
+
+	s.GET(p, func(r *Request) {
+		r.String(http.StatusOK, "ok")
+	})
+}
+
+// registerDebug registers the pprof handlers and the health check configured by PProfPath and HealthPath.
+func (s *Server) registerDebug(ctx context.Context) {
+	if s.config.PProfPath != "" {
+		s.EnablePProf(s.config.PProfPath)
+		s.Logger().Infof(ctx, "pprof registered at %s", s.config.PProfPath)
+	}
+
+	if s.config.HealthPath != "" {
+		s.EnableHealth(s.config.HealthPath)
+		s.Logger().Infof(ctx, "health check registered at %s", s.config.HealthPath)
+	}
+}
@@ -0,0 +1,29 @@
+package mhttp
+
+import (
+	"github.com/graingo/maltose/os/mlog"
+)
+
+const (
+	defaultLogLevelPattern = "/debug/log/level"
+)
+
+// EnableLogLevel mounts mlog.LevelHandler on `pattern`, "/debug/log/level" by default,
+// to get and change the level of the loggers at runtime:
+//
+//	curl -X PUT 'localhost:8080/debug/log/level?name=access&level=debug'
+//
+// It should only be enabled on servers that are not publicly reachable.
+func (s *Server) EnableLogLevel(pattern ...string) {
+	p := defaultLogLevelPattern
+	if len(pattern) > 0 && pattern[0] != "" {
+		p = pattern[0]
+	}
+
+	handler := mlog.LevelHandler()
+	serve := func(r *Request) {
+		handler.ServeHTTP(r.Writer, r.Request)
+	}
+	s.GET(p, serve)
+	s.PUT(p, serve)
+}
@@ -29,6 +29,8 @@ type localMetricManager struct {
 	HttpServerRequestDurationTotal mmetric.Counter
 	HttpServerRequestBodySize      mmetric.Counter
 	HttpServerResponseBodySize     mmetric.Counter
+	HttpServerConcurrencyInflight  mmetric.UpDownCounter
+	HttpServerConcurrencyQueued    mmetric.UpDownCounter
 }
 
 // global metric manager
@@ -87,6 +89,20 @@ func newMetricManager() *localMetricManager {
 				Unit: "bytes",
 			},
 		),
+		HttpServerConcurrencyInflight: meter.MustUpDownCounter(
+			"http.server.concurrency.inflight",
+			mmetric.MetricOption{
+				Help: "in-flight requests admitted by the concurrency limiter",
+				Unit: "",
+			},
+		),
+		HttpServerConcurrencyQueued: meter.MustUpDownCounter(
+			"http.server.concurrency.queued",
+			mmetric.MetricOption{
+				Help: "requests waiting in the concurrency limiter queue",
+				Unit: "",
+			},
+		),
 	}
 	return mm
 }
@@ -115,6 +131,18 @@ func (m *localMetricManager) GetMetricOptionForRequestByMap(attrMap mmetric.Attr
 	}
 }
 
+// get concurrency limiter metric option
+func (m *localMetricManager) GetMetricOptionForConcurrencyByMap(attrMap mmetric.AttributeMap) mmetric.Option {
+	return mmetric.Option{
+		Attributes: attrMap.Pick(
+			metricAttrKeyServerAddress,
+			metricAttrKeyServerPort,
+			metricAttrKeyHttpRoute,
+			metricAttrKeyHttpRequestMethod,
+		),
+	}
+}
+
 // get response metric option
 func (m *localMetricManager) GetMetricOptionForResponseByMap(attrMap mmetric.AttributeMap) mmetric.Option {
 	return mmetric.Option{
@@ -202,7 +230,7 @@ func getSchema(r *Request) string {
 
 // handle metrics before request
 func (s *Server) handleMetricsBeforeRequest(r *Request) {
-	if !mmetric.IsEnabled() {
+	if !s.config.MetricsEnable || !mmetric.IsEnabled() {
 		return
 	}
 
@@ -228,7 +256,7 @@ func (s *Server) handleMetricsBeforeRequest(r *Request) {
 
 // handle metrics after request done
 func (s *Server) handleMetricsAfterRequestDone(r *Request, startTime time.Time) {
-	if !mmetric.IsEnabled() {
+	if !s.config.MetricsEnable || !mmetric.IsEnabled() {
 		return
 	}
 
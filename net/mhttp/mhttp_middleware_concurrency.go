@@ -0,0 +1,120 @@
+package mhttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graingo/maltose/os/mmetric"
+)
+
+const (
+	defaultConcurrencyLimit = 100
+)
+
+// MiddlewareConcurrencyLimit creates a middleware that admits at most `max` concurrent requests.
+// When all slots are taken, up to `queue` more requests wait for at most `queueTimeout`
+// for a free slot. Requests beyond that are rejected with 503 and a Retry-After header.
+//
+// Each call creates an independent limiter, so a group or a route can have its own budget,
+// see WithConcurrencyLimit.
+func MiddlewareConcurrencyLimit(max int, queue int, queueTimeout time.Duration) MiddlewareFunc {
+	if max <= 0 {
+		max = defaultConcurrencyLimit
+	}
+	if queue < 0 {
+		queue = 0
+	}
+
+	var (
+		slots      = make(chan struct{}, max)
+		queued     atomic.Int64
+		retryAfter = strconv.Itoa(int(math.Max(1, math.Ceil(queueTimeout.Seconds()))))
+	)
+
+	reject := func(r *Request) {
+		r.Header("Retry-After", retryAfter)
+		r.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Service Unavailable",
+		})
+	}
+
+	return func(r *Request) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if queue == 0 || queueTimeout <= 0 {
+				reject(r)
+				return
+			}
+			if queued.Add(1) > int64(queue) {
+				queued.Add(-1)
+				reject(r)
+				return
+			}
+			r.server.handleMetricsConcurrencyQueued(r, 1)
+
+			timer := time.NewTimer(queueTimeout)
+			select {
+			case slots <- struct{}{}:
+				timer.Stop()
+				queued.Add(-1)
+				r.server.handleMetricsConcurrencyQueued(r, -1)
+			case <-timer.C:
+				queued.Add(-1)
+				r.server.handleMetricsConcurrencyQueued(r, -1)
+				reject(r)
+				return
+			case <-r.Request.Context().Done():
+				timer.Stop()
+				queued.Add(-1)
+				r.server.handleMetricsConcurrencyQueued(r, -1)
+				r.Abort()
+				return
+			}
+		}
+
+		r.server.handleMetricsConcurrencyInflight(r, 1)
+		defer func() {
+			<-slots
+			r.server.handleMetricsConcurrencyInflight(r, -1)
+		}()
+
+		r.Next()
+	}
+}
+
+// WithConcurrencyLimit returns a router group option applying MiddlewareConcurrencyLimit
+// to the group, with a budget independent of other groups.
+func WithConcurrencyLimit(max int, queue int, queueTimeout time.Duration) RouterGroupOption {
+	return func(group *RouterGroup) {
+		group.Use([]MiddlewareFunc{MiddlewareConcurrencyLimit(max, queue, queueTimeout)})
+	}
+}
+
+// handleMetricsConcurrencyInflight records the change of in-flight requests admitted by the concurrency limiter.
+func (s *Server) handleMetricsConcurrencyInflight(r *Request, delta float64) {
+	if !mmetric.IsEnabled() {
+		return
+	}
+	metricManager.HttpServerConcurrencyInflight.Add(
+		r.Request.Context(),
+		delta,
+		metricManager.GetMetricOptionForConcurrencyByMap(metricManager.GetMetricAttributeMap(r)),
+	)
+}
+
+// handleMetricsConcurrencyQueued records the change of requests queued by the concurrency limiter.
+func (s *Server) handleMetricsConcurrencyQueued(r *Request, delta float64) {
+	if !mmetric.IsEnabled() {
+		return
+	}
+	metricManager.HttpServerConcurrencyQueued.Add(
+		r.Request.Context(),
+		delta,
+		metricManager.GetMetricOptionForConcurrencyByMap(metricManager.GetMetricAttributeMap(r)),
+	)
+}
@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
 	"github.com/graingo/maltose/net/mtrace"
+	"github.com/graingo/maltose/os/mlog"
 	"github.com/graingo/mconv"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -37,8 +40,7 @@ func internalMiddlewareDefaultResponse() MiddlewareFunc {
 
 		// handle error case
 		if len(r.Errors) > 0 {
-			err := r.Errors.Last().Err
-			r.String(500, fmt.Sprintf("Error: %s", err.Error()))
+			writeError(r, r.Errors.Last().Err)
 			return
 		}
 
@@ -60,21 +62,38 @@ func internalMiddlewareDefaultResponse() MiddlewareFunc {
 	}
 }
 
+// writeError writes the error `err` with the HTTP status of its code and its message in the locale of the request.
+func writeError(r *Request, err error) {
+	r.String(mcode.HTTPStatus(merror.Code(err)), fmt.Sprintf("Error: %s", merror.Localize(err, r.Locale())))
+}
+
 // internalMiddlewareRecovery internal error recovery middleware
+// The panics of the handlers and the middleware are recovered where they happen as the error of the
+// request, see Server.ginHandler, and written by the response middleware like the other errors;
+// the panics escaping them are recovered here and written as an internal error.
 func internalMiddlewareRecovery() MiddlewareFunc {
 	return func(r *Request) {
 		defer func() {
-			if err := recover(); err != nil {
-				// record error log
-				r.Logger().Errorf(r.Request.Context(), "Panic recovered: %v", err)
-				// return 500 error
-				r.String(500, "Internal Server Error")
+			if v := recover(); v != nil {
+				recoverPanic(r, v)
+				if !r.Writer.Written() {
+					writeError(r, r.Errors.Last().Err)
+				}
 			}
 		}()
 		r.Next()
 	}
 }
 
+// recoverPanic logs the panic `v` with its stack, and sets it as the error of the request,
+// with the code mcode.CodeInternalError, aborting the remaining handlers, see merror.FromPanic.
+func recoverPanic(r *Request, v any) {
+	err := merror.FromPanic(v)
+	r.Logger().Errorw(r.Request.Context(), "Panic recovered", mlog.Err(err))
+	r.Error(err)
+	r.Abort()
+}
+
 // internalMiddlewareMetric internal metric collection middleware
 func internalMiddlewareMetric() MiddlewareFunc {
 	return func(r *Request) {
@@ -0,0 +1,181 @@
+package mhttp
+
+import (
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// IPFilterConfig defines the allow and deny lists of the IP filter middleware.
+// Entries are CIDRs like "10.0.0.0/8" and "2001:db8::/32", or single addresses.
+//
+// The deny list is evaluated first. If the allow list is not empty, only addresses
+// matching it are admitted. The lists can be replaced at runtime with SetAllow and SetDeny.
+type IPFilterConfig struct {
+	Allow []string
+	Deny  []string
+	// ErrorHandler is an optional function to handle blocked requests
+	ErrorHandler func(*Request)
+
+	rules atomic.Pointer[ipFilterRules]
+}
+
+// ipFilterRules is the parsed form of the allow and deny lists.
+type ipFilterRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// MiddlewareIPFilter creates a middleware that blocks requests by client IP address,
+// responding 403 with mcode.CodeForbidden. The client IP is resolved by r.ClientIP,
+// which respects the trusted proxies of the server, see Server.SetTrustedProxies: set them,
+// as the forwarded headers of all the clients are trusted by default.
+//
+// It panics if the lists contain invalid entries.
+func MiddlewareIPFilter(cfg *IPFilterConfig) MiddlewareFunc {
+	if cfg.rules.Load() == nil {
+		if err := cfg.setRules(cfg.Allow, cfg.Deny); err != nil {
+			panic(err)
+		}
+	}
+
+	return func(r *Request) {
+		ip := r.ClientIP()
+		if cfg.Allowed(ip) {
+			return
+		}
+
+		err := merror.NewCodef(mcode.CodeForbidden, "IP address %s is not allowed", ip)
+		r.Error(err)
+		r.Abort()
+		if cfg.ErrorHandler != nil {
+			cfg.ErrorHandler(r)
+			return
+		}
+		r.WriteResponse(mcode.HTTPStatus(mcode.CodeForbidden), DefaultResponse{
+			Code:    mcode.CodeForbidden.Code(),
+			Message: err.Error(),
+		})
+	}
+}
+
+// SetAllow replaces the allow list, it is safe to call while serving requests.
+func (cfg *IPFilterConfig) SetAllow(cidrs ...string) error {
+	var deny []string
+	if rules := cfg.rules.Load(); rules != nil {
+		deny = prefixStrings(rules.deny)
+	} else {
+		deny = cfg.Deny
+	}
+	return cfg.setRules(cidrs, deny)
+}
+
+// SetDeny replaces the deny list, it is safe to call while serving requests.
+func (cfg *IPFilterConfig) SetDeny(cidrs ...string) error {
+	var allow []string
+	if rules := cfg.rules.Load(); rules != nil {
+		allow = prefixStrings(rules.allow)
+	} else {
+		allow = cfg.Allow
+	}
+	return cfg.setRules(allow, cidrs)
+}
+
+// Allowed reports whether the IP address `ip` passes the filter.
+// Invalid addresses are not allowed.
+func (cfg *IPFilterConfig) Allowed(ip string) bool {
+	rules := cfg.rules.Load()
+	if rules == nil {
+		if err := cfg.setRules(cfg.Allow, cfg.Deny); err != nil {
+			return false
+		}
+		rules = cfg.rules.Load()
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	// IPv4-mapped IPv6 addresses like "::ffff:10.0.0.1" are matched as IPv4
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range rules.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(rules.allow) == 0 {
+		return true
+	}
+	for _, prefix := range rules.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// setRules parses and stores the allow and deny lists.
+func (cfg *IPFilterConfig) setRules(allow, deny []string) error {
+	allowPrefixes, err := parsePrefixes(allow)
+	if err != nil {
+		return err
+	}
+	denyPrefixes, err := parsePrefixes(deny)
+	if err != nil {
+		return err
+	}
+	cfg.rules.Store(&ipFilterRules{allow: allowPrefixes, deny: denyPrefixes})
+	return nil
+}
+
+// parsePrefixes parses CIDRs and single addresses into prefixes.
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var (
+			prefix netip.Prefix
+			err    error
+		)
+		if strings.Contains(entry, "/") {
+			prefix, err = netip.ParsePrefix(entry)
+		} else {
+			var addr netip.Addr
+			if addr, err = netip.ParseAddr(entry); err == nil {
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+		}
+		if err != nil {
+			return nil, merror.WrapCodef(err, mcode.CodeInvalidParameter, "invalid IP filter entry %q", entry)
+		}
+		prefixes = append(prefixes, unmapPrefix(prefix).Masked())
+	}
+	return prefixes, nil
+}
+
+// unmapPrefix converts IPv4-mapped IPv6 prefixes like "::ffff:10.0.0.0/104" to IPv4 prefixes.
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	if !addr.Is4In6() {
+		return prefix
+	}
+	bits := prefix.Bits() - 96
+	if bits < 0 {
+		bits = 0
+	}
+	return netip.PrefixFrom(addr.Unmap(), bits)
+}
+
+// prefixStrings converts prefixes back to their string form.
+func prefixStrings(prefixes []netip.Prefix) []string {
+	entries := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		entries = append(entries, prefix.String())
+	}
+	return entries
+}
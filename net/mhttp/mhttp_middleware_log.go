@@ -2,6 +2,8 @@ package mhttp
 
 import (
 	"time"
+
+	"github.com/graingo/maltose/os/mlog"
 )
 
 // MiddlewareLog is a middleware for logging HTTP requests.
@@ -27,24 +29,20 @@ func MiddlewareLog() MiddlewareFunc {
 		status := r.Writer.Status()
 
 		// record log
-		r.Logger().Infof(r.Request.Context(),
-			"[HTTP] %-3d | %13v | %-15s | %-7s | %s",
-			status,           // status code fixed 3 digits
-			latency,          // latency fixed 13 digits
-			r.ClientIP(),     // IP address fixed 15 digits
-			r.Request.Method, // HTTP method fixed 7 digits
-			path,
+		r.Logger().Infow(r.Request.Context(), "[HTTP] request",
+			"status", status,
+			"latency", latency,
+			"ip", r.ClientIP(),
+			"method", r.Request.Method,
+			"path", path,
 		)
 
 		// if there are errors, record error log
-		if len(r.Errors) > 0 {
-			for _, e := range r.Errors {
-				r.Logger().Errorf(r.Request.Context(),
-					"[HTTP] %s | Error: %v",
-					r.GetServerName(),
-					e.Err,
-				)
-			}
+		for _, e := range r.Errors {
+			r.Logger().Errorw(r.Request.Context(), "[HTTP] request error",
+				"server", r.GetServerName(),
+				mlog.Err(e.Err),
+			)
 		}
 	}
 }
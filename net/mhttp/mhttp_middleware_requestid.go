@@ -0,0 +1,43 @@
+package mhttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/graingo/maltose/net/mtrace"
+)
+
+const (
+	// RequestIDHeader is the header carrying the request id.
+	RequestIDHeader = "X-Request-Id"
+	// maxRequestIDLength is the maximum length of a request id accepted from the client.
+	maxRequestIDLength = 128
+)
+
+// MiddlewareRequestID creates a middleware that assigns a request id to each request.
+// The id is taken from the X-Request-Id header if present, or generated otherwise.
+// It is echoed in the response header and stored in the request context, so that
+// mtrace.GetRequestID and the loggers can read it.
+func MiddlewareRequestID() MiddlewareFunc {
+	return func(r *Request) {
+		requestID := r.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		r.Header(RequestIDHeader, requestID)
+		r.Request = r.Request.WithContext(mtrace.WithRequestID(r.Request.Context(), requestID))
+		r.Next()
+	}
+}
+
+// RequestID returns the request id assigned by MiddlewareRequestID.
+func (r *Request) RequestID() string {
+	return mtrace.GetRequestID(r.Request.Context())
+}
+
+// newRequestID generates a random request id.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -8,13 +8,21 @@ import (
 )
 
 // DefaultResponse standard response structure
+// The error fields are those of merror.JSON, so error bodies are the same as marshaled errors.
 type DefaultResponse struct {
-	Code    int    `json:"code"`    // business code
-	Message string `json:"message"` // prompt information
-	Data    any    `json:"data"`    // business data
+	Code    int          `json:"code" xml:"code" yaml:"code"`                                     // business code
+	Message string       `json:"message" xml:"message" yaml:"message"`                            // prompt information
+	Data    any          `json:"data" xml:"data" yaml:"data"`                                     // business data
+	Details any          `json:"details,omitempty" xml:"-" yaml:"details,omitempty"`              // error code detail
+	Caller  string       `json:"caller,omitempty" xml:"caller,omitempty" yaml:"caller,omitempty"` // error creation site in debug mode
+	Stack   []string     `json:"stack,omitempty" xml:"stack,omitempty" yaml:"stack,omitempty"`    // error stack in debug mode, see merror.SetDebug
+	Cause   *merror.JSON `json:"cause,omitempty" xml:"-" yaml:"cause,omitempty"`                  // wrapped causes in debug mode
 }
 
 // MiddlewareResponse standard response middleware
+// The response is serialized according to the Accept header, see Server.SetResponseTypes.
+// An error is written with the HTTP status of its code, see mcode.HTTPStatus and mcode.SetHTTPStatus,
+// and its message in the locale of the request, see Request.Locale and merror.Localize.
 func MiddlewareResponse() MiddlewareFunc {
 	return func(r *Request) {
 		r.Next()
@@ -25,9 +33,11 @@ func MiddlewareResponse() MiddlewareFunc {
 		}
 
 		var (
-			msg  string
-			code mcode.Code = mcode.CodeOK
-			data            = r.GetHandlerResponse()
+			msg    string
+			code   mcode.Code = mcode.CodeOK
+			data              = r.GetHandlerResponse()
+			status            = r.Writer.Status()
+			body   *merror.JSON
 		)
 
 		// handle error case
@@ -35,22 +45,18 @@ func MiddlewareResponse() MiddlewareFunc {
 			err := r.Errors.Last().Err
 			// get error code
 			code = merror.Code(err)
-			if code == mcode.CodeNil {
+			if mcode.Equal(code, mcode.CodeNil) {
 				code = mcode.CodeInternalError
 			}
-			msg = err.Error()
+			msg = merror.Localize(err, r.Locale())
+			body = merror.ToJSON(err)
 			data = nil
-		} else if status := r.Writer.Status(); status != http.StatusOK {
+			status = mcode.HTTPStatus(code)
+		} else if status != http.StatusOK {
 			// handle HTTP status code error
 			msg = http.StatusText(status)
-			switch status {
-			case http.StatusNotFound:
-				code = mcode.CodeNotFound
-			case http.StatusForbidden:
-				code = mcode.CodeForbidden
-			case http.StatusUnauthorized:
-				code = mcode.CodeNotAuthorized
-			default:
+			code = mcode.FromHTTPStatus(status)
+			if mcode.Equal(code, mcode.CodeOK) {
 				code = mcode.CodeInternalError
 			}
 			data = nil
@@ -60,11 +66,15 @@ func MiddlewareResponse() MiddlewareFunc {
 			msg = code.Message()
 		}
 
-		// return standard response
-		r.JSON(r.Writer.Status(), DefaultResponse{
+		// return standard response in the negotiated format
+		response := DefaultResponse{
 			Code:    code.Code(),
 			Message: msg,
 			Data:    data,
-		})
+		}
+		if body != nil {
+			response.Details, response.Caller, response.Stack, response.Cause = body.Details, body.Caller, body.Stack, body.Cause
+		}
+		r.WriteResponse(status, response)
 	}
 }
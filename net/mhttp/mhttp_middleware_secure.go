@@ -0,0 +1,168 @@
+package mhttp
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// secureHeadersMetaKey is the Meta key listing the secure headers a route opts out of,
+	// e.g. `secure_headers:"Content-Security-Policy,X-Frame-Options"`, or "-" for all of them.
+	secureHeadersMetaKey = "secure_headers"
+
+	headerStrictTransportSecurity = "Strict-Transport-Security"
+	headerXContentTypeOptions     = "X-Content-Type-Options"
+	headerXFrameOptions           = "X-Frame-Options"
+	headerReferrerPolicy          = "Referrer-Policy"
+	headerContentSecurityPolicy   = "Content-Security-Policy"
+	headerPermissionsPolicy       = "Permissions-Policy"
+)
+
+// SecureHeadersConfig defines the configuration for the secure headers middleware.
+// Empty values are not emitted, so a custom configuration only sets what it defines.
+type SecureHeadersConfig struct {
+	// HSTSMaxAge is the max-age of Strict-Transport-Security, zero disables it
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubDomains adds the includeSubDomains directive
+	HSTSIncludeSubDomains bool
+	// HSTSPreload adds the preload directive
+	HSTSPreload bool
+	// ContentTypeOptions is the value of X-Content-Type-Options, e.g. "nosniff"
+	ContentTypeOptions string
+	// FrameOptions is the value of X-Frame-Options, e.g. "DENY" or "SAMEORIGIN"
+	FrameOptions string
+	// ReferrerPolicy is the value of Referrer-Policy
+	ReferrerPolicy string
+	// ContentSecurityPolicy is the value of Content-Security-Policy
+	ContentSecurityPolicy string
+	// PermissionsPolicy is the value of Permissions-Policy
+	PermissionsPolicy string
+	// Headers overrides individual header values by name, an empty value removes the header
+	Headers map[string]string
+	// SkipFunc is an optional function to determine if the headers should be skipped
+	SkipFunc func(*Request) bool
+}
+
+// RecommendedSecureHeadersConfig returns the recommended secure headers configuration.
+func RecommendedSecureHeadersConfig() SecureHeadersConfig {
+	return SecureHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubDomains: true,
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'none'; object-src 'none'; base-uri 'self'",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+	}
+}
+
+// MiddlewareSecureHeaders creates a middleware that sets security related response headers.
+//
+// Strict-Transport-Security is only emitted on TLS requests, or on requests forwarded by
+// a trusted proxy with "X-Forwarded-Proto: https", see Server.SetTrustedProxies.
+// Routes opt out of headers by their Meta, e.g. `secure_headers:"Content-Security-Policy"`,
+// or of all headers with `secure_headers:"-"`.
+func MiddlewareSecureHeaders(config SecureHeadersConfig) MiddlewareFunc {
+	var (
+		headers = make(map[string]string)
+		hsts    string
+	)
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubDomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	for name, value := range map[string]string{
+		headerStrictTransportSecurity: hsts,
+		headerXContentTypeOptions:     config.ContentTypeOptions,
+		headerXFrameOptions:           config.FrameOptions,
+		headerReferrerPolicy:          config.ReferrerPolicy,
+		headerContentSecurityPolicy:   config.ContentSecurityPolicy,
+		headerPermissionsPolicy:       config.PermissionsPolicy,
+	} {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	for name, value := range config.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if value == "" {
+			delete(headers, name)
+			continue
+		}
+		headers[name] = value
+	}
+
+	return func(r *Request) {
+		if config.SkipFunc != nil && config.SkipFunc(r) {
+			return
+		}
+
+		var skipped []string
+		if info := r.RouteInfo(); info != nil {
+			if skip := info.Get(secureHeadersMetaKey).String(); skip != "" {
+				if strings.TrimSpace(skip) == "-" {
+					return
+				}
+				for _, name := range strings.Split(skip, ",") {
+					skipped = append(skipped, http.CanonicalHeaderKey(strings.TrimSpace(name)))
+				}
+			}
+		}
+
+		h := r.Writer.Header()
+		for name, value := range headers {
+			if containsString(skipped, name) {
+				continue
+			}
+			if name == headerStrictTransportSecurity && !r.isSecure() {
+				continue
+			}
+			h.Set(name, value)
+		}
+	}
+}
+
+// isSecure reports whether the request is made over TLS, directly or through
+// a trusted TLS-terminating proxy.
+func (r *Request) isSecure() bool {
+	if r.Request.TLS != nil {
+		return true
+	}
+	if !strings.EqualFold(r.GetHeader("X-Forwarded-Proto"), "https") {
+		return false
+	}
+	return r.fromTrustedProxy()
+}
+
+// fromTrustedProxy reports whether the remote address of the request is a trusted proxy.
+func (r *Request) fromTrustedProxy() bool {
+	addr, err := netip.ParseAddr(r.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range r.server.proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString checks whether `s` contains `v`.
+func containsString(s []string, v string) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
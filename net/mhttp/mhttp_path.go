@@ -0,0 +1,187 @@
+package mhttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRedirectTrailingSlash sets whether requests to "/users/" are redirected to "/users",
+// and the reverse, when only the other form is registered.
+// GET requests are redirected with 301, other methods with 308 to keep the method and body.
+func (s *Server) SetRedirectTrailingSlash(enabled bool) {
+	s.config.RedirectTrailingSlash = enabled
+}
+
+// SetRemoveExtraSlash sets whether repeated slashes like "//users" are merged before routing.
+func (s *Server) SetRemoveExtraSlash(enabled bool) {
+	s.config.RemoveExtraSlash = enabled
+}
+
+// SetCleanPath sets whether dot segments like "/a/../users" are resolved before routing.
+func (s *Server) SetCleanPath(enabled bool) {
+	s.config.CleanPath = enabled
+}
+
+// handler returns the http.Handler of the server, which normalizes the request path
+// according to the server config before routing.
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if s.config.MaxBodySize > 0 && req.Body != nil {
+			req.Body = http.MaxBytesReader(w, req.Body, int64(s.config.MaxBodySize))
+		}
+		if s.config.RemoveExtraSlash || s.config.CleanPath {
+			// work on the escaped path, so that encoded slashes like "%2F" are kept
+			escaped := req.URL.EscapedPath()
+			normalized := escaped
+			if s.config.RemoveExtraSlash {
+				normalized = removeExtraSlash(normalized)
+			}
+			if s.config.CleanPath {
+				normalized = removeDotSegments(normalized)
+			}
+			if normalized != escaped {
+				setEscapedPath(req.URL, normalized)
+			}
+		}
+		s.engine.ServeHTTP(w, req)
+	})
+}
+
+// handleNoRoute handles requests matching no route, redirecting to the trailing slash
+// variant of the path if enabled and the variant matches a route.
+func (s *Server) handleNoRoute(c *gin.Context) {
+	if !s.config.RedirectTrailingSlash || c.Request.Method == http.MethodConnect {
+		return
+	}
+	escaped := c.Request.URL.EscapedPath()
+	if escaped == "/" {
+		return
+	}
+	// the variant is matched on the path routed by gin, decoded unless UseRawPath is set
+	routed := c.Request.URL.Path
+	if s.engine.UseRawPath {
+		routed = escaped
+	}
+	target, routedTarget := toggleTrailingSlash(escaped), toggleTrailingSlash(routed)
+	matched := false
+	for _, route := range s.engine.Routes() {
+		if route.Method == c.Request.Method && matchRoutePattern(route.Path, routedTarget) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return
+	}
+
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	code := http.StatusMovedPermanently
+	if c.Request.Method != http.MethodGet {
+		code = http.StatusPermanentRedirect
+	}
+	c.Redirect(code, target)
+	c.Abort()
+}
+
+// toggleTrailingSlash returns `p` without its trailing slash, or with one if it has none.
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return strings.TrimSuffix(p, "/")
+	}
+	return p + "/"
+}
+
+// matchRoutePattern reports whether the routed `path` matches the gin route `pattern`.
+func matchRoutePattern(pattern, path string) bool {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, seg := range patternSegments {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+// removeExtraSlash merges repeated slashes in `p`.
+func removeExtraSlash(p string) string {
+	if !strings.Contains(p, "//") {
+		return p
+	}
+	var b strings.Builder
+	b.Grow(len(p))
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && i > 0 && p[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
+
+// removeDotSegments resolves "." and ".." segments of `p` as described in RFC 3986.
+// Empty segments and the trailing slash are preserved.
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	var (
+		segments = strings.Split(p, "/")
+		result   = make([]string, 0, len(segments))
+		last     = len(segments) - 1
+	)
+	for i, seg := range segments {
+		switch seg {
+		case ".":
+			if i == last {
+				result = append(result, "")
+			}
+		case "..":
+			// keep the leading empty segment of absolute paths
+			if len(result) > 1 {
+				result = result[:len(result)-1]
+			}
+			if i == last {
+				result = append(result, "")
+			}
+		default:
+			result = append(result, seg)
+		}
+	}
+	cleaned := strings.Join(result, "/")
+	if cleaned == "" {
+		return "/"
+	}
+	return cleaned
+}
+
+// setEscapedPath sets the escaped path `escaped` to `u`, keeping the raw form when
+// it differs from the default encoding of the decoded path.
+func setEscapedPath(u *url.URL, escaped string) {
+	decoded, err := url.PathUnescape(escaped)
+	if err != nil {
+		return
+	}
+	u.Path = decoded
+	u.RawPath = ""
+	if u.EscapedPath() != escaped {
+		u.RawPath = escaped
+	}
+}
@@ -19,6 +19,13 @@ type preBindItem struct {
 
 // bindRoutes binds all pre-bound routes.
 func (s *Server) bindRoutes(_ context.Context) {
+	// the normalized paths are routed on their raw form so that encoded slashes are not decoded
+	// before routing, the other paths are routed on their decoded form like gin does by default
+	s.engine.UseRawPath = s.config.RemoveExtraSlash || s.config.CleanPath
+
+	// build route information index for middlewares
+	s.buildRouteInfos()
+
 	processedGroups := make(map[*RouterGroup]bool)
 
 	for _, item := range s.preBindItems {
@@ -26,10 +33,7 @@ func (s *Server) bindRoutes(_ context.Context) {
 		if !processedGroups[group] {
 			processedGroups[group] = true
 			for _, middleware := range group.middlewares {
-				ginMiddleware := func(c *gin.Context) {
-					middleware(newRequest(c, s))
-				}
-				group.ginGroup.Use(ginMiddleware)
+				group.ginGroup.Use(s.ginHandler(middleware))
 			}
 		}
 	}
@@ -40,17 +44,11 @@ func (s *Server) bindRoutes(_ context.Context) {
 
 		// only handle route-level middlewares
 		for _, middleware := range item.RouteMiddlewares {
-			ginMiddleware := func(c *gin.Context) {
-				middleware(newRequest(c, s))
-			}
-			routeHandlers = append(routeHandlers, ginMiddleware)
+			routeHandlers = append(routeHandlers, s.ginHandler(middleware))
 		}
 
 		// add final handler function
-		finalHandler := func(c *gin.Context) {
-			item.HandlerFunc(newRequest(c, s))
-		}
-		routeHandlers = append(routeHandlers, finalHandler)
+		routeHandlers = append(routeHandlers, s.ginHandler(item.HandlerFunc))
 
 		// register to Gin
 		item.Group.ginGroup.Handle(item.Method, item.Path, routeHandlers...)
@@ -64,3 +62,18 @@ func (s *Server) bindRoutes(_ context.Context) {
 		group.middlewares = nil
 	}
 }
+
+// ginHandler returns the gin handler calling the handler or middleware `fn`. A panic of `fn` is
+// recovered as the error of the request, which aborts the remaining handlers, so that the middleware
+// before `fn`, like MiddlewareResponse, write it like the errors of the handlers, see recoverPanic.
+func (s *Server) ginHandler(fn func(r *Request)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := newRequest(c, s)
+		defer func() {
+			if v := recover(); v != nil {
+				recoverPanic(r, v)
+			}
+		}()
+		fn(r)
+	}
+}
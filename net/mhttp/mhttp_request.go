@@ -78,6 +78,23 @@ func (r *Request) Error(err error) *Request {
 	return r
 }
 
+// RouteInfo returns the information of the matched route, including its metadata.
+// It is available in all middlewares, and returns nil if no route matches the request.
+func (r *Request) RouteInfo() *RouteInfo {
+	return r.server.routeInfos[routeInfoKey(r.Request.Method, r.FullPath())]
+}
+
+// Locale returns the locale preferred by the Accept-Language header of the request,
+// or the server locale if the header is missing or only has wildcards, see ServerConfig.ServerLocale.
+func (r *Request) Locale() string {
+	for _, rg := range parseAccept(r.Request.Header.Get("Accept-Language")) {
+		if rg.q > 0 && rg.mediaType != "*" {
+			return rg.mediaType
+		}
+	}
+	return r.server.config.ServerLocale
+}
+
 // GetTranslator gets the translator.
 func (r *Request) GetTranslator() ut.Translator {
 	return r.server.translator
@@ -0,0 +1,130 @@
+package mhttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// CookieOption customizes a cookie written by SetCookie or DeleteCookie.
+type CookieOption func(cookie *http.Cookie)
+
+// WithCookieMaxAge sets the Max-Age of the cookie, it is truncated to seconds.
+func WithCookieMaxAge(maxAge time.Duration) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.MaxAge = int(maxAge / time.Second)
+	}
+}
+
+// WithCookiePath sets the Path of the cookie.
+func WithCookiePath(path string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Path = path
+	}
+}
+
+// WithCookieDomain sets the Domain of the cookie.
+func WithCookieDomain(domain string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Domain = domain
+	}
+}
+
+// WithCookieSecure sets the Secure attribute of the cookie.
+func WithCookieSecure(secure bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Secure = secure
+	}
+}
+
+// WithCookieHttpOnly sets the HttpOnly attribute of the cookie.
+func WithCookieHttpOnly(httpOnly bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.HttpOnly = httpOnly
+	}
+}
+
+// WithCookieSameSite sets the SameSite attribute of the cookie.
+func WithCookieSameSite(sameSite http.SameSite) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.SameSite = sameSite
+	}
+}
+
+// Cookie returns the URL-decoded value of the request cookie `name`,
+// and whether the cookie is present.
+func (r *Request) Cookie(name string) (string, bool) {
+	cookie, err := r.Request.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return cookie.Value, true
+	}
+	return value, true
+}
+
+// SetCookie adds a Set-Cookie header for `name` with the URL-encoded `value`.
+// The server cookie config provides the default attributes, which `opts` override.
+// It returns an error if the response header has already been written.
+func (r *Request) SetCookie(name, value string, opts ...CookieOption) error {
+	return r.writeCookie(name, url.QueryEscape(value), opts)
+}
+
+// DeleteCookie adds a Set-Cookie header expiring the cookie `name`.
+// The Path and Domain should match the ones used to set the cookie.
+// It returns an error if the response header has already been written.
+func (r *Request) DeleteCookie(name string, opts ...CookieOption) error {
+	opts = append(opts, func(cookie *http.Cookie) {
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+	})
+	return r.writeCookie(name, "", opts)
+}
+
+// writeCookie writes the cookie with the server defaults applied.
+func (r *Request) writeCookie(name, value string, opts []CookieOption) error {
+	if r.Writer.Written() {
+		return merror.NewCodef(mcode.CodeInvalidOperation, "cannot set cookie %q after the response is written", name)
+	}
+	config := r.server.config
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     config.CookiePath,
+		Domain:   config.CookieDomain,
+		Secure:   config.CookieSecure,
+		HttpOnly: config.CookieHttpOnly,
+		SameSite: config.CookieSameSite,
+	}
+	for _, opt := range opts {
+		opt(cookie)
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if err := cookie.Valid(); err != nil {
+		return merror.WrapCodef(err, mcode.CodeInvalidParameter, "invalid cookie %q", name)
+	}
+	r.Writer.Header().Add("Set-Cookie", cookie.String())
+	return nil
+}
+
+// parseSameSite parses the SameSite config value "lax", "strict", "none" or "default".
+func parseSameSite(s string) http.SameSite {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
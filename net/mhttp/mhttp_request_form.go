@@ -0,0 +1,470 @@
+package mhttp
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+const (
+	defaultFormMaxDepth       = 10
+	defaultFormMaxSliceLength = 100
+	formTagName               = "form"
+)
+
+// formOptions limits the nested form binding.
+type formOptions struct {
+	MaxDepth       int  // maximum number of segments in a single key, e.g. "a.b[0].c" has 4
+	MaxSliceLength int  // maximum length of an indexed slice
+	StrictIndex    bool // whether sparse indexes are rejected instead of compacted
+}
+
+// formNode is a node of the tree parsed from nested form keys.
+type formNode struct {
+	values  []string
+	fields  map[string]*formNode
+	indexes map[int]*formNode
+}
+
+// formSegment is a single segment of a nested form key.
+type formSegment struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindNestedForm binds the nested form and query keys of the request to `obj`.
+// It only applies to form bindings, flat keys are left to the gin form binding.
+func (r *Request) bindNestedForm(obj any) error {
+	switch binding.Default(r.Request.Method, r.ContentType()) {
+	case binding.Form, binding.FormMultipart:
+	default:
+		return nil
+	}
+	if err := r.Request.ParseForm(); err != nil {
+		return err
+	}
+	if err := r.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	return mapNestedForm(obj, r.Request.Form, r.server.formOptions())
+}
+
+// formOptions returns the nested form binding options of the server.
+func (s *Server) formOptions() formOptions {
+	opts := formOptions{
+		MaxDepth:       s.config.FormMaxDepth,
+		MaxSliceLength: s.config.FormMaxSliceLength,
+		StrictIndex:    s.config.FormStrictIndex,
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultFormMaxDepth
+	}
+	if opts.MaxSliceLength <= 0 {
+		opts.MaxSliceLength = defaultFormMaxSliceLength
+	}
+	return opts
+}
+
+// mapNestedForm maps the keys in dot-notation ("address.city") and with bracket
+// indexes ("items[0].sku") of `form` to the struct pointer `ptr`.
+//
+// Only the keys starting with the name of a struct, slice or map field are parsed, the other keys,
+// like "ids[]" of a field tagged `form:"ids[]"` or the keys matching no field, are left to the gin
+// form binding, so they are never rejected here.
+//
+// Sparse slice indexes like "items[0]" and "items[5]" are compacted into a slice
+// of length 2 keeping their order, unless StrictIndex is set, in which case an
+// error is returned.
+func mapNestedForm(ptr any, form map[string][]string, opts formOptions) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make(map[string]bool)
+	collectFormFields(v.Elem().Type(), fields)
+
+	root := &formNode{}
+	nested := false
+	for key, values := range form {
+		if !strings.ContainsAny(key, ".[") {
+			continue
+		}
+		// the keys naming a field, like "ids[]", the multiple values "ids[]" of a slice field "ids"
+		// and the keys of the other fields are left to the gin form binding
+		name := key[:strings.IndexAny(key, ".[")]
+		if _, ok := fields[key]; ok || !fields[name] || key == name+"[]" {
+			continue
+		}
+		segments, err := parseFormKey(key)
+		if err != nil {
+			return err
+		}
+		if len(segments) > opts.MaxDepth {
+			return merror.NewCodef(mcode.CodeInvalidParameter, "form key %q exceeds max depth %d", key, opts.MaxDepth)
+		}
+		node := root
+		for _, seg := range segments {
+			if seg.isIndex {
+				if seg.index >= opts.MaxSliceLength {
+					return merror.NewCodef(mcode.CodeInvalidParameter, "form key %q exceeds max slice length %d", key, opts.MaxSliceLength)
+				}
+				node = node.index(seg.index)
+			} else {
+				node = node.field(seg.name)
+			}
+		}
+		node.values = values
+		nested = true
+	}
+	if !nested {
+		return nil
+	}
+	return assignFormStruct(v.Elem(), root, "", opts)
+}
+
+// collectFormFields collects the form names of the fields of struct type `t`, including those of the
+// embedded structs, reporting whether they accept nested keys: the struct, slice and map fields.
+func collectFormFields(t reflect.Type, fields map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(formTagName), ",")
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" {
+			if ft.Kind() == reflect.Struct {
+				collectFormFields(ft, fields)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			fields[name] = ft != timeType
+		case reflect.Slice, reflect.Array:
+			fields[name] = ft.Elem().Kind() != reflect.Uint8
+		case reflect.Map:
+			fields[name] = true
+		default:
+			fields[name] = false
+		}
+	}
+}
+
+// parseFormKey splits the form key into segments.
+func parseFormKey(key string) ([]formSegment, error) {
+	var (
+		segments []formSegment
+		rest     = key
+	)
+	for rest != "" {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, merror.NewCodef(mcode.CodeInvalidParameter, "invalid form key %q: unclosed bracket", key)
+			}
+			inner := rest[1:end]
+			if inner == "" {
+				return nil, merror.NewCodef(mcode.CodeInvalidParameter, "invalid form key %q: empty brackets", key)
+			}
+			if index, err := strconv.Atoi(inner); err == nil {
+				if index < 0 {
+					return nil, merror.NewCodef(mcode.CodeInvalidParameter, "invalid form key %q: negative index", key)
+				}
+				segments = append(segments, formSegment{index: index, isIndex: true})
+			} else {
+				segments = append(segments, formSegment{name: inner})
+			}
+			rest = rest[end+1:]
+		case '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, merror.NewCodef(mcode.CodeInvalidParameter, "invalid form key %q: empty segment", key)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segments = append(segments, formSegment{name: rest[:end]})
+			rest = rest[end:]
+		}
+		if len(segments) == 0 {
+			return nil, merror.NewCodef(mcode.CodeInvalidParameter, "invalid form key %q: empty segment", key)
+		}
+	}
+	return segments, nil
+}
+
+func (n *formNode) field(name string) *formNode {
+	if n.fields == nil {
+		n.fields = make(map[string]*formNode)
+	}
+	child, ok := n.fields[name]
+	if !ok {
+		child = &formNode{}
+		n.fields[name] = child
+	}
+	return child
+}
+
+func (n *formNode) index(i int) *formNode {
+	if n.indexes == nil {
+		n.indexes = make(map[int]*formNode)
+	}
+	child, ok := n.indexes[i]
+	if !ok {
+		child = &formNode{}
+		n.indexes[i] = child
+	}
+	return child
+}
+
+// joinFormPath joins the field name to the parent path.
+func joinFormPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// assignFormStruct assigns the child nodes of `node` to the fields of struct `v`.
+func assignFormStruct(v reflect.Value, node *formNode, path string, opts formOptions) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(formTagName), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.Type().Elem().Kind() != reflect.Struct {
+					continue
+				}
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := assignFormStruct(fv, node, path, opts); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		child, ok := node.fields[name]
+		if !ok {
+			continue
+		}
+		if path == "" && child.fields == nil && child.indexes == nil {
+			// flat keys are bound by the gin form binding
+			continue
+		}
+		if err := assignFormValue(v.Field(i), child, joinFormPath(path, name), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignFormValue assigns `node` to `v` according to the kind of `v`.
+func assignFormValue(v reflect.Value, node *formNode, path string, opts formOptions) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignFormValue(v.Elem(), node, path, opts)
+	}
+
+	switch {
+	case node.indexes != nil:
+		return assignFormSlice(v, node, path, opts)
+	case node.fields != nil:
+		switch v.Kind() {
+		case reflect.Struct:
+			if v.Type() == timeType {
+				break
+			}
+			return assignFormStruct(v, node, path, opts)
+		case reflect.Map:
+			return assignFormMap(v, node, path, opts)
+		}
+		return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s does not accept nested keys", path)
+	}
+
+	if len(node.values) == 0 {
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(node.values), len(node.values))
+		for i, s := range node.values {
+			if err := setFormScalar(slice.Index(i), s, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setFormScalar(v, node.values[0], path)
+}
+
+// assignFormSlice assigns the indexed children of `node` to slice or array `v`.
+func assignFormSlice(v reflect.Value, node *formNode, path string, opts formOptions) error {
+	indexes := make([]int, 0, len(node.indexes))
+	for i := range node.indexes {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	if opts.StrictIndex {
+		for pos, index := range indexes {
+			if pos != index {
+				return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s has sparse index %d", path, index)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(indexes), len(indexes))
+		for pos, index := range indexes {
+			if err := assignFormValue(slice.Index(pos), node.indexes[index], fmt.Sprintf("%s[%d]", path, index), opts); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if len(indexes) > v.Len() {
+			return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s exceeds array length %d", path, v.Len())
+		}
+		for pos, index := range indexes {
+			if err := assignFormValue(v.Index(pos), node.indexes[index], fmt.Sprintf("%s[%d]", path, index), opts); err != nil {
+				return err
+			}
+		}
+	default:
+		return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s does not accept indexes", path)
+	}
+	return nil
+}
+
+// assignFormMap assigns the named children of `node` to map `v` with string keys.
+func assignFormMap(v reflect.Value, node *formNode, path string, opts formOptions) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s requires a map with string keys", path)
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	for key, child := range node.fields {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := assignFormValue(elem, child, joinFormPath(path, key), opts); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+// setFormScalar converts the string `s` and sets it to `v`.
+func setFormScalar(v reflect.Value, s string, path string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setFormScalar(v.Elem(), s, path)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return merror.NewCodef(mcode.CodeInvalidParameter, "invalid value %q for form field %s: %v", s, path, err)
+		}
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		if s != "" {
+			b, err = strconv.ParseBool(s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if v.Type() == durationType {
+			var d time.Duration
+			if s != "" {
+				d, err = time.ParseDuration(s)
+			}
+			n = int64(d)
+		} else if s != "" {
+			n, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if s != "" {
+			n, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if s != "" {
+			f, err = strconv.ParseFloat(s, v.Type().Bits())
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		if v.Type() != timeType {
+			return merror.NewCodef(mcode.CodeInvalidParameter, "form field %s requires nested keys", path)
+		}
+		var t time.Time
+		if s != "" {
+			t, err = time.Parse(time.RFC3339, s)
+		}
+		v.Set(reflect.ValueOf(t))
+	default:
+		return merror.NewCodef(mcode.CodeInvalidParameter, "unsupported type %s for form field %s", v.Type(), path)
+	}
+	if err != nil {
+		return merror.NewCodef(mcode.CodeInvalidParameter, "invalid value %q for form field %s: %v", s, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package mhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/graingo/maltose/util/mmeta"
+)
+
+const (
+	// providedFieldsKey is the key for storing the provided field names in the request.
+	providedFieldsKey contextKey = "MaltoseProvidedFields"
+	// validationPartial is the Meta `validation` value enabling partial validation.
+	validationPartial = "partial"
+)
+
+// presenceNode records which payload keys are present in the request, recursively.
+type presenceNode map[string]presenceNode
+
+// bindRequest binds and validates the request parameters to `req`.
+// Request structures tagged with `validation:"partial"` in their Meta are bound with ShouldBindPartial.
+func (r *Request) bindRequest(req any) error {
+	if mmeta.Get(req, "validation").String() == validationPartial {
+		return r.ShouldBindPartial(req)
+	}
+	// nested form binding, e.g. "items[0].sku" and "address.city"
+	if err := r.bindNestedForm(req); err != nil {
+		return err
+	}
+	return r.ShouldBind(req)
+}
+
+// ShouldBindPartial binds the request parameters to `obj` and validates only the fields
+// present in the request payload, so that `required` rules do not apply to absent fields
+// while format rules still apply to provided ones. It is designed for PATCH requests.
+//
+// Presence is detected by JSON object keys for JSON bodies and by parameter names for
+// form and query parameters. Other content types fall back to ShouldBind.
+// The provided fields can be retrieved with ProvidedFields after binding.
+func (r *Request) ShouldBindPartial(obj any) error {
+	var (
+		present presenceNode
+		tag     string
+	)
+	switch binding.Default(r.Request.Method, r.ContentType()) {
+	case binding.JSON:
+		body, err := r.readBody()
+		if err != nil {
+			return err
+		}
+		if present, err = jsonPresence(body); err != nil {
+			return err
+		}
+		if len(body) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			if binding.EnableDecoderUseNumber {
+				decoder.UseNumber()
+			}
+			if binding.EnableDecoderDisallowUnknownFields {
+				decoder.DisallowUnknownFields()
+			}
+			if err = decoder.Decode(obj); err != nil {
+				return err
+			}
+		}
+		tag = "json"
+	case binding.Form, binding.FormMultipart:
+		if err := r.Request.ParseForm(); err != nil {
+			return err
+		}
+		if err := r.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return err
+		}
+		if err := binding.MapFormWithTag(obj, r.Request.Form, formTagName); err != nil {
+			return err
+		}
+		if err := mapNestedForm(obj, r.Request.Form, r.server.formOptions()); err != nil {
+			return err
+		}
+		present = formPresence(r.Request.Form)
+		tag = formTagName
+	default:
+		return r.ShouldBind(obj)
+	}
+
+	var provided, excluded []string
+	if t := reflect.TypeOf(obj); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		provided, excluded = partialFields(t.Elem(), present, tag, "")
+	}
+	r.Set(string(providedFieldsKey), provided)
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		return v.StructExcept(obj, excluded...)
+	}
+	return nil
+}
+
+// ProvidedFields returns the struct field names present in the request payload, as bound
+// by ShouldBindPartial. Nested fields are joined with dots, e.g. "Address.City".
+func (r *Request) ProvidedFields() []string {
+	if v, ok := r.Get(string(providedFieldsKey)); ok {
+		if fields, ok := v.([]string); ok {
+			return fields
+		}
+	}
+	return nil
+}
+
+// IsFieldProvided checks whether the struct field `name` is present in the request payload.
+func (r *Request) IsFieldProvided(name string) bool {
+	for _, field := range r.ProvidedFields() {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads the request body and restores it for later reads.
+func (r *Request) readBody() ([]byte, error) {
+	if r.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = r.Request.Body.Close()
+	r.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// jsonPresence returns the keys present in the JSON object `body`.
+func jsonPresence(body []byte) (presenceNode, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return presenceNode{}, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	node := make(presenceNode, len(object))
+	for key, raw := range object {
+		child, err := jsonPresence(raw)
+		if err != nil || !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			child = presenceNode{}
+		}
+		node[key] = child
+	}
+	return node, nil
+}
+
+// formPresence returns the parameter names present in `form`.
+// Nested keys like "address.city" are split, indexed keys stop at the first index.
+func formPresence(form map[string][]string) presenceNode {
+	node := presenceNode{}
+	for key := range form {
+		if name, _, ok := strings.Cut(key, "["); ok {
+			key = name
+		}
+		current := node
+		for _, name := range strings.Split(key, ".") {
+			if name == "" {
+				break
+			}
+			child, ok := current[name]
+			if !ok {
+				child = presenceNode{}
+				current[name] = child
+			}
+			current = child
+		}
+	}
+	return node
+}
+
+// partialFields walks the struct type `t` and returns the provided and the absent field
+// namespaces according to `present`, using the payload names of tag `tag`.
+func partialFields(t reflect.Type, present presenceNode, tag string, prefix string) (provided, excluded []string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			p, e := partialFields(ft, present, tag, prefix+field.Name+".")
+			provided = append(provided, p...)
+			excluded = append(excluded, e...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		child, ok := lookupPresence(present, name, tag == "json")
+		if !ok {
+			excluded = append(excluded, prefix+field.Name)
+			continue
+		}
+		provided = append(provided, prefix+field.Name)
+		if ft.Kind() == reflect.Struct && ft != timeType && len(child) > 0 {
+			p, e := partialFields(ft, child, tag, prefix+field.Name+".")
+			provided = append(provided, p...)
+			excluded = append(excluded, e...)
+		}
+	}
+	return
+}
+
+// lookupPresence looks up `name` in `present`, case-insensitively if `fold` is true
+// like encoding/json does.
+func lookupPresence(present presenceNode, name string, fold bool) (presenceNode, bool) {
+	if child, ok := present[name]; ok {
+		return child, true
+	}
+	if fold {
+		for key, child := range present {
+			if strings.EqualFold(key, name) {
+				return child, true
+			}
+		}
+	}
+	return nil, false
+}
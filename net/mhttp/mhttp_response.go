@@ -0,0 +1,226 @@
+package mhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeXML  = "application/xml"
+	MediaTypeYAML = "application/yaml"
+)
+
+// ResponseEncoder encodes the response value `v` into `w`.
+type ResponseEncoder func(w io.Writer, v any) error
+
+// defaultResponseTypes is the default list of media types offered by the server, only JSON, so that
+// the browsers accepting "application/xml" get JSON. The other built-in types are offered with SetResponseTypes.
+var defaultResponseTypes = []string{
+	MediaTypeJSON,
+}
+
+// defaultResponseEncoders returns the built-in response encoders.
+func defaultResponseEncoders() map[string]ResponseEncoder {
+	return map[string]ResponseEncoder{
+		MediaTypeJSON:        encodeJSON,
+		MediaTypeXML:         encodeXML,
+		"text/xml":           encodeXML,
+		MediaTypeYAML:        encodeYAML,
+		"application/x-yaml": encodeYAML,
+		"text/yaml":          encodeYAML,
+	}
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+	return enc.Encode(v)
+}
+
+// RegisterResponseEncoder registers the encoder for `mediaType` and adds it to the offered types.
+func (s *Server) RegisterResponseEncoder(mediaType string, enc func(io.Writer, any) error) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	s.encoders[mediaType] = enc
+	for _, t := range s.config.ResponseTypes {
+		if t == mediaType {
+			return
+		}
+	}
+	s.config.ResponseTypes = append(s.config.ResponseTypes, mediaType)
+}
+
+// SetResponseTypes sets the media types the server offers for response negotiation.
+// JSON, or the first type if JSON is not offered, is used when the client does not express a usable preference.
+// The built-in types are JSON, XML ("application/xml", "text/xml") and YAML ("application/yaml",
+// "application/x-yaml", "text/yaml"), only JSON is offered by default:
+//
+//	s.SetResponseTypes(mhttp.MediaTypeJSON, mhttp.MediaTypeXML)
+func (s *Server) SetResponseTypes(mediaTypes ...string) {
+	types := make([]string, 0, len(mediaTypes))
+	for _, t := range mediaTypes {
+		types = append(types, strings.ToLower(strings.TrimSpace(t)))
+	}
+	s.config.ResponseTypes = types
+}
+
+// acceptRange is a single media range parsed from the Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+	index     int
+}
+
+// specificity returns how specific the media range is: "*/*" < "type/*" < "type/subtype".
+func (a acceptRange) specificity() int {
+	switch {
+	case a.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(a.mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the media range matches the `mediaType`.
+func (a acceptRange) matches(mediaType string) bool {
+	switch a.specificity() {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*"))
+	default:
+		return a.mediaType == mediaType
+	}
+}
+
+// parseAccept parses the Accept header into media ranges ordered by preference.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(k)) != "q" {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, index: i})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// negotiate returns the offered media type that best matches the `accept` header: the type with
+// the highest quality, which is the q-value of the most specific media range matching it, so that
+// "*/*, application/json;q=0" refuses JSON. Among the types of the same quality, those matched by
+// more specific ranges are preferred, then JSON, then the order of `offered`. Missing, wildcard or
+// unsatisfiable Accept headers fall back to JSON if it is offered, or else to the first offered type.
+func negotiate(accept string, offered []string) string {
+	fallback := MediaTypeJSON
+	if len(offered) > 0 && !slices.Contains(offered, MediaTypeJSON) {
+		fallback = offered[0]
+	}
+	var (
+		ranges = parseAccept(accept)
+		best   string
+		bestRg acceptRange
+	)
+	for _, t := range offered {
+		rg, ok := matchRange(ranges, t)
+		if !ok || rg.q <= 0 {
+			continue
+		}
+		if best == "" || rg.q > bestRg.q ||
+			rg.q == bestRg.q && (rg.specificity() > bestRg.specificity() || rg.specificity() == bestRg.specificity() && t == fallback) {
+			best, bestRg = t, rg
+		}
+	}
+	if best == "" {
+		return fallback
+	}
+	return best
+}
+
+// matchRange returns the most specific media range of `ranges` matching `mediaType`,
+// the first one of the header among the equally specific ones.
+func matchRange(ranges []acceptRange, mediaType string) (acceptRange, bool) {
+	var (
+		match acceptRange
+		found bool
+	)
+	for _, rg := range ranges {
+		if !rg.matches(mediaType) {
+			continue
+		}
+		if !found || rg.specificity() > match.specificity() ||
+			rg.specificity() == match.specificity() && rg.index < match.index {
+			match, found = rg, true
+		}
+	}
+	return match, found
+}
+
+// NegotiatedType returns the response media type negotiated from the Accept header.
+func (r *Request) NegotiatedType() string {
+	return negotiate(r.Request.Header.Get("Accept"), r.server.config.ResponseTypes)
+}
+
+// WriteResponse writes `data` with `status`, serialized in the negotiated media type.
+// The data which cannot be encoded in the negotiated type, like a map in XML, is written in JSON.
+func (r *Request) WriteResponse(status int, data any) {
+	mediaType := r.NegotiatedType()
+	enc, ok := r.server.encoders[mediaType]
+	if !ok {
+		mediaType, enc = MediaTypeJSON, encodeJSON
+	}
+
+	var buffer bytes.Buffer
+	err := enc(&buffer, data)
+	if err != nil && mediaType != MediaTypeJSON {
+		r.Logger().Warnf(r.Request.Context(), "encode response as %s failed, fall back to %s: %v", mediaType, MediaTypeJSON, err)
+		buffer.Reset()
+		mediaType, err = MediaTypeJSON, encodeJSON(&buffer, data)
+	}
+	if err != nil {
+		r.Logger().Errorf(r.Request.Context(), "encode response as %s failed: %v", mediaType, err)
+		r.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	r.Header("Vary", "Accept")
+	r.Data(status, mediaType+"; charset=utf-8", buffer.Bytes())
+}
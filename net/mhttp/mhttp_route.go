@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
+
+	"github.com/graingo/maltose/container/mvar"
 )
 
 type routeType int
@@ -20,16 +23,66 @@ type Route struct {
 	Path             string
 	HandlerFunc      HandlerFunc
 	Type             routeType
-	Controller       any            // controller object
-	ControllerMethod reflect.Method // controller method
-	ReqType          reflect.Type   // request parameter type
-	RespType         reflect.Type   // response type
+	Controller       any               // controller object
+	ControllerMethod reflect.Method    // controller method
+	ReqType          reflect.Type      // request parameter type
+	RespType         reflect.Type      // response type
+	Meta             map[string]string // metadata of the request parameter type
+}
+
+// RouteInfo is the information of the route matched by the request.
+type RouteInfo struct {
+	Method  string            // HTTP method
+	Path    string            // path pattern, e.g. "/users/:id"
+	Handler string            // handler name, e.g. "UserController.Create"
+	Meta    map[string]string // metadata of the request parameter type, e.g. summary, tag and custom keys
 }
 
 func (s *Server) Routes() []Route {
 	return s.routes
 }
 
+// Get retrieves the metadata value of `key`, it returns nil if `key` does not exist.
+func (ri *RouteInfo) Get(key string) *mvar.Var {
+	if ri == nil {
+		return nil
+	}
+	v, ok := ri.Meta[key]
+	if !ok {
+		return nil
+	}
+	return mvar.New(v)
+}
+
+// HandlerName returns the name of the route handler.
+func (r Route) HandlerName() string {
+	if r.Type == routeTypeController {
+		return fmt.Sprintf("%s.%s", reflect.TypeOf(r.Controller).Elem().Name(), r.ControllerMethod.Name)
+	}
+	if r.HandlerFunc == nil {
+		return ""
+	}
+	return runtime.FuncForPC(reflect.ValueOf(r.HandlerFunc).Pointer()).Name()
+}
+
+// routeInfoKey returns the key of the route information index.
+func routeInfoKey(method, path string) string {
+	return method + " " + path
+}
+
+// buildRouteInfos builds the route information index for lookups by method and path pattern.
+func (s *Server) buildRouteInfos() {
+	s.routeInfos = make(map[string]*RouteInfo, len(s.routes))
+	for _, route := range s.routes {
+		s.routeInfos[routeInfoKey(route.Method, route.Path)] = &RouteInfo{
+			Method:  route.Method,
+			Path:    route.Path,
+			Handler: route.HandlerName(),
+			Meta:    route.Meta,
+		}
+	}
+}
+
 func (s *Server) printRoute(ctx context.Context) {
 	// print server info
 	s.Logger().Infof(ctx, "HTTP server %s is running on %s", s.config.ServerName, s.config.Address)
@@ -49,8 +102,7 @@ func (s *Server) printRoute(ctx context.Context) {
 		// determine route type description
 		handlerType := "Handler"
 		if route.Type == routeTypeController {
-			controllerName := reflect.TypeOf(route.Controller).Elem().Name()
-			handlerType = fmt.Sprintf("%s.%s", controllerName, route.ControllerMethod.Name)
+			handlerType = route.HandlerName()
 		}
 
 		// get request and response type names
@@ -2,12 +2,15 @@ package mhttp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/graingo/maltose/os/mlog"
 )
 
 // SetStaticPath enhances the static file service.
@@ -16,13 +19,18 @@ func (s *Server) SetStaticPath(prefix string, directory string) {
 	s.engine.StaticFS(prefix, http.Dir(directory))
 }
 
-// Run starts the HTTP server.
+// Run starts the HTTP server, and blocks until it fails to start, it is shut down by Shutdown,
+// or the process receives SIGINT or SIGTERM. On a signal, it shuts the server down like Shutdown,
+// then closes the loggers of mlog.CloseAll so that their buffered entries are written before the process exits.
 func (s *Server) Run() {
 	ctx := context.Background()
 
 	// register OpenAPI and Swagger
 	s.registerDoc(ctx)
 
+	// register pprof and health check
+	s.registerDebug(ctx)
+
 	// register all routes before starting
 	s.bindRoutes(ctx)
 
@@ -31,16 +39,26 @@ func (s *Server) Run() {
 
 	srv := &http.Server{
 		Addr:           s.config.Address,
-		Handler:        s.engine,
+		Handler:        s.handler(),
 		ReadTimeout:    s.config.ReadTimeout,
 		WriteTimeout:   s.config.WriteTimeout,
 		IdleTimeout:    s.config.IdleTimeout,
 		MaxHeaderBytes: s.config.MaxHeaderBytes,
+		// route the internal errors of net/http, like TLS handshake errors, through the logger
+		ErrorLog: s.Logger().StdLogger(mlog.ErrorLevel),
 	}
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		return
+	}
+	s.httpServer = srv
+	s.mu.Unlock()
 
-	// create error channel
+	// create error channel, closed when the server stops serving
 	errChan := make(chan error, 1)
 	go func() {
+		defer close(errChan)
 		var err error
 		if s.config.TLSEnable {
 			if s.config.TLSCertFile == "" || s.config.TLSKeyFile == "" {
@@ -59,28 +77,52 @@ func (s *Server) Run() {
 	// listen system signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
 
 	select {
-	case err := <-errChan:
-		s.Logger().Errorf(ctx, "HTTP server %s start failed: %v", s.config.ServerName, err)
+	case err, ok := <-errChan:
+		if ok {
+			s.Logger().Errorf(ctx, "HTTP server %s start failed: %v", s.config.ServerName, err)
+		}
 	case <-quit:
-		s.Logger().Infof(ctx, "Shutting down server...")
-
 		timeout := 5 * time.Second
 		if s.config.GracefulEnable {
 			timeout = s.config.GracefulTimeout
 		}
-
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
+		if err := errors.Join(s.Shutdown(ctx), mlog.CloseAll()); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to shut down server: %v\n", err)
+		}
+	}
+}
+
+// Shutdown shuts down the server started by Run gracefully, see http.Server.Shutdown: it stops
+// listening, waits for GracefulWaitTime if GracefulEnable is set, then waits for the active
+// connections until `ctx` is done. It then closes the logger set by SetLogger, not the shared
+// mlog instance, which is closed by mlog.CloseAll. Run returns once the server stops listening,
+// and a server shut down before Run does not start. It implements minstance.IShutdown, so that
+// the server is shut down when it is removed from its container.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.httpServer
+	s.shutdown = true
+	s.mu.Unlock()
+
+	var err error
+	if srv != nil {
+		s.Logger().Infof(ctx, "Shutting down server...")
 		if s.config.GracefulEnable {
 			// wait for active connections to complete
 			time.Sleep(s.config.GracefulWaitTime)
 		}
-
-		if err := srv.Shutdown(ctx); err != nil {
+		if err = srv.Shutdown(ctx); err != nil {
 			s.Logger().Errorf(ctx, "Server forced to shutdown: %v", err)
 		}
 	}
+	if s.config.Logger != nil {
+		err = errors.Join(err, s.config.Logger.Close())
+	}
+	return err
 }
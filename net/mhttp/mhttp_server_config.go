@@ -1,10 +1,18 @@
 package mhttp
 
 import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/os/mcfg"
 	"github.com/graingo/maltose/os/mlog"
-	"github.com/graingo/mconv"
 )
 
 // ServerConfig is the server configuration.
@@ -18,6 +26,16 @@ type ServerConfig struct {
 	WriteTimeout   time.Duration
 	IdleTimeout    time.Duration
 	MaxHeaderBytes int
+	MaxBodySize    mcfg.ByteSize // maximum size of a request body in bytes, 0 means no limit
+
+	// path normalization config, the paths are routed on their raw form when RemoveExtraSlash or CleanPath
+	// is set, so that an encoded slash like "%2F" stays within its segment
+	RedirectTrailingSlash bool // redirect to the registered variant of a path with or without trailing slash
+	RemoveExtraSlash      bool // merge repeated slashes before routing
+	CleanPath             bool // resolve "." and ".." path segments before routing
+
+	// client IP config
+	TrustedProxies []string // CIDRs of proxies whose forwarded headers are trusted by ClientIP, all until SetTrustedProxies
 
 	// TLS config
 	TLSEnable     bool
@@ -35,8 +53,32 @@ type ServerConfig struct {
 	SwaggerPath     string
 	SwaggerTemplate string
 
-	// log config
-	Logger *mlog.Logger
+	// cookie config, the defaults of r.SetCookie
+	CookiePath     string
+	CookieDomain   string
+	CookieSecure   bool
+	CookieHttpOnly bool
+	CookieSameSite http.SameSite
+
+	// response config
+	ResponseTypes []string // media types offered for content negotiation, JSON or else the first one is the default
+
+	// form binding config
+	FormMaxDepth       int  // maximum segments of a nested form key like "items[0].sku"
+	FormMaxSliceLength int  // maximum length of an indexed form slice
+	FormStrictIndex    bool // reject sparse form indexes instead of compacting them
+
+	// validation config
+	ValidateAllErrors bool // return all field errors of a request as a joined error instead of the first one
+
+	// debug config, the routes are registered on Run when the path is not empty
+	PProfPath     string // path of the pprof handlers, see EnablePProf
+	HealthPath    string // path of the health check, see EnableHealth
+	MetricsEnable bool   // collect the server metrics when mmetric is enabled
+
+	// log config, the logger of the server and its middleware, like the access log and the recovery,
+	// the mlog instance "default" if nil, see Server.Logger
+	Logger *mlog.Logger `mapstructure:"-"`
 }
 
 func NewConfig() ServerConfig {
@@ -50,6 +92,9 @@ func NewConfig() ServerConfig {
 		IdleTimeout:    time.Second * 60,
 		MaxHeaderBytes: 1 << 20, // 1MB
 
+		// path normalization default config
+		RedirectTrailingSlash: true,
+
 		// TLS default config
 		TLSEnable: false,
 
@@ -58,73 +103,93 @@ func NewConfig() ServerConfig {
 		GracefulTimeout:  time.Second * 30,
 		GracefulWaitTime: time.Second * 5,
 
-		// log default config
-		Logger: mlog.New(),
+		// cookie default config
+		CookiePath:     "/",
+		CookieHttpOnly: true,
+		CookieSameSite: http.SameSiteLaxMode,
+
+		// response default config
+		ResponseTypes: append([]string(nil), defaultResponseTypes...),
+
+		// form binding default config
+		FormMaxDepth:       defaultFormMaxDepth,
+		FormMaxSliceLength: defaultFormMaxSliceLength,
+
+		// debug default config
+		MetricsEnable: true,
 	}
 }
 
-// SetConfigWithMap sets the server config.
+// SetConfigWithMap sets the server config from a map like the `server` section of the config file,
+// decoded into ServerConfig by mcfg.Decode: keys match the fields ignoring case, "_" and "-", so
+// "readTimeout" and "read_timeout" are the same key, durations are like "30s", MaxBodySize is like "8MB"
+// and CookieSameSite is "lax", "strict", "none" or "default". Unknown keys are logged as warnings to
+// catch typos, nested maps like the sections of other server instances and "logger", applied by
+// the frame, see mins.Server, are ignored.
 func (s *Server) SetConfigWithMap(configMap map[string]any) {
-	if v, ok := configMap["address"]; ok {
-		s.config.Address = mconv.ToString(v)
-	}
-	if v, ok := configMap["server_name"]; ok {
-		s.config.ServerName = mconv.ToString(v)
-	}
-	if v, ok := configMap["server_root"]; ok {
-		s.config.ServerRoot = mconv.ToString(v)
-	}
-	if v, ok := configMap["server_locale"]; ok {
-		s.config.ServerLocale = mconv.ToString(v)
-	}
-	if v, ok := configMap["read_timeout"]; ok {
-		s.config.ReadTimeout = mconv.ToDuration(v)
-	}
-	if v, ok := configMap["write_timeout"]; ok {
-		s.config.WriteTimeout = mconv.ToDuration(v)
-	}
-	if v, ok := configMap["idle_timeout"]; ok {
-		s.config.IdleTimeout = mconv.ToDuration(v)
-	}
-	if v, ok := configMap["max_header_bytes"]; ok {
-		s.config.MaxHeaderBytes = mconv.ToInt(v)
-	}
-
-	// TLS config
-	if v, ok := configMap["tls_enable"]; ok {
-		s.config.TLSEnable = mconv.ToBool(v)
-	}
-	if v, ok := configMap["tls_cert_file"]; ok {
-		s.config.TLSCertFile = mconv.ToString(v)
-	}
-	if v, ok := configMap["tls_key_file"]; ok {
-		s.config.TLSKeyFile = mconv.ToString(v)
-	}
-	if v, ok := configMap["tls_server_name"]; ok {
-		s.config.TLSServerName = mconv.ToString(v)
+	var (
+		ctx      = context.Background()
+		config   = s.config
+		metadata mcfg.DecodeMetadata
+	)
+	// the slices are decoded into new ones, not into those of the current config
+	config.TrustedProxies, config.ResponseTypes = nil, nil
+	err := mcfg.Decode(configMap, &config, mcfg.WithMetadata(&metadata), mcfg.WithDecodeHook(sameSiteHook))
+	if err != nil {
+		s.Logger().Errorf(ctx, "set server config failed: %v", err)
+	}
+	var unknown []string
+	for _, key := range metadata.Unused {
+		if _, isMap := configMap[key].(map[string]any); !isMap && key != "logger" {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		s.Logger().Warnf(ctx, "unknown server config keys: %s", strings.Join(unknown, ", "))
+	}
+
+	// the settings with side effects are applied by their setters
+	proxies, responseTypes := config.TrustedProxies, config.ResponseTypes
+	config.TrustedProxies, config.ResponseTypes = s.config.TrustedProxies, s.config.ResponseTypes
+	s.config = config
+	if slices.Contains(metadata.Keys, "TrustedProxies") {
+		if err = s.SetTrustedProxies(proxies...); err != nil {
+			s.Logger().Errorf(ctx, "set trusted proxies failed: %v", err)
+		}
+	}
+	if slices.Contains(metadata.Keys, "ResponseTypes") {
+		s.SetResponseTypes(responseTypes...)
 	}
+}
 
-	// graceful shutdown config
-	if v, ok := configMap["graceful_enable"]; ok {
-		s.config.GracefulEnable = mconv.ToBool(v)
-	}
-	if v, ok := configMap["graceful_timeout"]; ok {
-		s.config.GracefulTimeout = mconv.ToDuration(v)
-	}
-	if v, ok := configMap["graceful_wait_time"]; ok {
-		s.config.GracefulWaitTime = mconv.ToDuration(v)
+// sameSiteHook decodes the strings of CookieSameSite with parseSameSite.
+func sameSiteHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if to != reflect.TypeOf(http.SameSite(0)) || from.Kind() != reflect.String {
+		return data, nil
 	}
+	return parseSameSite(data.(string)), nil
+}
 
-	// API doc config
-	if v, ok := configMap["openapi_path"]; ok {
-		s.config.OpenapiPath = mconv.ToString(v)
-	}
-	if v, ok := configMap["swagger_path"]; ok {
-		s.config.SwaggerPath = mconv.ToString(v)
-	}
-	if v, ok := configMap["swagger_template"]; ok {
-		s.config.SwaggerTemplate = mconv.ToString(v)
-	}
+// WatchConfig re-applies the settings of the config section `key` of `config`, like "server",
+// that can change at runtime whenever the section changes, see mcfg.Config.OnKeyChange and mcfg.Config.Watch.
+//
+// Only the node "logger", like the log level, is hot-reloadable: it is applied to the logger of the server,
+// which is the shared mlog instance "default" unless set by SetLogger. The fields of ServerConfig are
+// read by the running server and its handlers without locking, so they are not re-applied: the address,
+// timeouts, TLS, graceful shutdown, body size, trusted proxies, response types, cookie, form, validation
+// and debug settings take effect when a new server is configured, that is after a restart of the process.
+func (s *Server) WatchConfig(config *mcfg.Config, key string) {
+	config.OnKeyChange(key, func(_ []mcfg.Change) {
+		ctx := context.Background()
+		logger, _ := config.GetStringMap(ctx, key)["logger"].(map[string]any)
+		if len(logger) == 0 {
+			return
+		}
+		if err := s.Logger().SetConfigWithMap(logger); err != nil {
+			s.Logger().Errorf(ctx, "apply logger config failed: %v", err)
+		}
+	})
 }
 
 // SetAddress sets the server listening address.
@@ -137,7 +202,34 @@ func (s *Server) SetServerName(name string) {
 	s.config.ServerName = name
 }
 
-// Logger gets the logger instance.
+// SetTrustedProxies sets the CIDRs or addresses of the proxies whose forwarded headers
+// like X-Forwarded-For are trusted when resolving r.ClientIP.
+// By default, like gin, the forwarded headers of all the clients are trusted, which lets the clients
+// choose their IP when the server is not behind a proxy; calling it without proxies, or with an empty
+// "trusted_proxies" config, makes r.ClientIP use the remote address of the connection.
+func (s *Server) SetTrustedProxies(proxies ...string) error {
+	prefixes, err := parsePrefixes(proxies)
+	if err != nil {
+		return merror.WrapCode(err, mcode.CodeInvalidConfiguration, "invalid trusted proxies")
+	}
+	if err = s.engine.SetTrustedProxies(proxies); err != nil {
+		return merror.WrapCode(err, mcode.CodeInvalidConfiguration, "invalid trusted proxies")
+	}
+	s.config.TrustedProxies = proxies
+	s.proxies = prefixes
+	return nil
+}
+
+// SetLogger sets the logger of the server and its middleware.
+func (s *Server) SetLogger(logger *mlog.Logger) {
+	s.config.Logger = logger
+}
+
+// Logger gets the logger of the server, the mlog instance "default" if none is set,
+// configured by the node "logger" of the default config like mins.Log.
 func (s *Server) Logger() *mlog.Logger {
+	if s.config.Logger == nil {
+		return mlog.Instance()
+	}
 	return s.config.Logger
 }
@@ -0,0 +1,147 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGauge is an up-down counter keeping its value, to check the metrics of the concurrency limiter.
+type testGauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (g *testGauge) Add(_ context.Context, value float64, _ ...mmetric.Option) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value += value
+}
+
+func (g *testGauge) Inc(ctx context.Context, opts ...mmetric.Option) { g.Add(ctx, 1, opts...) }
+
+func (g *testGauge) Dec(ctx context.Context, opts ...mmetric.Option) { g.Add(ctx, -1, opts...) }
+
+func (g *testGauge) get() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// concurrencyTest is a server limited by MiddlewareConcurrencyLimit, whose route "/hold"
+// holds its slot until released.
+type concurrencyTest struct {
+	server   *Server
+	started  chan struct{}
+	release  chan struct{}
+	inflight *testGauge
+	queued   *testGauge
+}
+
+func newConcurrencyTest(t *testing.T, max, queue int, queueTimeout time.Duration) *concurrencyTest {
+	ct := &concurrencyTest{
+		server:   New(),
+		started:  make(chan struct{}, 10),
+		release:  make(chan struct{}),
+		inflight: &testGauge{},
+		queued:   &testGauge{},
+	}
+	inflight, queued := metricManager.HttpServerConcurrencyInflight, metricManager.HttpServerConcurrencyQueued
+	metricManager.HttpServerConcurrencyInflight, metricManager.HttpServerConcurrencyQueued = ct.inflight, ct.queued
+	t.Cleanup(func() {
+		metricManager.HttpServerConcurrencyInflight, metricManager.HttpServerConcurrencyQueued = inflight, queued
+	})
+
+	ct.server.Use(MiddlewareConcurrencyLimit(max, queue, queueTimeout))
+	ct.server.GET("/hold", func(r *Request) {
+		ct.started <- struct{}{}
+		<-ct.release
+		r.String(http.StatusOK, "ok")
+	})
+	ct.server.GET("/ping", func(r *Request) { r.String(http.StatusOK, "pong") })
+	ct.server.bindRoutes(context.Background())
+	return ct
+}
+
+func (ct *concurrencyTest) serve(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ct.server.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// hold serves "/hold" in the background once it holds its slot, the response is sent when released.
+func (ct *concurrencyTest) hold(t *testing.T) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() { done <- ct.serve("/hold") }()
+	select {
+	case <-ct.started:
+	case <-time.After(time.Second):
+		t.Fatal("the request did not get a slot")
+	}
+	return done
+}
+
+func TestMiddlewareConcurrencyLimit_Admission(t *testing.T) {
+	ct := newConcurrencyTest(t, 2, 0, 0)
+
+	first, second := ct.hold(t), ct.hold(t)
+	assert.Equal(t, 2.0, ct.inflight.get())
+
+	// all the slots are taken and there is no queue
+	w := ct.serve("/ping")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Service Unavailable")
+
+	close(ct.release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+	assert.Equal(t, 0.0, ct.inflight.get())
+	assert.Equal(t, http.StatusOK, ct.serve("/ping").Code)
+}
+
+func TestMiddlewareConcurrencyLimit_Queue(t *testing.T) {
+	ct := newConcurrencyTest(t, 1, 1, 5*time.Second)
+	held := ct.hold(t)
+
+	// the next request waits in the queue for the slot
+	waiting := make(chan *httptest.ResponseRecorder, 1)
+	go func() { waiting <- ct.serve("/ping") }()
+	require.Eventually(t, func() bool { return ct.queued.get() == 1 }, time.Second, time.Millisecond)
+
+	// the queue is full
+	w := ct.serve("/ping")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+
+	close(ct.release)
+	assert.Equal(t, http.StatusOK, (<-held).Code)
+	w = <-waiting
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pong", w.Body.String())
+	assert.Equal(t, 0.0, ct.queued.get())
+	assert.Equal(t, 0.0, ct.inflight.get())
+}
+
+func TestMiddlewareConcurrencyLimit_QueueTimeout(t *testing.T) {
+	ct := newConcurrencyTest(t, 1, 1, 20*time.Millisecond)
+	held := ct.hold(t)
+
+	start := time.Now()
+	w := ct.serve("/ping")
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, 0.0, ct.queued.get())
+	assert.Equal(t, 1.0, ct.inflight.get())
+
+	close(ct.release)
+	assert.Equal(t, http.StatusOK, (<-held).Code)
+}
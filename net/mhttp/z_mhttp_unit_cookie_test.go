@@ -0,0 +1,83 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveCookie(s *Server, handler HandlerFunc, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	s.GET("/cookie", handler)
+	s.bindRoutes(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/cookie", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestRequest_Cookie(t *testing.T) {
+	var (
+		value   string
+		present bool
+		missing bool
+	)
+	serveCookie(New(), func(r *Request) {
+		value, present = r.Cookie("name")
+		_, missing = r.Cookie("missing")
+	}, &http.Cookie{Name: "name", Value: "a%20b%3Bc"})
+	assert.True(t, present)
+	assert.Equal(t, "a b;c", value)
+	assert.False(t, missing)
+}
+
+func TestRequest_SetCookie(t *testing.T) {
+	w := serveCookie(New(), func(r *Request) {
+		assert.NoError(t, r.SetCookie("session", "a b;c"))
+		assert.NoError(t, r.SetCookie("remember", "1",
+			WithCookieMaxAge(time.Hour),
+			WithCookiePath("/app"),
+			WithCookieDomain("example.com"),
+			WithCookieSecure(true),
+			WithCookieHttpOnly(false),
+			WithCookieSameSite(http.SameSiteStrictMode),
+		))
+		r.String(http.StatusOK, "ok")
+		assert.Error(t, r.SetCookie("late", "1"))
+		assert.Error(t, r.DeleteCookie("late"))
+	})
+	cookies := w.Header().Values("Set-Cookie")
+	assert.Equal(t, []string{
+		"session=a+b%3Bc; Path=/; HttpOnly; SameSite=Lax",
+		"remember=1; Path=/app; Domain=example.com; Max-Age=3600; Secure; SameSite=Strict",
+	}, cookies)
+}
+
+func TestRequest_SetCookie_ServerDefaults(t *testing.T) {
+	s := New()
+	s.SetConfigWithMap(map[string]any{
+		"cookie_secure":    true,
+		"cookie_same_site": "none",
+		"cookie_http_only": false,
+		"cookie_domain":    "example.com",
+	})
+	w := serveCookie(s, func(r *Request) {
+		assert.NoError(t, r.SetCookie("id", "1"))
+		assert.Error(t, r.SetCookie("bad name", "1"))
+	})
+	assert.Equal(t, "id=1; Path=/; Domain=example.com; Secure; SameSite=None", w.Header().Get("Set-Cookie"))
+}
+
+func TestRequest_DeleteCookie(t *testing.T) {
+	w := serveCookie(New(), func(r *Request) {
+		assert.NoError(t, r.DeleteCookie("session", WithCookiePath("/app")))
+	})
+	assert.Equal(t, "session=; Path=/app; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; HttpOnly; SameSite=Lax",
+		w.Header().Get("Set-Cookie"))
+}
@@ -0,0 +1,50 @@
+package mhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrorResponseBody tests that the standard response writes errors like merror.JSON
+func TestErrorResponseBody(t *testing.T) {
+	s := New()
+	s.Use(MiddlewareResponse())
+	s.GET("/orders", func(r *Request) {
+		detail := map[string]any{"field": "qty"}
+		r.Error(merror.Wrap(merror.NewCode(mcode.WithCode(mcode.CodeValidationFailed, detail), `invalid "qty"`), "create order"))
+	})
+	s.bindRoutes(context.Background())
+
+	serve := func() map[string]any {
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := serve()
+	assert.Equal(t, float64(mcode.CodeValidationFailed.Code()), body["code"])
+	assert.Equal(t, `create order: invalid "qty"`, body["message"])
+	assert.Equal(t, map[string]any{"field": "qty"}, body["details"])
+	assert.NotContains(t, body, "caller")
+	assert.NotContains(t, body, "stack")
+	assert.NotContains(t, body, "cause")
+
+	merror.SetDebug(true)
+	t.Cleanup(func() { merror.SetDebug(false) })
+	body = serve()
+	assert.Contains(t, body["caller"], "z_mhttp_unit_error_json_test.go:")
+	assert.NotEmpty(t, body["stack"])
+	cause, ok := body["cause"].(map[string]any)
+	require.True(t, ok, "expected the cause chain, got %v", body)
+	assert.Equal(t, `invalid "qty"`, cause["message"])
+}
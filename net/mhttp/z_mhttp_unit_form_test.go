@@ -0,0 +1,399 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/util/mmeta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type formItem struct {
+	Sku  string   `form:"sku"`
+	Qty  int      `form:"qty"`
+	Tags []string `form:"tags"`
+}
+
+type formAddress struct {
+	City string `form:"city"`
+	Zip  *int   `form:"zip"`
+}
+
+type formTarget struct {
+	Name     string            `form:"name"`
+	Items    []formItem        `form:"items"`
+	PtrItems []*formItem       `form:"ptr_items"`
+	Address  formAddress       `form:"address"`
+	Billing  *formAddress      `form:"billing"`
+	Labels   map[string]string `form:"labels"`
+	Scores   []int             `form:"scores"`
+	Pair     [2]string         `form:"pair"`
+	Timeout  time.Duration     `form:"opts.timeout"`
+	Ignored  string            `form:"-"`
+	Untagged struct {
+		Value float64
+	}
+}
+
+func TestMapNestedForm(t *testing.T) {
+	zip := 10115
+	defaultOpts := formOptions{MaxDepth: defaultFormMaxDepth, MaxSliceLength: defaultFormMaxSliceLength}
+	strictOpts := defaultOpts
+	strictOpts.StrictIndex = true
+
+	tests := []struct {
+		name    string
+		form    url.Values
+		opts    formOptions
+		want    func(t *testing.T, v *formTarget)
+		wantErr string
+	}{
+		{
+			name: "flat keys are ignored",
+			form: url.Values{"name": {"john"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Empty(t, v.Name)
+			},
+		},
+		{
+			name: "nested struct",
+			form: url.Values{"address.city": {"Berlin"}, "address.zip": {"10115"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, "Berlin", v.Address.City)
+				assert.Equal(t, &zip, v.Address.Zip)
+			},
+		},
+		{
+			name: "nested pointer struct is allocated",
+			form: url.Values{"billing.city": {"Paris"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				require.NotNil(t, v.Billing)
+				assert.Equal(t, "Paris", v.Billing.City)
+			},
+		},
+		{
+			name: "indexed slice of structs",
+			form: url.Values{
+				"items[0].sku": {"abc"}, "items[0].qty": {"2"},
+				"items[1].sku": {"def"}, "items[1].qty": {"5"},
+			},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, []formItem{{Sku: "abc", Qty: 2}, {Sku: "def", Qty: 5}}, v.Items)
+			},
+		},
+		{
+			name: "indexed slice of struct pointers",
+			form: url.Values{"ptr_items[0].sku": {"abc"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				require.Len(t, v.PtrItems, 1)
+				assert.Equal(t, "abc", v.PtrItems[0].Sku)
+			},
+		},
+		{
+			name: "nested scalar slice inside indexed struct",
+			form: url.Values{"items[0].tags[0]": {"a"}, "items[0].tags[1]": {"b"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				require.Len(t, v.Items, 1)
+				assert.Equal(t, []string{"a", "b"}, v.Items[0].Tags)
+			},
+		},
+		{
+			name: "repeated values of nested slice",
+			form: url.Values{"items[0].tags": {"a", "b", "c"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, []string{"a", "b", "c"}, v.Items[0].Tags)
+			},
+		},
+		{
+			name: "indexed scalar slice",
+			form: url.Values{"scores[0]": {"1"}, "scores[1]": {"2"}, "scores[2]": {"3"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, []int{1, 2, 3}, v.Scores)
+			},
+		},
+		{
+			name: "sparse indexes are compacted in order",
+			form: url.Values{"scores[7]": {"3"}, "scores[0]": {"1"}, "scores[3]": {"2"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, []int{1, 2, 3}, v.Scores)
+			},
+		},
+		{
+			name:    "sparse indexes are rejected in strict mode",
+			form:    url.Values{"scores[0]": {"1"}, "scores[2]": {"2"}},
+			opts:    strictOpts,
+			wantErr: "scores has sparse index 2",
+		},
+		{
+			name: "contiguous indexes are accepted in strict mode",
+			form: url.Values{"scores[1]": {"2"}, "scores[0]": {"1"}},
+			opts: strictOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, []int{1, 2}, v.Scores)
+			},
+		},
+		{
+			name: "fixed array",
+			form: url.Values{"pair[0]": {"x"}, "pair[1]": {"y"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, [2]string{"x", "y"}, v.Pair)
+			},
+		},
+		{
+			name:    "fixed array overflow",
+			form:    url.Values{"pair[0]": {"x"}, "pair[1]": {"y"}, "pair[2]": {"z"}},
+			opts:    defaultOpts,
+			wantErr: "pair exceeds array length 2",
+		},
+		{
+			name: "map with bracket keys",
+			form: url.Values{"labels[env]": {"prod"}, "labels.team": {"core"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, v.Labels)
+			},
+		},
+		{
+			name: "untagged field uses field name",
+			form: url.Values{"Untagged.Value": {"1.5"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, 1.5, v.Untagged.Value)
+			},
+		},
+		{
+			name: "ignored field",
+			form: url.Values{"-.x": {"1"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Empty(t, v.Ignored)
+			},
+		},
+		{
+			name: "unknown keys are ignored",
+			form: url.Values{"unknown.key": {"1"}, "unknown[0]": {"2"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Nil(t, v.Items)
+			},
+		},
+		{
+			name: "empty numeric value is zero",
+			form: url.Values{"items[0].qty": {""}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Equal(t, 0, v.Items[0].Qty)
+			},
+		},
+		{
+			name:    "conversion error names full path",
+			form:    url.Values{"items[0].qty": {"1"}, "items[1].qty": {"two"}},
+			opts:    defaultOpts,
+			wantErr: "items[1].qty",
+		},
+		{
+			name:    "conversion error names full path of compacted index",
+			form:    url.Values{"items[4].qty": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "items[4].qty",
+		},
+		{
+			name:    "conversion error in nested pointer",
+			form:    url.Values{"address.zip": {"abc"}},
+			opts:    defaultOpts,
+			wantErr: "address.zip",
+		},
+		{
+			name:    "max depth exceeded",
+			form:    url.Values{"items[0].tags[0]": {"a"}},
+			opts:    formOptions{MaxDepth: 3, MaxSliceLength: 10},
+			wantErr: "exceeds max depth 3",
+		},
+		{
+			name:    "max slice length exceeded",
+			form:    url.Values{"scores[10]": {"1"}},
+			opts:    formOptions{MaxDepth: 10, MaxSliceLength: 10},
+			wantErr: "exceeds max slice length 10",
+		},
+		{
+			name:    "index on struct field",
+			form:    url.Values{"address[0]": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "address does not accept indexes",
+		},
+		{
+			name: "nested key on scalar field is left to flat binding",
+			form: url.Values{"name.first": {"x"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Empty(t, v.Name)
+			},
+		},
+		{
+			name:    "unclosed bracket",
+			form:    url.Values{"items[0.sku": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "unclosed bracket",
+		},
+		{
+			name:    "empty brackets",
+			form:    url.Values{"items[].sku": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "empty brackets",
+		},
+		{
+			name:    "negative index",
+			form:    url.Values{"items[-1].sku": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "negative index",
+		},
+		{
+			name:    "empty segment",
+			form:    url.Values{"address..city": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "empty segment",
+		},
+		{
+			name:    "empty segment after dot",
+			form:    url.Values{"address.": {"x"}},
+			opts:    defaultOpts,
+			wantErr: "empty segment",
+		},
+		{
+			name: "malformed keys matching no field are ignored",
+			form: url.Values{".city": {"x"}, "unknown[": {"x"}, "unknown[].a": {"x"}, "name[0": {"x"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Empty(t, v.Name)
+			},
+		},
+		{
+			name: "empty brackets of a slice field are left to flat binding",
+			form: url.Values{"scores[]": {"1", "2"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Nil(t, v.Scores)
+			},
+		},
+		{
+			name: "duration field with dotted tag name is left to flat binding",
+			form: url.Values{"opts.timeout": {"1s"}},
+			opts: defaultOpts,
+			want: func(t *testing.T, v *formTarget) {
+				assert.Zero(t, v.Timeout)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v formTarget
+			err := mapNestedForm(&v, tt.form, tt.opts)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.want(t, &v)
+		})
+	}
+}
+
+type formOrderReq struct {
+	mmeta.Meta `path:"/orders" method:"POST"`
+	Customer   string     `form:"customer" binding:"required"`
+	Items      []formItem `form:"items" binding:"required,min=1,dive"`
+	Address    struct {
+		City string `form:"city" binding:"required"`
+	} `form:"address"`
+}
+
+type formOrderRes struct {
+	Customer string
+	Count    int
+	City     string
+}
+
+type formController struct{}
+
+func (c *formController) Create(_ context.Context, req *formOrderReq) (*formOrderRes, error) {
+	return &formOrderRes{Customer: req.Customer, Count: len(req.Items), City: req.Address.City}, nil
+}
+
+func TestServer_NestedFormBinding(t *testing.T) {
+	s := New()
+	s.BindObject(&formController{})
+	s.bindRoutes(context.Background())
+
+	body := url.Values{
+		"customer":     {"john"},
+		"items[0].sku": {"abc"},
+		"items[0].qty": {"2"},
+		"items[1].sku": {"def"},
+		"address.city": {"Berlin"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "&{john 2 Berlin}", w.Body.String())
+
+	body.Set("items[1].qty", "many")
+	req = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "items[1].qty")
+}
+
+type formIDsReq struct {
+	mmeta.Meta `path:"/ids" method:"POST"`
+	IDs        []int  `form:"ids[]"`
+	Name       string `form:"name"`
+}
+
+type formIDsRes struct {
+	IDs  []int
+	Name string
+}
+
+type formIDsController struct{}
+
+func (c *formIDsController) Create(_ context.Context, req *formIDsReq) (*formIDsRes, error) {
+	return &formIDsRes{IDs: req.IDs, Name: req.Name}, nil
+}
+
+// TestServer_FlatFormKeys tests that the keys with brackets of the flat fields and the unknown keys
+// are left to the gin form binding
+func TestServer_FlatFormKeys(t *testing.T) {
+	s := New()
+	s.BindObject(&formIDsController{})
+	s.bindRoutes(context.Background())
+
+	body := "ids[]=1&ids[]=2&name=john&unknown[=x&unknown[].a=y&name.first=z"
+	req := httptest.NewRequest(http.MethodPost, "/ids", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "&{[1 2] john}", w.Body.String())
+}
@@ -0,0 +1,134 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilterConfig_Allowed(t *testing.T) {
+	cfg := &IPFilterConfig{
+		Allow: []string{"10.0.0.0/24", "2001:db8::/126", "192.168.1.7"},
+		Deny:  []string{"10.0.0.128/30", "::ffff:10.0.0.200/127"},
+	}
+	assert.NoError(t, cfg.setRules(cfg.Allow, cfg.Deny))
+
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		// 10.0.0.0/24 boundaries
+		{"9.255.255.255", false},
+		{"10.0.0.0", true},
+		{"10.0.0.255", true},
+		{"10.0.1.0", false},
+		// 10.0.0.128/30 is denied even though allowed by 10.0.0.0/24
+		{"10.0.0.127", true},
+		{"10.0.0.128", false},
+		{"10.0.0.131", false},
+		{"10.0.0.132", true},
+		// IPv4-mapped deny entry ::ffff:10.0.0.200/127 matches 10.0.0.200-201
+		{"10.0.0.199", true},
+		{"10.0.0.200", false},
+		{"10.0.0.201", false},
+		{"10.0.0.202", true},
+		// IPv4-mapped client addresses are matched as IPv4
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:10.0.0.129", false},
+		{"::ffff:10.0.1.0", false},
+		// single address entry
+		{"192.168.1.6", false},
+		{"192.168.1.7", true},
+		{"192.168.1.8", false},
+		// 2001:db8::/126 boundaries
+		{"2001:db7:ffff:ffff:ffff:ffff:ffff:ffff", false},
+		{"2001:db8::", true},
+		{"2001:db8::3", true},
+		{"2001:db8::4", false},
+		{"2001:db8::1%eth0", true},
+		// invalid addresses
+		{"", false},
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, cfg.Allowed(tt.ip), tt.ip)
+	}
+}
+
+func TestIPFilterConfig_EmptyAllow(t *testing.T) {
+	cfg := &IPFilterConfig{Deny: []string{"203.0.113.0/24"}}
+	assert.True(t, cfg.Allowed("203.0.112.255"))
+	assert.False(t, cfg.Allowed("203.0.113.0"))
+	assert.False(t, cfg.Allowed("203.0.113.255"))
+	assert.True(t, cfg.Allowed("203.0.114.0"))
+	assert.True(t, cfg.Allowed("::1"))
+}
+
+func TestIPFilterConfig_Invalid(t *testing.T) {
+	cfg := &IPFilterConfig{Allow: []string{"10.0.0.0/33"}}
+	assert.Panics(t, func() { MiddlewareIPFilter(cfg) })
+
+	cfg = &IPFilterConfig{}
+	assert.Error(t, cfg.SetDeny("bad"))
+	assert.True(t, cfg.Allowed("10.0.0.1"))
+}
+
+func TestMiddlewareIPFilter(t *testing.T) {
+	cfg := &IPFilterConfig{Allow: []string{"10.0.0.0/8"}}
+
+	s := New()
+	assert.NoError(t, s.SetTrustedProxies("127.0.0.1"))
+	s.Use(MiddlewareIPFilter(cfg))
+	s.GET("/ping", func(r *Request) { r.String(http.StatusOK, "pong") })
+	s.bindRoutes(context.Background())
+
+	serve := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("10.1.2.3:1234", "").Code)
+	w := serve("192.0.2.1:1234", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "not allowed")
+
+	// forwarded headers are used only from trusted proxies
+	assert.Equal(t, http.StatusOK, serve("127.0.0.1:1234", "10.1.2.3").Code)
+	assert.Equal(t, http.StatusForbidden, serve("192.0.2.1:1234", "10.1.2.3").Code)
+
+	// hot reload
+	assert.NoError(t, cfg.SetDeny("10.1.0.0/16"))
+	assert.Equal(t, http.StatusForbidden, serve("10.1.2.3:1234", "").Code)
+	assert.Equal(t, http.StatusOK, serve("10.2.2.3:1234", "").Code)
+	assert.NoError(t, cfg.SetAllow())
+	assert.Equal(t, http.StatusOK, serve("192.0.2.1:1234", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("10.1.2.3:1234", "").Code)
+}
+
+// TestServer_TrustedProxies tests that the forwarded headers are trusted by default, like gin
+func TestServer_TrustedProxies(t *testing.T) {
+	s := New()
+	s.GET("/ip", func(r *Request) { r.String(http.StatusOK, r.ClientIP()) })
+	s.bindRoutes(context.Background())
+	serve := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "10.1.2.3", serve())
+	assert.NoError(t, s.SetTrustedProxies())
+	assert.Equal(t, "192.0.2.1", serve())
+}
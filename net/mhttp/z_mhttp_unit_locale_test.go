@@ -0,0 +1,77 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequest_Locale tests picking the locale from the Accept-Language header
+func TestRequest_Locale(t *testing.T) {
+	s := New()
+	s.GET("/locale", func(r *Request) { r.String(http.StatusOK, r.Locale()) })
+	s.bindRoutes(context.Background())
+
+	tests := []struct {
+		header string
+		locale string
+	}{
+		{"", "zh"},
+		{"*", "zh"},
+		{"en-US,en;q=0.9", "en-us"},
+		{"fr;q=0.5, de", "de"},
+		{"en;q=0, ja", "ja"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/locale", nil)
+		req.Header.Set("Accept-Language", tt.header)
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		assert.Equal(t, tt.locale, w.Body.String(), tt.header)
+	}
+}
+
+// the codes are registered at package level, so that the tests can run several times
+var (
+	_                = mcode.RegisterRange("mhttp-test", 20200, 20299)
+	codeUserNotFound = mcode.New(20201, "User Not Found", nil)
+)
+
+// TestLocalizedErrorResponse tests that error responses are localized for the request
+func TestLocalizedErrorResponse(t *testing.T) {
+	merror.RegisterMessages("zh-CN", map[int]string{20201: "用户不存在: {name}"})
+	merror.RegisterMessages("en", map[int]string{20201: "user {name} does not exist"})
+	assert.Panics(t, func() { merror.RegisterMessages("en", map[int]string{20202: "unclosed {name"}) })
+
+	s := New()
+	s.Use(MiddlewareResponse())
+	s.GET("/users", func(r *Request) {
+		r.Error(merror.Wrap(merror.NewCode(mcode.WithCode(codeUserNotFound, map[string]any{"name": "alice"}), "load"), "get user"))
+	})
+	s.GET("/detail", func(r *Request) {
+		r.Error(merror.WrapCode(merror.WithDetail(merror.NewCode(codeUserNotFound), "name", "bob"), codeUserNotFound, "lookup"))
+	})
+	s.GET("/plain", func(r *Request) { r.Error(merror.NewCode(mcode.CodeNotFound, "no such order")) })
+	s.bindRoutes(context.Background())
+
+	serve := func(path, language string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	assert.Contains(t, serve("/users", "zh-CN,zh;q=0.9"), "用户不存在: alice")
+	assert.Contains(t, serve("/users", "en-GB"), "user alice does not exist")
+	// details added by merror.WithDetail are template parameters
+	assert.Contains(t, serve("/detail", "zh-CN"), "用户不存在: bob")
+	assert.Contains(t, serve("/detail", "en"), `"details":{"name":"bob"}`)
+	// no template for the locale or the code
+	assert.Contains(t, serve("/users", "fr"), "get user: load")
+	assert.Contains(t, serve("/plain", "en"), "no such order")
+}
@@ -0,0 +1,27 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/os/mlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_EnableLogLevel(t *testing.T) {
+	l := mlog.Instance("mhttp-log-level")
+	l.SetLevel(mlog.InfoLevel)
+
+	s := New()
+	s.EnableLogLevel()
+	s.bindRoutes(context.Background())
+
+	req := httptest.NewRequest(http.MethodPut, "/debug/log/level?name=mhttp-log-level&level=debug", nil)
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"mhttp-log-level","level":"debug"}`, w.Body.String())
+	assert.Equal(t, mlog.DebugLevel, l.GetLevel())
+}
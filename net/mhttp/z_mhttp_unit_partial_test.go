@@ -0,0 +1,82 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type partialAddress struct {
+	City string `json:"city" form:"city" binding:"required"`
+	Zip  string `json:"zip" form:"zip" binding:"required,len=5"`
+}
+
+type partialUser struct {
+	Name     string         `json:"name" form:"name" binding:"required,min=2"`
+	Age      int            `json:"age" form:"age" binding:"gte=0,lte=150"`
+	Nickname *string        `json:"nickname" form:"nickname"`
+	Address  partialAddress `json:"address" form:"address"`
+}
+
+// TestRequest_ShouldBindPartial tests validating only the fields present in the payload
+func TestRequest_ShouldBindPartial(t *testing.T) {
+	var (
+		user     partialUser
+		provided []string
+	)
+	s := New()
+	s.PATCH("/users", func(r *Request) {
+		user = partialUser{}
+		err := r.ShouldBindPartial(&user)
+		provided = r.ProvidedFields()
+		if err != nil {
+			r.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		r.String(http.StatusOK, "ok")
+	})
+	s.bindRoutes(context.Background())
+	serve := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		provided    []string
+	}{
+		{"omitted fields", MediaTypeJSON, `{"age":30}`, http.StatusOK, []string{"Age"}},
+		{"explicit zero", MediaTypeJSON, `{"age":0}`, http.StatusOK, []string{"Age"}},
+		{"explicit null", MediaTypeJSON, `{"nickname":null}`, http.StatusOK, []string{"Nickname"}},
+		{"provided field is validated", MediaTypeJSON, `{"name":""}`, http.StatusBadRequest, []string{"Name"}},
+		{"nested field", MediaTypeJSON, `{"address":{"zip":"10115"}}`, http.StatusOK, []string{"Address", "Address.Zip"}},
+		{"nested field is validated", MediaTypeJSON, `{"address":{"zip":"1"}}`, http.StatusBadRequest, []string{"Address", "Address.Zip"}},
+		{"empty object", MediaTypeJSON, `{}`, http.StatusOK, nil},
+		{"form keys", "application/x-www-form-urlencoded", "name=john&address.city=Berlin", http.StatusOK, []string{"Name", "Address", "Address.City"}},
+		{"form empty value", "application/x-www-form-urlencoded", "name=", http.StatusBadRequest, []string{"Name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.contentType, tt.body)
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.ElementsMatch(t, tt.provided, provided)
+		})
+	}
+
+	// the values are bound
+	assert.Equal(t, http.StatusOK, serve(MediaTypeJSON, `{"name":"john","nickname":"jo"}`).Code)
+	assert.Equal(t, "john", user.Name)
+	require.NotNil(t, user.Nickname)
+	assert.Equal(t, "jo", *user.Nickname)
+}
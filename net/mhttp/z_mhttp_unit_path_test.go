@@ -0,0 +1,174 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPathTestServer(options ...func(s *Server)) *Server {
+	s := New()
+	for _, option := range options {
+		option(s)
+	}
+	s.GET("/users", func(r *Request) { r.String(http.StatusOK, "list") })
+	s.POST("/users", func(r *Request) { r.String(http.StatusOK, "create") })
+	s.GET("/teams/", func(r *Request) { r.String(http.StatusOK, "teams") })
+	s.GET("/files/:name", func(r *Request) { r.String(http.StatusOK, "file:"+r.Param("name")) })
+	s.bindRoutes(context.Background())
+	return s
+}
+
+func servePath(s *Server, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader("")))
+	return w
+}
+
+func TestServer_RedirectTrailingSlash(t *testing.T) {
+	s := newPathTestServer()
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{"GET removes trailing slash", http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{"GET adds trailing slash", http.MethodGet, "/teams", http.StatusMovedPermanently, "/teams/"},
+		{"GET keeps query string", http.MethodGet, "/users/?page=2&size=10", http.StatusMovedPermanently, "/users?page=2&size=10"},
+		{"POST uses 308", http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users"},
+		{"POST keeps query string", http.MethodPost, "/users/?dry_run=1", http.StatusPermanentRedirect, "/users?dry_run=1"},
+		{"unregistered method is not redirected", http.MethodPut, "/users/", http.StatusNotFound, ""},
+		{"unknown path is not redirected", http.MethodGet, "/unknown/", http.StatusNotFound, ""},
+		{"param route", http.MethodGet, "/files/a.txt/", http.StatusMovedPermanently, "/files/a.txt"},
+		{"exact match is served", http.MethodGet, "/users", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := servePath(s, tt.method, tt.target)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+
+	s = newPathTestServer(func(s *Server) { s.SetRedirectTrailingSlash(false) })
+	w := servePath(s, http.MethodGet, "/users/")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = servePath(s, http.MethodPost, "/users/")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_RemoveExtraSlash(t *testing.T) {
+	s := newPathTestServer()
+	assert.Equal(t, http.StatusNotFound, servePath(s, http.MethodGet, "//users").Code)
+
+	s = newPathTestServer(func(s *Server) { s.SetRemoveExtraSlash(true) })
+	for _, target := range []string{"//users", "/users", "///users?x=1"} {
+		w := servePath(s, http.MethodGet, target)
+		assert.Equal(t, http.StatusOK, w.Code, target)
+		assert.Equal(t, "list", w.Body.String(), target)
+	}
+	w := servePath(s, http.MethodPost, "//users")
+	assert.Equal(t, "create", w.Body.String())
+}
+
+func TestServer_CleanPath(t *testing.T) {
+	s := newPathTestServer()
+	assert.Equal(t, http.StatusNotFound, servePath(s, http.MethodGet, "/a/../users").Code)
+
+	s = newPathTestServer(func(s *Server) { s.SetCleanPath(true) })
+	tests := []struct {
+		target string
+		body   string
+	}{
+		{"/a/../users", "list"},
+		{"/./users", "list"},
+		{"/../../users", "list"},
+		{"/files/x/../b.txt", "file:b.txt"},
+		{"/teams/./", "teams"},
+	}
+	for _, tt := range tests {
+		w := servePath(s, http.MethodGet, tt.target)
+		assert.Equal(t, http.StatusOK, w.Code, tt.target)
+		assert.Equal(t, tt.body, w.Body.String(), tt.target)
+	}
+}
+
+func TestServer_EncodedSlash(t *testing.T) {
+	s := newPathTestServer(func(s *Server) {
+		s.SetRemoveExtraSlash(true)
+		s.SetCleanPath(true)
+	})
+
+	// an encoded slash is a single segment and is decoded only for the parameter value
+	w := servePath(s, http.MethodGet, "/files/a%2Fb.txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "file:a/b.txt", w.Body.String())
+
+	// encoded dot segments with encoded slashes are not resolved
+	w = servePath(s, http.MethodGet, "/files/..%2Fsecret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "file:../secret", w.Body.String())
+
+	// encoded slashes survive the normalization of other segments
+	w = servePath(s, http.MethodGet, "//files/x/../a%2Fb")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "file:a/b", w.Body.String())
+
+	// the redirect location keeps the encoded slash
+	w = servePath(s, http.MethodGet, "/files/a%2Fb/")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/files/a%2Fb", w.Header().Get("Location"))
+}
+
+func TestServer_EncodedPathDefault(t *testing.T) {
+	// without path normalization, the encoded paths are routed like a plain gin engine
+	s := newPathTestServer()
+	engine := gin.New()
+	engine.GET("/users", func(c *gin.Context) { c.String(http.StatusOK, "list") })
+	engine.GET("/files/:name", func(c *gin.Context) { c.String(http.StatusOK, "file:"+c.Param("name")) })
+
+	for _, target := range []string{
+		"/users",
+		"/files/a%20b.txt",
+		"/files/%E4%B8%AD.txt",
+		"/files/a%252Fb",
+		"/files/a%2Fb.txt",
+		"/files/a%2Fb.txt/",
+		"/%75sers",
+	} {
+		want := httptest.NewRecorder()
+		engine.ServeHTTP(want, httptest.NewRequest(http.MethodGet, target, nil))
+		w := servePath(s, http.MethodGet, target)
+		assert.Equal(t, want.Code, w.Code, target)
+		if want.Code == http.StatusOK {
+			assert.Equal(t, want.Body.String(), w.Body.String(), target)
+		}
+	}
+}
+
+func TestRemoveDotSegments(t *testing.T) {
+	tests := map[string]string{
+		"/":            "/",
+		"/a/b":         "/a/b",
+		"/a/./b":       "/a/b",
+		"/a/../b":      "/b",
+		"/a/b/..":      "/a/",
+		"/a/b/.":       "/a/b/",
+		"/..":          "/",
+		"/a//../b":     "/a/b",
+		"/a/b/":        "/a/b/",
+		"/a/.../b":     "/a/.../b",
+		"/a/..%2Fb/..": "/a/",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, removeDotSegments(in), in)
+	}
+}
@@ -0,0 +1,72 @@
+package mhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecovery tests that panics of handlers are recovered as internal errors
+func TestRecovery(t *testing.T) {
+	s := New()
+	s.GET("/panic", func(r *Request) { panic("boom") })
+	s.GET("/nil-map", func(r *Request) {
+		var m map[string]int
+		m["key"] = 1
+	})
+	s.GET("/ok", func(r *Request) { r.String(http.StatusOK, "ok") })
+	s.bindRoutes(context.Background())
+
+	tests := map[string]string{
+		"/panic":   "Error: panic: boom",
+		"/nil-map": "Error: panic: assignment to entry in nil map",
+	}
+	for path, body := range tests {
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+// TestRecovery_Response tests that panics are written by the response middleware like the other errors
+func TestRecovery_Response(t *testing.T) {
+	var recovered error
+	s := New()
+	s.Use(func(r *Request) {
+		r.Next()
+		recovered = r.Errors.Last().Err
+	})
+	s.Use(MiddlewareResponse())
+	s.GET("/panic", func(r *Request) { panic("boom") })
+	s.GET("/middleware", func(r *Request) { r.String(http.StatusOK, "unreachable") },
+		func(r *Request) { panic("middleware boom") })
+	s.bindRoutes(context.Background())
+
+	for path, message := range map[string]string{"/panic": "panic: boom", "/middleware": "panic: middleware boom"} {
+		recovered = nil
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+
+		var res DefaultResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res), path)
+		assert.Equal(t, mcode.CodeInternalError.Code(), res.Code, path)
+		assert.Equal(t, message, res.Message, path)
+		assert.Nil(t, res.Data, path)
+
+		// the middleware before the panic see its error
+		assert.True(t, mcode.Equal(mcode.CodeInternalError, merror.Code(recovered)), path)
+	}
+}
@@ -0,0 +1,43 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRequestID(t *testing.T, requestID string) (header string, seen string) {
+	s := New()
+	s.Use(MiddlewareRequestID())
+	s.GET("/ping", func(r *Request) {
+		seen = r.RequestID()
+		r.String(http.StatusOK, "pong")
+	})
+	s.bindRoutes(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Header().Get(RequestIDHeader), seen
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	header, seen := serveRequestID(t, "req-1")
+	assert.Equal(t, "req-1", header)
+	assert.Equal(t, "req-1", seen)
+
+	header, seen = serveRequestID(t, "")
+	assert.Len(t, header, 32)
+	assert.Equal(t, header, seen)
+
+	header, _ = serveRequestID(t, strings.Repeat("x", maxRequestIDLength+1))
+	assert.Len(t, header, 32)
+}
@@ -0,0 +1,113 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+// TestParseAccept tests ordering the media ranges by q-value, then by specificity
+func TestParseAccept(t *testing.T) {
+	var types []string
+	for _, rg := range parseAccept("text/*;q=0.5, application/json;q=0.9, */*;q=0.1, text/plain;q=0.5, application/xml, ;q=1") {
+		types = append(types, rg.mediaType)
+	}
+	assert.Equal(t, []string{"application/xml", "application/json", "text/plain", "text/*", "*/*"}, types)
+
+	ranges := parseAccept("Application/YAML ; Q=0.3 ; charset=utf-8, text/xml;q=bad")
+	assert.Equal(t, "application/yaml", ranges[1].mediaType)
+	assert.Equal(t, 0.3, ranges[1].q)
+	assert.Equal(t, 1.0, ranges[0].q, "an invalid q-value is ignored")
+}
+
+// TestNegotiate tests picking the offered media type from the Accept header
+func TestNegotiate(t *testing.T) {
+	offered := []string{MediaTypeJSON, MediaTypeXML, MediaTypeYAML}
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"missing", "", MediaTypeJSON},
+		{"exact", "application/yaml", MediaTypeYAML},
+		{"q-value", "application/json;q=0.5, application/xml", MediaTypeXML},
+		{"any", "*/*", MediaTypeJSON},
+		{"subtype wildcard", "text/html, application/*;q=0.9", MediaTypeJSON},
+		{"wildcard with exclusion", "*/*, application/json;q=0", MediaTypeXML},
+		{"unsatisfiable", "text/html", MediaTypeJSON},
+		{"browser", browserAccept, MediaTypeXML},
+		{"specific over wildcard", "*/*, application/yaml", MediaTypeYAML},
+		{"subtype wildcard refused", "application/*;q=0, */*", MediaTypeJSON},
+		{"all refused", "*/*;q=0", MediaTypeJSON},
+		{"wildcard refused but one", "*/*;q=0, application/yaml", MediaTypeYAML},
+		{"subtype wildcard refused but one", "application/*;q=0, application/xml;q=0.5", MediaTypeXML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiate(tt.accept, offered))
+		})
+	}
+	assert.Equal(t, MediaTypeJSON, negotiate(browserAccept, defaultResponseTypes), "browsers get JSON by default")
+
+	// JSON is the fallback whenever it is offered, wherever it is in the list
+	xmlFirst := []string{MediaTypeXML, MediaTypeJSON}
+	for _, accept := range []string{"", "*/*", "text/html", "application/*"} {
+		assert.Equal(t, MediaTypeJSON, negotiate(accept, xmlFirst), accept)
+	}
+	assert.Equal(t, MediaTypeXML, negotiate("application/xml", xmlFirst))
+	assert.Equal(t, MediaTypeXML, negotiate("*/*, application/json;q=0", xmlFirst))
+	// the first offered type otherwise
+	assert.Equal(t, MediaTypeYAML, negotiate("*/*", []string{MediaTypeYAML, MediaTypeXML}))
+	assert.Equal(t, MediaTypeJSON, negotiate("*/*", nil))
+}
+
+// TestRequest_WriteResponse tests writing the response in the negotiated media type
+func TestRequest_WriteResponse(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	serve := func(s *Server, path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w
+	}
+	routes := func(s *Server) {
+		s.GET("/struct", func(r *Request) { r.WriteResponse(http.StatusOK, user{Name: "alice"}) })
+		s.GET("/map", func(r *Request) { r.WriteResponse(http.StatusOK, map[string]any{"name": "alice"}) })
+		s.bindRoutes(context.Background())
+	}
+
+	// JSON only by default
+	s := New()
+	routes(s)
+	w := serve(s, "/struct", browserAccept)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"name":"alice"}`, w.Body.String())
+
+	// XML offered
+	s = New()
+	s.SetResponseTypes(MediaTypeJSON, MediaTypeXML)
+	routes(s)
+	w = serve(s, "/struct", MediaTypeXML)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<name>alice</name>")
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	// a map cannot be encoded in XML, it falls back to JSON
+	w = serve(s, "/map", MediaTypeXML)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"name":"alice"}`, w.Body.String())
+
+	// JSON failing is an internal error
+	s.GET("/chan", func(r *Request) { r.WriteResponse(http.StatusOK, make(chan int)) })
+	s.bindRoutes(context.Background())
+	assert.Equal(t, http.StatusInternalServerError, serve(s, "/chan", MediaTypeJSON).Code)
+}
@@ -0,0 +1,84 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/util/mmeta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeInfoReq struct {
+	mmeta.Meta `path:"/orders/:id" method:"PUT" summary:"Update order" auth:"admin"`
+}
+
+type routeInfoRes struct{}
+
+type routeInfoController struct{}
+
+func (c *routeInfoController) Update(_ context.Context, _ *routeInfoReq) (*routeInfoRes, error) {
+	return &routeInfoRes{}, nil
+}
+
+func routeInfoHandler(r *Request) {
+	r.String(http.StatusOK, "ok")
+}
+
+// TestRequest_RouteInfo tests the information of the matched route seen by the middlewares
+func TestRequest_RouteInfo(t *testing.T) {
+	var info *RouteInfo
+	capture := func(r *Request) {
+		info = r.RouteInfo()
+		r.Next()
+	}
+	s := New()
+	s.Use(capture)
+	s.GET("/users/:id", routeInfoHandler)
+	s.BindObject(&routeInfoController{})
+	s.Group("/api").GET("/items", routeInfoHandler, capture)
+	s.Any("/any", routeInfoHandler)
+	s.bindRoutes(context.Background())
+	serve := func(method, path string) {
+		info = nil
+		s.handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+
+	// handler route
+	serve(http.MethodGet, "/users/1")
+	require.NotNil(t, info)
+	assert.Equal(t, http.MethodGet, info.Method)
+	assert.Equal(t, "/users/:id", info.Path)
+	assert.Contains(t, info.Handler, "routeInfoHandler")
+	assert.Nil(t, info.Get("summary"))
+
+	// controller route with meta
+	serve(http.MethodPut, "/orders/1")
+	require.NotNil(t, info)
+	assert.Equal(t, "/orders/:id", info.Path)
+	assert.Equal(t, "routeInfoController.Update", info.Handler)
+	assert.Equal(t, "Update order", info.Get("summary").String())
+	assert.Equal(t, "admin", info.Get("auth").String())
+	assert.Nil(t, info.Get("missing"))
+
+	// group prefix
+	serve(http.MethodGet, "/api/items")
+	require.NotNil(t, info)
+	assert.Equal(t, "/api/items", info.Path)
+
+	// any method
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		serve(method, "/any")
+		require.NotNil(t, info, method)
+		assert.Equal(t, method, info.Method)
+		assert.Equal(t, "/any", info.Path)
+	}
+
+	// no route
+	serve(http.MethodGet, "/missing")
+	assert.Nil(t, info)
+	var missing *RouteInfo
+	assert.Nil(t, missing.Get("summary"))
+}
@@ -0,0 +1,117 @@
+package mhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/util/mmeta"
+	"github.com/stretchr/testify/assert"
+)
+
+type secureTestReq struct {
+	mmeta.Meta `path:"/embed" method:"GET" secure_headers:"X-Frame-Options, content-security-policy"`
+}
+
+type secureTestPublicReq struct {
+	mmeta.Meta `path:"/public" method:"GET" secure_headers:"-"`
+}
+
+type secureTestRes struct{}
+
+type secureTestController struct{}
+
+func (c *secureTestController) Embed(ctx context.Context, req *secureTestReq) (*secureTestRes, error) {
+	return &secureTestRes{}, nil
+}
+
+func (c *secureTestController) Public(ctx context.Context, req *secureTestPublicReq) (*secureTestRes, error) {
+	return &secureTestRes{}, nil
+}
+
+func newSecureTestServer(config SecureHeadersConfig) *Server {
+	s := New()
+	_ = s.SetTrustedProxies("10.0.0.0/8")
+	s.Use(MiddlewareSecureHeaders(config))
+	s.GET("/ping", func(r *Request) { r.String(http.StatusOK, "pong") })
+	s.BindObject(&secureTestController{})
+	s.bindRoutes(context.Background())
+	return s
+}
+
+func serveSecure(s *Server, target string, modify func(req *http.Request)) http.Header {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if modify != nil {
+		modify(req)
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+	return w.Header()
+}
+
+func TestMiddlewareSecureHeaders_Recommended(t *testing.T) {
+	s := newSecureTestServer(RecommendedSecureHeadersConfig())
+
+	h := serveSecure(s, "/ping", nil)
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", h.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", h.Get("Referrer-Policy"))
+	assert.NotEmpty(t, h.Get("Content-Security-Policy"))
+	assert.NotEmpty(t, h.Get("Permissions-Policy"))
+	// plain HTTP requests get no HSTS
+	assert.Empty(t, h.Get("Strict-Transport-Security"))
+
+	h = serveSecure(s, "/ping", func(req *http.Request) { req.TLS = &tls.ConnectionState{} })
+	assert.Equal(t, "max-age=31536000; includeSubDomains", h.Get("Strict-Transport-Security"))
+
+	// X-Forwarded-Proto is honored only from trusted proxies
+	h = serveSecure(s, "/ping", func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "https") })
+	assert.Empty(t, h.Get("Strict-Transport-Security"))
+	h = serveSecure(s, "/ping", func(req *http.Request) {
+		req.RemoteAddr = "10.1.1.1:1234"
+		req.Header.Set("X-Forwarded-Proto", "https")
+	})
+	assert.NotEmpty(t, h.Get("Strict-Transport-Security"))
+}
+
+func TestMiddlewareSecureHeaders_RouteOptOut(t *testing.T) {
+	s := newSecureTestServer(RecommendedSecureHeadersConfig())
+
+	h := serveSecure(s, "/embed", nil)
+	assert.Empty(t, h.Get("X-Frame-Options"))
+	assert.Empty(t, h.Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+
+	h = serveSecure(s, "/public", nil)
+	assert.Empty(t, h.Get("X-Content-Type-Options"))
+	assert.Empty(t, h.Get("Referrer-Policy"))
+}
+
+func TestMiddlewareSecureHeaders_Custom(t *testing.T) {
+	config := RecommendedSecureHeadersConfig()
+	config.HSTSMaxAge = time.Hour
+	config.HSTSIncludeSubDomains = false
+	config.HSTSPreload = true
+	config.Headers = map[string]string{
+		"x-frame-options":    "SAMEORIGIN",
+		"Permissions-Policy": "",
+		"X-Custom":           "yes",
+	}
+	s := newSecureTestServer(config)
+	h := serveSecure(s, "/ping", func(req *http.Request) { req.TLS = &tls.ConnectionState{} })
+	assert.Equal(t, "max-age=3600; preload", h.Get("Strict-Transport-Security"))
+	assert.Equal(t, "SAMEORIGIN", h.Get("X-Frame-Options"))
+	assert.Empty(t, h.Get("Permissions-Policy"))
+	assert.Equal(t, "yes", h.Get("X-Custom"))
+
+	// fully custom mode only sets what is defined
+	s = newSecureTestServer(SecureHeadersConfig{ContentTypeOptions: "nosniff"})
+	h = serveSecure(s, "/ping", func(req *http.Request) { req.TLS = &tls.ConnectionState{} })
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Empty(t, h.Get("Strict-Transport-Security"))
+	assert.Empty(t, h.Get("X-Frame-Options"))
+}
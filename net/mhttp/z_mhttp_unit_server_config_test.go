@@ -0,0 +1,133 @@
+package mhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/graingo/maltose/os/mlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_SetConfigWithMap(t *testing.T) {
+	var logs bytes.Buffer
+	s := New()
+	logger := mlog.New()
+	logger.SetStdoutPrint(false)
+	logger.AddWriter(&logs)
+	s.SetLogger(logger)
+	s.SetConfigWithMap(map[string]any{
+		"address":          ":9000",
+		"readTimeout":      "10s",
+		"Write-Timeout":    "20s",
+		"maxBodySize":      "1KB",
+		"tlsCertFile":      "/etc/tls/cert.pem",
+		"graceful_timeout": "15s",
+		"pprofPath":        "/debug/pprof",
+		"healthPath":       "/healthz",
+		"metricsEnable":    false,
+		"trusted_proxies":  "10.0.0.0/8, 192.168.0.0/16",
+		"response_types":   []any{"Application/JSON", "application/xml"},
+		"cookie_same_site": "strict",
+		"form_max_depth":   "4",
+		"read_timout":      "5s",
+		"admin":            map[string]any{"address": ":9001"},
+		"logger":           map[string]any{"level": "debug"},
+	})
+
+	assert.Equal(t, ":9000", s.config.Address)
+	assert.Equal(t, 10*time.Second, s.config.ReadTimeout)
+	assert.Equal(t, 20*time.Second, s.config.WriteTimeout)
+	assert.Equal(t, mcfg.ByteSize(1000), s.config.MaxBodySize)
+	assert.Equal(t, "/etc/tls/cert.pem", s.config.TLSCertFile)
+	assert.Equal(t, 15*time.Second, s.config.GracefulTimeout)
+	assert.Equal(t, "/debug/pprof", s.config.PProfPath)
+	assert.Equal(t, "/healthz", s.config.HealthPath)
+	assert.False(t, s.config.MetricsEnable)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.0.0/16"}, s.config.TrustedProxies)
+	assert.Len(t, s.proxies, 2)
+	assert.Equal(t, []string{MediaTypeJSON, MediaTypeXML}, s.config.ResponseTypes)
+	assert.Equal(t, http.SameSiteStrictMode, s.config.CookieSameSite)
+	assert.Equal(t, 4, s.config.FormMaxDepth)
+	// the fields which are not set keep their values
+	assert.Equal(t, 60*time.Second, s.config.IdleTimeout)
+	assert.Same(t, logger, s.Logger())
+
+	// only the unknown keys are reported, not the nested nodes
+	assert.Contains(t, logs.String(), "unknown server config keys: read_timout")
+	assert.NotContains(t, logs.String(), "admin")
+
+	// the invalid values are reported, the valid ones are applied
+	logs.Reset()
+	s.SetConfigWithMap(map[string]any{"idle_timeout": "soon", "address": ":9002", "trusted_proxies": "invalid"})
+	assert.Equal(t, ":9002", s.config.Address)
+	assert.Contains(t, logs.String(), "IdleTimeout")
+	assert.Contains(t, logs.String(), "set trusted proxies failed")
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.0.0/16"}, s.config.TrustedProxies)
+}
+
+func TestServer_MaxBodySize(t *testing.T) {
+	s := New()
+	s.SetConfigWithMap(map[string]any{"max_body_size": 8, "health_path": "/healthz"})
+	s.POST("/echo", func(r *Request) {
+		body, err := io.ReadAll(r.Request.Body)
+		if err != nil {
+			r.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		r.String(http.StatusOK, string(body))
+	})
+	s.registerDebug(context.Background())
+	s.bindRoutes(context.Background())
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+	w := serve(http.MethodPost, "/echo", "small")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "small", w.Body.String())
+
+	w = serve(http.MethodPost, "/echo", "larger than eight bytes")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = serve(http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+func TestServer_WatchConfig(t *testing.T) {
+	ctx := context.Background()
+	adapter, err := mcfg.NewAdapterFile()
+	assert.NoError(t, err)
+	config := mcfg.NewWithAdapter(adapter)
+	assert.NoError(t, config.LoadFromBytes([]byte("server:\n  address: \":9000\"\n  logger:\n    level: info\n"), "yaml"))
+
+	s := New()
+	s.SetLogger(mlog.New())
+	s.Logger().SetLevel(mlog.InfoLevel)
+	s.WatchConfig(config, "server")
+
+	assert.NoError(t, config.Set(ctx, "server.address", ":9001"))
+	assert.Equal(t, mlog.InfoLevel, s.Logger().GetLevel())
+
+	assert.NoError(t, config.Set(ctx, "server.logger.level", "debug"))
+	assert.Equal(t, mlog.DebugLevel, s.Logger().GetLevel())
+	assert.Equal(t, defaultPort, s.config.Address)
+}
+
+func TestServer_Logger(t *testing.T) {
+	s := New()
+	assert.Same(t, mlog.Instance(), s.Logger())
+
+	logger := mlog.New()
+	s.SetLogger(logger)
+	assert.Same(t, logger, s.Logger())
+}
@@ -0,0 +1,50 @@
+package mhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorStatus tests that error responses use the HTTP status of the error code
+func TestErrorStatus(t *testing.T) {
+	serve := func(s *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	routes := func(s *Server) {
+		s.GET("/missing", func(r *Request) { r.Error(merror.NewCode(mcode.CodeNotFound, "user not found")) })
+		s.GET("/invalid", func(r *Request) {
+			r.Error(merror.Wrap(merror.NewCode(mcode.CodeValidationFailed, "name is required"), "bind"))
+		})
+		s.GET("/plain", func(r *Request) { r.Error(merror.New("boom")) })
+		s.GET("/denied", func(r *Request) { r.Status(http.StatusUnauthorized) })
+		s.bindRoutes(context.Background())
+	}
+
+	// default response
+	s := New()
+	routes(s)
+	assert.Equal(t, http.StatusNotFound, serve(s, "/missing").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, "/invalid").Code)
+	assert.Equal(t, http.StatusInternalServerError, serve(s, "/plain").Code)
+
+	// standard response
+	s = New()
+	s.Use(MiddlewareResponse())
+	routes(s)
+	w := serve(s, "/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":104`)
+	assert.Equal(t, http.StatusBadRequest, serve(s, "/invalid").Code)
+	assert.Equal(t, http.StatusInternalServerError, serve(s, "/plain").Code)
+	w = serve(s, "/denied")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":105`)
+}
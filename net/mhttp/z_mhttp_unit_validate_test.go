@@ -0,0 +1,60 @@
+package mhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/util/mmeta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateUserReq struct {
+	mmeta.Meta `path:"/users" method:"POST"`
+	Name       string `json:"name" binding:"required"`
+	Age        int    `json:"age" binding:"gte=18"`
+}
+
+type validateUserRes struct{}
+
+type validateController struct{}
+
+func (c *validateController) Create(_ context.Context, _ *validateUserReq) (*validateUserRes, error) {
+	return &validateUserRes{}, nil
+}
+
+// TestValidateAllErrors tests returning the first or all field errors of a request
+func TestValidateAllErrors(t *testing.T) {
+	serve := func(configMap map[string]any) map[string]any {
+		s := New()
+		s.SetConfigWithMap(configMap)
+		s.Use(MiddlewareResponse())
+		s.BindObject(&validateController{})
+		s.bindRoutes(context.Background())
+
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"age": 7}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := serve(map[string]any{})
+	assert.Equal(t, map[string]any{"field": "name"}, body["details"])
+
+	body = serve(map[string]any{"validateAllErrors": true})
+	details, ok := body["details"].([]any)
+	require.True(t, ok, "expected the field errors, got %v", body)
+	require.Len(t, details, 2)
+	assert.Equal(t, map[string]any{"field": "name"}, details[0].(map[string]any)["details"])
+	assert.Equal(t, map[string]any{"field": "age"}, details[1].(map[string]any)["details"])
+	assert.Contains(t, body["message"], "name")
+	assert.Contains(t, body["message"], "age")
+}
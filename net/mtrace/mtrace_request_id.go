@@ -0,0 +1,22 @@
+package mtrace
+
+import "context"
+
+// requestIDCtxKey is the context key of the request id.
+type requestIDCtxKey struct{}
+
+// WithRequestID injects the request id into the context.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, requestID)
+}
+
+// GetRequestID gets the request id from the context.
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDCtxKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
@@ -2,10 +2,13 @@ package mcfg
 
 import (
 	"context"
-	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/graingo/maltose/container/minstance"
 	"github.com/graingo/maltose/container/mvar"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/internal/intlog"
 )
 
 var (
@@ -14,7 +17,20 @@ var (
 
 // Config 是配置管理对象
 type Config struct {
-	adapter Adapter
+	adapter       Adapter            // 配置来源，默认为 AdapterFile，见 SetAdapter
+	mu            sync.Mutex         // 保护适配器、回调和覆盖配置的来源
+	env           envOptions         // 环境变量覆盖层，见 SetEnvPrefix
+	flags         FlagSource         // 命令行参数覆盖层，见 BindFlags
+	defaults      map[string]any     // 优先级最低的默认值，见 SetDefault
+	overrides     map[string]any     // 优先级最高的内存覆盖层，见 Set
+	sensitive     []string           // 敏感配置键的模式，见 SetSensitivePatterns
+	interpolation bool               // 是否替换配置值中的引用，见 EnableInterpolation
+	secrets       secretFiles        // 从文件读取的配置值，见 EnableSecretFiles
+	decryption    decryption         // 加密配置值的解密，见 SetDecryptFunc
+	keyMatching   KeyMatching        // 配置键的匹配方式，见 SetKeyMatching
+	subs          map[string]*Config // 子配置，见 Sub
+	onChange      []*changeHandler   // 配置变更的回调，见 OnChange
+	onChangeError []ChangeErrorFunc  // 重新加载失败的回调，见 OnChangeError
 }
 
 const (
@@ -45,46 +61,53 @@ func NewWithAdapter(adapter Adapter) *Config {
 // Instance 返回一个具有默认设置的 Config 实例
 // 参数 `name` 是实例的名称。但需要注意的是，如果配置目录中存在文件 "name.yaml"，则将其设置为默认配置文件
 //
-// 注意：如果配置目录中存在文件 "name.yaml"，则将其设置为默认配置文件
+// 注意：如果配置目录中存在文件 "name.yaml"，则将其设置为默认配置文件，查找顺序见 AddSearchPath
 // 如果配置目录中不存在文件 "name.yaml"，则使用默认配置文件名 "config"
+// 配置文件不存在或读取失败时配置为空且 Available 返回 false，需要启动失败的应用调用 Load 获取错误
 func Instance(name ...string) *Config {
 	var instanceName = DefaultInstanceName
 	if len(name) > 0 && name[0] != "" {
 		instanceName = name[0]
 	}
 
-	return instances.GetOrSetFunc(instanceName, func() any {
-		adapterFile, err := NewAdapterFile()
+	return minstance.GetOrSet(instances, instanceName, func() *Config {
+		adapterFile, err := newAdapterFile()
 		if err != nil {
-			_ = fmt.Errorf(`create config instance failed: %+v`, err)
-			return nil
+			intlog.Errorf(context.Background(), "%+v", merror.Wrapf(err, `create config instance "%s" failed`, instanceName))
 		}
 		if instanceName != DefaultInstanceName {
-			adapterFile.SetFileName(instanceName)
+			if path, _ := searchFile(instanceName); path != "" {
+				adapterFile.SetFileName(instanceName)
+			}
 		}
 		return NewWithAdapter(adapterFile)
-	}).(*Config)
+	})
 }
 
-// SetAdapter 设置配置适配器
+// SetAdapter 设置配置适配器，用于从文件以外的来源读取配置，例如 contrib/config 下的 etcd 和 nacos。
+// 适配器实现 WatchAdapter 时支持 Watch 热加载，运行中更换适配器对并发的读取是安全的。
 func (c *Config) SetAdapter(adapter Adapter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.adapter = adapter
 }
 
 // GetAdapter 获取配置适配器
 func (c *Config) GetAdapter() Adapter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.adapter
 }
 
 // Get 获取指定键的配置值
-// 可选参数 `def` 是默认值，如果配置值为空，则返回默认值
-// 如果配置值为空，并且没有提供默认值，则返回 nil
+// 配置键以 "." 分隔各级的键名，数字的段是数组的索引，例如 "upstreams.0.host"；
+// 键名中的点写作 "\."，例如 "hosts.example\.com"。键不存在、索引越界或经过标量值时返回 nil，
+// 键是 map 或数组时返回整个子树，见 GetStringMap。
+// 可选参数 `def` 是默认值，如果配置不存在，则返回默认值；值为空字符串、0 或 false 时返回该值，YAML 的 null 视为不存在
+// 如果配置不存在，并且没有提供默认值，则返回 nil
+// 优先级为：Set > 命令行参数 > 环境变量 > 配置 > SetDefault 的默认值 > `def`，见 BindFlags 和 AutomaticEnv
 func (c *Config) Get(ctx context.Context, pattern string, def ...any) (*mvar.Var, error) {
-	var (
-		err   error
-		value any
-	)
-	value, err = c.adapter.Get(ctx, pattern)
+	value, err := c.effective(ctx, pattern, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -97,13 +120,113 @@ func (c *Config) Get(ctx context.Context, pattern string, def ...any) (*mvar.Var
 	return mvar.New(value), nil
 }
 
-// Data 获取所有配置数据
+// effective 返回配置键 `pattern` 的有效值，包括默认值，开启时替换其中的引用、读取文件中的值并解密，
+// `stack` 是正在插值的配置键，见 EnableInterpolation、EnableSecretFiles 和 SetDecryptFunc
+func (c *Config) effective(ctx context.Context, pattern string, stack []string) (any, error) {
+	pattern = c.foldKey(pattern)
+	value, err := c.resolve(ctx, pattern, stack)
+	if err != nil {
+		return nil, err
+	}
+	return c.decrypt(pattern, value)
+}
+
+// resolve 返回配置键 `pattern` 解密前的有效值，见 effective
+func (c *Config) resolve(ctx context.Context, pattern string, stack []string) (any, error) {
+	pattern = c.foldKey(pattern)
+	value, err := c.value(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if defaults := c.defaultValue(pattern); defaults != nil {
+		value = withDefaults(value, defaults)
+	}
+	if value != nil && c.interpolationEnabled() {
+		if value, err = c.interpolate(ctx, pattern, value, stack); err != nil {
+			return nil, err
+		}
+	}
+	if c.secretSuffix() != "" {
+		return c.resolveSecrets(ctx, pattern, value, stack)
+	}
+	return value, nil
+}
+
+// value 返回配置来源中配置键 `pattern` 的值，包括 Set、命令行参数和环境变量覆盖的值，不包括默认值
+func (c *Config) value(ctx context.Context, pattern string) (any, error) {
+	pattern = c.foldKey(pattern)
+	override, overridden, hidden := c.override(splitKey(strings.ToLower(pattern)))
+	if hidden {
+		return nil, nil
+	}
+	if _, isMap := override.(map[string]any); overridden && !isMap {
+		return override, nil
+	}
+	layers := c.layers()
+	if v, ok := lookupLayers(layers, pattern); ok && !overridden {
+		return v, nil
+	}
+	value, err := c.reader().Get(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) > 0 {
+		value = applyLayers(layers, pattern, value)
+	}
+	if overridden {
+		value = overlayValue(value, override)
+	}
+	return value, nil
+}
+
+// Data 获取所有配置数据，包括 Set、命令行参数和环境变量覆盖的值以及默认值
 func (c *Config) Data(ctx context.Context) (map[string]any, error) {
-	return c.adapter.Data(ctx)
+	data, err := c.data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := c.decrypt("", data)
+	if err != nil {
+		return nil, err
+	}
+	data, _ = decrypted.(map[string]any)
+	return data, nil
+}
+
+// data 返回解密前的所有配置数据，见 Data
+func (c *Config) data(ctx context.Context) (map[string]any, error) {
+	data, err := c.reader().Data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if layers := c.layers(); len(layers) > 0 {
+		data, _ = applyLayers(layers, "", data).(map[string]any)
+	}
+	if overrides, _, _ := c.override(nil); overrides != nil {
+		data, _ = overlayValue(data, overrides).(map[string]any)
+	}
+	if defaults := c.defaultValue(""); defaults != nil {
+		data, _ = withDefaults(data, defaults).(map[string]any)
+	}
+	if data != nil && c.interpolationEnabled() {
+		interpolated, err := c.interpolate(ctx, "", data, nil)
+		if err != nil {
+			return nil, err
+		}
+		data, _ = interpolated.(map[string]any)
+	}
+	if data != nil && c.secretSuffix() != "" {
+		resolved, err := c.resolveSecrets(ctx, "", data, nil)
+		if err != nil {
+			return nil, err
+		}
+		data, _ = resolved.(map[string]any)
+	}
+	return data, nil
 }
 
-// Available 检查适配器是否可用
+// Available 检查适配器是否可用，对于文件适配器是否读取了配置文件，见 Load
 // 可选参数 `resource` 是资源名称，如果资源名称不为空，则检查资源是否可用
 func (c *Config) Available(ctx context.Context, resource ...string) bool {
-	return c.adapter.Available(ctx, resource...)
+	return c.GetAdapter().Available(ctx, resource...)
 }
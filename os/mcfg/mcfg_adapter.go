@@ -2,7 +2,8 @@ package mcfg
 
 import "context"
 
-// Adapter 定义配置适配器接口
+// Adapter 定义配置适配器接口，是配置的来源，默认的实现是读取配置文件的 AdapterFile。
+// 其他来源可在外部的包中实现，并通过 Config.SetAdapter 设置，命令行参数、环境变量和默认值对所有适配器生效。
 type Adapter interface {
 	// Get 获取指定键的配置值
 	Get(ctx context.Context, pattern string) (any, error)
@@ -14,3 +15,12 @@ type Adapter interface {
 	// 可选参数 `resource` 指定某些配置资源。
 	Available(ctx context.Context, resource ...string) bool
 }
+
+// WatchAdapter 是支持监听配置变更的适配器，见 Config.Watch
+type WatchAdapter interface {
+	Adapter
+
+	// Watch 监听配置变更，直到 `ctx` 被取消。
+	// 每次重新加载后以变更前后的配置数据调用 `fn`，加载失败时以错误调用 `fn` 并保留上次有效的配置。
+	Watch(ctx context.Context, fn func(old, new map[string]any, err error)) error
+}
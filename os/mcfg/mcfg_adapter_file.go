@@ -2,72 +2,267 @@ package mcfg
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/internal/intlog"
 	"github.com/spf13/viper"
 )
 
+// watchDebounce 是合并配置文件连续变更的时间窗口，例如编辑器先写临时文件再重命名
+const watchDebounce = 100 * time.Millisecond
+
 var (
-	supportedFileTypes = []string{"yaml", "yml", "json", "toml", "properties", "ini"}
-	defaultConfigDir   = []string{"/", "config/", "config", "/config", "/config/", "./config"}
+	// defaultConfigDir 是默认的配置目录，在 AddSearchPath 注册的目录之后查找
+	defaultConfigDir = []string{"/", "config/", "config", "/config", "/config/", "./config", "."}
 )
 
 type AdapterFile struct {
-	v        *viper.Viper
-	fileName string
+	v          atomic.Pointer[viper.Viper] // 当前的配置，重新加载时原子替换
+	mu         sync.Mutex                  // 保护配置文件列表，串行化配置文件的读取
+	fileName   string
+	files      []string       // 按顺序合并的配置文件，见 SetFiles
+	paths      []string       // 当前的配置读取的所有文件的绝对路径，包括引用的文件
+	sliceMerge SliceMerge     // 合并多个配置文件时数组的合并方式
+	profile    string         // 当前的环境，见 SetProfile
+	content    map[string]any // 在配置文件之后合并的配置内容，见 SetContent
 }
 
-// NewAdapterFile 创建一个新的文件适配器
+// NewAdapterFile 创建一个新的文件适配器，读取默认配置文件 "config" 和当前环境的配置文件，见 SetProfile。
+// 配置文件的查找顺序见 AddSearchPath，没有配置文件时配置为空，读取失败时返回错误。
 func NewAdapterFile() (*AdapterFile, error) {
-	v := viper.New()
-	v.SetConfigName(DefaultConfigFileName)
-
-	for _, dir := range defaultConfigDir {
-		v.AddConfigPath(dir)
+	a, err := newAdapterFile()
+	if err != nil {
+		return nil, err
 	}
+	return a, nil
+}
 
-	v.ReadInConfig()
-	return &AdapterFile{
-		v:        v,
-		fileName: DefaultConfigFileName,
-	}, nil
+// newAdapterFile 创建一个新的文件适配器，配置文件读取失败时返回配置为空的适配器和错误
+func newAdapterFile() (*AdapterFile, error) {
+	a := &AdapterFile{profile: envProfile()}
+	if err := a.setFileName(DefaultConfigFileName); err != nil && !IsFileNotFound(err) {
+		return a, err
+	}
+	return a, nil
 }
 
-// SetFileName 设置配置文件名
+// SetFileName 设置配置文件名并重新读取配置文件，查找顺序见 AddSearchPath
 func (c *AdapterFile) SetFileName(name string) {
+	if err := c.setFileName(name); err != nil && !IsFileNotFound(err) {
+		intlog.Errorf(context.Background(), "%+v", err)
+	}
+}
+
+// setFileName 设置配置文件名并重新读取配置文件，没有配置文件或读取失败时配置为空，
+// 没有配置文件时返回 FileNotFoundError
+func (c *AdapterFile) setFileName(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var files []string
+	path, tried := searchFile(name)
+	if path != "" {
+		files = []string{path}
+	}
+	err := c.load(files, c.sliceMerge)
+	if err != nil {
+		_ = c.load(nil, c.sliceMerge)
+	}
 	c.fileName = name
-	c.v.SetConfigName(name)
-	// 重新读取配置文件
-	c.v.ReadInConfig()
+	if err == nil && path == "" {
+		err = &FileNotFoundError{Name: name, Paths: tried}
+	}
+	return err
+}
+
+// SetConfigFile 设置配置文件的路径，替代按文件名查找，文件不存在时返回 FileNotFoundError
+func (c *AdapterFile) SetConfigFile(path string) error {
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return &FileNotFoundError{Name: path, Paths: []string{path}}
+	}
+	return c.SetFiles(path)
 }
 
-// Get 获取配置值
+// readFile 以扩展名对应的解析函数读取配置文件 `path`
+func readFile(path string) (map[string]any, error) {
+	if _, ok := decoder(filepath.Ext(path)); !ok {
+		return nil, merror.NewCodef(mcode.CodeNotSupported, `unsupported config file type "%s"`, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `read config file "%s" failed`, path)
+	}
+	return decodeContent(content, filepath.Ext(path), fmt.Sprintf(`config file "%s"`, path))
+}
+
+// decodeContent 以格式 `format` 的解析函数解析配置内容 `content`，`source` 是内容的描述，用于错误信息
+func decodeContent(content []byte, format string, source string) (map[string]any, error) {
+	decode, ok := decoder(format)
+	if !ok {
+		return nil, merror.NewCodef(mcode.CodeNotSupported, `unsupported config format "%s" of %s`, format, source)
+	}
+	data, err := decode(content)
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `parse %s failed`, source)
+	}
+	m, _ := normalizeValue(data).(map[string]any)
+	if m == nil {
+		m = map[string]any{}
+	}
+	return m, nil
+}
+
+// String 返回读取的配置文件的描述，用于错误信息
+func (c *AdapterFile) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var description string
+	switch len(c.files) {
+	case 0:
+		description = fmt.Sprintf(`config file "%s" (not found)`, c.fileName)
+	case 1:
+		description = fmt.Sprintf(`config file "%s"`, c.files[0])
+	default:
+		description = fmt.Sprintf(`config files "%s"`, strings.Join(c.files, `", "`))
+	}
+	if c.content != nil {
+		description += " and config content"
+	}
+	if c.profile != "" {
+		description += fmt.Sprintf(` with profile "%s"`, c.profile)
+	}
+	return description
+}
+
+// Get 获取配置值，配置键的语法见 Config.Get
 func (c *AdapterFile) Get(ctx context.Context, pattern string) (any, error) {
-	return c.v.Get(pattern), nil
+	var (
+		v     = c.v.Load()
+		path  = splitKey(pattern)
+		value any
+	)
+	// viper 以 "." 分隔键名，因此含有点的键名从全部配置中查找
+	if strings.Contains(path[0], ".") {
+		value = v.AllSettings()[strings.ToLower(path[0])]
+	} else {
+		value = v.Get(path[0])
+	}
+	value, _ = lookupPath(value, path[1:])
+	return value, nil
 }
 
 // Data 获取所有配置数据
 func (c *AdapterFile) Data(ctx context.Context) (map[string]any, error) {
-	return c.v.AllSettings(), nil
+	return c.v.Load().AllSettings(), nil
 }
 
-// Available 检查和后端配置服务是否可用。
-// 可选参数 `resource` 指定某些配置资源。
-func (c *AdapterFile) Available(ctx context.Context, resource ...string) bool {
-	checkFileName := c.fileName
-	if len(resource) > 0 && resource[0] != "" {
-		checkFileName = resource[0]
+// Watch 监听配置文件的变更并重新加载，直到 `ctx` 被取消，实现 WatchAdapter 接口。
+// 监听的是配置文件所在的目录，因此支持先写临时文件再重命名的编辑器；合并多个配置文件时监听所有的文件，
+// 包括引用的文件。连续的变更在 watchDebounce 内合并为一次重新加载，加载失败时保留上次有效的配置。
+func (c *AdapterFile) Watch(ctx context.Context, fn func(old, new map[string]any, err error)) error {
+	paths := c.watchedPaths()
+	if len(paths) == 0 {
+		return merror.NewCodef(mcode.CodeMissingConfiguration, `config file "%s" not found`, c.fileName)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return merror.WrapCode(err, mcode.CodeOperationFailed, `create config file watcher failed`)
 	}
+	if err = watchDirs(watcher, paths); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go c.watch(ctx, watcher, fn)
+	return nil
+}
+
+// watchDirs 监听配置文件 `paths` 所在的目录
+func watchDirs(watcher *fsnotify.Watcher, paths []string) error {
+	for _, path := range paths {
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			return merror.WrapCodef(err, mcode.CodeOperationFailed, `watch config file "%s" failed`, path)
+		}
+	}
+	return nil
+}
 
-	for _, dir := range defaultConfigDir {
-		for _, fileType := range supportedFileTypes {
-			path := dir + checkFileName + "." + fileType
-			if IsExist(path) {
-				return true
+// watch 处理配置文件所在目录的事件，直到 `ctx` 被取消
+func (c *AdapterFile) watch(ctx context.Context, watcher *fsnotify.Watcher, fn func(old, new map[string]any, err error)) {
+	watchEvents(ctx, watcher, watchDebounce, func(event fsnotify.Event) bool {
+		return slices.Contains(c.watchedPaths(), filepath.Clean(event.Name))
+	}, func() {
+		c.reload(fn)
+		// 重新加载后可能引用了其他目录的文件
+		if err := watchDirs(watcher, c.watchedPaths()); err != nil {
+			fn(nil, nil, err)
+		}
+	}, func(err error) {
+		fn(nil, nil, merror.WrapCode(err, mcode.CodeOperationFailed, `watch config file failed`))
+	})
+}
+
+// watchEvents 处理 `watcher` 的事件，直到 `ctx` 被取消，返回时关闭 `watcher`：
+// `match` 为 nil 或返回 true 的事件在 `debounce` 内合并为一次 `fire` 调用，监听的错误传给 `fail`
+func watchEvents(ctx context.Context, watcher *fsnotify.Watcher, debounce time.Duration, match func(event fsnotify.Event) bool, fire func(), fail func(err error)) {
+	defer watcher.Close()
+	var (
+		timer   = time.NewTimer(debounce)
+		pending = false // 是否有等待处理的变更
+	)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || (match != nil && !match(event)) {
+				continue
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(debounce)
+			pending = true
+		case <-timer.C:
+			if pending {
+				pending = false
+				fire()
 			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fail(err)
 		}
 	}
-	return false
+}
+
+// Available 检查是否读取了配置文件或设置了配置内容。
+// 可选参数 `resource` 是配置文件名，检查是否能找到该配置文件。
+func (c *AdapterFile) Available(ctx context.Context, resource ...string) bool {
+	if len(resource) > 0 && resource[0] != "" {
+		path, _ := searchFile(resource[0])
+		return path != ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.files) > 0 || c.content != nil
 }
 
 // IsExist 检查文件是否存在
@@ -0,0 +1,59 @@
+package mcfg
+
+// SetContent 设置名为 `name` 的实例的 YAML 或 JSON 配置内容，默认为默认实例，见 Config.LoadFromBytes
+func SetContent(content string, name ...string) error {
+	return Instance(name...).LoadFromBytes([]byte(content), "yaml")
+}
+
+// ClearContent 清除名为 `name` 的实例的配置内容，默认为默认实例，见 Config.ClearContent
+func ClearContent(name ...string) error {
+	return Instance(name...).ClearContent()
+}
+
+// LoadFromBytes 以格式 `format`（例如 "yaml"、"json" 或已注册的扩展名，见 RegisterDecoder）解析配置内容 `data`，
+// 用于测试中不需要临时文件的配置和以 go:embed 嵌入的默认配置。配置内容在所有配置文件之后合并，
+// 因此优先级高于配置文件，合并方式见 SetFiles；环境变量、插值和类型化的读取方法与配置文件相同。
+// 再次调用时替换之前的配置内容，解析失败时返回错误并保留当前的配置。
+func (c *Config) LoadFromBytes(data []byte, format string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.SetContent(data, format)
+}
+
+// ClearContent 清除 LoadFromBytes 设置的配置内容，用于测试之间重置配置
+func (c *Config) ClearContent() error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.ClearContent()
+}
+
+// SetContent 设置在配置文件之后合并的配置内容，见 Config.LoadFromBytes
+func (c *AdapterFile) SetContent(data []byte, format string) error {
+	content, err := decodeContent(data, format, "config content")
+	if err != nil {
+		return err
+	}
+	return c.setContent(content)
+}
+
+// ClearContent 清除配置内容
+func (c *AdapterFile) ClearContent() error {
+	return c.setContent(nil)
+}
+
+// setContent 设置配置内容并重新读取配置文件，失败时保留当前的配置内容
+func (c *AdapterFile) setContent(content map[string]any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.content
+	c.content = content
+	if err := c.load(c.files, c.sliceMerge); err != nil {
+		c.content = old
+		return err
+	}
+	return nil
+}
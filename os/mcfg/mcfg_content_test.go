@@ -0,0 +1,69 @@
+package mcfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_LoadFromBytes(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", mergeCommon)
+	c.EnableInterpolation(true)
+	content := `{"server": {"address": ":9000", "url": "http://localhost${server.address}"}, "pool": {"timeout": "5s"}}`
+	if err := c.LoadFromBytes([]byte(content), "json"); err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+
+	// 配置内容在配置文件之后合并
+	if v := c.GetString(ctx, "server.address"); v != ":9000" {
+		t.Errorf("GetString() expected :9000 of the content, got %v", v)
+	}
+	if v := c.GetDuration(ctx, "server.timeout"); v != 30*time.Second {
+		t.Errorf("GetDuration() expected 30s of the file, got %v", v)
+	}
+	if v := c.GetString(ctx, "server.url"); v != "http://localhost:9000" {
+		t.Errorf("GetString() expected the interpolated url, got %v", v)
+	}
+	if v := c.GetDuration(ctx, "pool.timeout"); v != 5*time.Second {
+		t.Errorf("GetDuration() expected 5s, got %v", v)
+	}
+
+	if err := c.LoadFromBytes([]byte("server: [invalid"), "yaml"); err == nil {
+		t.Error("LoadFromBytes() expected the parse error")
+	}
+	if err := c.LoadFromBytes([]byte("a = 1"), "ini"); err == nil {
+		t.Error("LoadFromBytes() expected the unsupported format error")
+	}
+	if v := c.GetString(ctx, "server.address"); v != ":9000" {
+		t.Errorf("GetString() expected the content kept after the error, got %v", v)
+	}
+
+	if err := c.ClearContent(); err != nil {
+		t.Fatalf("ClearContent() error = %v", err)
+	}
+	if v := c.GetString(ctx, "server.address"); v != ":8000" {
+		t.Errorf("GetString() expected :8000 of the file, got %v", v)
+	}
+}
+
+func TestSetContent(t *testing.T) {
+	chdirTemp(t, "other.yaml", "")
+	if err := mcfg.SetContent("app:\n  name: embedded\n", "contenttest"); err != nil {
+		t.Fatalf("SetContent() error = %v", err)
+	}
+	c := mcfg.Instance("contenttest")
+	if !c.Available(ctx) || c.GetString(ctx, "app.name") != "embedded" {
+		t.Errorf("Instance() expected the content, got %v", c.GetString(ctx, "app.name"))
+	}
+	if err := c.Load(ctx); err != nil {
+		t.Errorf("Load() expected no error with the content, got %v", err)
+	}
+
+	if err := mcfg.ClearContent("contenttest"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Available(ctx) || c.GetString(ctx, "app.name") != "" {
+		t.Errorf("ClearContent() expected the empty config, got %v", c.GetString(ctx, "app.name"))
+	}
+}
@@ -0,0 +1,147 @@
+package mcfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// decoders 是按扩展名注册的配置文件解析函数，见 RegisterDecoder
+	decoders = map[string]func([]byte) (map[string]any, error){
+		"yaml": decodeYAML,
+		"yml":  decodeYAML,
+		"json": decodeJSON,
+		"toml": decodeTOML,
+	}
+	// decoderExts 是查找配置文件时依次尝试的扩展名，内置的格式在前
+	decoderExts = []string{"yaml", "yml", "json", "toml"}
+	decodersMu  sync.RWMutex
+)
+
+// RegisterDecoder 注册扩展名为 `ext` 的配置文件的解析函数，例如 "hcl" 或 "properties"，
+// 已注册的扩展名被替换。解析结果与内置格式一样被规范化，见 normalizeValue。
+func RegisterDecoder(ext string, fn func([]byte) (map[string]any, error)) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	if _, ok := decoders[ext]; !ok {
+		decoderExts = append(decoderExts, ext)
+	}
+	decoders[ext] = fn
+}
+
+// decoder 返回扩展名为 `ext` 的配置文件的解析函数
+func decoder(ext string) (func([]byte) (map[string]any, error), bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	fn, ok := decoders[strings.ToLower(strings.TrimPrefix(ext, "."))]
+	return fn, ok
+}
+
+// supportedExts 返回已注册解析函数的扩展名
+func supportedExts() []string {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	return append([]string(nil), decoderExts...)
+}
+
+// decodeYAML 解析 YAML 格式的配置
+func decodeYAML(data []byte) (map[string]any, error) {
+	m := map[string]any{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeJSON 解析 JSON 格式的配置，数字保留为 json.Number 以区分整数和浮点数
+func decodeJSON(data []byte) (map[string]any, error) {
+	m := map[string]any{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return m, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeTOML 解析 TOML 格式的配置
+func decodeTOML(data []byte) (map[string]any, error) {
+	m := map[string]any{}
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// normalizeValue 将不同格式解析出的值规范化为相同的表示，使类型转换的结果与格式无关：
+// map 为 map[string]any，列表为 []any，整数为 int，浮点数为 float64
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = normalizeValue(item)
+		}
+		return m
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = normalizeValue(item)
+		}
+		return list
+	case []map[string]any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = normalizeValue(item)
+		}
+		return list
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return normalizeValue(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		if v >= math.MinInt && v <= math.MaxInt {
+			return int(v)
+		}
+		return v
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		if v <= math.MaxInt {
+			return int(v)
+		}
+		return v
+	case float32:
+		return float64(v)
+	}
+	return value
+}
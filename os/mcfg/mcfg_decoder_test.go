@@ -0,0 +1,132 @@
+package mcfg_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+var formatFixtures = map[string]string{
+	"yaml": `
+app:
+  name: "test-app"
+  debug: true
+  port: 8080
+  ratio: 0.5
+  tags: ["a", "b"]
+database:
+  settings:
+    timeout: 30
+  replicas:
+    - host: "db1"
+      port: 5432
+    - host: "db2"
+      port: 5433
+`,
+	"json": `{
+  "app": {"name": "test-app", "debug": true, "port": 8080, "ratio": 0.5, "tags": ["a", "b"]},
+  "database": {
+    "settings": {"timeout": 30},
+    "replicas": [{"host": "db1", "port": 5432}, {"host": "db2", "port": 5433}]
+  }
+}`,
+	"toml": `
+[app]
+name = "test-app"
+debug = true
+port = 8080
+ratio = 0.5
+tags = ["a", "b"]
+
+[database.settings]
+timeout = 30
+
+[[database.replicas]]
+host = "db1"
+port = 5432
+
+[[database.replicas]]
+host = "db2"
+port = 5433
+`,
+}
+
+// newFileConfig 创建读取配置文件 `name` 的配置，内容为 `content`
+func newFileConfig(t *testing.T, name, content string) *mcfg.Config {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = adapter.SetConfigFile(path); err != nil {
+		t.Fatalf("SetConfigFile() error = %v", err)
+	}
+	return mcfg.NewWithAdapter(adapter)
+}
+
+func TestConfig_FileFormats(t *testing.T) {
+	var expected map[string]any
+	for _, format := range []string{"yaml", "json", "toml"} {
+		c := newFileConfig(t, "config."+format, formatFixtures[format])
+		data, err := c.Data(ctx)
+		if err != nil {
+			t.Fatalf("%s: Data() error = %v", format, err)
+		}
+		if expected == nil {
+			expected = data
+		} else if !reflect.DeepEqual(expected, data) {
+			t.Errorf("%s: Data() expected %v, got %v", format, expected, data)
+		}
+
+		val, _ := c.Get(ctx, "app.port")
+		if _, ok := val.Val().(int); !ok || val.Int() != 8080 {
+			t.Errorf("%s: Get() expected int 8080, got %T %v", format, val.Val(), val)
+		}
+		val, _ = c.Get(ctx, "app.ratio")
+		if val.Float64() != 0.5 {
+			t.Errorf("%s: Get() expected 0.5, got %v", format, val)
+		}
+		val, _ = c.Get(ctx, "database.settings.timeout")
+		if val.Int() != 30 {
+			t.Errorf("%s: Get() expected 30, got %v", format, val)
+		}
+		val, _ = c.Get(ctx, "database.replicas")
+		if replicas, ok := val.Val().([]any); !ok || len(replicas) != 2 {
+			t.Errorf("%s: Get() expected 2 replicas, got %v", format, val)
+		}
+	}
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	mcfg.RegisterDecoder(".kv", func(data []byte) (map[string]any, error) {
+		m := map[string]any{}
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+			key, value, _ := bytes.Cut(line, []byte("="))
+			m[string(key)] = string(value)
+		}
+		return m, nil
+	})
+	c := newFileConfig(t, "app.kv", "name=kv-app\nport=9000\n")
+	val, _ := c.Get(ctx, "name")
+	if val.String() != "kv-app" {
+		t.Errorf("Get() expected 'kv-app', got %v", val)
+	}
+	val, _ = c.Get(ctx, "port")
+	if val.Int() != 9000 {
+		t.Errorf("Get() expected 9000, got %v", val)
+	}
+
+	adapter, _ := mcfg.NewAdapterFile()
+	path := filepath.Join(t.TempDir(), "app.unknown")
+	_ = os.WriteFile(path, []byte("x"), 0o644)
+	if err := adapter.SetConfigFile(path); err == nil {
+		t.Error("SetConfigFile() expected error for an unsupported file type")
+	}
+}
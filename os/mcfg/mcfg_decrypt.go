@@ -0,0 +1,160 @@
+package mcfg
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// DefaultEncryptedPattern 是默认的加密配置值的模式，匹配 SOPS 风格的值，例如 "ENC[AES256_GCM,data:...]"
+const DefaultEncryptedPattern = `^ENC\[.+\]$`
+
+// defaultEncryptedRegexp 是编译后的 DefaultEncryptedPattern
+var defaultEncryptedRegexp = regexp.MustCompile(DefaultEncryptedPattern)
+
+// DecryptFunc 解密配置键 `key` 的加密值 `ciphertext` 并返回明文，返回的错误不应包含密文和明文
+type DecryptFunc func(key string, ciphertext string) (string, error)
+
+// decryption 是解密配置值的设置
+type decryption struct {
+	fn      DecryptFunc       // 解密函数，为 nil 时不解密
+	pattern *regexp.Regexp    // 加密值的模式，为 nil 时使用 DefaultEncryptedPattern
+	cache   map[string]string // 配置键和密文到明文的缓存，配置重新加载时清空
+}
+
+// SetDecryptFunc 设置解密配置值的函数，用于提交到代码仓库的加密配置，例如以 SOPS 或 KMS 加密的值：
+//
+//	db:
+//	  password: ENC[AES256_GCM,data:...] # Get 和 Unmarshal 返回 `fn` 解密的明文
+//
+// 匹配 SetEncryptedPattern 的字符串值在读取时解密并缓存，配置重新加载时重新解密；解密失败时 Get、Data 和
+// Load 返回包含配置键的错误，错误信息不包含密文和明文。解密的值在 Redacted、Dump 和 Handler 的输出中视为敏感的值。
+// `fn` 为 nil 时不解密。
+func (c *Config) SetDecryptFunc(fn DecryptFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decryption.fn = fn
+	c.decryption.cache = nil
+}
+
+// SetEncryptedPattern 设置加密配置值的正则表达式，默认为 DefaultEncryptedPattern，见 SetDecryptFunc
+func (c *Config) SetEncryptedPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid encrypted value pattern "%s"`, pattern)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decryption.pattern = re
+	c.decryption.cache = nil
+	return nil
+}
+
+// encryptedPattern 返回加密配置值的模式，没有设置解密函数时返回 nil
+func (c *Config) encryptedPattern() *regexp.Regexp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.decryption.fn == nil {
+		return nil
+	}
+	if c.decryption.pattern == nil {
+		return defaultEncryptedRegexp
+	}
+	return c.decryption.pattern
+}
+
+// decrypt 返回解密配置键 `key` 的值 `value` 中的加密值后的值，没有设置解密函数时返回 `value`
+func (c *Config) decrypt(key string, value any) (any, error) {
+	pattern := c.encryptedPattern()
+	if pattern == nil || value == nil {
+		return value, nil
+	}
+	return c.decryptTree(key, value, pattern)
+}
+
+// decryptTree 返回解密 `value` 中匹配 `pattern` 的字符串后的副本
+func (c *Config) decryptTree(key string, value any, pattern *regexp.Regexp) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !pattern.MatchString(v) {
+			return v, nil
+		}
+		return c.decryptValue(key, v)
+	case map[string]any:
+		if v == nil {
+			return v, nil
+		}
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			decrypted, err := c.decryptTree(appendKey(key, k), item, pattern)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = decrypted
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			decrypted, err := c.decryptTree(appendKey(key, strconv.Itoa(i)), item, pattern)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = decrypted
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// decryptValue 返回配置键 `key` 的加密值 `ciphertext` 的明文
+func (c *Config) decryptValue(key string, ciphertext string) (string, error) {
+	cacheKey := key + "\x00" + ciphertext
+	c.mu.Lock()
+	fn := c.decryption.fn
+	plaintext, cached := c.decryption.cache[cacheKey]
+	c.mu.Unlock()
+	if cached {
+		return plaintext, nil
+	}
+	plaintext, err := fn(key, ciphertext)
+	if err != nil {
+		return "", merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `decrypt config "%s" failed`, key)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.decryption.cache == nil {
+		c.decryption.cache = map[string]string{}
+	}
+	c.decryption.cache[cacheKey] = plaintext
+	return plaintext, nil
+}
+
+// decryptChange 返回解密后的重新加载前后的配置数据 `old` 和 `new`，加载后的配置重新解密，见 Watch
+func (c *Config) decryptChange(old, new map[string]any) (map[string]any, map[string]any, error) {
+	pattern := c.encryptedPattern()
+	if pattern == nil {
+		return old, new, nil
+	}
+	decryptedOld, err := c.decryptTree("", old, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.clearDecrypted()
+	decryptedNew, err := c.decryptTree("", new, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, _ = decryptedOld.(map[string]any)
+	new, _ = decryptedNew.(map[string]any)
+	return old, new, nil
+}
+
+// clearDecrypted 清空解密的缓存，之后读取配置时重新解密
+func (c *Config) clearDecrypted() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decryption.cache = nil
+}
@@ -0,0 +1,144 @@
+package mcfg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const decryptFixture = `
+api:
+  key: ENC[AES256,data:yek-ipa]
+  hosts:
+    - ENC[AES256,data:1.0.0.01]
+db:
+  host: localhost
+`
+
+// reverseDecrypt 以去掉 "ENC[AES256,data:" 和 "]" 后反转的字符串作为明文，并记录解密的配置键
+func reverseDecrypt(keys *[]string) func(key string, ciphertext string) (string, error) {
+	return func(key string, ciphertext string) (string, error) {
+		*keys = append(*keys, key)
+		data := strings.TrimSuffix(strings.TrimPrefix(ciphertext, "ENC[AES256,data:"), "]")
+		if data == "bad" {
+			return "", errors.New("authentication failed")
+		}
+		runes := []rune(data)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	}
+}
+
+func TestConfig_Decrypt(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", decryptFixture)
+	var keys []string
+	c.SetDecryptFunc(reverseDecrypt(&keys))
+
+	if val, err := c.Get(ctx, "api.key"); err != nil || val.String() != "api-key" {
+		t.Errorf("Get() expected the decrypted value, got %v, %v", val, err)
+	}
+	if val, err := c.Get(ctx, "api.hosts.0"); err != nil || val.String() != "10.0.0.1" {
+		t.Errorf("Get() expected the decrypted slice element, got %v, %v", val, err)
+	}
+	var config struct {
+		API struct {
+			Key   string
+			Hosts []string
+		}
+	}
+	if err := c.Unmarshal(ctx, &config); err != nil || config.API.Key != "api-key" || config.API.Hosts[0] != "10.0.0.1" {
+		t.Errorf("Unmarshal() expected the decrypted values, got %+v, %v", config, err)
+	}
+	if !strings.Contains(strings.Join(keys, ","), "api.hosts.0") {
+		t.Errorf("SetDecryptFunc() expected the config key of the value, got %v", keys)
+	}
+
+	// 解密的值被缓存，Load 重新解密
+	calls := len(keys)
+	_, _ = c.Get(ctx, "api.key")
+	if len(keys) != calls {
+		t.Errorf("Get() expected the cached value, got %d decryptions", len(keys)-calls)
+	}
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(keys) == calls {
+		t.Error("Load() expected the values to be decrypted again")
+	}
+
+	dump, err := c.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if strings.Contains(dump, "api-key") || strings.Contains(dump, "ENC[") || !strings.Contains(dump, "localhost") {
+		t.Errorf("Dump() expected the encrypted values to be redacted, got\n%s", dump)
+	}
+}
+
+func TestConfig_DecryptError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("db:\n  password: ENC[AES256,data:bad]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = adapter.SetConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	c := mcfg.NewWithAdapter(adapter)
+	var keys []string
+	c.SetDecryptFunc(reverseDecrypt(&keys))
+	err = c.Load(ctx)
+	if err == nil {
+		t.Fatal("Load() expected error for the value that cannot be decrypted")
+	}
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	if !strings.Contains(err.Error(), `"db.password"`) || strings.Contains(err.Error(), "ENC[") {
+		t.Errorf("Load() expected error with the config key and without the ciphertext, got %v", err)
+	}
+	if _, err = c.Get(ctx, "db.password"); err == nil {
+		t.Error("Get() expected error for the value that cannot be decrypted")
+	}
+
+	// 修复后重新加载
+	if err = os.WriteFile(path, []byte("db:\n  password: ENC[AES256,data:drowssap]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "db.password"); val.String() != "password" {
+		t.Errorf("Get() expected the decrypted value after reload, got %v", val)
+	}
+}
+
+func TestConfig_SetEncryptedPattern(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+api:
+  key: "vault:olleh"
+  name: ENC[plain]
+`)
+	c.SetDecryptFunc(func(key string, ciphertext string) (string, error) {
+		return strings.TrimPrefix(ciphertext, "vault:"), nil
+	})
+	if err := c.SetEncryptedPattern(`^vault:`); err != nil {
+		t.Fatalf("SetEncryptedPattern() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "api.key"); val.String() != "olleh" {
+		t.Errorf("Get() expected the value matching the pattern to be decrypted, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "api.name"); val.String() != "ENC[plain]" {
+		t.Errorf("Get() expected the value not matching the pattern to be unchanged, got %v", val)
+	}
+	merrortest.AssertCode(t, c.SetEncryptedPattern(`(`), mcode.CodeInvalidParameter)
+}
@@ -0,0 +1,166 @@
+package mcfg
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// SetDefault 设置配置键 `key` 的默认值，优先级最低，在配置文件等来源都没有该键时生效。
+// 默认值包含在 Data 中，但 IsSet 对其返回 false。
+func (c *Config) SetDefault(key string, value any) {
+	c.SetDefaults(map[string]any{key: value})
+}
+
+// SetDefaults 批量设置配置键的默认值，见 SetDefault
+func (c *Config) SetDefaults(defaults map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	merged := deepCopyMap(c.defaults)
+	for key, value := range defaults {
+		setPath(merged, splitKey(strings.ToLower(key)), normalizeValue(value))
+	}
+	c.defaults = merged
+}
+
+// IsSet 检查配置键 `key` 是否由配置来源设置，包括命令行参数和环境变量，不包括默认值
+func (c *Config) IsSet(ctx context.Context, key string) bool {
+	value, err := c.value(ctx, key)
+	return err == nil && value != nil
+}
+
+// defaultValue 返回配置键 `key` 的默认值
+func (c *Config) defaultValue(key string) any {
+	c.mu.Lock()
+	defaults := c.defaults
+	c.mu.Unlock()
+	if len(defaults) == 0 {
+		return nil
+	}
+	var tree any = defaults
+	if c.folding() {
+		tree, _ = foldTree(defaults, "", c.secretSuffix())
+		key = c.foldKey(key)
+	}
+	if key == "" {
+		return tree
+	}
+	value, _ := lookupPath(tree, splitKey(key))
+	return value
+}
+
+// withDefaults 返回以默认值 `defaults` 补全 `value` 中缺少的键后的值
+func withDefaults(value any, defaults any) any {
+	if value == nil {
+		if m, ok := defaults.(map[string]any); ok {
+			return deepCopyMap(m)
+		}
+		return defaults
+	}
+	m, ok := value.(map[string]any)
+	defaultMap, isMap := defaults.(map[string]any)
+	if !ok || !isMap {
+		return value
+	}
+	result := make(map[string]any, len(m)+len(defaultMap))
+	for key, v := range m {
+		result[key] = v
+	}
+	for key, v := range defaultMap {
+		result[key] = withDefaults(result[key], v)
+	}
+	return result
+}
+
+// deepCopyMap 返回 `m` 的深拷贝，其中的 map 被复制
+func deepCopyMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		if child, ok := value.(map[string]any); ok {
+			value = deepCopyMap(child)
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// applyDefaultTags 返回以结构体 `t` 的字段的 default 标签补全 `input` 中缺少的键后的值，
+// 例如字段 `Port int default:"8080"`
+func applyDefaultTags(t reflect.Type, input map[string]any, tagName string) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return input
+	}
+	result := make(map[string]any, len(input))
+	for key, value := range input {
+		result[key] = value
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		// 嵌入的结构体的字段视为外层的字段，见 decode
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			result = applyDefaultTags(field.Type, result, tagName)
+			continue
+		}
+		name, names := fieldNames(field, tagName)
+		if name == "-" {
+			continue
+		}
+		key, found := findKey(result, names)
+		if !found {
+			if value, ok := field.Tag.Lookup("default"); ok {
+				result[name] = value
+				continue
+			}
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		child, _ := result[key].(map[string]any)
+		if found && child == nil {
+			continue
+		}
+		if child = applyDefaultTags(fieldType, child, tagName); len(child) > 0 {
+			if !found {
+				key = name
+			}
+			result[key] = child
+		}
+	}
+	return result
+}
+
+// fieldNames 返回结构体字段 `field` 的名称和匹配配置键的名称，有 `tagName` 标签的字段只匹配标签名，
+// 否则匹配字段名和 json 标签名；名称为 "-" 表示字段不解码
+func fieldNames(field reflect.StructField, tagName string) (name string, names []string) {
+	name, _, _ = strings.Cut(field.Tag.Get(tagName), ",")
+	if name != "" {
+		return name, []string{name}
+	}
+	names = []string{field.Name}
+	if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+		names = append(names, jsonName)
+	}
+	return field.Name, names
+}
+
+// findKey 返回 `m` 中匹配 `names` 之一的键，匹配方式同 matchName
+func findKey(m map[string]any, names []string) (string, bool) {
+	for key := range m {
+		for _, name := range names {
+			if matchName(key, name) {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,87 @@
+package mcfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_SetDefault(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unmarshalFixture)
+	c.SetDefault("server.addr", ":80")
+	c.SetDefaults(map[string]any{
+		"server.port":  8080,
+		"logger.Level": "info",
+	})
+
+	// 配置中的值优先于默认值
+	if val, err := c.Get(ctx, "server.addr"); err != nil || val.String() != ":8000" {
+		t.Errorf("Get() expected :8000, got %v, %v", val, err)
+	}
+	if val, err := c.Get(ctx, "server.port", 9090); err != nil || val.Int() != 8080 {
+		t.Errorf("Get() expected default 8080, got %v, %v", val, err)
+	}
+	server, err := c.Get(ctx, "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := server.Map(); m["port"] != 8080 || m["addr"] != ":8000" {
+		t.Errorf("Get() expected the defaults under the config, got %v", m)
+	}
+
+	data, err := c.Data(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level := data["logger"].(map[string]any)["level"]; level != "info" {
+		t.Errorf("Data() expected default level info, got %v", level)
+	}
+
+	if !c.IsSet(ctx, "server.addr") {
+		t.Error("IsSet() expected true for a configured key")
+	}
+	if c.IsSet(ctx, "server.port") || c.IsSet(ctx, "logger") {
+		t.Error("IsSet() expected false for a default")
+	}
+	t.Setenv("DEFAULT_SERVER_PORT", "7000")
+	c.BindEnv("server.port", "DEFAULT_SERVER_PORT")
+	if !c.IsSet(ctx, "server.port") {
+		t.Error("IsSet() expected true for an overridden key")
+	}
+}
+
+func TestConfig_UnmarshalDefaultTag(t *testing.T) {
+	type limitConfig struct {
+		Burst int `default:"10"`
+	}
+	type httpConfig struct {
+		Address      string        `mapstructure:"addr" default:":80"`
+		Port         int           `default:"8080"`
+		WriteTimeout time.Duration `json:"write_timeout" default:"1m"`
+		Methods      []string      `default:"GET,POST"`
+		Limit        limitConfig
+	}
+	c := newFileConfig(t, "config.yaml", unmarshalFixture)
+	c.SetDefault("server.write_timeout", "5s")
+
+	var server httpConfig
+	if err := c.UnmarshalKey(ctx, "server", &server); err != nil {
+		t.Fatalf("UnmarshalKey() error = %v", err)
+	}
+	if server.Address != ":8000" || server.Port != 8080 || server.WriteTimeout != 5*time.Second {
+		t.Errorf("UnmarshalKey() got %+v", server)
+	}
+	if len(server.Methods) != 2 || server.Limit.Burst != 10 {
+		t.Errorf("UnmarshalKey() expected the default tags, got %+v", server)
+	}
+
+	// 配置不存在时只设置默认值
+	missing := httpConfig{Address: "unchanged"}
+	if err := c.UnmarshalKey(ctx, "missing", &missing, mcfg.WithStrict(true)); err != nil {
+		t.Fatalf("UnmarshalKey() error = %v", err)
+	}
+	if missing.Address != ":80" || missing.Port != 8080 || missing.WriteTimeout != time.Minute {
+		t.Errorf("UnmarshalKey() expected the default tags, got %+v", missing)
+	}
+}
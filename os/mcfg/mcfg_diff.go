@@ -0,0 +1,102 @@
+package mcfg
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ChangeOp 是配置键的变更类型，见 Change
+type ChangeOp string
+
+const (
+	ChangeAdded    ChangeOp = "added"   // 新增的配置键
+	ChangeRemoved  ChangeOp = "removed" // 删除的配置键
+	ChangeModified ChangeOp = "changed" // 值变更的配置键
+)
+
+// Change 是配置键 `Key` 的变更，新增时 Old 为 nil，删除时 New 为 nil
+type Change struct {
+	Key string   `json:"key"`
+	Old any      `json:"old"`
+	New any      `json:"new"`
+	Op  ChangeOp `json:"op"`
+}
+
+// KeyChangeFunc 是配置键变更的回调，参数为按配置键排序的变更，见 OnKeyChange
+type KeyChangeFunc func(changes []Change)
+
+// Diff 比较变更前后的配置数据 `old` 和 `new`，返回按配置键排序的变更。
+// map 逐键比较，切片作为整体比较，因此切片的变更只报告切片的配置键，例如 "upstreams"。
+func Diff(old, new map[string]any) []Change {
+	var changes []Change
+	diffTree("", old, new, &changes)
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return changes
+}
+
+// OnKeyChange 注册配置键 `prefix` 及其下的键变更的回调，例如 `OnKeyChange("database", fn)` 只在 "database.*"
+// 变更时调用 `fn`，参数只包括这些键的变更；`prefix` 为空时任何变更都调用 `fn`。
+// 包含 `prefix` 的 map 或切片整体变更时也调用 `fn`。变更来自 Watch、Set 等，见 OnChange 和 Diff。
+func (c *Config) OnKeyChange(prefix string, fn KeyChangeFunc) {
+	c.OnChange(func(old, new map[string]any) {
+		var (
+			key     = strings.ToLower(c.foldKey(prefix))
+			changes []Change
+		)
+		for _, change := range Diff(old, new) {
+			if matchPrefix(change.Key, key) {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) > 0 {
+			fn(changes)
+		}
+	})
+}
+
+// diffTree 将配置键 `key` 的值从 `old` 到 `new` 的变更添加到 `changes`
+func diffTree(key string, old, new any, changes *[]Change) {
+	oldMap, oldIsMap := old.(map[string]any)
+	newMap, newIsMap := new.(map[string]any)
+	if oldIsMap && newIsMap {
+		for k, oldValue := range oldMap {
+			diffTree(appendKey(key, k), oldValue, newMap[k], changes)
+		}
+		for k, newValue := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				diffTree(appendKey(key, k), nil, newValue, changes)
+			}
+		}
+		return
+	}
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		*changes = append(*changes, Change{Key: key, New: new, Op: ChangeAdded})
+	case new == nil:
+		*changes = append(*changes, Change{Key: key, Old: old, Op: ChangeRemoved})
+	case !reflect.DeepEqual(old, new):
+		*changes = append(*changes, Change{Key: key, Old: old, New: new, Op: ChangeModified})
+	}
+}
+
+// appendKey 返回配置键 `key` 下键名为 `name` 的配置键，键名中的点写作 "\."
+func appendKey(key, name string) string {
+	name = strings.ReplaceAll(name, ".", `\.`)
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+// matchPrefix 检查配置键 `key` 是否是 `prefix` 或其下的键，或者是包含 `prefix` 的上级键
+func matchPrefix(key, prefix string) bool {
+	key = strings.ToLower(key)
+	if prefix == "" || key == "" || key == prefix {
+		return true
+	}
+	return strings.HasPrefix(key, prefix+".") || strings.HasPrefix(prefix, key+".")
+}
@@ -0,0 +1,82 @@
+package mcfg_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestDiff(t *testing.T) {
+	old := map[string]any{
+		"database": map[string]any{"host": "localhost", "port": 5432},
+		"server":   map[string]any{"addr": ":8000"},
+		"upstreams": []any{
+			map[string]any{"host": "10.0.0.1"},
+		},
+		"debug": true,
+	}
+	new := map[string]any{
+		"database": map[string]any{"host": "db.internal", "port": 5432, "pool": 10},
+		"server":   map[string]any{"addr": ":8000"},
+		"upstreams": []any{
+			map[string]any{"host": "10.0.0.1"},
+			map[string]any{"host": "10.0.0.2"},
+		},
+	}
+	changes := mcfg.Diff(old, new)
+	expected := []mcfg.Change{
+		{Key: "database.host", Old: "localhost", New: "db.internal", Op: mcfg.ChangeModified},
+		{Key: "database.pool", New: 10, Op: mcfg.ChangeAdded},
+		{Key: "debug", Old: true, Op: mcfg.ChangeRemoved},
+		{Key: "upstreams", Old: old["upstreams"], New: new["upstreams"], Op: mcfg.ChangeModified},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Diff() expected %+v, got %+v", expected, changes)
+	}
+	if changes = mcfg.Diff(old, old); len(changes) != 0 {
+		t.Errorf("Diff() expected no changes for the same data, got %+v", changes)
+	}
+}
+
+func TestConfig_OnKeyChange(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+database:
+  host: localhost
+server:
+  addr: ":8000"
+`)
+	var database, all [][]mcfg.Change
+	c.OnKeyChange("Database", func(changes []mcfg.Change) {
+		database = append(database, changes)
+	})
+	c.OnKeyChange("", func(changes []mcfg.Change) {
+		all = append(all, changes)
+	})
+
+	if err := c.Set(ctx, "server.addr", ":9000"); err != nil {
+		t.Fatal(err)
+	}
+	if len(database) != 0 {
+		t.Errorf("OnKeyChange() expected no call for other keys, got %+v", database)
+	}
+	if len(all) != 1 || all[0][0].Key != "server.addr" || all[0][0].New != ":9000" {
+		t.Errorf("OnKeyChange() expected the change of server.addr, got %+v", all)
+	}
+
+	if err := c.Set(ctx, "database.host", "db.internal"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []mcfg.Change{{Key: "database.host", Old: "localhost", New: "db.internal", Op: mcfg.ChangeModified}}
+	if len(database) != 1 || !reflect.DeepEqual(database[0], expected) {
+		t.Errorf("OnKeyChange() expected %+v, got %+v", expected, database)
+	}
+
+	// 包含前缀的上级键变更
+	if err := c.Set(ctx, "database", "disabled"); err != nil {
+		t.Fatal(err)
+	}
+	if len(database) != 2 || database[1][0].Key != "database" || database[1][0].Op != mcfg.ChangeModified {
+		t.Errorf("OnKeyChange() expected the change of database, got %+v", database)
+	}
+}
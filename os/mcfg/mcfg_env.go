@@ -0,0 +1,113 @@
+package mcfg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envKeyReplacer 将配置键的分隔符映射为环境变量名的下划线
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// envOptions 是环境变量覆盖层的设置，优先级高于配置文件和默认值
+type envOptions struct {
+	prefix    string              // 环境变量名的前缀，例如 "MALTOSE"
+	automatic bool                // 是否自动以环境变量覆盖配置键，见 AutomaticEnv
+	bindings  map[string]string   // 配置键到环境变量名的绑定，见 BindEnv
+	fold      func(string) string // 折叠配置键的函数，开启 KeyMatchFold 时不为空，见 SetKeyMatching
+}
+
+// SetEnvPrefix 设置自动覆盖配置的环境变量名前缀，
+// 例如前缀 "MALTOSE" 时环境变量 MALTOSE_SERVER_ADDRESS 覆盖配置 "server.address"
+func (c *Config) SetEnvPrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.env.prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+}
+
+// AutomaticEnv 设置是否自动以环境变量覆盖配置，环境变量名为前缀加上大写的配置键，
+// 其中的 "." 和 "-" 映射为 "_"。覆盖的是配置中已有的键和 Get 读取的键，
+// 其他的键需通过 BindEnv 绑定才会出现在 Data 中。
+func (c *Config) AutomaticEnv(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.env.automatic = enabled
+}
+
+// BindEnv 绑定配置键 `key` 到环境变量 `envName`，用于不符合自动映射规则的环境变量名，
+// 无论是否开启 AutomaticEnv 都生效，且不受前缀影响
+func (c *Config) BindEnv(key string, envName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bindings := make(map[string]string, len(c.env.bindings)+1)
+	for k, v := range c.env.bindings {
+		bindings[k] = v
+	}
+	bindings[strings.ToLower(key)] = envName
+	c.env.bindings = bindings
+}
+
+// enabled 检查环境变量覆盖层是否生效
+func (o envOptions) enabled() bool {
+	return o.automatic || len(o.bindings) > 0
+}
+
+// lookup 返回覆盖配置键 `key` 的环境变量值
+func (o envOptions) lookup(key string) (string, bool) {
+	name := o.name(key)
+	if name == "" {
+		return "", false
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok && o.fold != nil {
+		_, value, ok = lookupEnvFold(name)
+	}
+	return value, ok
+}
+
+// name 返回覆盖配置键 `key` 的环境变量名，没有时返回空字符串
+func (o envOptions) name(key string) string {
+	key = strings.ToLower(key)
+	if name, ok := o.bindings[key]; ok {
+		return name
+	}
+	if o.fold != nil {
+		key = o.fold(key)
+		for bound, name := range o.bindings {
+			if o.fold(bound) == key {
+				return name
+			}
+		}
+	}
+	if !o.automatic || key == "" {
+		return ""
+	}
+	name := strings.ToUpper(envKeyReplacer.Replace(key))
+	if o.prefix != "" {
+		name = o.prefix + "_" + name
+	}
+	return name
+}
+
+// origin 实现 overrideLayer 接口
+func (o envOptions) origin(key string) (Source, string) {
+	name := o.name(key)
+	if _, ok := os.LookupEnv(name); !ok && o.fold != nil {
+		if actual, _, ok := lookupEnvFold(name); ok {
+			name = actual
+		}
+	}
+	return SourceEnv, fmt.Sprintf(`environment variable "%s"`, name)
+}
+
+// keys 返回绑定的配置键，它们即使不在配置中也会被设置
+func (o envOptions) keys() []string {
+	keys := make([]string, 0, len(o.bindings))
+	for key := range o.bindings {
+		if o.fold != nil {
+			key = o.fold(key)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,114 @@
+package mcfg_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+// mapAdapter 是以 map 保存配置数据的适配器
+type mapAdapter struct {
+	data map[string]any
+}
+
+func (m *mapAdapter) Get(_ context.Context, pattern string) (any, error) {
+	var value any = m.data
+	for _, key := range strings.Split(pattern, ".") {
+		child, ok := value.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		value = child[key]
+	}
+	return value, nil
+}
+
+func (m *mapAdapter) Data(_ context.Context) (map[string]any, error) {
+	return m.data, nil
+}
+
+func (m *mapAdapter) Available(_ context.Context, _ ...string) bool {
+	return true
+}
+
+func newEnvConfig() *mcfg.Config {
+	return mcfg.NewWithAdapter(&mapAdapter{data: map[string]any{
+		"server": map[string]any{
+			"address": ":8000",
+			"timeout": 30,
+		},
+		"app": map[string]any{"name": "test-app"},
+	}})
+}
+
+func TestConfig_Env(t *testing.T) {
+	t.Setenv("MALTOSE_SERVER_ADDRESS", ":9000")
+	t.Setenv("MALTOSE_SERVER_TIMEOUT", "60")
+	t.Setenv("MALTOSE_APP_VERSION", "2.0.0")
+
+	c := newEnvConfig()
+	// 未开启时不覆盖
+	val, _ := c.Get(ctx, "server.address")
+	if val.String() != ":8000" {
+		t.Errorf("Get() expected ':8000', got %v", val)
+	}
+
+	c.SetEnvPrefix("MALTOSE_")
+	c.AutomaticEnv(true)
+	val, _ = c.Get(ctx, "server.address")
+	if val.String() != ":9000" {
+		t.Errorf("Get() expected ':9000', got %v", val)
+	}
+	// 环境变量的值通过相同的类型转换
+	val, _ = c.Get(ctx, "server.timeout")
+	if val.Int() != 60 {
+		t.Errorf("Get() expected 60, got %v", val)
+	}
+	// 配置中没有的键
+	val, _ = c.Get(ctx, "app.version", "1.0.0")
+	if val.String() != "2.0.0" {
+		t.Errorf("Get() expected '2.0.0', got %v", val)
+	}
+	val, _ = c.Get(ctx, "app.missing", "default")
+	if val.String() != "default" {
+		t.Errorf("Get() expected 'default', got %v", val)
+	}
+
+	// 读取上级键时覆盖其中的值
+	val, _ = c.Get(ctx, "server")
+	if server := val.Map(); server["address"] != ":9000" {
+		t.Errorf("Get() expected address ':9000', got %v", server)
+	}
+	data, _ := c.Data(ctx)
+	if address := data["server"].(map[string]any)["address"]; address != ":9000" {
+		t.Errorf("Data() expected address ':9000', got %v", address)
+	}
+}
+
+func TestConfig_BindEnv(t *testing.T) {
+	t.Setenv("PORT", ":7000")
+	t.Setenv("DATABASE_URL", "postgres://localhost")
+
+	c := newEnvConfig()
+	c.BindEnv("server.address", "PORT")
+	c.BindEnv("database.dsn", "DATABASE_URL")
+	c.BindEnv("cache.url", "UNSET_CACHE_URL")
+
+	val, _ := c.Get(ctx, "server.address")
+	if val.String() != ":7000" {
+		t.Errorf("Get() expected ':7000', got %v", val)
+	}
+	val, _ = c.Get(ctx, "database")
+	if database := val.Map(); database["dsn"] != "postgres://localhost" {
+		t.Errorf("Get() expected dsn, got %v", database)
+	}
+	data, _ := c.Data(ctx)
+	if dsn := data["database"].(map[string]any)["dsn"]; dsn != "postgres://localhost" {
+		t.Errorf("Data() expected dsn, got %v", dsn)
+	}
+	if _, ok := data["cache"]; ok {
+		t.Errorf("Data() expected no cache, got %v", data["cache"])
+	}
+}
@@ -0,0 +1,223 @@
+package mcfg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue 是敏感配置值在输出中的替代值，见 SetSensitivePatterns
+const RedactedValue = "******"
+
+// defaultSensitivePatterns 是默认的敏感配置键的模式
+var defaultSensitivePatterns = []string{"password", "secret", "token"}
+
+// Source 是配置值的来源，见 Explain
+type Source string
+
+const (
+	SourceNone    Source = ""        // 配置不存在
+	SourceSet     Source = "set"     // Set 设置的值
+	SourceFlag    Source = "flag"    // 命令行参数
+	SourceEnv     Source = "env"     // 环境变量
+	SourceAdapter Source = "adapter" // 配置适配器，例如配置文件
+	SourceDefault Source = "default" // SetDefault 的默认值
+	SourceFile    Source = "file"    // 密钥文件，见 EnableSecretFiles
+)
+
+// Explanation 是配置键的有效值及其来源
+type Explanation struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source Source `json:"source"`
+	Origin string `json:"origin,omitempty"` // 来源的描述，例如环境变量名和配置文件路径
+}
+
+// Explain 返回配置键 `key` 的有效值以及提供该值的优先级最高的来源，
+// 用于排查服务实际使用的配置，值为 map 时其中的键可能来自较低优先级的来源
+func (c *Config) Explain(ctx context.Context, key string) (*Explanation, error) {
+	value, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	e := &Explanation{Key: key}
+	if value == nil {
+		return e, nil
+	}
+	e.Value = value.Val()
+	if c.isSecret(ctx, key) {
+		path, _ := c.effective(ctx, key+c.secretSuffix(), nil)
+		e.Source, e.Origin = SourceFile, fmt.Sprintf(`secret file "%v"`, path)
+		return e, nil
+	}
+	if _, overridden, hidden := c.override(splitKey(strings.ToLower(c.foldKey(key)))); overridden || hidden {
+		e.Source, e.Origin = SourceSet, "Config.Set"
+		return e, nil
+	}
+	for _, layer := range c.layers() {
+		if _, ok := layer.lookup(c.foldKey(key)); ok {
+			e.Source, e.Origin = layer.origin(c.foldKey(key))
+			return e, nil
+		}
+	}
+	if v, err := c.reader().Get(ctx, c.foldKey(key)); err == nil && v != nil {
+		e.Source, e.Origin = SourceAdapter, describeAdapter(c.GetAdapter())
+		return e, nil
+	}
+	e.Source, e.Origin = SourceDefault, "Config.SetDefault"
+	return e, nil
+}
+
+// SetSensitivePatterns 设置敏感配置键的模式，键名包含任一模式（不区分大小写）的值在 Redacted、Dump 和
+// Handler 的输出中替换为 RedactedValue，默认为 "password"、"secret" 和 "token"
+func (c *Config) SetSensitivePatterns(patterns ...string) {
+	lowered := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lowered[i] = strings.ToLower(pattern)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sensitive = lowered
+}
+
+// Redacted 返回替换敏感的值后的全部配置数据，见 SetSensitivePatterns
+func (c *Config) Redacted(ctx context.Context) (map[string]any, error) {
+	// 替换解密前的加密值，因此输出不包含解密的明文
+	data, err := c.data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	redacted, _ := redact(data, c.sensitivePatterns(), c.secretSuffix(), c.encryptedPattern()).(map[string]any)
+	return redacted, nil
+}
+
+// Dump 返回替换敏感的值后的全部配置数据的 YAML，键按字母排序以便比较，开头的注释说明配置的来源和当前的环境
+func (c *Config) Dump(ctx context.Context) (string, error) {
+	data, err := c.Redacted(ctx)
+	if err != nil {
+		return "", err
+	}
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# sources: %s\n", c.sources())
+	if profile := c.Profile(); profile != "" {
+		fmt.Fprintf(&b, "# profile: %s\n", profile)
+	}
+	b.Write(content)
+	return b.String(), nil
+}
+
+// Handler 返回以 JSON 输出替换敏感的值后的全部配置数据的 HTTP 处理器，用于挂载为内部的调试接口，
+// 例如 mhttp 的 Server.EnableConfigDump；请求参数 `key` 不为空时输出该配置键的 Explain。
+func (c *Config) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var (
+			output any
+			err    error
+		)
+		if key := r.URL.Query().Get("key"); key != "" {
+			var e *Explanation
+			if e, err = c.Explain(r.Context(), key); err == nil {
+				if (c.isSensitive(key) || e.Source == SourceFile) && e.Value != nil {
+					e.Value = RedactedValue
+				} else if e.Value != nil {
+					value, _ := c.resolve(r.Context(), key, nil)
+					e.Value = redact(value, c.sensitivePatterns(), c.secretSuffix(), c.encryptedPattern())
+				}
+				output = e
+			}
+		} else {
+			var data map[string]any
+			if data, err = c.Redacted(r.Context()); err == nil {
+				output = map[string]any{
+					"sources": c.sources(),
+					"profile": c.Profile(),
+					"config":  data,
+				}
+			}
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(output)
+	})
+}
+
+// sensitivePatterns 返回敏感配置键的模式
+func (c *Config) sensitivePatterns() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sensitive == nil {
+		return defaultSensitivePatterns
+	}
+	return c.sensitive
+}
+
+// isSensitive 检查配置键 `key` 的任一级键名是否敏感
+func (c *Config) isSensitive(key string) bool {
+	patterns := c.sensitivePatterns()
+	for _, segment := range splitKey(key) {
+		if matchSensitive(segment, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact 返回将 `value` 中敏感的键的值替换为 RedactedValue 后的副本，
+// `secretSuffix` 不为空时从密钥文件读取的值也是敏感的，匹配 `encrypted` 的加密值也是敏感的，
+// 见 EnableSecretFiles 和 SetDecryptFunc
+func redact(value any, patterns []string, secretSuffix string, encrypted *regexp.Regexp) any {
+	switch v := value.(type) {
+	case string:
+		if encrypted != nil && encrypted.MatchString(v) {
+			return RedactedValue
+		}
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			secret := secretSuffix != "" && v[strings.ToLower(key)+secretSuffix] != nil
+			if item != nil && (secret || matchSensitive(key, patterns)) {
+				result[key] = RedactedValue
+				continue
+			}
+			result[key] = redact(item, patterns, secretSuffix, encrypted)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = redact(item, patterns, secretSuffix, encrypted)
+		}
+		return result
+	}
+	return value
+}
+
+// matchSensitive 检查键名 `key` 是否包含任一模式 `patterns`，不区分大小写
+func matchSensitive(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if pattern != "" && strings.Contains(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package mcfg_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const explainFixture = `
+server:
+  address: ":8000"
+  timeout: 30s
+database:
+  dsn: mysql://localhost/app
+  password: db-secret
+clients:
+  - name: api
+    api_token: abc
+`
+
+func TestConfig_Explain(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", explainFixture)
+	c.SetDefault("server.port", 8080)
+	t.Setenv("EXPLAIN_SERVER_TIMEOUT", "60s")
+	c.BindEnv("server.timeout", "EXPLAIN_SERVER_TIMEOUT")
+	if err := c.Set(ctx, "database.dsn", "mysql://runtime/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		value  any
+		source mcfg.Source
+		origin string
+	}{
+		{"server.address", ":8000", mcfg.SourceAdapter, "config.yaml"},
+		{"server.timeout", "60s", mcfg.SourceEnv, `environment variable "EXPLAIN_SERVER_TIMEOUT"`},
+		{"server.port", 8080, mcfg.SourceDefault, "Config.SetDefault"},
+		{"database.dsn", "mysql://runtime/app", mcfg.SourceSet, "Config.Set"},
+		{"missing", nil, mcfg.SourceNone, ""},
+	}
+	for _, tt := range tests {
+		e, err := c.Explain(ctx, tt.key)
+		if err != nil {
+			t.Fatalf("Explain(%q) error = %v", tt.key, err)
+		}
+		if e.Value != tt.value || e.Source != tt.source || !strings.Contains(e.Origin, tt.origin) {
+			t.Errorf("Explain(%q) expected %v from %s %s, got %+v", tt.key, tt.value, tt.source, tt.origin, e)
+		}
+	}
+}
+
+func TestConfig_Dump(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", explainFixture)
+	dump, err := c.Dump(ctx)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if strings.Contains(dump, "db-secret") || strings.Contains(dump, "abc") {
+		t.Errorf("Dump() expected the sensitive values to be redacted, got\n%s", dump)
+	}
+	if !strings.Contains(dump, "password: '"+mcfg.RedactedValue+"'") || !strings.Contains(dump, "# sources: config file") {
+		t.Errorf("Dump() got\n%s", dump)
+	}
+	// 键按字母排序
+	if strings.Index(dump, "clients:") > strings.Index(dump, "database:") || strings.Index(dump, "database:") > strings.Index(dump, "server:") {
+		t.Errorf("Dump() expected the sorted keys, got\n%s", dump)
+	}
+	again, _ := c.Dump(ctx)
+	if again != dump {
+		t.Error("Dump() expected the deterministic output")
+	}
+
+	c.SetSensitivePatterns("DSN")
+	data, err := c.Redacted(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := data["database"].(map[string]any)
+	if database["dsn"] != mcfg.RedactedValue || database["password"] != "db-secret" {
+		t.Errorf("Redacted() expected the dsn to be redacted, got %v", database)
+	}
+}
+
+func TestConfig_Handler(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", explainFixture)
+	handler := c.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Handler() expected status 200, got %d", w.Code)
+	}
+	var output struct {
+		Sources string         `json:"sources"`
+		Config  map[string]any `json:"config"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+	if output.Config["database"].(map[string]any)["password"] != mcfg.RedactedValue {
+		t.Errorf("Handler() expected the redacted password, got %v", output.Config)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/config?key=database.password", nil))
+	var e mcfg.Explanation
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Value != mcfg.RedactedValue || e.Source != mcfg.SourceAdapter {
+		t.Errorf("Handler() expected the redacted explanation, got %+v", e)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Handler() expected status 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package mcfg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// ConfigFlagName 是选择配置文件的命令行参数名，例如 `--config=/etc/app/config.yaml`
+const ConfigFlagName = "config"
+
+// FlagSource 是覆盖配置的命令行参数的来源，用于适配 pflag 等其他参数解析库
+type FlagSource interface {
+	// Changed 返回在命令行中设置的参数名和值，参数名是配置键，例如 "server.address"
+	Changed() map[string]string
+}
+
+// FlagSourceFunc 是函数形式的 FlagSource，例如适配 pflag：
+//
+//	c.BindFlagSource(mcfg.FlagSourceFunc(func() map[string]string {
+//		changed := map[string]string{}
+//		fs.Visit(func(f *pflag.Flag) { changed[f.Name] = f.Value.String() })
+//		return changed
+//	}))
+type FlagSourceFunc func() map[string]string
+
+// Changed 实现 FlagSource 接口
+func (f FlagSourceFunc) Changed() map[string]string {
+	return f()
+}
+
+// flagSetSource 是标准库 flag.FlagSet 的 FlagSource
+type flagSetSource struct {
+	fs *flag.FlagSet
+}
+
+// Changed 实现 FlagSource 接口，flag.FlagSet.Visit 只访问设置过的参数
+func (s flagSetSource) Changed() map[string]string {
+	changed := map[string]string{}
+	s.fs.Visit(func(f *flag.Flag) {
+		changed[f.Name] = f.Value.String()
+	})
+	return changed
+}
+
+// BindFlags 绑定命令行参数 `fs`，在命令行中设置的参数以其参数名为配置键覆盖配置，
+// 例如 `--server.address=:8081` 覆盖配置 "server.address"，优先级高于环境变量。
+// 参数的默认值不覆盖配置，需先定义参数，见 ParseFlags。
+func (c *Config) BindFlags(fs *flag.FlagSet) {
+	c.BindFlagSource(flagSetSource{fs: fs})
+}
+
+// BindFlagSource 绑定命令行参数的来源 `source`，见 BindFlags
+func (c *Config) BindFlagSource(source FlagSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flags = source
+}
+
+// ParseFlags 解析命令行参数 `args` 并绑定到配置，见 BindFlags。
+// 参数 `--config` 在其他参数之前选择配置文件，然后为配置中的各个键定义未定义的参数，
+// 因此 `--server.address=:8081` 无需在 main 中定义；未定义且不是配置键的参数按 `fs` 的方式报错。
+func (c *Config) ParseFlags(fs *flag.FlagSet, args []string) error {
+	if path := flagValue(args, ConfigFlagName); path != "" {
+		adapter, ok := c.GetAdapter().(*AdapterFile)
+		if !ok {
+			return merror.NewCodef(mcode.CodeNotSupported, `config adapter %T does not support selecting a config file`, c.GetAdapter())
+		}
+		if err := adapter.SetConfigFile(path); err != nil {
+			return err
+		}
+	}
+	if fs.Lookup(ConfigFlagName) == nil {
+		fs.String(ConfigFlagName, "", "config file path")
+	}
+	data, err := c.GetAdapter().Data(context.Background())
+	if err != nil {
+		return err
+	}
+	defineFlags(fs, "", data)
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	c.BindFlags(fs)
+	return nil
+}
+
+// ParseFlags 以程序的命令行参数调用默认配置实例的 ParseFlags，用于 main 中的常见场景
+func ParseFlags() error {
+	return Instance().ParseFlags(flag.CommandLine, os.Args[1:])
+}
+
+// defineFlags 为配置数据 `data` 中键 `prefix` 下的各个值定义未定义的参数
+func defineFlags(fs *flag.FlagSet, prefix string, data map[string]any) {
+	for key, value := range data {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			defineFlags(fs, key, v)
+		case []any:
+			// 列表无法以单个参数覆盖
+		default:
+			if fs.Lookup(key) == nil {
+				fs.String(key, fmt.Sprint(v), "config "+key)
+			}
+		}
+	}
+}
+
+// flagValue 返回 `args` 中参数 `name` 的值，支持 `-name value`、`--name=value` 等形式
+func flagValue(args []string, name string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// flagLayer 是命令行参数的覆盖层
+type flagLayer struct {
+	changed map[string]string   // 设置过的参数，参数名为小写的配置键
+	fold    func(string) string // 折叠配置键的函数，见 SetKeyMatching
+}
+
+// newFlagLayer 创建 `source` 当前的覆盖层，`fold` 不为空时以其折叠参数名，见 SetKeyMatching
+func newFlagLayer(source FlagSource, fold func(string) string) flagLayer {
+	changed := map[string]string{}
+	for name, value := range source.Changed() {
+		if name == ConfigFlagName {
+			continue
+		}
+		name = strings.ToLower(name)
+		if fold != nil {
+			name = fold(name)
+		}
+		changed[name] = value
+	}
+	return flagLayer{changed: changed, fold: fold}
+}
+
+// lookup 实现 overrideLayer 接口
+func (l flagLayer) lookup(key string) (string, bool) {
+	key = strings.ToLower(key)
+	if l.fold != nil {
+		key = l.fold(key)
+	}
+	value, ok := l.changed[key]
+	return value, ok
+}
+
+// origin 实现 overrideLayer 接口
+func (l flagLayer) origin(key string) (Source, string) {
+	return SourceFlag, fmt.Sprintf(`flag "--%s"`, strings.ToLower(key))
+}
+
+// keys 实现 overrideLayer 接口
+func (l flagLayer) keys() []string {
+	keys := make([]string, 0, len(l.changed))
+	for key := range l.changed {
+		keys = append(keys, key)
+	}
+	return keys
+}
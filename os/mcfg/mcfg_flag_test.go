@@ -0,0 +1,77 @@
+package mcfg_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_BindFlags(t *testing.T) {
+	t.Setenv("MALTOSE_SERVER_ADDRESS", ":9000")
+	c := newEnvConfig()
+	c.SetEnvPrefix("MALTOSE")
+	c.AutomaticEnv(true)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("server.address", ":1000", "server address")
+	fs.String("server.timeout", "10", "server timeout")
+	if err := fs.Parse([]string{"--server.address=:8081"}); err != nil {
+		t.Fatal(err)
+	}
+	c.BindFlags(fs)
+
+	// 命令行参数 > 环境变量 > 配置，未设置的参数的默认值不覆盖配置
+	val, _ := c.Get(ctx, "server.address")
+	if val.String() != ":8081" {
+		t.Errorf("Get() expected ':8081', got %v", val)
+	}
+	val, _ = c.Get(ctx, "server.timeout")
+	if val.Int() != 30 {
+		t.Errorf("Get() expected 30, got %v", val)
+	}
+	data, _ := c.Data(ctx)
+	if address := data["server"].(map[string]any)["address"]; address != ":8081" {
+		t.Errorf("Data() expected address ':8081', got %v", address)
+	}
+}
+
+func TestConfig_ParseFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte(fixtureConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := mcfg.NewWithAdapter(adapter)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "verbose output")
+	err = c.ParseFlags(fs, []string{"--config", path, "--database.port=6543", "-verbose", "arg"})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if !*verbose || fs.Arg(0) != "arg" {
+		t.Errorf("ParseFlags() expected the flags of the application, got %v %v", *verbose, fs.Args())
+	}
+	val, _ := c.Get(ctx, "database.port")
+	if val.Int() != 6543 {
+		t.Errorf("Get() expected 6543, got %v", val)
+	}
+	val, _ = c.Get(ctx, "app.name")
+	if val.String() != "test-app" {
+		t.Errorf("Get() expected 'test-app', got %v", val)
+	}
+
+	// 未定义且不是配置键的参数不被忽略
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err = c.ParseFlags(fs, []string{"--unknown=1"}); err == nil {
+		t.Error("ParseFlags() expected error for an unknown flag")
+	}
+}
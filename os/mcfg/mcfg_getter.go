@@ -0,0 +1,267 @@
+package mcfg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/mconv"
+)
+
+// 类型化的读取方法以相同的规则转换各个来源的值，例如环境变量的字符串 "true"、"30s" 和 "a,b"。
+// 不带 E 后缀的方法在配置不存在时返回可选参数 `def` 的默认值或零值，无法转换时返回零值，带 E 后缀的方法返回错误：
+// 配置不存在时错误码为 mcode.CodeMissingConfiguration，无法转换时为 mcode.CodeInvalidConfiguration。
+// 配置不存在指所有来源中都没有该键且没有 SetDefault 的默认值，值为空字符串、0 或 false 时返回该值而不是 `def`。
+
+// GetString 获取字符串类型的配置值，见 GetStringE
+func (c *Config) GetString(ctx context.Context, pattern string, def ...string) string {
+	v, _ := getAs(ctx, c, pattern, mconv.ToStringE, def)
+	return v
+}
+
+// GetStringE 获取字符串类型的配置值
+func (c *Config) GetStringE(ctx context.Context, pattern string) (string, error) {
+	return getAs(ctx, c, pattern, mconv.ToStringE, nil)
+}
+
+// GetInt 获取 int 类型的配置值，见 GetIntE
+func (c *Config) GetInt(ctx context.Context, pattern string, def ...int) int {
+	v, _ := getAs(ctx, c, pattern, mconv.ToIntE, def)
+	return v
+}
+
+// GetIntE 获取 int 类型的配置值
+func (c *Config) GetIntE(ctx context.Context, pattern string) (int, error) {
+	return getAs(ctx, c, pattern, mconv.ToIntE, nil)
+}
+
+// GetInt64 获取 int64 类型的配置值，见 GetInt64E
+func (c *Config) GetInt64(ctx context.Context, pattern string, def ...int64) int64 {
+	v, _ := getAs(ctx, c, pattern, mconv.ToInt64E, def)
+	return v
+}
+
+// GetInt64E 获取 int64 类型的配置值
+func (c *Config) GetInt64E(ctx context.Context, pattern string) (int64, error) {
+	return getAs(ctx, c, pattern, mconv.ToInt64E, nil)
+}
+
+// GetFloat64 获取 float64 类型的配置值，见 GetFloat64E
+func (c *Config) GetFloat64(ctx context.Context, pattern string, def ...float64) float64 {
+	v, _ := getAs(ctx, c, pattern, mconv.ToFloat64E, def)
+	return v
+}
+
+// GetFloat64E 获取 float64 类型的配置值
+func (c *Config) GetFloat64E(ctx context.Context, pattern string) (float64, error) {
+	return getAs(ctx, c, pattern, mconv.ToFloat64E, nil)
+}
+
+// GetBool 获取 bool 类型的配置值，见 GetBoolE
+func (c *Config) GetBool(ctx context.Context, pattern string, def ...bool) bool {
+	v, _ := getAs(ctx, c, pattern, mconv.ToBoolE, def)
+	return v
+}
+
+// GetBoolE 获取 bool 类型的配置值，支持 "true"、"1" 等字符串
+func (c *Config) GetBoolE(ctx context.Context, pattern string) (bool, error) {
+	return getAs(ctx, c, pattern, mconv.ToBoolE, nil)
+}
+
+// GetDuration 获取 time.Duration 类型的配置值，见 GetDurationE
+func (c *Config) GetDuration(ctx context.Context, pattern string, def ...time.Duration) time.Duration {
+	v, _ := getAs(ctx, c, pattern, parseDuration, def)
+	return v
+}
+
+// GetDurationE 获取 time.Duration 类型的配置值，支持 "30s" 等字符串，数字和数字字符串为秒数
+func (c *Config) GetDurationE(ctx context.Context, pattern string) (time.Duration, error) {
+	return getAs(ctx, c, pattern, parseDuration, nil)
+}
+
+// GetBytes 获取以字节为单位的大小的配置值，见 GetBytesE
+func (c *Config) GetBytes(ctx context.Context, pattern string, def ...int64) int64 {
+	v, _ := getAs(ctx, c, pattern, ParseBytes, def)
+	return v
+}
+
+// GetBytesE 获取以字节为单位的大小的配置值，支持 "8MB"、"512KiB" 等字符串，不区分大小写，数字为字节数。
+// KB、MB、GB 和 TB 以 1000 进位，KiB、MiB、GiB、TiB 以及单字母的 K、M、G、T 以 1024 进位。
+func (c *Config) GetBytesE(ctx context.Context, pattern string) (int64, error) {
+	return getAs(ctx, c, pattern, ParseBytes, nil)
+}
+
+// GetTime 获取 time.Time 类型的配置值，见 GetTimeE，可选参数是时间格式而不是默认值
+func (c *Config) GetTime(ctx context.Context, pattern string, format ...string) time.Time {
+	v, _ := c.GetTimeE(ctx, pattern, format...)
+	return v
+}
+
+// GetTimeE 获取 time.Time 类型的配置值，可选参数 `format` 是字符串值的时间格式
+func (c *Config) GetTimeE(ctx context.Context, pattern string, format ...string) (time.Time, error) {
+	return getAs(ctx, c, pattern, func(value any) (time.Time, error) {
+		return mconv.ToTimeE(value, format...)
+	}, nil)
+}
+
+// GetStringSlice 获取 []string 类型的配置值，见 GetStringSliceE
+func (c *Config) GetStringSlice(ctx context.Context, pattern string, def ...[]string) []string {
+	v, _ := getAs(ctx, c, pattern, toStringSlice, def)
+	return v
+}
+
+// GetStringSliceE 获取 []string 类型的配置值，字符串值以逗号分隔，例如环境变量 "a,b"
+func (c *Config) GetStringSliceE(ctx context.Context, pattern string) ([]string, error) {
+	return getAs(ctx, c, pattern, toStringSlice, nil)
+}
+
+// GetIntSlice 获取 []int 类型的配置值，见 GetIntSliceE
+func (c *Config) GetIntSlice(ctx context.Context, pattern string, def ...[]int) []int {
+	v, _ := getAs(ctx, c, pattern, toIntSlice, def)
+	return v
+}
+
+// GetIntSliceE 获取 []int 类型的配置值，字符串值以逗号分隔，例如环境变量 "1,2"
+func (c *Config) GetIntSliceE(ctx context.Context, pattern string) ([]int, error) {
+	return getAs(ctx, c, pattern, toIntSlice, nil)
+}
+
+// GetStringMap 获取 map[string]any 类型的配置值，见 GetStringMapE
+func (c *Config) GetStringMap(ctx context.Context, pattern string, def ...map[string]any) map[string]any {
+	v, _ := getAs(ctx, c, pattern, toStringMap, def)
+	return v
+}
+
+// GetStringMapE 获取 map[string]any 类型的配置值，字符串值按 JSON 解析
+func (c *Config) GetStringMapE(ctx context.Context, pattern string) (map[string]any, error) {
+	return getAs(ctx, c, pattern, toStringMap, nil)
+}
+
+// GetMap 获取保留嵌套结构的 map[string]any 类型的配置值，见 GetMapE
+func (c *Config) GetMap(ctx context.Context, pattern string, def ...map[string]any) map[string]any {
+	v, _ := getAs(ctx, c, pattern, toMap, def)
+	return v
+}
+
+// GetMapE 获取保留嵌套结构的 map[string]any 类型的配置值，用于原样传给驱动等的选项，例如 "redis.options"。
+// 返回的是配置的深拷贝，修改它不影响配置；其中的 map 均为 map[string]any，列表均为 []any，
+// 因此可以直接编码为 JSON。字符串值按 JSON 解析。
+func (c *Config) GetMapE(ctx context.Context, pattern string) (map[string]any, error) {
+	return getAs(ctx, c, pattern, toMap, nil)
+}
+
+// GetMapSlice 获取 []map[string]any 类型的配置值，见 GetMapSliceE
+func (c *Config) GetMapSlice(ctx context.Context, pattern string, def ...[]map[string]any) []map[string]any {
+	v, _ := getAs(ctx, c, pattern, toMapSlice, def)
+	return v
+}
+
+// GetMapSliceE 获取 []map[string]any 类型的配置值，用于对象的列表，例如 "server.upstreams"。
+// 与 GetMapE 相同，返回的是保留嵌套结构的深拷贝，字符串值按 JSON 解析。
+func (c *Config) GetMapSliceE(ctx context.Context, pattern string) ([]map[string]any, error) {
+	return getAs(ctx, c, pattern, toMapSlice, nil)
+}
+
+// getAs 获取配置值并以 `convert` 转换，配置不存在时返回 `def` 的第一个值
+func getAs[T any](ctx context.Context, c *Config, pattern string, convert func(any) (T, error), def []T) (T, error) {
+	var zero T
+	v, err := c.Get(ctx, pattern)
+	if err != nil {
+		return zero, err
+	}
+	if v == nil {
+		if len(def) > 0 {
+			return def[0], nil
+		}
+		return zero, merror.NewCodef(mcode.CodeMissingConfiguration, `config "%s" not found`, pattern)
+	}
+	result, err := convert(v.Val())
+	if err != nil {
+		return zero, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `invalid config "%s"`, pattern)
+	}
+	return result, nil
+}
+
+// toStringSlice 将配置值转换为 []string，字符串值以逗号分隔
+func toStringSlice(value any) ([]string, error) {
+	return mconv.ToStringSliceE(splitList(value))
+}
+
+// toIntSlice 将配置值转换为 []int，字符串值以逗号分隔
+func toIntSlice(value any) ([]int, error) {
+	return mconv.ToIntSliceE(splitList(value))
+}
+
+// toStringMap 将配置值转换为 map[string]any，字符串值按 JSON 解析
+func toStringMap(value any) (map[string]any, error) {
+	if s, ok := value.(string); ok {
+		return mconv.ToMapFromJSONE(s)
+	}
+	return mconv.ToMapE(value)
+}
+
+// toMap 将配置值转换为规范化的 map[string]any 的深拷贝，字符串值按 JSON 解析
+func toMap(value any) (map[string]any, error) {
+	value, err := parseJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := normalizeValue(value).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf(`cannot convert %T to map`, value)
+	}
+	return m, nil
+}
+
+// toMapSlice 将配置值转换为规范化的 []map[string]any 的深拷贝，字符串值按 JSON 解析
+func toMapSlice(value any) ([]map[string]any, error) {
+	value, err := parseJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := normalizeValue(value).([]any)
+	if !ok {
+		return nil, fmt.Errorf(`cannot convert %T to map slice`, value)
+	}
+	result := make([]map[string]any, len(list))
+	for i, item := range list {
+		if result[i], ok = item.(map[string]any); !ok {
+			return nil, fmt.Errorf(`cannot convert item %d of type %T to map`, i, item)
+		}
+	}
+	return result, nil
+}
+
+// parseJSON 按 JSON 解析字符串值，其他值原样返回
+func parseJSON(value any) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	var result any
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// splitList 将字符串值以逗号分隔为列表，其他值原样返回
+func splitList(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []string{}
+	}
+	items := strings.Split(s, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
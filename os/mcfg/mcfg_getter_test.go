@@ -0,0 +1,217 @@
+package mcfg_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_TypedGetters(t *testing.T) {
+	file := newFileConfig(t, "config.yaml", `
+app:
+  name: test-app
+  debug: true
+  port: 8080
+  ratio: 0.5
+  timeout: 30s
+  started: 2025-01-02T15:04:05Z
+  tags: [a, b]
+  ports: [80, 443]
+  labels:
+    team: core
+`)
+	env := mcfg.NewWithAdapter(&mapAdapter{data: map[string]any{}})
+	env.SetEnvPrefix("TYPED")
+	env.AutomaticEnv(true)
+	for key, value := range map[string]string{
+		"TYPED_APP_NAME":    "test-app",
+		"TYPED_APP_DEBUG":   "true",
+		"TYPED_APP_PORT":    "8080",
+		"TYPED_APP_RATIO":   "0.5",
+		"TYPED_APP_TIMEOUT": "30s",
+		"TYPED_APP_STARTED": "2025-01-02T15:04:05Z",
+		"TYPED_APP_TAGS":    "a, b",
+		"TYPED_APP_PORTS":   "80,443",
+		"TYPED_APP_LABELS":  `{"team": "core"}`,
+	} {
+		t.Setenv(key, value)
+	}
+
+	// 配置文件和环境变量的值转换结果相同
+	for name, c := range map[string]*mcfg.Config{"file": file, "env": env} {
+		if v := c.GetString(ctx, "app.name"); v != "test-app" {
+			t.Errorf("%s: GetString() got %v", name, v)
+		}
+		if v := c.GetBool(ctx, "app.debug"); !v {
+			t.Errorf("%s: GetBool() got %v", name, v)
+		}
+		if v := c.GetInt(ctx, "app.port"); v != 8080 {
+			t.Errorf("%s: GetInt() got %v", name, v)
+		}
+		if v := c.GetInt64(ctx, "app.port"); v != 8080 {
+			t.Errorf("%s: GetInt64() got %v", name, v)
+		}
+		if v := c.GetFloat64(ctx, "app.ratio"); v != 0.5 {
+			t.Errorf("%s: GetFloat64() got %v", name, v)
+		}
+		if v := c.GetDuration(ctx, "app.timeout"); v != 30*time.Second {
+			t.Errorf("%s: GetDuration() got %v", name, v)
+		}
+		if v := c.GetTime(ctx, "app.started"); !v.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)) {
+			t.Errorf("%s: GetTime() got %v", name, v)
+		}
+		if v := c.GetStringSlice(ctx, "app.tags"); len(v) != 2 || v[0] != "a" || v[1] != "b" {
+			t.Errorf("%s: GetStringSlice() got %v", name, v)
+		}
+		if v := c.GetIntSlice(ctx, "app.ports"); len(v) != 2 || v[0] != 80 || v[1] != 443 {
+			t.Errorf("%s: GetIntSlice() got %v", name, v)
+		}
+		if v := c.GetStringMap(ctx, "app.labels"); v["team"] != "core" {
+			t.Errorf("%s: GetStringMap() got %v", name, v)
+		}
+	}
+
+	// 不存在和无法转换的值
+	v, err := file.GetIntE(ctx, "app.missing")
+	if v != 0 {
+		t.Errorf("GetIntE() expected 0 for the missing value, got %v", v)
+	}
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+	v, err = file.GetIntE(ctx, "app.name")
+	if v != 0 {
+		t.Errorf("GetIntE() expected 0 for the invalid value, got %v", v)
+	}
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	if v := file.GetBool(ctx, "app.missing"); v {
+		t.Errorf("GetBool() expected false, got %v", v)
+	}
+}
+
+func TestConfig_GetterDefaults(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+app:
+  name: ""
+  port: 0
+  debug: false
+  tags: []
+  nothing: null
+`)
+	c.SetDefault("app.timeout", "10s")
+
+	// 空值不是不存在，返回配置的值
+	if v := c.GetString(ctx, "app.name", "default"); v != "" {
+		t.Errorf("GetString() expected the empty string, got %q", v)
+	}
+	if v := c.GetInt(ctx, "app.port", 8080); v != 0 {
+		t.Errorf("GetInt() expected 0, got %v", v)
+	}
+	if v := c.GetBool(ctx, "app.debug", true); v {
+		t.Errorf("GetBool() expected false, got %v", v)
+	}
+	if v := c.GetStringSlice(ctx, "app.tags", []string{"a"}); len(v) != 0 {
+		t.Errorf("GetStringSlice() expected the empty slice, got %v", v)
+	}
+	if v, _ := c.Get(ctx, "app.name", "default"); v.String() != "" {
+		t.Errorf("Get() expected the empty string, got %v", v)
+	}
+
+	// 不存在时返回默认值，null 视为不存在
+	if v := c.GetString(ctx, "app.missing", "default"); v != "default" {
+		t.Errorf("GetString() expected default, got %q", v)
+	}
+	if v := c.GetString(ctx, "app.nothing", "default"); v != "default" {
+		t.Errorf("GetString() expected default for null, got %q", v)
+	}
+	if v := c.GetInt(ctx, "app.workers", 4); v != 4 {
+		t.Errorf("GetInt() expected 4, got %v", v)
+	}
+	if v := c.GetStringMap(ctx, "app.labels", map[string]any{"team": "core"}); v["team"] != "core" {
+		t.Errorf("GetStringMap() expected the default map, got %v", v)
+	}
+	if v, _ := c.Get(ctx, "app.missing", "default"); v.String() != "default" {
+		t.Errorf("Get() expected default, got %v", v)
+	}
+
+	// SetDefault 的默认值优先于参数的默认值
+	if v := c.GetDuration(ctx, "app.timeout", time.Minute); v != 10*time.Second {
+		t.Errorf("GetDuration() expected 10s of SetDefault, got %v", v)
+	}
+	// 无法转换时返回零值而不是默认值
+	if v := c.GetInt(ctx, "app.name", 8080); v != 0 {
+		t.Errorf("GetInt() expected 0 for the invalid value, got %v", v)
+	}
+	_, err := c.GetStringE(ctx, "app.missing")
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+}
+
+func TestConfig_GetMap(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+redis:
+  options:
+    pool:
+      size: 10
+      idle: [1, 2]
+    readonly: true
+server:
+  upstreams:
+    - host: a
+      port: 80
+    - host: b
+      tags:
+        zone: east
+`)
+	options := c.GetMap(ctx, "redis.options")
+	pool, ok := options["pool"].(map[string]any)
+	if !ok || pool["size"] != 10 || options["readonly"] != true {
+		t.Fatalf("GetMap() expected the nested structure, got %#v", options)
+	}
+
+	// 返回深拷贝，修改不影响配置
+	pool["size"] = 20
+	pool["idle"].([]any)[0] = 100
+	if again := c.GetMap(ctx, "redis.options"); again["pool"].(map[string]any)["size"] != 10 ||
+		again["pool"].(map[string]any)["idle"].([]any)[0] != 1 {
+		t.Errorf("GetMap() expected a deep copy, got %#v", again)
+	}
+
+	// map[any]any 规范化为 map[string]any，可以编码为 JSON
+	if err := c.Set(ctx, "driver", map[any]any{"opts": map[any]any{1: "one"}, "list": []any{map[any]any{"k": "v"}}}); err != nil {
+		t.Fatal(err)
+	}
+	driver, err := c.GetMapE(ctx, "driver")
+	if err != nil {
+		t.Fatalf("GetMapE() error = %v", err)
+	}
+	content, err := json.Marshal(driver)
+	if err != nil || string(content) != `{"list":[{"k":"v"}],"opts":{"1":"one"}}` {
+		t.Errorf("GetMapE() expected a JSON compatible map, got %s, %v", content, err)
+	}
+
+	upstreams := c.GetMapSlice(ctx, "server.upstreams")
+	if len(upstreams) != 2 || upstreams[0]["host"] != "a" || upstreams[1]["tags"].(map[string]any)["zone"] != "east" {
+		t.Errorf("GetMapSlice() got %#v", upstreams)
+	}
+	upstreams[0]["host"] = "changed"
+	if v := c.GetMapSlice(ctx, "server.upstreams"); v[0]["host"] != "a" {
+		t.Errorf("GetMapSlice() expected a deep copy, got %#v", v)
+	}
+
+	// 字符串值按 JSON 解析，例如环境变量
+	if err = c.Set(ctx, "env.options", `{"pool":{"size":5}}`); err != nil {
+		t.Fatal(err)
+	}
+	if v := c.GetMap(ctx, "env.options"); v["pool"].(map[string]any)["size"] != float64(5) {
+		t.Errorf("GetMap() expected the parsed JSON, got %#v", v)
+	}
+
+	_, err = c.GetMapE(ctx, "redis.options.pool.size")
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	_, err = c.GetMapSliceE(ctx, "redis.options")
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	_, err = c.GetMapE(ctx, "redis.missing")
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+}
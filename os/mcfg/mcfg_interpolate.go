@@ -0,0 +1,142 @@
+package mcfg
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/mconv"
+)
+
+// EnableInterpolation 设置是否替换配置值中的引用，默认关闭以免改变已有的配置：
+//
+//	url: "https://${HOST:-localhost}:${PORT}/api" # 环境变量，":-" 之后是未设置时的默认值
+//	log_path: "${paths.base}/logs"                # 配置键的有效值，包括环境变量等覆盖的值
+//	price: "$${literal}"                          # "$${" 表示字面的 "${"
+//
+// 名称含有 "." 的引用是配置键，否则是环境变量，环境变量未设置时查找同名的配置键。
+// 值完全是一个引用时保留被引用的值的类型。引用在每次读取时替换，因此重新加载后使用新的值；
+// 引用不存在且没有默认值时，以及循环引用时，Get 和 Data 返回错误。
+func (c *Config) EnableInterpolation(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interpolation = enabled
+}
+
+// interpolationEnabled 检查是否开启插值
+func (c *Config) interpolationEnabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interpolation
+}
+
+// interpolate 返回替换配置键 `key` 的值 `value` 中的引用后的值，`stack` 是正在插值的配置键，用于发现循环引用
+func (c *Config) interpolate(ctx context.Context, key string, value any, stack []string) (any, error) {
+	if key != "" {
+		lower := strings.ToLower(key)
+		for i, k := range stack {
+			if k == lower {
+				loop := append(append([]string(nil), stack[i:]...), lower)
+				return nil, merror.NewCodef(mcode.CodeInvalidConfiguration, `config interpolation cycle: %s`, strings.Join(loop, " -> "))
+			}
+		}
+		stack = append(stack[:len(stack):len(stack)], lower)
+	}
+	switch v := value.(type) {
+	case string:
+		return c.interpolateString(ctx, key, v, stack)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			childKey := strings.ReplaceAll(k, ".", `\.`)
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			interpolated, err := c.interpolate(ctx, childKey, item, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = interpolated
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			childKey := mconv.ToString(i)
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			interpolated, err := c.interpolate(ctx, childKey, item, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = interpolated
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// interpolateString 返回替换字符串 `s` 中的引用后的值
+func (c *Config) interpolateString(ctx context.Context, key string, s string, stack []string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+		// "$${" 是字面的 "${"
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1])
+			b.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			break
+		}
+		end += start
+		value, err := c.reference(ctx, key, s[start+2:end], stack)
+		if err != nil {
+			return nil, err
+		}
+		// 值完全是一个引用时保留被引用的值的类型
+		if start == 0 && end == len(s)-1 && b.Len() == 0 {
+			return value, nil
+		}
+		b.WriteString(s[:start])
+		b.WriteString(mconv.ToString(value))
+		s = s[end+1:]
+	}
+	return b.String(), nil
+}
+
+// reference 返回引用 `expr` 的值，`expr` 是环境变量名或配置键，可带有 ":-" 之后的默认值
+func (c *Config) reference(ctx context.Context, key string, expr string, stack []string) (any, error) {
+	name, def, hasDef := strings.Cut(expr, ":-")
+	name = strings.TrimSpace(name)
+	if !strings.Contains(name, ".") {
+		if value := os.Getenv(name); value != "" {
+			return value, nil
+		}
+	}
+	value, err := c.effective(ctx, name, stack)
+	if err != nil {
+		return nil, err
+	}
+	if value != nil {
+		return value, nil
+	}
+	if hasDef {
+		return def, nil
+	}
+	return nil, merror.NewCodef(mcode.CodeMissingConfiguration, `config "%s" references undefined "%s"`, key, name)
+}
@@ -0,0 +1,81 @@
+package mcfg_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const interpolateFixture = `
+paths:
+  base: /srv/app
+  logs: ${paths.base}/logs
+server:
+  host: ${INTERPOLATE_HOST:-localhost}
+  port: ${INTERPOLATE_PORT}
+  url: "http://${INTERPOLATE_HOST:-localhost}:${server.port}"
+  workers: ${pool.size}
+pool:
+  size: 8
+price: "$${literal}"
+`
+
+func TestConfig_Interpolation(t *testing.T) {
+	t.Setenv("INTERPOLATE_PORT", "8000")
+	c := newFileConfig(t, "config.yaml", interpolateFixture)
+
+	// 默认不替换引用
+	if val, _ := c.Get(ctx, "paths.logs"); val.String() != "${paths.base}/logs" {
+		t.Errorf("Get() expected the raw value, got %v", val)
+	}
+
+	c.EnableInterpolation(true)
+	if val, err := c.Get(ctx, "paths.logs"); err != nil || val.String() != "/srv/app/logs" {
+		t.Errorf("Get() expected /srv/app/logs, got %v, %v", val, err)
+	}
+	if val, _ := c.Get(ctx, "server.host"); val.String() != "localhost" {
+		t.Errorf("Get() expected default localhost, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "server.url"); val.String() != "http://localhost:8000" {
+		t.Errorf("Get() expected http://localhost:8000, got %v", val)
+	}
+	// 值完全是一个引用时保留被引用的值的类型
+	if val, _ := c.Get(ctx, "server.workers"); val.Val() != 8 {
+		t.Errorf("Get() expected int 8, got %#v", val.Val())
+	}
+	if val, _ := c.Get(ctx, "price"); val.String() != "${literal}" {
+		t.Errorf("Get() expected the escaped ${literal}, got %v", val)
+	}
+
+	// 使用被引用的配置键的有效值
+	t.Setenv("INTERPOLATE_HOST", "example.com")
+	if err := c.Set(ctx, "paths.base", "/opt/app"); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := c.Get(ctx, "paths.logs"); val.String() != "/opt/app/logs" {
+		t.Errorf("Get() expected /opt/app/logs, got %v", val)
+	}
+	data, err := c.Data(ctx)
+	if err != nil {
+		t.Fatalf("Data() error = %v", err)
+	}
+	if url := data["server"].(map[string]any)["url"]; url != "http://example.com:8000" {
+		t.Errorf("Data() expected http://example.com:8000, got %v", url)
+	}
+}
+
+func TestConfig_InterpolationErrors(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", "a: ${b.value}\nb:\n  value: ${c.value}\nc:\n  value: ${a}\nmissing: ${no.such.key}\n")
+	c.EnableInterpolation(true)
+
+	_, err := c.Get(ctx, "a")
+	if err == nil || !strings.Contains(err.Error(), "a -> b.value -> c.value -> a") {
+		t.Errorf("Get() expected the cycle error, got %v", err)
+	}
+	if _, err = c.Data(ctx); err == nil {
+		t.Error("Data() expected the cycle error")
+	}
+	_, err = c.Get(ctx, "missing")
+	if err == nil || !strings.Contains(err.Error(), "no.such.key") {
+		t.Errorf("Get() expected the undefined reference error, got %v", err)
+	}
+}
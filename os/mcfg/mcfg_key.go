@@ -0,0 +1,76 @@
+package mcfg
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// splitKey 将配置键 `key` 以 "." 分隔为路径，其中 "\." 表示键名中的点，"\\" 表示反斜杠，
+// 例如 "hosts.example\.com.port" 的路径为 ["hosts", "example.com", "port"]
+func splitKey(key string) []string {
+	if !strings.Contains(key, `\`) {
+		return strings.Split(key, ".")
+	}
+	var (
+		path    []string
+		segment strings.Builder
+	)
+	for i := 0; i < len(key); i++ {
+		switch ch := key[i]; {
+		case ch == '\\' && i+1 < len(key) && (key[i+1] == '.' || key[i+1] == '\\'):
+			i++
+			segment.WriteByte(key[i])
+		case ch == '.':
+			path = append(path, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(ch)
+		}
+	}
+	return append(path, segment.String())
+}
+
+// lookupPath 返回 `value` 中路径 `path` 的值，数字的段是切片的索引，map 的键不区分大小写。
+// 路径不存在、索引越界或经过标量值时返回 false。
+func lookupPath(value any, path []string) (any, bool) {
+	for _, segment := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			item, ok := v[segment]
+			if !ok {
+				if item, ok = lookupFold(v, segment); !ok {
+					return nil, false
+				}
+			}
+			value = item
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			rv := reflect.ValueOf(value)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				return nil, false
+			}
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= rv.Len() {
+				return nil, false
+			}
+			value = rv.Index(index).Interface()
+		}
+	}
+	return value, true
+}
+
+// lookupFold 返回 `m` 中不区分大小写地匹配 `key` 的值
+func lookupFold(m map[string]any, key string) (any, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
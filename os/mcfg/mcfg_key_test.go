@@ -0,0 +1,96 @@
+package mcfg_test
+
+import (
+	"testing"
+)
+
+const keyFixture = `
+upstreams:
+  - name: api
+    hosts:
+      - host: 10.0.0.1
+        port: 8080
+      - host: 10.0.0.2
+        port: 8081
+    labels:
+      zone: east
+  - name: web
+    hosts: []
+hosts:
+  example.com:
+    port: 443
+  'a\b':
+    port: 80
+matrix:
+  - [1, 2]
+  - [3, 4]
+name: demo
+`
+
+func TestConfig_GetPath(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", keyFixture)
+
+	tests := []struct {
+		pattern string
+		want    any
+	}{
+		{"name", "demo"},
+		{"upstreams.0.name", "api"},
+		{"upstreams.1.name", "web"},
+		{"upstreams.0.hosts.1.host", "10.0.0.2"},
+		{"upstreams.0.hosts.1.port", 8081},
+		{"upstreams.0.labels.zone", "east"},
+		{"Upstreams.0.Labels.Zone", "east"},
+		{"matrix.1.0", 3},
+		{`hosts.example\.com.port`, 443},
+		{`hosts.a\\b.port`, 80},
+		// 不存在的路径
+		{"upstreams.2.name", nil},
+		{"upstreams.-1.name", nil},
+		{"upstreams.first.name", nil},
+		{"upstreams.1.hosts.0", nil},
+		{"name.0", nil},
+		{"name.first", nil},
+		{"upstreams.0.hosts.0.host.ip", nil},
+		{"hosts.example.com.port", nil},
+		{"missing.0", nil},
+	}
+	for _, tt := range tests {
+		val, err := c.Get(ctx, tt.pattern)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		if tt.want == nil {
+			if val != nil {
+				t.Errorf("Get(%q) expected nil, got %v", tt.pattern, val)
+			}
+			continue
+		}
+		if val == nil || val.Val() != tt.want {
+			t.Errorf("Get(%q) expected %v, got %v", tt.pattern, tt.want, val)
+		}
+	}
+
+	// 子树
+	val, err := c.Get(ctx, "upstreams.0")
+	if err != nil || val == nil {
+		t.Fatalf("Get() expected the subtree, got %v, %v", val, err)
+	}
+	if m := val.Map(); m["name"] != "api" || len(m["hosts"].([]any)) != 2 {
+		t.Errorf("Get() expected the upstream map, got %v", m)
+	}
+	labels, err := c.GetStringMapE(ctx, "upstreams.0.labels")
+	if err != nil || labels["zone"] != "east" {
+		t.Errorf("GetStringMapE() expected the labels, got %v, %v", labels, err)
+	}
+
+	// 默认值使用相同的语法
+	c.SetDefault(`hosts.example\.org.port`, 8443)
+	if val, _ = c.Get(ctx, `hosts.example\.org.port`); val == nil || val.Int() != 8443 {
+		t.Errorf("Get() expected the default 8443, got %v", val)
+	}
+	if val, _ = c.Get(ctx, `hosts.example\.com.port`); val == nil || val.Int() != 443 {
+		t.Errorf("Get() expected 443, got %v", val)
+	}
+}
@@ -0,0 +1,187 @@
+package mcfg
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// KeyMatching 是配置键的匹配方式，见 SetKeyMatching
+type KeyMatching int
+
+const (
+	// KeyMatchCaseInsensitive 不区分大小写地匹配配置键，是默认的匹配方式，与配置文件的键转为小写一致
+	KeyMatchCaseInsensitive KeyMatching = iota
+	// KeyMatchFold 另外忽略键名中的 "_" 和 "-"，因此 "maxBodySize"、"maxbodysize" 和 "max_body_size" 是同一个键
+	KeyMatchFold
+)
+
+// SetKeyMatching 设置配置键的匹配方式，默认为 KeyMatchCaseInsensitive。
+//
+// KeyMatchFold 对配置来源的数据、环境变量、命令行参数、Set 和 SetDefault 的键一致地折叠，
+// 例如环境变量 MAX_BODY_SIZE 覆盖配置文件中的 maxBodySize；Data 返回折叠后的键，例如 "maxbodysize"，
+// 引用密钥文件的键保留后缀，见 EnableSecretFiles。同一个 map 中的两个键折叠后相同时，
+// Load、Get 和 Data 返回错误。Unmarshal 匹配字段名时总是忽略大小写和下划线，见 UnmarshalKey。
+func (c *Config) SetKeyMatching(mode KeyMatching) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyMatching = mode
+}
+
+// folding 检查是否折叠配置键
+func (c *Config) folding() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keyMatching == KeyMatchFold
+}
+
+// foldKey 返回折叠后的配置键 `key`，没有开启 KeyMatchFold 时原样返回
+func (c *Config) foldKey(key string) string {
+	if key == "" || !c.folding() {
+		return key
+	}
+	return foldKeyPath(key, c.secretSuffix())
+}
+
+// foldPath 返回折叠后的路径 `path`
+func (c *Config) foldPath(path []string) []string {
+	suffix := c.secretSuffix()
+	folded := make([]string, len(path))
+	for i, segment := range path {
+		folded[i] = foldName(segment, suffix)
+	}
+	return folded
+}
+
+// foldKeyPath 返回折叠配置键 `key` 的各级键名后的配置键，见 foldName
+func foldKeyPath(key string, suffix string) string {
+	path := splitKey(key)
+	for i, segment := range path {
+		path[i] = foldName(segment, suffix)
+	}
+	return joinKey(path)
+}
+
+// foldName 返回转为小写并去掉 "_" 和 "-" 的键名，键名以密钥文件的后缀 `suffix` 结尾时保留后缀
+func foldName(name string, suffix string) string {
+	name = strings.ToLower(name)
+	if suffix != "" {
+		if base, ok := strings.CutSuffix(name, suffix); ok && base != "" {
+			return foldName(base, "") + suffix
+		}
+	}
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+// joinKey 以 "." 连接路径 `path` 为配置键，转义键名中的点和反斜杠，见 splitKey
+func joinKey(path []string) string {
+	escaped := make([]string, len(path))
+	for i, segment := range path {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, ".", `\.`).Replace(segment)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// foldTree 返回折叠 `value` 中所有 map 的键后的副本，`key` 是 `value` 的配置键，
+// 两个键折叠后相同时返回错误，副本中保留其中之一
+func foldTree(value any, key string, suffix string) (any, error) {
+	var firstErr error
+	switch v := value.(type) {
+	case map[string]any:
+		var (
+			result   = make(map[string]any, len(v))
+			original = make(map[string]string, len(v))
+			keys     = make([]string, 0, len(v))
+		)
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			folded := foldName(k, suffix)
+			childKey := joinKey([]string{k})
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			if other, ok := original[folded]; ok && firstErr == nil {
+				otherKey := joinKey([]string{other})
+				if key != "" {
+					otherKey = key + "." + otherKey
+				}
+				firstErr = merror.NewCodef(mcode.CodeInvalidConfiguration, `config keys "%s" and "%s" are the same after folding`, otherKey, childKey)
+			}
+			item, err := foldTree(v[k], childKey, suffix)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result[folded], original[folded] = item, k
+		}
+		return result, firstErr
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			folded, err := foldTree(item, key, suffix)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result[i] = folded
+		}
+		return result, firstErr
+	}
+	return value, nil
+}
+
+// reader 返回读取配置数据的适配器，开启 KeyMatchFold 时返回折叠键的适配器
+func (c *Config) reader() Adapter {
+	adapter := c.GetAdapter()
+	if !c.folding() {
+		return adapter
+	}
+	return foldedAdapter{Adapter: adapter, suffix: c.secretSuffix()}
+}
+
+// foldedAdapter 是折叠适配器数据的键的适配器，每次读取时折叠全部配置数据
+type foldedAdapter struct {
+	Adapter
+	suffix string // 密钥文件的后缀，见 foldName
+}
+
+// Get 实现 Adapter 接口，`pattern` 是折叠后的配置键
+func (a foldedAdapter) Get(ctx context.Context, pattern string) (any, error) {
+	data, err := a.Data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := lookupPath(data, splitKey(pattern))
+	return value, nil
+}
+
+// Data 实现 Adapter 接口
+func (a foldedAdapter) Data(ctx context.Context) (map[string]any, error) {
+	data, err := a.Adapter.Data(ctx)
+	if err != nil || data == nil {
+		return data, err
+	}
+	folded, err := foldTree(data, "", a.suffix)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := folded.(map[string]any)
+	return m, nil
+}
+
+// lookupEnvFold 返回名称折叠后与 `name` 相同的环境变量的名称和值，例如 "MAXBODYSIZE" 匹配 MAX_BODY_SIZE
+func lookupEnvFold(name string) (string, string, bool) {
+	folded := foldName(name, "")
+	for _, env := range os.Environ() {
+		k, v, _ := strings.Cut(env, "=")
+		if foldName(k, "") == folded {
+			return k, v, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,89 @@
+package mcfg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const keyMatchFixture = `
+server:
+  maxBodySize: 8MB
+  read_timeout: 30s
+  idle-timeout: 60s
+`
+
+func TestConfig_KeyMatching(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", keyMatchFixture)
+
+	// 默认不区分大小写，但不忽略下划线
+	if v := c.GetString(ctx, "SERVER.MAXBODYSIZE"); v != "8MB" {
+		t.Errorf("GetString() expected 8MB, got %v", v)
+	}
+	if v := c.GetString(ctx, "server.max_body_size"); v != "" {
+		t.Errorf("GetString() expected no match without folding, got %v", v)
+	}
+
+	c.SetKeyMatching(mcfg.KeyMatchFold)
+	for _, key := range []string{"server.maxBodySize", "server.maxbodysize", "server.max_body_size", "Server.Max-Body-Size"} {
+		if v := c.GetBytes(ctx, key); v != 8_000_000 {
+			t.Errorf("GetBytes(%s) expected 8000000, got %v", key, v)
+		}
+	}
+	if v := c.GetString(ctx, "server.readTimeout"); v != "30s" {
+		t.Errorf("GetString() expected 30s, got %v", v)
+	}
+	if v := c.GetString(ctx, "server.idle_timeout"); v != "60s" {
+		t.Errorf("GetString() expected 60s, got %v", v)
+	}
+
+	// 环境变量、Set 和默认值使用相同的折叠
+	t.Setenv("KEYMATCH_SERVER_READ_TIMEOUT", "45s")
+	c.SetEnvPrefix("KEYMATCH")
+	c.AutomaticEnv(true)
+	if v := c.GetString(ctx, "server.readtimeout"); v != "45s" {
+		t.Errorf("GetString() expected 45s of the environment variable, got %v", v)
+	}
+	if err := c.Set(ctx, "server.idleTimeout", "90s"); err != nil {
+		t.Fatal(err)
+	}
+	if v := c.GetString(ctx, "server.idle-timeout"); v != "90s" {
+		t.Errorf("GetString() expected 90s of Set, got %v", v)
+	}
+	c.SetDefault("server.write_timeout", "15s")
+	if v := c.GetString(ctx, "server.writeTimeout"); v != "15s" {
+		t.Errorf("GetString() expected 15s of SetDefault, got %v", v)
+	}
+	data, err := c.Data(ctx)
+	if err != nil {
+		t.Fatalf("Data() error = %v", err)
+	}
+	server := data["server"].(map[string]any)
+	if server["readtimeout"] != "45s" || server["idletimeout"] != "90s" || server["writetimeout"] != "15s" || len(server) != 4 {
+		t.Errorf("Data() expected the folded keys, got %v", server)
+	}
+
+	var cfg struct {
+		MaxBodySize mcfg.ByteSize `mapstructure:"max_body_size"`
+		ReadTimeout string
+	}
+	if err = c.UnmarshalKey(ctx, "server", &cfg); err != nil || cfg.MaxBodySize != 8_000_000 || cfg.ReadTimeout != "45s" {
+		t.Errorf("UnmarshalKey() expected the folded keys, got %+v, %v", cfg, err)
+	}
+}
+
+func TestConfig_KeyMatchingCollision(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", "server:\n  max_body_size: 8MB\n  maxBodySize: 4MB\n")
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	c.SetKeyMatching(mcfg.KeyMatchFold)
+	err := c.Load(ctx)
+	if err == nil || !strings.Contains(err.Error(), `"server.max_body_size"`) || !strings.Contains(err.Error(), `"server.maxbodysize"`) {
+		t.Errorf("Load() expected the collision error, got %v", err)
+	}
+	if _, err = c.Get(ctx, "server.maxBodySize"); err == nil {
+		t.Error("Get() expected the collision error")
+	}
+}
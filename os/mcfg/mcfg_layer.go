@@ -0,0 +1,120 @@
+package mcfg
+
+import "strings"
+
+// overrideLayer 是覆盖配置的来源，例如环境变量和命令行参数
+type overrideLayer interface {
+	// lookup 返回覆盖配置键 `key` 的值
+	lookup(key string) (string, bool)
+	// keys 返回即使不在配置中也会被设置的配置键
+	keys() []string
+	// origin 返回配置键 `key` 的来源及其描述，例如环境变量名，见 Explain
+	origin(key string) (Source, string)
+}
+
+// layers 返回覆盖配置的来源，按优先级从高到低排列：命令行参数 > 环境变量
+func (c *Config) layers() []overrideLayer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		layers []overrideLayer
+		fold   func(string) string
+	)
+	if c.keyMatching == KeyMatchFold {
+		suffix := c.secrets.fileSuffix()
+		fold = func(key string) string {
+			return foldKeyPath(key, suffix)
+		}
+	}
+	if c.flags != nil {
+		layers = append(layers, newFlagLayer(c.flags, fold))
+	}
+	if c.env.enabled() {
+		env := c.env
+		env.fold = fold
+		layers = append(layers, env)
+	}
+	return layers
+}
+
+// lookupLayers 返回优先级最高的覆盖配置键 `key` 的值
+func lookupLayers(layers []overrideLayer, key string) (string, bool) {
+	for _, layer := range layers {
+		if v, ok := layer.lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// applyLayers 返回以 `layers` 覆盖 `value` 中各个键后的值，`value` 是配置键 `key` 的值，
+// `key` 为空时 `value` 是全部配置数据
+func applyLayers(layers []overrideLayer, key string, value any) any {
+	// 按优先级从低到高应用，优先级高的覆盖优先级低的
+	for i := len(layers) - 1; i >= 0; i-- {
+		value = applyLayer(layers[i], key, value)
+	}
+	return value
+}
+
+// applyLayer 返回以 `layer` 覆盖 `value` 中各个键后的值
+func applyLayer(layer overrideLayer, key string, value any) any {
+	value = overlay(layer, key, value)
+	prefix := strings.ToLower(key)
+	if prefix != "" {
+		prefix += "."
+	}
+	for _, bound := range layer.keys() {
+		if !strings.HasPrefix(bound, prefix) {
+			continue
+		}
+		v, ok := layer.lookup(bound)
+		if !ok {
+			continue
+		}
+		m, isMap := value.(map[string]any)
+		if value != nil && !isMap {
+			continue
+		}
+		if m == nil {
+			m = map[string]any{}
+			value = m
+		}
+		setPath(m, strings.Split(strings.TrimPrefix(bound, prefix), "."), v)
+	}
+	return value
+}
+
+// overlay 返回以 `layer` 覆盖 `value` 中各个键后的副本，`value` 不是 map 时原样返回
+func overlay(layer overrideLayer, key string, value any) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		childKey := k
+		if key != "" {
+			childKey = key + "." + k
+		}
+		if override, ok := layer.lookup(childKey); ok {
+			result[k] = override
+			continue
+		}
+		result[k] = overlay(layer, childKey, v)
+	}
+	return result
+}
+
+// setPath 设置 `m` 中路径 `path` 的值为 `value`，按需创建中间的 map
+func setPath(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			m[key] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = value
+}
@@ -0,0 +1,53 @@
+package mcfg
+
+import (
+	"context"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// Load 重新读取配置并返回读取的错误，用于没有配置时需要启动失败的应用：
+// 没有找到配置文件时返回包含尝试过的路径的 FileNotFoundError，配置文件无效时返回解析的错误，
+// 开启 KeyMatchFold 时配置键折叠后相同也返回错误，设置 SetDecryptFunc 时重新解密，解密失败也返回错误。
+// Instance 和 Get 不会因为配置文件缺失或无效而失败，此时 Available 返回 false，
+// Get 只返回 SetDefault 的默认值和环境变量等覆盖的值。
+func (c *Config) Load(ctx context.Context) error {
+	if adapter, ok := c.GetAdapter().(*AdapterFile); ok {
+		if err := adapter.Load(ctx); err != nil {
+			return err
+		}
+	} else if !c.Available(ctx) {
+		return merror.NewCodef(mcode.CodeMissingConfiguration, `%s is not available`, describeAdapter(c.GetAdapter()))
+	}
+	// 检查折叠后相同的配置键，见 SetKeyMatching
+	if c.folding() {
+		if _, err := c.reader().Data(ctx); err != nil {
+			return err
+		}
+	}
+	if c.encryptedPattern() != nil {
+		c.clearDecrypted()
+		if _, err := c.Data(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load 重新读取配置文件，没有指定配置文件时按文件名查找，见 Config.Load 和 SetContent
+func (c *AdapterFile) Load(ctx context.Context) error {
+	c.mu.Lock()
+	files, name := c.files, c.fileName
+	if len(files) > 0 {
+		defer c.mu.Unlock()
+		return c.load(files, c.sliceMerge)
+	}
+	content := c.content
+	c.mu.Unlock()
+	// 设置了配置内容时没有配置文件不是错误，例如以 go:embed 嵌入的默认配置
+	if err := c.setFileName(name); err != nil && (content == nil || !IsFileNotFound(err)) {
+		return err
+	}
+	return nil
+}
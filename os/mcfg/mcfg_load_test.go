@@ -0,0 +1,77 @@
+package mcfg_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_LoadMissingFile(t *testing.T) {
+	chdirTemp(t, "other.yaml", "app: other\n")
+	c, err := mcfg.New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.Available(ctx) {
+		t.Error("Available() expected false without a config file")
+	}
+	c.SetDefault("app.port", 8080)
+	if val, err := c.Get(ctx, "app.name"); err != nil || val != nil {
+		t.Errorf("Get() expected nil, got %v, %v", val, err)
+	}
+	if port := c.GetInt(ctx, "app.port"); port != 8080 {
+		t.Errorf("GetInt() expected the default 8080, got %v", port)
+	}
+	err = c.Load(ctx)
+	if !mcfg.IsFileNotFound(err) || !strings.Contains(err.Error(), filepath.Join("config", "config.yaml")) {
+		t.Errorf("Load() expected FileNotFoundError with the tried paths, got %v", err)
+	}
+
+	// 之后创建的配置文件在 Load 时读取
+	if err = os.WriteFile(filepath.Join("config", "config.yaml"), []byte("app:\n  name: created\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Load(ctx); err != nil || !c.Available(ctx) || c.GetString(ctx, "app.name") != "created" {
+		t.Errorf("Load() expected the created config, got %v, %v", c.GetString(ctx, "app.name"), err)
+	}
+}
+
+func TestConfig_LoadEmptyFile(t *testing.T) {
+	chdirTemp(t, "config.yaml", "")
+	c, err := mcfg.New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = c.Load(ctx); err != nil {
+		t.Errorf("Load() error = %v", err)
+	}
+	if !c.Available(ctx) {
+		t.Error("Available() expected true for the empty config file")
+	}
+	if data, err := c.Data(ctx); err != nil || len(data) != 0 {
+		t.Errorf("Data() expected the empty config, got %v, %v", data, err)
+	}
+}
+
+func TestConfig_LoadMalformedFile(t *testing.T) {
+	chdirTemp(t, "config.yaml", "app: [unclosed\n")
+	if _, err := mcfg.New(); err == nil {
+		t.Error("New() expected the parse error")
+	}
+
+	// Instance 不会 panic
+	c := mcfg.Instance("loadmalformed")
+	if c.Available(ctx) {
+		t.Error("Available() expected false for the malformed config file")
+	}
+	if val, err := c.Get(ctx, "app"); err != nil || val != nil {
+		t.Errorf("Get() expected nil, got %v, %v", val, err)
+	}
+	err := c.Load(ctx)
+	if err == nil || mcfg.IsFileNotFound(err) || !strings.Contains(err.Error(), "config.yaml") {
+		t.Errorf("Load() expected the parse error, got %v", err)
+	}
+}
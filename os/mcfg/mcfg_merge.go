@@ -0,0 +1,212 @@
+package mcfg
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/mconv"
+	"github.com/spf13/viper"
+)
+
+// IncludeKey 是配置文件中引用其他配置文件的键，值为一个或多个相对于该文件所在目录的路径，
+// 引用的文件先于该文件合并，因此该文件的值覆盖引用的文件：
+//
+//	include: config.common.yaml
+//	server:
+//	  address: ":8001"
+const IncludeKey = "include"
+
+// SliceMerge 是合并多个配置文件时数组的合并方式
+type SliceMerge int
+
+const (
+	// SliceReplace 以后面的文件的数组替换前面的文件的数组，是默认的合并方式
+	SliceReplace SliceMerge = iota
+	// SliceAppend 将后面的文件的数组追加到前面的文件的数组之后
+	SliceAppend
+)
+
+// AddFile 将配置文件 `path` 合并到当前的配置文件之后，见 SetFiles
+func (c *Config) AddFile(path string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.AddFile(path)
+}
+
+// SetFiles 按顺序读取并合并配置文件 `paths`，替代按文件名在配置目录中查找，见 AdapterFile.SetFiles
+func (c *Config) SetFiles(paths ...string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.SetFiles(paths...)
+}
+
+// SetSliceMerge 设置合并多个配置文件时数组的合并方式，默认为 SliceReplace
+func (c *Config) SetSliceMerge(merge SliceMerge) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.SetSliceMerge(merge)
+}
+
+// fileAdapter 返回配置文件适配器，适配器不是 AdapterFile 时返回错误
+func (c *Config) fileAdapter() (*AdapterFile, error) {
+	adapter, ok := c.GetAdapter().(*AdapterFile)
+	if !ok {
+		return nil, merror.NewCodef(mcode.CodeNotSupported, `config adapter %T does not support selecting config files`, c.GetAdapter())
+	}
+	return adapter, nil
+}
+
+// AddFile 将配置文件 `path` 合并到当前的配置文件之后，见 SetFiles
+func (c *AdapterFile) AddFile(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load(append(append([]string(nil), c.files...), path), c.sliceMerge)
+}
+
+// SetFiles 按顺序读取并合并配置文件 `paths`，例如共用的 config.common.yaml 和服务的 config.service.yaml。
+// 后面的文件的值覆盖前面的文件的标量值，map 逐键合并，数组的合并方式见 SetSliceMerge；
+// 任一文件读取失败时返回错误并保留当前的配置。配置文件可通过 IncludeKey 引用其他配置文件。
+func (c *AdapterFile) SetFiles(paths ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load(paths, c.sliceMerge)
+}
+
+// SetSliceMerge 设置合并多个配置文件时数组的合并方式，并重新读取配置文件
+func (c *AdapterFile) SetSliceMerge(merge SliceMerge) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(c.files, merge); err != nil {
+		return err
+	}
+	c.sliceMerge = merge
+	return nil
+}
+
+// load 读取并合并配置文件 `files` 及其当前环境的配置文件，成功时替换当前的配置，调用方需持有 c.mu
+func (c *AdapterFile) load(files []string, merge SliceMerge) error {
+	v, paths, err := loadFiles(withProfile(files, c.profile), c.content, merge)
+	if err != nil {
+		return err
+	}
+	c.files, c.paths = files, paths
+	if len(files) > 0 {
+		last := files[len(files)-1]
+		c.fileName = strings.TrimSuffix(filepath.Base(last), filepath.Ext(last))
+	}
+	c.v.Store(v)
+	return nil
+}
+
+// reload 重新读取当前的配置文件，成功时原子替换当前的配置
+func (c *AdapterFile) reload(fn func(old, new map[string]any, err error)) {
+	c.mu.Lock()
+	old := c.v.Load()
+	err := c.load(c.files, c.sliceMerge)
+	v := c.v.Load()
+	c.mu.Unlock()
+	if err != nil {
+		fn(nil, nil, err)
+		return
+	}
+	fn(old.AllSettings(), v.AllSettings(), nil)
+}
+
+// watchedPaths 返回当前的配置读取的所有文件的绝对路径，包括引用的文件
+func (c *AdapterFile) watchedPaths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paths
+}
+
+// loadFiles 按顺序读取并合并配置文件 `files` 和配置内容 `content`，返回合并的配置和读取的所有文件的绝对路径
+func loadFiles(files []string, content map[string]any, merge SliceMerge) (*viper.Viper, []string, error) {
+	var (
+		data  = map[string]any{}
+		paths []string
+	)
+	for _, file := range files {
+		if err := mergeFile(data, file, merge, nil, &paths); err != nil {
+			return nil, nil, err
+		}
+	}
+	if content != nil {
+		mergeMap(data, deepCopyMap(content), merge)
+	}
+	v := viper.New()
+	if len(files) > 0 {
+		v.SetConfigFile(files[len(files)-1])
+	}
+	if err := v.MergeConfigMap(data); err != nil {
+		return nil, nil, merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `load config files %v failed`, files)
+	}
+	return v, paths, nil
+}
+
+// mergeFile 将配置文件 `path` 及其引用的文件合并到 `data`，`including` 是正在引用的文件，用于发现循环引用
+func mergeFile(data map[string]any, path string, merge SliceMerge, including []string, paths *[]string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return merror.WrapCodef(err, mcode.CodeOperationFailed, `invalid config file path "%s"`, path)
+	}
+	for _, p := range including {
+		if p == abs {
+			return merror.NewCodef(mcode.CodeInvalidConfiguration, `config file "%s" includes itself`, path)
+		}
+	}
+	content, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	*paths = append(*paths, abs)
+	if include, ok := content[IncludeKey]; ok {
+		delete(content, IncludeKey)
+		includes, err := mconv.ToStringSliceE(include)
+		if err != nil {
+			return merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `invalid include of config file "%s"`, path)
+		}
+		for _, include := range includes {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			if err = mergeFile(data, include, merge, append(including, abs), paths); err != nil {
+				return err
+			}
+		}
+	}
+	mergeMap(data, content, merge)
+	return nil
+}
+
+// mergeMap 将 `src` 深度合并到 `dst`：map 逐键合并，数组按 `merge` 合并，其他情况 `src` 的值替换 `dst` 的值。
+// map 的键转为小写，与读取单个配置文件时相同。
+func mergeMap(dst map[string]any, src map[string]any, merge SliceMerge) {
+	for key, value := range src {
+		key = strings.ToLower(key)
+		switch v := value.(type) {
+		case map[string]any:
+			child, ok := dst[key].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				dst[key] = child
+			}
+			mergeMap(child, v, merge)
+		case []any:
+			if existing, ok := dst[key].([]any); ok && merge == SliceAppend {
+				dst[key] = append(append([]any(nil), existing...), v...)
+				continue
+			}
+			dst[key] = v
+		default:
+			dst[key] = value
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package mcfg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+// writeFiles 在临时目录中创建配置文件 `files`，返回文件名到路径的映射
+func writeFiles(t *testing.T, files map[string]string) map[string]string {
+	dir := t.TempDir()
+	paths := make(map[string]string, len(files))
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths[name] = path
+	}
+	return paths
+}
+
+// newFilesConfig 创建使用文件适配器的配置，由测试设置配置文件
+func newFilesConfig(t *testing.T) *mcfg.Config {
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mcfg.NewWithAdapter(adapter)
+}
+
+const (
+	mergeCommon = `
+server:
+  address: ":8000"
+  timeout: 30s
+  tls:
+    enable: true
+proxies: ["10.0.0.1", "10.0.0.2"]
+database: mysql://common
+cache:
+  addr: redis://common
+Logger:
+  Level: info
+`
+	mergeService = `
+server:
+  address: ":8001"
+  tls: false
+proxies: ["10.0.0.3"]
+database:
+  dsn: mysql://service
+cache: redis://service
+logger:
+  level: debug
+`
+)
+
+func TestConfig_SetFiles(t *testing.T) {
+	paths := writeFiles(t, map[string]string{
+		"config.common.yaml":  mergeCommon,
+		"config.service.yaml": mergeService,
+	})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.common.yaml"], paths["config.service.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    any
+	}{
+		{"server.address", ":8001"},         // 标量覆盖标量
+		{"server.timeout", "30s"},           // map 逐键合并
+		{"server.tls", false},               // 标量覆盖 map
+		{"database.dsn", "mysql://service"}, // map 覆盖标量
+		{"cache", "redis://service"},        // 标量覆盖 map
+		{"proxies", []any{"10.0.0.3"}},      // 数组默认替换
+		{"logger.level", "debug"},           // 键不区分大小写
+	}
+	for _, tt := range tests {
+		val, err := c.Get(ctx, tt.pattern)
+		if err != nil || val == nil || !reflect.DeepEqual(val.Val(), tt.want) {
+			t.Errorf("Get(%q) expected %v, got %v, %v", tt.pattern, tt.want, val, err)
+		}
+	}
+
+	if err := c.SetSliceMerge(mcfg.SliceAppend); err != nil {
+		t.Fatalf("SetSliceMerge() error = %v", err)
+	}
+	if val := c.GetStringSlice(ctx, "proxies"); !reflect.DeepEqual(val, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}) {
+		t.Errorf("Get() expected the appended proxies, got %v", val)
+	}
+
+	// 文件的顺序决定覆盖的方向
+	if err := c.SetFiles(paths["config.service.yaml"], paths["config.common.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":8000" {
+		t.Errorf("Get() expected :8000, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "server.tls.enable"); val == nil || !val.Bool() {
+		t.Errorf("Get() expected the tls map, got %v", val)
+	}
+
+	// 读取失败时保留当前的配置
+	if err := c.AddFile(filepath.Join(filepath.Dir(paths["config.common.yaml"]), "missing.yaml")); err == nil {
+		t.Error("AddFile() expected error for a missing file")
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":8000" {
+		t.Errorf("Get() expected the current config, got %v", val)
+	}
+	if err := c.AddFile(paths["config.service.yaml"]); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":8001" {
+		t.Errorf("Get() expected :8001, got %v", val)
+	}
+
+	if err := mcfg.NewWithAdapter(newMockAdapter()).SetFiles(paths["config.common.yaml"]); err == nil {
+		t.Error("SetFiles() expected error for an adapter without files")
+	}
+}
+
+func TestConfig_Include(t *testing.T) {
+	paths := writeFiles(t, map[string]string{
+		"config.yaml":               "include: [shared/config.common.yaml]\n" + mergeService,
+		"shared/config.common.yaml": "include: config.base.yaml\n" + mergeCommon,
+		"shared/config.base.yaml":   "app:\n  name: base\nserver:\n  address: \":80\"\n",
+		"cycle.yaml":                "include: cycle.other.yaml\n",
+		"cycle.other.yaml":          "include: cycle.yaml\n",
+	})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "app.name"); val == nil || val.String() != "base" {
+		t.Errorf("Get() expected the value of the nested include, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "server.timeout"); val == nil || val.String() != "30s" {
+		t.Errorf("Get() expected the value of the include, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":8001" {
+		t.Errorf("Get() expected the including file to override, got %v", val)
+	}
+	if c.IsSet(ctx, mcfg.IncludeKey) {
+		t.Error("IsSet() expected the include directive to be removed")
+	}
+
+	err := c.SetFiles(paths["cycle.yaml"])
+	if err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("SetFiles() expected error for an include cycle, got %v", err)
+	}
+}
+
+func TestConfig_WatchFiles(t *testing.T) {
+	paths := writeFiles(t, map[string]string{
+		"config.yaml":        "include: config.common.yaml\n" + mergeService,
+		"config.common.yaml": mergeCommon,
+	})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+	changes := make(chan map[string]any, 10)
+	c.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := c.Watch(watchCtx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// 引用的文件变更时重新加载
+	if err := os.WriteFile(paths["config.common.yaml"], []byte("server:\n  timeout: 60s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-changes:
+		if timeout := data["server"].(map[string]any)["timeout"]; timeout != "60s" {
+			t.Errorf("OnChange() expected timeout 60s, got %v", timeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called")
+	}
+}
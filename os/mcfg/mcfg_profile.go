@@ -0,0 +1,79 @@
+package mcfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// profileEnvNames 是按顺序读取当前环境的环境变量名，见 SetProfile
+var profileEnvNames = []string{"MALTOSE_ENV", "APP_ENV"}
+
+// Profile 返回当前的环境，例如 "dev"，见 SetProfile
+func (c *Config) Profile() string {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return ""
+	}
+	return adapter.Profile()
+}
+
+// SetProfile 设置当前的环境，见 AdapterFile.SetProfile
+func (c *Config) SetProfile(profile string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.SetProfile(profile)
+}
+
+// Profile 返回当前的环境
+func (c *AdapterFile) Profile() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.profile
+}
+
+// SetProfile 设置当前的环境并重新读取配置文件，默认为环境变量 MALTOSE_ENV 或 APP_ENV 的值。
+// 每个配置文件之后合并同目录下该环境的配置文件，例如环境 "dev" 时 config.yaml 之后合并 config.dev.yaml，
+// 合并方式见 SetFiles。环境的配置文件不存在时忽略，读取失败时返回错误并保留当前的环境和配置。
+func (c *AdapterFile) SetProfile(profile string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.profile
+	c.profile = profile
+	if err := c.load(c.files, c.sliceMerge); err != nil {
+		c.profile = old
+		return err
+	}
+	return nil
+}
+
+// envProfile 返回环境变量设置的当前的环境
+func envProfile() string {
+	for _, name := range profileEnvNames {
+		if profile := strings.TrimSpace(os.Getenv(name)); profile != "" {
+			return profile
+		}
+	}
+	return ""
+}
+
+// withProfile 返回在每个配置文件 `files` 之后加上环境 `profile` 的配置文件的列表
+func withProfile(files []string, profile string) []string {
+	if profile == "" {
+		return files
+	}
+	result := make([]string, 0, len(files)*2)
+	for _, file := range files {
+		result = append(result, file)
+		base := strings.TrimSuffix(file, filepath.Ext(file))
+		for _, ext := range supportedExts() {
+			if path := base + "." + profile + "." + ext; IsExist(path) {
+				result = append(result, path)
+				break
+			}
+		}
+	}
+	return result
+}
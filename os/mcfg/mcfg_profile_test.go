@@ -0,0 +1,92 @@
+package mcfg_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_SetProfile(t *testing.T) {
+	paths := writeFiles(t, map[string]string{
+		"config.yaml":        mergeCommon,
+		"config.dev.yaml":    "server:\n  address: \":9000\"\n",
+		"config.broken.yaml": "server: [invalid",
+	})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+
+	if err := c.SetProfile("dev"); err != nil {
+		t.Fatalf("SetProfile() error = %v", err)
+	}
+	if c.Profile() != "dev" {
+		t.Errorf("Profile() expected dev, got %q", c.Profile())
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":9000" {
+		t.Errorf("Get() expected the dev address, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "server.timeout"); val == nil || val.String() != "30s" {
+		t.Errorf("Get() expected the base timeout, got %v", val)
+	}
+
+	// 环境的配置文件不存在时忽略
+	if err := c.SetProfile("prod"); err != nil {
+		t.Fatalf("SetProfile() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":8000" {
+		t.Errorf("Get() expected the base address, got %v", val)
+	}
+
+	// 环境的配置文件读取失败时返回错误
+	if err := c.SetProfile("broken"); err == nil || !strings.Contains(err.Error(), "config.broken.yaml") {
+		t.Errorf("SetProfile() expected error for the broken profile, got %v", err)
+	}
+	if c.Profile() != "prod" {
+		t.Errorf("Profile() expected prod to be kept, got %q", c.Profile())
+	}
+
+	if err := mcfg.NewWithAdapter(newMockAdapter()).SetProfile("dev"); err == nil {
+		t.Error("SetProfile() expected error for an adapter without files")
+	}
+}
+
+func TestNewAdapterFile_Profile(t *testing.T) {
+	chdirTemp(t, "config.yaml", mergeCommon)
+	writeConfig := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join("config", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("config.test.yaml", "server:\n  address: \":7000\"\n")
+	writeConfig("config.staging.yaml", "server:\n  address: \":7001\"\n")
+	writeConfig("config.broken.yaml", "server: [invalid")
+
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("MALTOSE_ENV", "test")
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatalf("NewAdapterFile() error = %v", err)
+	}
+	c := mcfg.NewWithAdapter(adapter)
+	if c.Profile() != "test" {
+		t.Errorf("Profile() expected test of MALTOSE_ENV, got %q", c.Profile())
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":7000" {
+		t.Errorf("Get() expected the test address, got %v", val)
+	}
+
+	t.Setenv("MALTOSE_ENV", "")
+	if adapter, err = mcfg.NewAdapterFile(); err != nil || adapter.Profile() != "staging" {
+		t.Errorf("NewAdapterFile() expected profile staging of APP_ENV, got %v", err)
+	}
+
+	t.Setenv("MALTOSE_ENV", "broken")
+	if _, err = mcfg.NewAdapterFile(); err == nil {
+		t.Error("NewAdapterFile() expected error for the broken profile")
+	}
+}
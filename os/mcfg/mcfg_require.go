@@ -0,0 +1,65 @@
+package mcfg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/graingo/maltose/container/mvar"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// RequireKeys 检查配置键 `keys` 是否都有值，包括默认值，用于在启动时发现缺少的配置，
+// 例如数据库的 DSN。返回的错误列出所有缺少的键和读取的配置来源。
+func (c *Config) RequireKeys(ctx context.Context, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		value, err := c.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return merror.NewCodef(mcode.CodeMissingConfiguration, `missing required config keys [%s] in %s`, strings.Join(missing, ", "), c.sources())
+	}
+	return nil
+}
+
+// MustGet 获取配置键 `key` 的值，配置不存在或读取失败时 panic，见 RequireKeys
+func (c *Config) MustGet(ctx context.Context, key string) *mvar.Var {
+	if err := c.RequireKeys(ctx, key); err != nil {
+		panic(err)
+	}
+	value, _ := c.Get(ctx, key)
+	return value
+}
+
+// sources 返回配置来源的描述，用于错误信息
+func (c *Config) sources() string {
+	sources := []string{describeAdapter(c.GetAdapter())}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.env.enabled() {
+		if c.env.prefix != "" {
+			sources = append(sources, fmt.Sprintf(`environment variables with prefix "%s"`, c.env.prefix))
+		} else {
+			sources = append(sources, "environment variables")
+		}
+	}
+	if c.flags != nil {
+		sources = append(sources, "command-line flags")
+	}
+	return strings.Join(sources, ", ")
+}
+
+// describeAdapter 返回配置适配器的描述，适配器实现 fmt.Stringer 时使用其描述，例如配置文件的路径
+func describeAdapter(adapter Adapter) string {
+	if stringer, ok := adapter.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("config adapter %T", adapter)
+}
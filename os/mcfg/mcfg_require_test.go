@@ -0,0 +1,71 @@
+package mcfg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+)
+
+func TestConfig_RequireKeys(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unmarshalFixture)
+	c.SetDefault("server.port", 8080)
+
+	if err := c.RequireKeys(ctx, "server.addr", "server.port"); err != nil {
+		t.Errorf("RequireKeys() error = %v", err)
+	}
+	err := c.RequireKeys(ctx, "database.dsn", "server.addr", "redis.addr")
+	if err == nil {
+		t.Fatal("RequireKeys() expected error for missing keys")
+	}
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+	// 一次列出所有缺少的键和配置文件
+	for _, want := range []string{"[database.dsn, redis.addr]", `config file "`, "config.yaml"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("RequireKeys() expected error containing %q, got %v", want, err)
+		}
+	}
+
+	if val := c.MustGet(ctx, "server.addr"); val.String() != ":8000" {
+		t.Errorf("MustGet() expected :8000, got %v", val)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustGet() expected panic for a missing key")
+		}
+	}()
+	c.MustGet(ctx, "database.dsn")
+}
+
+func TestConfig_UnmarshalValidate(t *testing.T) {
+	type databaseConfig struct {
+		DSN     string `validate:"required"`
+		MaxConn int    `json:"max_conn" validate:"min=1"`
+	}
+	c := newFileConfig(t, "config.yaml", unmarshalFixture+`
+database:
+  max_conn: 0
+`)
+	var database databaseConfig
+	err := c.UnmarshalKey(ctx, "database", &database)
+	if err == nil {
+		t.Fatal("UnmarshalKey() expected error for invalid config")
+	}
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	for _, want := range []string{`invalid config "database"`, `"database.dsn" failed on "required"`, `"database.max_conn" failed on "min=1"`, "config.yaml"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("UnmarshalKey() expected error containing %q, got %v", want, err)
+		}
+	}
+
+	t.Setenv("REQUIRE_DATABASE_DSN", "mysql://localhost/app")
+	c.BindEnv("database.dsn", "REQUIRE_DATABASE_DSN")
+	c.SetDefault("database.max_conn", 10)
+	if err = c.UnmarshalKey(ctx, "database", &database); err == nil {
+		t.Error("UnmarshalKey() expected error for the configured max_conn 0")
+	}
+	if database.DSN != "mysql://localhost/app" {
+		t.Errorf("UnmarshalKey() expected the dsn of the environment variable, got %q", database.DSN)
+	}
+}
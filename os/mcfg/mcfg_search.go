@@ -0,0 +1,142 @@
+package mcfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/graingo/maltose/errors/mcode"
+)
+
+// ConfigEnvName 是指定配置文件或配置目录的环境变量名，见 AddSearchPath
+const ConfigEnvName = "MALTOSE_CONFIG"
+
+var (
+	searchMu    sync.RWMutex
+	searchPaths []string // AddSearchPath 注册的配置目录
+)
+
+// AddSearchPath 注册查找配置文件的目录。按文件名查找配置文件的顺序为：
+//
+//  1. 环境变量 MALTOSE_CONFIG：值为目录时在其中查找，值为文件时作为默认配置文件 "config" 使用；
+//  2. AddSearchPath 注册的目录，按注册的顺序；
+//  3. 默认的配置目录，即根目录、工作目录下的 config 目录、/config 目录和工作目录。
+//
+// 每个目录中依次查找已注册解析函数的扩展名的文件，见 RegisterDecoder；
+// Config.SetFile 和 SetFiles 指定的文件优先于查找的文件。
+func AddSearchPath(dir string) {
+	searchMu.Lock()
+	defer searchMu.Unlock()
+	if dir != "" && !slices.Contains(searchPaths, dir) {
+		searchPaths = append(searchPaths, dir)
+	}
+}
+
+// FileNotFoundError 是没有找到配置文件的错误，以 errors.As 或 IsFileNotFound 检查，
+// 错误码为 mcode.CodeMissingConfiguration，以便应用决定没有配置文件时是否退出
+type FileNotFoundError struct {
+	Name  string   // 配置文件名或路径
+	Paths []string // 按顺序尝试的路径
+}
+
+// Error 实现 error 接口
+func (e *FileNotFoundError) Error() string {
+	return fmt.Sprintf(`config file "%s" not found, tried: %s`, e.Name, strings.Join(e.Paths, ", "))
+}
+
+// Code 返回错误码 mcode.CodeMissingConfiguration，见 merror.Code
+func (e *FileNotFoundError) Code() mcode.Code {
+	return mcode.CodeMissingConfiguration
+}
+
+// IsFileNotFound 检查 `err` 是否是没有找到配置文件的错误
+func IsFileNotFound(err error) bool {
+	var notFound *FileNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// SetFileName 设置配置文件名并重新读取配置文件，查找顺序见 AddSearchPath，
+// 没有找到时返回 FileNotFoundError 且配置为空
+func (c *Config) SetFileName(name string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.setFileName(name)
+}
+
+// SetFile 读取配置文件 `path`，替代按文件名查找，文件不存在时返回 FileNotFoundError
+func (c *Config) SetFile(path string) error {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return err
+	}
+	return adapter.SetConfigFile(path)
+}
+
+// AvailableFile 返回读取的配置文件的路径，合并多个配置文件时为第一个，没有配置文件时返回空字符串
+func (c *Config) AvailableFile() string {
+	adapter, err := c.fileAdapter()
+	if err != nil {
+		return ""
+	}
+	return adapter.AvailableFile()
+}
+
+// AvailableFile 返回读取的配置文件的路径，合并多个配置文件时为第一个，没有配置文件时返回空字符串
+func (c *AdapterFile) AvailableFile() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.files) == 0 {
+		return ""
+	}
+	return c.files[0]
+}
+
+// searchFile 按 AddSearchPath 的顺序查找名为 `name` 的配置文件，返回其路径和尝试过的路径，没有找到时路径为空
+func searchFile(name string) (string, []string) {
+	var tried []string
+	if env := os.Getenv(ConfigEnvName); env != "" {
+		info, err := os.Stat(env)
+		switch {
+		case err == nil && !info.IsDir():
+			if name == DefaultConfigFileName {
+				return env, nil
+			}
+		case err == nil:
+			if path := searchDir(env, name, &tried); path != "" {
+				return path, tried
+			}
+		default:
+			tried = append(tried, env)
+		}
+	}
+	searchMu.RLock()
+	dirs := append(slices.Clone(searchPaths), defaultConfigDir...)
+	searchMu.RUnlock()
+	for _, dir := range dirs {
+		if path := searchDir(dir, name, &tried); path != "" {
+			return path, tried
+		}
+	}
+	return "", tried
+}
+
+// searchDir 在目录 `dir` 中查找名为 `name` 的配置文件，将尝试过的路径添加到 `tried`
+func searchDir(dir, name string, tried *[]string) string {
+	for _, ext := range supportedExts() {
+		path := filepath.Join(dir, name+"."+ext)
+		if slices.Contains(*tried, path) {
+			continue
+		}
+		*tried = append(*tried, path)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package mcfg_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_SearchPaths(t *testing.T) {
+	chdirTemp(t, "config.yaml", "source: workdir\n")
+	search := writeFiles(t, map[string]string{
+		"config.yaml":   "source: search\n",
+		"searchdb.yaml": "source: database\n",
+	})
+	searchDir := filepath.Dir(search["config.yaml"])
+	env := writeFiles(t, map[string]string{
+		"app.yaml":    "source: env file\n",
+		"config.json": `{"source": "env dir"}`,
+	})
+
+	source := func(c *mcfg.Config) string {
+		t.Helper()
+		return c.GetString(ctx, "source")
+	}
+	c, err := mcfg.New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if source(c) != "workdir" || c.AvailableFile() != filepath.Join("config", "config.yaml") {
+		t.Errorf("New() expected the config of the working directory, got %v from %v", source(c), c.AvailableFile())
+	}
+
+	// 注册的目录优先于工作目录
+	mcfg.AddSearchPath(searchDir)
+	if c, err = mcfg.New(); err != nil || source(c) != "search" || c.AvailableFile() != search["config.yaml"] {
+		t.Errorf("New() expected the config of the search path, got %v, %v", source(c), err)
+	}
+	if c = mcfg.Instance("searchdb"); source(c) != "database" {
+		t.Errorf("Instance() expected searchdb.yaml, got %v", source(c))
+	}
+	if c = mcfg.Instance("searchmissing"); source(c) != "search" {
+		t.Errorf("Instance() expected config.yaml for the missing file, got %v", source(c))
+	}
+
+	// 环境变量优先于注册的目录
+	t.Setenv(mcfg.ConfigEnvName, env["app.yaml"])
+	if c, err = mcfg.New(); err != nil || source(c) != "env file" {
+		t.Errorf("New() expected the file of %s, got %v, %v", mcfg.ConfigEnvName, source(c), err)
+	}
+	t.Setenv(mcfg.ConfigEnvName, filepath.Dir(env["config.json"]))
+	if c, err = mcfg.New(); err != nil || source(c) != "env dir" {
+		t.Errorf("New() expected the directory of %s, got %v, %v", mcfg.ConfigEnvName, source(c), err)
+	}
+
+	// 指定的文件优先于查找的文件
+	if err = c.SetFile(search["searchdb.yaml"]); err != nil || source(c) != "database" {
+		t.Errorf("SetFile() expected searchdb.yaml, got %v, %v", source(c), err)
+	}
+	err = c.SetFile(filepath.Join(searchDir, "missing.yaml"))
+	if !mcfg.IsFileNotFound(err) {
+		t.Errorf("SetFile() expected FileNotFoundError, got %v", err)
+	}
+	merrortest.AssertCode(t, err, mcode.CodeMissingConfiguration)
+	if err = c.SetFileName("searchdb"); err != nil || source(c) != "database" {
+		t.Errorf("SetFileName() expected searchdb.yaml, got %v, %v", source(c), err)
+	}
+	err = c.SetFileName("missing")
+	if !mcfg.IsFileNotFound(err) {
+		t.Fatalf("SetFileName() expected FileNotFoundError, got %v", err)
+	}
+	if notFound := err.(*mcfg.FileNotFoundError); !slices.Contains(notFound.Paths, filepath.Join(searchDir, "missing.yaml")) {
+		t.Errorf("FileNotFoundError expected the tried paths, got %v", notFound.Paths)
+	}
+	if c.AvailableFile() != "" || source(c) != "" {
+		t.Errorf("SetFileName() expected the empty config, got %v from %v", source(c), c.AvailableFile())
+	}
+}
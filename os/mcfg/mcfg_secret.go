@@ -0,0 +1,234 @@
+package mcfg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// DefaultSecretFileSuffix 是默认的引用文件的配置键后缀，见 EnableSecretFiles
+const DefaultSecretFileSuffix = "_file"
+
+// secretFiles 是从文件读取配置值的设置
+type secretFiles struct {
+	enabled bool              // 是否读取文件中的值
+	suffix  string            // 引用文件的配置键后缀，为空时使用 DefaultSecretFileSuffix
+	cache   map[string]string // 文件路径到内容的缓存，配置重新加载时清空
+}
+
+// EnableSecretFiles 设置是否从文件读取配置值，用于 Kubernetes 和 Docker 以文件挂载的密钥：
+//
+//	db:
+//	  password_file: /run/secrets/dbpass # 设置 "db.password" 为文件的内容，去掉末尾的换行
+//
+// 后缀见 SetSecretFileSuffix。配置键和带后缀的配置键同时存在时 Get 和 Data 返回错误。
+// 文件的内容在首次读取后缓存，配置重新加载时重新读取，WatchSecretFiles 监听文件的轮换；
+// 这些值在 Redacted、Dump 和 Handler 的输出中视为敏感的值。
+func (c *Config) EnableSecretFiles(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets.enabled = enabled
+}
+
+// SetSecretFileSuffix 设置引用文件的配置键后缀，默认为 DefaultSecretFileSuffix，见 EnableSecretFiles
+func (c *Config) SetSecretFileSuffix(suffix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets.suffix = strings.ToLower(suffix)
+	c.secrets.cache = nil
+}
+
+// WatchSecretFiles 监听已读取的密钥文件所在的目录，直到 `ctx` 被取消。文件变更后重新读取，
+// 并以变更前后的配置数据调用 OnChange 注册的回调；监听的是目录，因此支持 Kubernetes 以符号链接轮换的密钥。
+func (c *Config) WatchSecretFiles(ctx context.Context) error {
+	if c.secretSuffix() == "" {
+		return merror.NewCode(mcode.CodeNotSupported, `secret files are not enabled`)
+	}
+	// 读取全部配置以找到引用的文件
+	if _, err := c.Data(ctx); err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return merror.WrapCode(err, mcode.CodeOperationFailed, `create secret file watcher failed`)
+	}
+	if err = watchDirs(watcher, c.secretPaths()); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go c.watchSecrets(ctx, watcher)
+	return nil
+}
+
+// watchSecrets 处理密钥文件所在目录的事件，直到 `ctx` 被取消
+func (c *Config) watchSecrets(ctx context.Context, watcher *fsnotify.Watcher) {
+	watchEvents(ctx, watcher, watchDebounce, nil, func() {
+		old, err := c.Data(ctx)
+		if err != nil {
+			c.notify(nil, nil, err)
+			return
+		}
+		c.clearSecrets()
+		data, err := c.Data(ctx)
+		c.notify(old, data, err)
+		if err = watchDirs(watcher, c.secretPaths()); err != nil {
+			c.notify(nil, nil, err)
+		}
+	}, func(err error) {
+		c.notify(nil, nil, merror.WrapCode(err, mcode.CodeOperationFailed, `watch secret file failed`))
+	})
+}
+
+// secretSuffix 返回引用文件的配置键后缀，没有开启时返回空字符串
+func (c *Config) secretSuffix() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.secrets.fileSuffix()
+}
+
+// fileSuffix 返回引用文件的配置键后缀，没有开启时返回空字符串
+func (s secretFiles) fileSuffix() string {
+	if !s.enabled {
+		return ""
+	}
+	if s.suffix == "" {
+		return DefaultSecretFileSuffix
+	}
+	return s.suffix
+}
+
+// resolveSecrets 返回以引用的文件的内容设置配置键 `key` 的值 `value` 中的值后的值
+func (c *Config) resolveSecrets(ctx context.Context, key string, value any, stack []string) (any, error) {
+	suffix := c.secretSuffix()
+	if key != "" && !strings.HasSuffix(strings.ToLower(key), suffix) {
+		path, err := c.effective(ctx, key+suffix, stack)
+		if err != nil {
+			return nil, err
+		}
+		if path != nil {
+			if value != nil {
+				return nil, merror.NewCodef(mcode.CodeInvalidConfiguration, `config "%s" and "%s%s" are both set`, key, key, suffix)
+			}
+			return c.readSecret(key, path)
+		}
+	}
+	return c.resolveSecretTree(key, value, suffix)
+}
+
+// resolveSecretTree 返回以引用的文件的内容设置 `value` 中带后缀 `suffix` 的键对应的键后的副本
+func (c *Config) resolveSecretTree(key string, value any, suffix string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			childKey := strings.ReplaceAll(k, ".", `\.`)
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			resolved, err := c.resolveSecretTree(childKey, item, suffix)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = resolved
+		}
+		for k, path := range v {
+			name, ok := strings.CutSuffix(strings.ToLower(k), suffix)
+			if !ok || name == "" || path == nil {
+				continue
+			}
+			childKey := strings.ReplaceAll(name, ".", `\.`)
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			if v[name] != nil {
+				return nil, merror.NewCodef(mcode.CodeInvalidConfiguration, `config "%s" and "%s%s" are both set`, childKey, childKey, suffix)
+			}
+			secret, err := c.readSecret(childKey, path)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = secret
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			childKey := strconv.Itoa(i)
+			if key != "" {
+				childKey = key + "." + childKey
+			}
+			resolved, err := c.resolveSecretTree(childKey, item, suffix)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// readSecret 返回配置键 `key` 引用的文件 `path` 的内容，去掉末尾的换行
+func (c *Config) readSecret(key string, path any) (string, error) {
+	name, ok := path.(string)
+	if !ok || name == "" {
+		return "", merror.NewCodef(mcode.CodeInvalidConfiguration, `invalid secret file path %v of config "%s"`, path, key)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	c.mu.Lock()
+	secret, cached := c.secrets.cache[name]
+	c.mu.Unlock()
+	if cached {
+		return secret, nil
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return "", merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `read secret file "%s" of config "%s" failed`, name, key)
+	}
+	secret = strings.TrimRight(string(content), "\r\n")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.secrets.cache == nil {
+		c.secrets.cache = map[string]string{}
+	}
+	c.secrets.cache[name] = secret
+	return secret, nil
+}
+
+// secretPaths 返回已读取的密钥文件的路径
+func (c *Config) secretPaths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	paths := make([]string, 0, len(c.secrets.cache))
+	for path := range c.secrets.cache {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// clearSecrets 清空密钥文件的缓存，之后读取配置时重新读取文件
+func (c *Config) clearSecrets() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.secrets.cache = nil
+}
+
+// isSecret 检查配置键 `key` 的值是否来自密钥文件
+func (c *Config) isSecret(ctx context.Context, key string) bool {
+	suffix := c.secretSuffix()
+	if suffix == "" || strings.HasSuffix(strings.ToLower(key), suffix) {
+		return false
+	}
+	path, err := c.value(ctx, key+suffix)
+	return err == nil && path != nil
+}
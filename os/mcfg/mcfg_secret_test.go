@@ -0,0 +1,113 @@
+package mcfg_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+// writeSecret 写入内容为 `content` 的密钥文件并返回其路径
+func writeSecret(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfig_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSecret(t, dir, "dbpass", "s3cret\n")
+	c := newFileConfig(t, "config.yaml", fmt.Sprintf("db:\n  host: localhost\n  password_file: %q\n", path))
+
+	// 默认不读取文件
+	if val, _ := c.Get(ctx, "db.password"); val != nil {
+		t.Errorf("Get() expected nil, got %v", val)
+	}
+
+	c.EnableSecretFiles(true)
+	if val, err := c.Get(ctx, "db.password"); err != nil || val.String() != "s3cret" {
+		t.Errorf("Get() expected s3cret, got %v, %v", val, err)
+	}
+	if val, _ := c.Get(ctx, "db"); val.Map()["password"] != "s3cret" {
+		t.Errorf("Get() expected the password in the map, got %v", val)
+	}
+	data, err := c.Data(ctx)
+	if err != nil || data["db"].(map[string]any)["password"] != "s3cret" {
+		t.Errorf("Data() expected the password, got %v, %v", data, err)
+	}
+
+	// 从文件读取的值是敏感的
+	redacted, err := c.Redacted(ctx)
+	if err != nil || redacted["db"].(map[string]any)["password"] != mcfg.RedactedValue {
+		t.Errorf("Redacted() expected the redacted password, got %v, %v", redacted, err)
+	}
+	e, err := c.Explain(ctx, "db.password")
+	if err != nil || e.Source != mcfg.SourceFile || !strings.Contains(e.Origin, path) {
+		t.Errorf("Explain() expected the secret file source, got %+v, %v", e, err)
+	}
+
+	// 配置键和带后缀的配置键同时存在
+	c = newFileConfig(t, "config.yaml", fmt.Sprintf("db:\n  password: plain\n  password_file: %q\n", path))
+	c.EnableSecretFiles(true)
+	if _, err = c.Get(ctx, "db.password"); err == nil || !strings.Contains(err.Error(), "db.password_file") {
+		t.Errorf("Get() expected the conflict error, got %v", err)
+	}
+	if _, err = c.Data(ctx); err == nil {
+		t.Error("Data() expected the conflict error")
+	}
+}
+
+func TestConfig_SecretFileSuffix(t *testing.T) {
+	path := writeSecret(t, t.TempDir(), "token", "abc")
+	c := newFileConfig(t, "config.yaml", fmt.Sprintf("api:\n  token_path: %q\n  missing_path: /no/such/file\n", path))
+	c.EnableSecretFiles(true)
+	c.SetSecretFileSuffix("_PATH")
+	if val, err := c.Get(ctx, "api.token"); err != nil || val.String() != "abc" {
+		t.Errorf("Get() expected abc, got %v, %v", val, err)
+	}
+	if _, err := c.Get(ctx, "api.missing"); err == nil || !strings.Contains(err.Error(), "/no/such/file") {
+		t.Errorf("Get() expected the read error, got %v", err)
+	}
+}
+
+func TestConfig_WatchSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSecret(t, dir, "dbpass", "old")
+	c := newFileConfig(t, "config.yaml", fmt.Sprintf("db:\n  password_file: %q\n", path))
+	if err := c.WatchSecretFiles(ctx); err == nil {
+		t.Error("WatchSecretFiles() expected error when not enabled")
+	}
+	c.EnableSecretFiles(true)
+	changes := make(chan map[string]any, 10)
+	c.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := c.WatchSecretFiles(watchCtx); err != nil {
+		t.Fatalf("WatchSecretFiles() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "db.password"); val.String() != "old" {
+		t.Fatalf("Get() expected old, got %v", val)
+	}
+
+	writeSecret(t, dir, "dbpass", "rotated\n")
+	select {
+	case data := <-changes:
+		if password := data["db"].(map[string]any)["password"]; password != "rotated" {
+			t.Errorf("OnChange() expected the rotated password, got %v", password)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called for the rotation")
+	}
+	if val, _ := c.Get(ctx, "db.password"); val.String() != "rotated" {
+		t.Errorf("Get() expected rotated, got %v", val)
+	}
+}
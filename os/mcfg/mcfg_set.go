@@ -0,0 +1,113 @@
+package mcfg
+
+import (
+	"context"
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// Set 在优先级最高的内存覆盖层设置配置键 `key` 的值，例如测试和功能开关，
+// 优先级为：Set > 命令行参数 > 环境变量 > 配置 > 默认值。覆盖层不修改配置来源的数据，
+// 因此重新加载配置文件不会覆盖它，Unset 删除它；map 值与配置逐键合并。
+// 配置数据变更时调用 OnChange 注册的回调，与配置文件的变更相同。
+func (c *Config) Set(ctx context.Context, key string, value any) error {
+	if key == "" {
+		return merror.NewCode(mcode.CodeInvalidParameter, `config key is required`)
+	}
+	path := splitKey(strings.ToLower(c.foldKey(key)))
+	return c.updateOverrides(ctx, func(overrides map[string]any) {
+		setPath(overrides, path, normalizeValue(value))
+	})
+}
+
+// Unset 删除 Set 设置的配置键 `key` 的值，配置数据变更时调用 OnChange 注册的回调
+func (c *Config) Unset(ctx context.Context, key string) error {
+	path := splitKey(strings.ToLower(c.foldKey(key)))
+	return c.updateOverrides(ctx, func(overrides map[string]any) {
+		deletePath(overrides, path)
+	})
+}
+
+// updateOverrides 以 `update` 修改覆盖层的副本并替换覆盖层，然后通知配置数据的变更，调用 `update` 时持有 c.mu
+func (c *Config) updateOverrides(ctx context.Context, update func(overrides map[string]any)) error {
+	old, err := c.Data(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	overrides := deepCopyMap(c.overrides)
+	update(overrides)
+	c.overrides = overrides
+	c.mu.Unlock()
+
+	data, err := c.Data(ctx)
+	if err != nil {
+		return err
+	}
+	c.notify(old, data, nil)
+	return nil
+}
+
+// override 返回覆盖层中路径 `path` 的值，`hidden` 表示路径经过覆盖层中的标量值，因此配置中的值被隐藏
+func (c *Config) override(path []string) (value any, found bool, hidden bool) {
+	c.mu.Lock()
+	overrides := c.overrides
+	c.mu.Unlock()
+	if len(overrides) == 0 {
+		return nil, false, false
+	}
+	value = overrides
+	if c.folding() {
+		// 覆盖层的键在 Set 时已折叠，此处处理之后才开启 KeyMatchFold 的情况
+		value, _ = foldTree(overrides, "", c.secretSuffix())
+		path = c.foldPath(path)
+	}
+	for _, segment := range path {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false, true
+		}
+		if value, ok = m[segment]; !ok {
+			return nil, false, false
+		}
+	}
+	return value, true, false
+}
+
+// overlayValue 返回以覆盖层的值 `override` 覆盖 `value` 后的值，两者都是 map 时逐键合并
+func overlayValue(value any, override any) any {
+	overrideMap, ok := override.(map[string]any)
+	if !ok {
+		return override
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return deepCopyMap(overrideMap)
+	}
+	result := make(map[string]any, len(m)+len(overrideMap))
+	for key, v := range m {
+		result[key] = v
+	}
+	for key, v := range overrideMap {
+		result[key] = overlayValue(result[key], v)
+	}
+	return result
+}
+
+// deletePath 删除 `m` 中路径 `path` 的值，并删除因此为空的 map
+func deletePath(m map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	child, ok := m[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+	deletePath(child, path[1:])
+	if len(child) == 0 {
+		delete(m, path[0])
+	}
+}
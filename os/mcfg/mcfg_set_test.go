@@ -0,0 +1,138 @@
+package mcfg_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfig_Set(t *testing.T) {
+	paths := writeFiles(t, map[string]string{"config.yaml": mergeCommon})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+	changes := make(chan map[string]any, 10)
+	c.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+
+	t.Setenv("SET_SERVER_ADDRESS", ":7000")
+	c.BindEnv("server.address", "SET_SERVER_ADDRESS")
+	if err := c.Set(ctx, "Server.Address", ":9000"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// 覆盖层的优先级高于环境变量
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":9000" {
+		t.Errorf("Get() expected :9000, got %v", val)
+	}
+	select {
+	case data := <-changes:
+		if address := data["server"].(map[string]any)["address"]; address != ":9000" {
+			t.Errorf("OnChange() expected address :9000, got %v", address)
+		}
+	default:
+		t.Error("OnChange() not called for Set")
+	}
+
+	// map 值与配置逐键合并，标量值隐藏配置中的子树
+	if err := c.Set(ctx, "server.tls", map[string]any{"cert": "server.crt"}); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := c.Get(ctx, "server.tls"); val == nil || val.Map()["enable"] != true || val.Map()["cert"] != "server.crt" {
+		t.Errorf("Get() expected the merged tls, got %v", val)
+	}
+	if err := c.Set(ctx, "cache", "redis://runtime"); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := c.Get(ctx, "cache.addr"); val != nil {
+		t.Errorf("Get() expected the hidden cache.addr, got %v", val)
+	}
+	data, err := c.Data(ctx)
+	if err != nil || data["cache"] != "redis://runtime" {
+		t.Errorf("Data() expected the runtime cache, got %v, %v", data["cache"], err)
+	}
+
+	// 删除后恢复较低优先级的值
+	if err = c.Unset(ctx, "server.address"); err != nil {
+		t.Fatalf("Unset() error = %v", err)
+	}
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":7000" {
+		t.Errorf("Get() expected :7000 of the environment variable, got %v", val)
+	}
+	if err = c.Unset(ctx, "cache"); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := c.Get(ctx, "cache.addr"); val == nil || val.String() != "redis://common" {
+		t.Errorf("Get() expected the config cache.addr, got %v", val)
+	}
+	if err = c.Set(ctx, "", 1); err == nil {
+		t.Error("Set() expected error for an empty key")
+	}
+}
+
+func TestConfig_SetReload(t *testing.T) {
+	paths := writeFiles(t, map[string]string{"config.yaml": mergeCommon})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatalf("SetFiles() error = %v", err)
+	}
+	reloads := make(chan struct{}, 1)
+	c.OnChange(func(old, new map[string]any) {
+		if new["database"] == "mysql://reloaded" {
+			select {
+			case reloads <- struct{}{}:
+			default:
+			}
+		}
+	})
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := c.Watch(watchCtx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := c.Set(ctx, "server.address", ":9000"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_ = c.Set(ctx, "toggle", i)
+				_, _ = c.Get(ctx, "server")
+				_, _ = c.Data(ctx)
+				_ = c.Unset(ctx, "toggle")
+			}
+		}(i)
+	}
+	content := "server:\n  address: \":8000\"\ndatabase: mysql://reloaded\n"
+	if err := os.WriteFile(paths["config.yaml"], []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(5 * time.Second):
+		t.Error("OnChange() not called for the reload")
+	}
+	close(stop)
+	wg.Wait()
+
+	// 重新加载不覆盖 Set 的值
+	if val, _ := c.Get(ctx, "server.address"); val.String() != ":9000" {
+		t.Errorf("Get() expected :9000 after the reload, got %v", val)
+	}
+	if val, _ := c.Get(ctx, "database"); val.String() != "mysql://reloaded" {
+		t.Errorf("Get() expected the reloaded database, got %v", val)
+	}
+}
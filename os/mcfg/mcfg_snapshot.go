@@ -0,0 +1,69 @@
+package mcfg
+
+import (
+	"context"
+	"fmt"
+)
+
+// Snapshot 是配置在某一时刻的只读视图，提供与 Config 相同的 Get、类型转换的 Get 和 Unmarshal，见 Config.Snapshot
+type Snapshot struct {
+	*Config
+}
+
+// Snapshot 返回当前配置的快照，快照的值不随重新加载、Set 和环境变量等变化，
+// 用于在一次请求中读取一致的多个配置值，例如同一次加载的 "server.read_timeout" 和 "server.write_timeout"。
+//
+// 快照包含 Data 的全部有效值，其中的引用、密钥文件和加密值已解析，配置键的匹配方式与当前配置相同。
+// Config 的每次读取都来自某一次加载的完整配置，重新加载时原子替换，但连续的两次读取可能来自不同的加载。
+func (c *Config) Snapshot(ctx context.Context) (*Snapshot, error) {
+	data, err := c.Data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &Snapshot{
+		Config: &Config{
+			adapter:     &snapshotAdapter{data: data, source: c.adapter},
+			sensitive:   c.sensitive,
+			keyMatching: c.keyMatching,
+		},
+	}, nil
+}
+
+// snapshotAdapter 是以快照的配置数据为配置来源的适配器，见 Config.Snapshot
+type snapshotAdapter struct {
+	data   map[string]any // 快照的配置数据，不会被修改
+	source Adapter        // 快照的配置来源，用于错误信息
+}
+
+// String 返回快照的描述，用于错误信息
+func (a *snapshotAdapter) String() string {
+	return fmt.Sprintf(`snapshot of %s`, describeAdapter(a.source))
+}
+
+// Get 实现 Adapter 接口，返回的 map 是副本
+func (a *snapshotAdapter) Get(_ context.Context, pattern string) (any, error) {
+	if pattern == "" {
+		return deepCopyMap(a.data), nil
+	}
+	value, _ := lookupPath(a.data, splitKey(pattern))
+	if m, ok := value.(map[string]any); ok {
+		return deepCopyMap(m), nil
+	}
+	return value, nil
+}
+
+// Data 实现 Adapter 接口，返回的 map 是副本
+func (a *snapshotAdapter) Data(_ context.Context) (map[string]any, error) {
+	return deepCopyMap(a.data), nil
+}
+
+// Available 实现 Adapter 接口，检查快照中是否有配置数据
+func (a *snapshotAdapter) Available(_ context.Context, resource ...string) bool {
+	if len(resource) > 0 && resource[0] != "" {
+		_, ok := lookupPath(a.data, splitKey(resource[0]))
+		return ok
+	}
+	return len(a.data) > 0
+}
@@ -0,0 +1,103 @@
+package mcfg_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_Snapshot(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+server:
+  read_timeout: 10s
+  write_timeout: 10s
+  tags: [a, b]
+`)
+	snapshot, err := c.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if err = c.Set(ctx, "server.read_timeout", "30s"); err != nil {
+		t.Fatal(err)
+	}
+	if d := snapshot.GetDuration(ctx, "server.read_timeout"); d.String() != "10s" {
+		t.Errorf("Snapshot() expected the value before Set, got %v", d)
+	}
+	if d := c.GetDuration(ctx, "server.read_timeout"); d.String() != "30s" {
+		t.Errorf("GetDuration() expected the value of Set, got %v", d)
+	}
+	if tags := snapshot.GetStringSlice(ctx, "Server.Tags"); len(tags) != 2 {
+		t.Errorf("Snapshot() expected the tags, got %v", tags)
+	}
+
+	// 修改返回的 map 不影响快照
+	server := snapshot.GetStringMap(ctx, "server")
+	server["write_timeout"] = "0s"
+	if d := snapshot.GetDuration(ctx, "server.write_timeout"); d.String() != "10s" {
+		t.Errorf("Snapshot() expected the snapshot to be immutable, got %v", d)
+	}
+	if !snapshot.Available(ctx) || snapshot.Available(ctx, "database") {
+		t.Error("Available() expected only the keys of the snapshot to be available")
+	}
+}
+
+func TestConfig_SnapshotConcurrentReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	write := func(i int) {
+		tmp := filepath.Join(dir, fmt.Sprintf("config-%d.tmp", i))
+		content := fmt.Sprintf("server:\n  read_timeout: %d\n  write_timeout: %d\n", i, i)
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Error(err)
+		}
+	}
+	write(0)
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = adapter.SetConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	c := mcfg.NewWithAdapter(adapter)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			write(i)
+			if err := c.Load(ctx); err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				snapshot, err := c.Snapshot(ctx)
+				if err != nil {
+					t.Errorf("Snapshot() error = %v", err)
+					return
+				}
+				read, write := snapshot.GetInt(ctx, "server.read_timeout"), snapshot.GetInt(ctx, "server.write_timeout")
+				if read != write {
+					t.Errorf("Snapshot() expected the values of one reload, got read_timeout %d and write_timeout %d", read, write)
+					return
+				}
+				_ = c.GetInt(ctx, "server.read_timeout")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,83 @@
+package mcfg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Sub 返回以配置键 `key` 为根的子配置，例如 `Sub("database")` 的 "host" 读取 "database.host"，
+// 用于只需要自己的配置段的组件。子配置不复制配置数据，而是读取当前配置的有效值，
+// 因此包括环境变量、Set 和重新加载后的值；同一个键返回同一个子配置。
+// 配置键不存在时返回空的子配置，其 Available 返回 false。子配置的 OnChange 回调只在其子树变更时调用，
+// 变更来自当前配置的 Watch 和 Set；子配置的 Set、SetDefault 和环境变量等设置只对子配置生效。
+func (c *Config) Sub(key string) *Config {
+	if key == "" {
+		return c
+	}
+	lower := strings.ToLower(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sub, ok := c.subs[lower]; ok {
+		return sub
+	}
+	sub := NewWithAdapter(&subAdapter{parent: c, key: key})
+	path := splitKey(lower)
+	// 调用方持有 c.mu，因此直接注册回调
+	c.onChange = append(c.onChange, &changeHandler{fn: func(old, new map[string]any) {
+		oldValue, _ := lookupPath(old, path)
+		newValue, _ := lookupPath(new, path)
+		oldMap, _ := oldValue.(map[string]any)
+		newMap, _ := newValue.(map[string]any)
+		sub.notify(oldMap, newMap, nil)
+	}})
+	if c.subs == nil {
+		c.subs = map[string]*Config{}
+	}
+	c.subs[lower] = sub
+	return sub
+}
+
+// subAdapter 是以父配置中配置键 `key` 的值为配置来源的适配器，见 Config.Sub
+type subAdapter struct {
+	parent *Config
+	key    string
+}
+
+// String 返回子配置的描述，用于错误信息
+func (a *subAdapter) String() string {
+	return fmt.Sprintf(`config "%s" of %s`, a.key, a.parent.sources())
+}
+
+// Get 实现 Adapter 接口
+func (a *subAdapter) Get(ctx context.Context, pattern string) (any, error) {
+	key := a.key
+	if pattern != "" {
+		key += "." + pattern
+	}
+	value, err := a.parent.Get(ctx, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return value.Val(), nil
+}
+
+// Data 实现 Adapter 接口，配置键的值不是 map 时返回空
+func (a *subAdapter) Data(ctx context.Context) (map[string]any, error) {
+	value, err := a.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	data, _ := value.(map[string]any)
+	return data, nil
+}
+
+// Available 实现 Adapter 接口，检查父配置中是否有配置键的值
+func (a *subAdapter) Available(ctx context.Context, resource ...string) bool {
+	pattern := ""
+	if len(resource) > 0 {
+		pattern = resource[0]
+	}
+	value, err := a.Get(ctx, pattern)
+	return err == nil && value != nil
+}
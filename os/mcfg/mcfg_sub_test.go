@@ -0,0 +1,117 @@
+package mcfg_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+const subFixture = `
+database:
+  host: localhost
+  port: 5432
+  pool:
+    size: 8
+server:
+  address: ":8000"
+`
+
+func TestConfig_Sub(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", subFixture)
+	t.Setenv("SUB_DATABASE_HOST", "db.internal")
+	c.BindEnv("database.host", "SUB_DATABASE_HOST")
+
+	sub := c.Sub("Database")
+	if sub != c.Sub("database") {
+		t.Error("Sub() expected the same view for the same key")
+	}
+	if !sub.Available(ctx) {
+		t.Error("Available() expected true for the existing key")
+	}
+	// 子配置读取父配置的有效值
+	if v := sub.GetString(ctx, "host"); v != "db.internal" {
+		t.Errorf("GetString() expected db.internal of the environment variable, got %v", v)
+	}
+	if v := sub.GetInt(ctx, "port"); v != 5432 {
+		t.Errorf("GetInt() expected 5432, got %v", v)
+	}
+	if v := sub.Sub("pool").GetInt(ctx, "size"); v != 8 {
+		t.Errorf("Sub().GetInt() expected 8, got %v", v)
+	}
+	data, err := sub.Data(ctx)
+	if err != nil || data["host"] != "db.internal" || data["port"] != 5432 {
+		t.Errorf("Data() expected the database section, got %v, %v", data, err)
+	}
+	var database struct {
+		Host string
+		Port int
+	}
+	if err = sub.Unmarshal(ctx, &database); err != nil || database.Port != 5432 {
+		t.Errorf("Unmarshal() expected the database section, got %+v, %v", database, err)
+	}
+
+	missing := c.Sub("missing")
+	if missing == nil || missing.Available(ctx) {
+		t.Fatal("Sub() expected an unavailable view for the missing key")
+	}
+	if v := missing.GetString(ctx, "host", "default"); v != "default" {
+		t.Errorf("GetString() expected default, got %v", v)
+	}
+}
+
+func TestConfig_SubOnChange(t *testing.T) {
+	paths := writeFiles(t, map[string]string{"config.yaml": subFixture})
+	c := newFilesConfig(t)
+	if err := c.SetFiles(paths["config.yaml"]); err != nil {
+		t.Fatal(err)
+	}
+	sub := c.Sub("database")
+	changes := make(chan map[string]any, 10)
+	sub.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+
+	// 子树以外的变更不调用回调
+	if err := c.Set(ctx, "server.address", ":9000"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-changes:
+		t.Errorf("OnChange() expected no call, got %v", data)
+	default:
+	}
+	if err := c.Set(ctx, "database.host", "db2"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-changes:
+		if data["host"] != "db2" {
+			t.Errorf("OnChange() expected host db2, got %v", data)
+		}
+	default:
+		t.Error("OnChange() not called for Set")
+	}
+
+	// 重新加载后读取新的值
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := c.Watch(watchCtx); err != nil {
+		t.Fatal(err)
+	}
+	content := "database:\n  host: localhost\n  port: 6432\nserver:\n  address: \":8000\"\n"
+	if err := os.WriteFile(paths["config.yaml"], []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-changes:
+		if data["port"] != 6432 {
+			t.Errorf("OnChange() expected port 6432, got %v", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called for the reload")
+	}
+	if v := sub.GetInt(ctx, "port"); v != 6432 {
+		t.Errorf("GetInt() expected 6432 after the reload, got %v", v)
+	}
+}
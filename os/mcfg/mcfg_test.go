@@ -77,6 +77,22 @@ func TestConfig_Adapter(t *testing.T) {
 	if val.String() != "mock_value" {
 		t.Errorf("Get() expected 'mock_value', got %v", val)
 	}
+	if c.GetAdapter() != mcfg.Adapter(mockAdapter) {
+		t.Errorf("GetAdapter() expected the set adapter, got %T", c.GetAdapter())
+	}
+
+	// 运行中更换适配器
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, _ = c.Get(ctx, "mock.key")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.SetAdapter(newMockAdapter())
+	}
+	<-done
 }
 
 // 辅助测试的 mock 适配器
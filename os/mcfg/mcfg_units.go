@@ -0,0 +1,107 @@
+package mcfg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graingo/mconv"
+)
+
+// ByteSize 是以字节为单位的大小，Unmarshal 时支持 "8MB"、"512KiB" 等字符串，见 GetBytesE
+type ByteSize int64
+
+// byteUnits 是大小的单位（小写）对应的字节数，十进制单位以 1000 进位，二进制单位以 1024 进位
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+)
+
+// parseDuration 将配置值转换为 time.Duration，字符串为 Go 的时间间隔格式（例如 "1m30s"），
+// 数字和数字字符串为秒数
+func parseDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+			return secondsToDuration(seconds), nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf(`invalid duration "%s"`, v)
+		}
+		return d, nil
+	}
+	seconds, err := mconv.ToFloat64E(value)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid duration %v`, value)
+	}
+	return secondsToDuration(seconds), nil
+}
+
+// secondsToDuration 将秒数转换为 time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// ParseBytes 将配置值转换为字节数，字符串为数字和可选的单位，不区分大小写，例如 "8MB"、"1.5 GiB" 和 "512k"：
+// KB、MB、GB 和 TB 以 1000 进位，KiB、MiB、GiB、TiB 以及单字母的 K、M、G、T 以 1024 进位；数字为字节数
+func ParseBytes(value any) (int64, error) {
+	switch v := value.(type) {
+	case ByteSize:
+		return int64(v), nil
+	case string:
+		s := strings.TrimSpace(v)
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i < 0 {
+			i = len(s)
+		}
+		number, err := strconv.ParseFloat(s[:i], 64)
+		unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+		if err != nil || !ok || number*unit > math.MaxInt64 {
+			return 0, fmt.Errorf(`invalid byte size "%s"`, v)
+		}
+		return int64(number * unit), nil
+	}
+	size, err := mconv.ToInt64E(value)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid byte size %v`, value)
+	}
+	return size, nil
+}
+
+// unitHook 是以 parseDuration 和 ParseBytes 解码 time.Duration 和 ByteSize 字段的解码钩子
+func unitHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	switch to {
+	case durationType:
+		return parseDuration(data)
+	case byteSizeType:
+		size, err := ParseBytes(data)
+		return ByteSize(size), err
+	}
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package mcfg_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+const unitsFixture = `
+server:
+  read_timeout: 30s
+  idle_timeout: 90
+  max_body_size: 8MB
+  max_header_size: 512 kib
+  buffer: 1.5GiB
+  limit: 1024
+invalid:
+  timeout: soon
+  size: 8XB
+`
+
+func TestConfig_GetDurationBytes(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unitsFixture)
+	c.SetDefault("server.write_timeout", "15")
+
+	for key, expected := range map[string]time.Duration{
+		"server.read_timeout":  30 * time.Second,
+		"server.idle_timeout":  90 * time.Second,
+		"server.write_timeout": 15 * time.Second,
+	} {
+		if v, err := c.GetDurationE(ctx, key); err != nil || v != expected {
+			t.Errorf("GetDurationE(%s) expected %v, got %v, %v", key, expected, v, err)
+		}
+	}
+	for key, expected := range map[string]int64{
+		"server.max_body_size":   8_000_000,
+		"server.max_header_size": 512 << 10,
+		"server.buffer":          3 << 29,
+		"server.limit":           1024,
+	} {
+		if v, err := c.GetBytesE(ctx, key); err != nil || v != expected {
+			t.Errorf("GetBytesE(%s) expected %v, got %v, %v", key, expected, v, err)
+		}
+	}
+
+	_, err := c.GetDurationE(ctx, "invalid.timeout")
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	if !merror.MessageContains(err, "invalid.timeout") {
+		t.Errorf("GetDurationE() expected the error of the key, got %v", err)
+	}
+	_, err = c.GetBytesE(ctx, "invalid.size")
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	if !merror.MessageContains(err, "invalid.size") {
+		t.Errorf("GetBytesE() expected the error of the key, got %v", err)
+	}
+}
+
+func TestConfig_UnmarshalUnits(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unitsFixture)
+	var server struct {
+		ReadTimeout   time.Duration
+		IdleTimeout   time.Duration
+		MaxBodySize   mcfg.ByteSize
+		MaxHeaderSize mcfg.ByteSize `mapstructure:"max_header_size"`
+	}
+	if err := c.UnmarshalKey(ctx, "server", &server); err != nil {
+		t.Fatalf("UnmarshalKey() error = %v", err)
+	}
+	if server.ReadTimeout != 30*time.Second || server.IdleTimeout != 90*time.Second {
+		t.Errorf("UnmarshalKey() expected 30s and 90s, got %v and %v", server.ReadTimeout, server.IdleTimeout)
+	}
+	if server.MaxBodySize != 8_000_000 || server.MaxHeaderSize != 512<<10 {
+		t.Errorf("UnmarshalKey() expected 8MB and 512KiB, got %v and %v", server.MaxBodySize, server.MaxHeaderSize)
+	}
+
+	var invalid struct {
+		Size mcfg.ByteSize `mapstructure:"size"`
+	}
+	err := c.UnmarshalKey(ctx, "invalid", &invalid)
+	if err == nil || !strings.Contains(err.Error(), `"invalid"`) || !strings.Contains(err.Error(), "size") {
+		t.Errorf("UnmarshalKey() expected the invalid size error, got %v", err)
+	}
+}
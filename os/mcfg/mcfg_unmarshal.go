@@ -0,0 +1,232 @@
+package mcfg
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/mitchellh/mapstructure"
+)
+
+// unmarshalOptions 是 Unmarshal 的解码选项
+type unmarshalOptions struct {
+	strict      bool            // 配置中有结构体没有的键时报错
+	weaklyTyped bool            // 是否转换不同类型的值，例如字符串 "8080" 到 int
+	tagName     string          // 字段的标签名
+	metadata    *DecodeMetadata // 保存解码的字段和未使用的配置键
+	hooks       []DecodeHook    // 在内置的转换之前调用的解码钩子
+}
+
+// DecodeMetadata 是解码的结果，见 WithMetadata
+type DecodeMetadata struct {
+	Keys   []string // 解码的字段，嵌套的字段以 "." 连接，例如 "TLS.CertFile"
+	Unused []string // 没有匹配字段的配置键，例如拼写错误的键
+}
+
+// DecodeHook 在解码前转换类型为 `from` 的值 `data` 到类型 `to`，不处理的值原样返回，见 WithDecodeHook
+type DecodeHook func(from reflect.Type, to reflect.Type, data any) (any, error)
+
+// UnmarshalOption 配置 Unmarshal 和 UnmarshalKey 的解码方式
+type UnmarshalOption func(o *unmarshalOptions)
+
+// WithStrict 设置是否在配置中有结构体没有的键时报错，用于发现配置键的拼写错误，默认关闭
+func WithStrict(strict bool) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.strict = strict
+	}
+}
+
+// WithWeaklyTyped 设置是否转换不同类型的值，例如环境变量的字符串 "8080" 到 int，默认开启
+func WithWeaklyTyped(enabled bool) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.weaklyTyped = enabled
+	}
+}
+
+// WithTagName 设置字段的标签名，默认为 "mapstructure"，没有该标签的字段使用 json 标签
+func WithTagName(name string) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.tagName = name
+	}
+}
+
+// WithMetadata 设置保存解码结果的 `metadata`，例如非严格模式下报告未使用的配置键
+func WithMetadata(metadata *DecodeMetadata) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithDecodeHook 添加解码钩子，用于内置的转换不支持的类型，例如从字符串解码的枚举
+func WithDecodeHook(hook DecodeHook) UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.hooks = append(o.hooks, hook)
+	}
+}
+
+// Unmarshal 将全部配置数据解码到结构体指针 `v`，包括环境变量等覆盖的值，见 UnmarshalKey
+func (c *Config) Unmarshal(ctx context.Context, v any, opts ...UnmarshalOption) error {
+	data, err := c.Data(ctx)
+	if err != nil {
+		return err
+	}
+	options := newUnmarshalOptions(opts)
+	if err = decode(data, v, "", options); err != nil {
+		return err
+	}
+	return c.validate("", data, v, options)
+}
+
+// UnmarshalKey 将配置键 `key` 的值解码到指针 `v`，配置不存在时只设置 default 标签的默认值。
+//
+// 字段以 mapstructure 标签、json 标签或字段名匹配配置键，匹配时忽略大小写、下划线和连字符，
+// 因此配置键 "read_timeout" 匹配字段 ReadTimeout；嵌入的结构体的字段视为外层的字段。
+// 字符串值可解码为 time.Duration（例如 "30s"，数字为秒数）、ByteSize（例如 "8MB"）、time.Time（RFC 3339）
+// 和以逗号分隔的切片，无法解析时错误信息包含字段的配置键。
+// 所有来源中都没有的字段使用 default 标签的值，例如 `Port int default:"8080"`；
+// 解码后校验结构体的 validate 标签，例如 `DSN string validate:"required"`，返回的错误以配置键列出所有不合法的字段，
+// 例如 "server.upstreams.0.port"，切片和 map 中的结构体需要 dive 标签才会校验。
+func (c *Config) UnmarshalKey(ctx context.Context, key string, v any, opts ...UnmarshalOption) error {
+	value, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	var input any
+	if value != nil {
+		input = value.Val()
+	}
+	options := newUnmarshalOptions(opts)
+	if err = decode(input, v, key, options); err != nil {
+		return err
+	}
+	return c.validate(key, input, v, options)
+}
+
+// Decode 将已读取的值 `input`，例如组件的配置节点的 map，解码到指针 `v`，解码方式与 UnmarshalKey 相同，
+// 但不校验 validate 标签；用于以 map 设置配置的组件，例如 mhttp.Server.SetConfigWithMap
+func Decode(input any, v any, opts ...UnmarshalOption) error {
+	return decode(input, v, "", newUnmarshalOptions(opts))
+}
+
+// newUnmarshalOptions 返回应用 `opts` 后的解码选项
+func newUnmarshalOptions(opts []UnmarshalOption) unmarshalOptions {
+	options := unmarshalOptions{
+		weaklyTyped: true,
+		tagName:     "mapstructure",
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// decode 将配置键 `key` 的值 `input` 解码到 `v`
+func decode(input any, v any, key string, options unmarshalOptions) error {
+	hooks := []mapstructure.DecodeHookFunc{jsonTagHook(options.tagName)}
+	for _, hook := range options.hooks {
+		hooks = append(hooks, mapstructure.DecodeHookFuncType(hook))
+	}
+	hooks = append(hooks, unitHook, mapstructure.StringToTimeHookFunc(time.RFC3339), stringToSliceHook)
+	var metadata *mapstructure.Metadata
+	if options.metadata != nil {
+		metadata = &mapstructure.Metadata{}
+		defer func() {
+			options.metadata.Keys, options.metadata.Unused = metadata.Keys, metadata.Unused
+		}()
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
+		Metadata:         metadata,
+		ErrorUnused:      options.strict,
+		WeaklyTypedInput: options.weaklyTyped,
+		Squash:           true,
+		TagName:          options.tagName,
+		MatchName:        matchName,
+		Result:           v,
+	})
+	if err != nil {
+		return merror.WrapCode(err, mcode.CodeInvalidParameter, `invalid unmarshal target`)
+	}
+	if m, ok := input.(map[string]any); ok || input == nil {
+		m = applyDefaultTags(reflect.TypeOf(v), m, options.tagName)
+		// 配置不存在且没有默认值时 `v` 不变
+		if input == nil && len(m) == 0 {
+			return nil
+		}
+		input = m
+	}
+	if err = decoder.Decode(input); err != nil {
+		if key == "" {
+			return merror.WrapCode(err, mcode.CodeInvalidConfiguration, `unmarshal config failed`)
+		}
+		return merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `unmarshal config "%s" failed`, key)
+	}
+	return nil
+}
+
+// stringToSliceHook 将字符串值以逗号分隔为切片，与 GetStringSlice 相同，见 splitList
+func stringToSliceHook(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Slice || to.Elem().Kind() == reflect.Uint8 {
+		return data, nil
+	}
+	return splitList(data), nil
+}
+
+// matchName 匹配配置键和字段名，忽略大小写、"_" 和 "-"，与配置键的折叠相同，见 foldName
+func matchName(mapKey, fieldName string) bool {
+	return strings.EqualFold(nameReplacer.Replace(mapKey), nameReplacer.Replace(fieldName))
+}
+
+// nameReplacer 去掉配置键和字段名中的 "_" 和 "-"，见 matchName
+var nameReplacer = strings.NewReplacer("_", "", "-", "")
+
+// jsonTagHook 返回将配置键重命名为字段名的解码钩子，用于没有 `tagName` 标签而有 json 标签的字段
+func jsonTagHook(tagName string) mapstructure.DecodeHookFuncValue {
+	return func(from reflect.Value, to reflect.Value) (any, error) {
+		data, ok := from.Interface().(map[string]any)
+		if !ok {
+			return from.Interface(), nil
+		}
+		t := to.Type()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return data, nil
+		}
+		names := map[string]string{}
+		jsonTagNames(t, tagName, names)
+		if len(names) == 0 {
+			return data, nil
+		}
+		renamed := make(map[string]any, len(data))
+		for key, value := range data {
+			if name, ok := names[strings.ToLower(key)]; ok {
+				key = name
+			}
+			renamed[key] = value
+		}
+		return renamed, nil
+	}
+}
+
+// jsonTagNames 将结构体 `t` 中只有 json 标签的字段的标签名（小写）映射到字段名，保存到 `names`
+func jsonTagNames(t reflect.Type, tagName string, names map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup(tagName); ok {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			jsonTagNames(field.Type, tagName, names)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" && !matchName(name, field.Name) {
+			names[strings.ToLower(name)] = field.Name
+		}
+	}
+}
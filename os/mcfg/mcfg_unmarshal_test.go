@@ -0,0 +1,135 @@
+package mcfg_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+type tlsConfig struct {
+	Enable   bool
+	CertFile string `json:"cert"`
+}
+
+type serverConfig struct {
+	tlsConfig
+	Address        string        `mapstructure:"addr"`
+	ReadTimeout    time.Duration // 匹配 read_timeout
+	MaxHeaderBytes int           `json:"max_header_bytes"`
+	Proxies        []string      `json:"trusted_proxies"`
+	Started        time.Time
+}
+
+const unmarshalFixture = `
+server:
+  addr: ":8000"
+  read_timeout: 30s
+  max_header_bytes: "1024"
+  trusted_proxies: "10.0.0.0/8, 192.168.0.0/16"
+  started: 2025-01-02T15:04:05Z
+  enable: true
+  cert: server.crt
+app:
+  name: test-app
+`
+
+func TestConfig_UnmarshalKey(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unmarshalFixture)
+
+	var server serverConfig
+	if err := c.UnmarshalKey(ctx, "server", &server); err != nil {
+		t.Fatalf("UnmarshalKey() error = %v", err)
+	}
+	if server.Address != ":8000" || server.ReadTimeout != 30*time.Second || server.MaxHeaderBytes != 1024 {
+		t.Errorf("UnmarshalKey() got %+v", server)
+	}
+	if len(server.Proxies) != 2 || server.Proxies[1] != "192.168.0.0/16" {
+		t.Errorf("UnmarshalKey() expected 2 proxies, got %v", server.Proxies)
+	}
+	if !server.Enable || server.CertFile != "server.crt" {
+		t.Errorf("UnmarshalKey() expected the embedded fields, got %+v", server.tlsConfig)
+	}
+	if !server.Started.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("UnmarshalKey() got started %v", server.Started)
+	}
+
+	// 覆盖的值同样被解码
+	t.Setenv("UNMARSHAL_SERVER_ADDR", ":9000")
+	c.SetEnvPrefix("UNMARSHAL")
+	c.AutomaticEnv(true)
+	if err := c.UnmarshalKey(ctx, "server", &server); err != nil || server.Address != ":9000" {
+		t.Errorf("UnmarshalKey() expected ':9000', got %v, %v", server.Address, err)
+	}
+
+	// 配置不存在时不变
+	if err := c.UnmarshalKey(ctx, "missing", &server); err != nil || server.Address != ":9000" {
+		t.Errorf("UnmarshalKey() expected no change, got %v, %v", server.Address, err)
+	}
+
+	if err := c.UnmarshalKey(ctx, "server", &server, mcfg.WithWeaklyTyped(false)); err == nil {
+		t.Error("UnmarshalKey() expected error without weakly typed conversion")
+	}
+}
+
+func TestConfig_UnmarshalStrict(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", unmarshalFixture)
+
+	var config struct {
+		App struct {
+			Name string
+		}
+	}
+	if err := c.Unmarshal(ctx, &config); err != nil || config.App.Name != "test-app" {
+		t.Errorf("Unmarshal() expected 'test-app', got %v, %v", config.App.Name, err)
+	}
+	if err := c.Unmarshal(ctx, &config, mcfg.WithStrict(true)); err == nil {
+		t.Error("Unmarshal() expected error for the unknown key server")
+	}
+	var app struct {
+		Nmae string
+	}
+	if err := c.UnmarshalKey(ctx, "app", &app, mcfg.WithStrict(true)); err == nil {
+		t.Error("UnmarshalKey() expected error for a typo")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type level int
+	levelHook := func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to != reflect.TypeOf(level(0)) || from.Kind() != reflect.String {
+			return data, nil
+		}
+		return map[string]level{"low": 1, "high": 2}[data.(string)], nil
+	}
+	var (
+		config struct {
+			ReadTimeout time.Duration
+			MaxBodySize mcfg.ByteSize
+			Level       level
+			Name        string
+		}
+		metadata mcfg.DecodeMetadata
+	)
+	config.Name = "kept"
+	err := mcfg.Decode(map[string]any{
+		"read-timeout":  "30s",
+		"max_body_size": "8MB",
+		"level":         "high",
+		"read_timout":   "5s",
+	}, &config, mcfg.WithMetadata(&metadata), mcfg.WithDecodeHook(levelHook))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if config.ReadTimeout != 30*time.Second || config.MaxBodySize != 8_000_000 || config.Level != 2 || config.Name != "kept" {
+		t.Errorf("Decode() got %+v", config)
+	}
+	if len(metadata.Unused) != 1 || metadata.Unused[0] != "read_timout" {
+		t.Errorf("Decode() expected the unused key read_timout, got %v", metadata.Unused)
+	}
+	if !slices.Contains(metadata.Keys, "ReadTimeout") || slices.Contains(metadata.Keys, "Name") {
+		t.Errorf("Decode() expected the decoded fields, got %v", metadata.Keys)
+	}
+}
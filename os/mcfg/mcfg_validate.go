@@ -0,0 +1,148 @@
+package mcfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// structValidator 校验 Unmarshal 解码的结构体的 validate 标签
+var structValidator = validator.New()
+
+// validate 校验从配置键 `key` 的值 `input` 解码到 `v` 的结果，`v` 是结构体指针时检查其 validate 标签，
+// 例如 `DSN string validate:"required"`。返回的错误以配置键列出所有不合法的字段，而不是字段名。
+func (c *Config) validate(key string, input any, v any, options unmarshalOptions) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	err := structValidator.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		if key == "" {
+			return merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `invalid config in %s`, c.sources())
+		}
+		return merror.WrapCodef(err, mcode.CodeInvalidConfiguration, `invalid config "%s" in %s`, key, c.sources())
+	}
+	violations := make([]string, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		path := joinKey(configPath(t, input, fieldError.StructNamespace(), options.tagName))
+		if key != "" {
+			path = key + "." + path
+		}
+		rule := fieldError.Tag()
+		if param := fieldError.Param(); param != "" {
+			rule += "=" + param
+		}
+		violations = append(violations, fmt.Sprintf(`"%s" failed on "%s"`, path, rule))
+	}
+	if key == "" {
+		return merror.NewCodef(mcode.CodeInvalidConfiguration, `invalid config in %s: %s`, c.sources(), strings.Join(violations, "; "))
+	}
+	return merror.NewCodef(mcode.CodeInvalidConfiguration, `invalid config "%s" in %s: %s`, key, c.sources(), strings.Join(violations, "; "))
+}
+
+// configPath 返回校验错误的字段命名空间 `namespace` 对应的配置键路径，例如 "Server.Upstreams[0].Port" 对应 "upstreams.0.port"。
+// 各级的键名使用解码的值 `input` 中匹配字段的键，配置中没有该键时使用字段的标签名或小写的字段名。
+func configPath(t reflect.Type, input any, namespace string, tagName string) []string {
+	var path []string
+	segments := splitNamespace(namespace)
+	// 第一段是结构体的类型名，匿名结构体没有类型名
+	if t.Name() != "" && len(segments) > 0 && segments[0] == t.Name() {
+		segments = segments[1:]
+	}
+	for _, segment := range segments {
+		name, indexes := splitIndexes(segment)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		var field reflect.StructField
+		found := false
+		if t != nil && t.Kind() == reflect.Struct {
+			field, found = t.FieldByName(name)
+		}
+		if !found {
+			path = append(path, strings.ToLower(name))
+			path = append(path, indexes...)
+			t, input = nil, nil
+			continue
+		}
+		t = field.Type
+		// 嵌入的结构体的字段视为外层的字段，见 decode
+		if field.Anonymous && len(indexes) == 0 {
+			continue
+		}
+		_, names := fieldNames(field, tagName)
+		m, _ := input.(map[string]any)
+		if key, ok := findKey(m, names); ok {
+			path = append(path, key)
+			input = m[key]
+		} else {
+			path = append(path, strings.ToLower(names[len(names)-1]))
+			input = nil
+		}
+		for _, index := range indexes {
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if kind := t.Kind(); kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map {
+				t = t.Elem()
+			}
+			path = append(path, index)
+			input, _ = lookupPath(input, []string{index})
+		}
+	}
+	return path
+}
+
+// splitNamespace 以 "." 分隔字段命名空间，忽略 map 键中的点，例如 "Config.Hosts[example.com].Port"
+func splitNamespace(namespace string) []string {
+	var (
+		segments []string
+		depth    int
+		start    int
+	)
+	for i, r := range namespace {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				segments = append(segments, namespace[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, namespace[start:])
+}
+
+// splitIndexes 将命名空间的段 `segment` 分为字段名和切片的索引或 map 的键，例如 "Upstreams[0]" 分为 "Upstreams" 和 ["0"]
+func splitIndexes(segment string) (name string, indexes []string) {
+	name, rest, found := strings.Cut(segment, "[")
+	if !found {
+		return name, nil
+	}
+	rest = "[" + rest
+	for strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			break
+		}
+		indexes = append(indexes, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return name, indexes
+}
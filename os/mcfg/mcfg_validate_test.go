@@ -0,0 +1,62 @@
+package mcfg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror/merrortest"
+)
+
+func TestConfig_UnmarshalValidatePaths(t *testing.T) {
+	type upstream struct {
+		Host string `validate:"required"`
+		Port int    `validate:"min=1,max=65535"`
+	}
+	type tlsConfig struct {
+		CertFile string `mapstructure:"cert_file" validate:"required"`
+	}
+	type serverConfig struct {
+		Addr        string     `validate:"required"`
+		ReadTimeout int        `json:"read_timeout" validate:"gte=0"`
+		TLS         *tlsConfig `validate:"required"`
+		Upstreams   []upstream `validate:"min=1,dive"`
+	}
+	c := newFileConfig(t, "config.yaml", `
+server:
+  addr: ":8000"
+  read_timeout: -1
+  tls: {}
+  upstreams:
+    - host: "10.0.0.1"
+      port: 8080
+    - port: 70000
+`)
+	var server serverConfig
+	err := c.UnmarshalKey(ctx, "server", &server)
+	if err == nil {
+		t.Fatal("UnmarshalKey() expected error for invalid config")
+	}
+	merrortest.AssertCode(t, err, mcode.CodeInvalidConfiguration)
+	for _, want := range []string{
+		`"server.read_timeout" failed on "gte=0"`,
+		`"server.tls.cert_file" failed on "required"`,
+		`"server.upstreams.1.host" failed on "required"`,
+		`"server.upstreams.1.port" failed on "max=65535"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("UnmarshalKey() expected error containing %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "upstreams.0") || strings.Contains(err.Error(), "ReadTimeout") {
+		t.Errorf("UnmarshalKey() expected only the invalid fields by config key, got %v", err)
+	}
+
+	var config struct {
+		Server serverConfig
+	}
+	err = c.Unmarshal(ctx, &config)
+	if err == nil || !strings.Contains(err.Error(), `"server.upstreams.1.port" failed on "max=65535"`) {
+		t.Errorf("Unmarshal() expected error with the config key of the field, got %v", err)
+	}
+}
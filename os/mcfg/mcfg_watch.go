@@ -0,0 +1,98 @@
+package mcfg
+
+import (
+	"context"
+	"reflect"
+	"slices"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/internal/intlog"
+)
+
+// ChangeFunc 是配置变更的回调，参数为变更前后的配置数据
+type ChangeFunc func(old, new map[string]any)
+
+// ChangeErrorFunc 是配置重新加载失败的回调，此时仍使用上次有效的配置
+type ChangeErrorFunc func(err error)
+
+// Watch 监听配置变更并热加载，直到 `ctx` 被取消，适配器需实现 WatchAdapter。
+// 配置变更后调用 OnChange 注册的回调，重新加载失败时调用 OnChangeError 注册的回调。
+func (c *Config) Watch(ctx context.Context) error {
+	adapter, ok := c.GetAdapter().(WatchAdapter)
+	if !ok {
+		return merror.NewCodef(mcode.CodeNotSupported, `config adapter %T does not support watching`, c.GetAdapter())
+	}
+	return adapter.Watch(ctx, func(old, new map[string]any, err error) {
+		if err == nil {
+			// 重新加载后重新读取密钥文件并重新解密，见 EnableSecretFiles 和 SetDecryptFunc
+			c.clearSecrets()
+			old, new, err = c.decryptChange(old, new)
+		}
+		c.notify(old, new, err)
+	})
+}
+
+// changeHandler 是注册的配置变更回调，以指针区分同一个函数的多次注册，见 addOnChange
+type changeHandler struct {
+	fn ChangeFunc
+}
+
+// OnChange 注册配置变更的回调，回调的 panic 会被捕获并记录；变更的配置键见 Diff 和 OnKeyChange
+func (c *Config) OnChange(fn ChangeFunc) {
+	c.addOnChange(fn)
+}
+
+// addOnChange 注册配置变更的回调，返回注销该回调的函数
+func (c *Config) addOnChange(fn ChangeFunc) (remove func()) {
+	handler := &changeHandler{fn: fn}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, handler)
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// 复制后删除，notify 可能正在遍历原来的切片
+		c.onChange = slices.DeleteFunc(slices.Clone(c.onChange), func(h *changeHandler) bool {
+			return h == handler
+		})
+	}
+}
+
+// OnChangeError 注册配置重新加载失败的回调，例如配置文件暂时不是有效的 YAML
+func (c *Config) OnChangeError(fn ChangeErrorFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChangeError = append(c.onChangeError, fn)
+}
+
+// notify 调用配置变更或重新加载失败的回调
+func (c *Config) notify(old, new map[string]any, err error) {
+	c.mu.Lock()
+	onChange, onChangeError := c.onChange, c.onChangeError
+	c.mu.Unlock()
+
+	if err != nil {
+		intlog.Errorf(context.Background(), "failed to reload config: %v", err)
+		for _, fn := range onChangeError {
+			safeCall(func() { fn(err) })
+		}
+		return
+	}
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	for _, handler := range onChange {
+		safeCall(func() { handler.fn(old, new) })
+	}
+}
+
+// safeCall 调用回调 `fn`，捕获并记录其 panic
+func safeCall(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			intlog.Errorf(context.Background(), "config change callback panic: %v", r)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,132 @@
+package mcfg
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// WatchOption 是 WatchKey 和 WatchFile 的选项
+type WatchOption func(o *watchOptions)
+
+// watchOptions 是 WatchKey 和 WatchFile 的设置
+type watchOptions struct {
+	debounce time.Duration // 合并变更的时间，为 0 时不合并
+}
+
+// WithDebounce 设置合并变更的时间 `d`，`d` 内的多次变更只调用一次回调，参数为第一次变更前和最后一次变更后的值；
+// WatchKey 默认不合并，WatchFile 默认为 100ms
+func WithDebounce(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.debounce = d
+	}
+}
+
+// newWatchOptions 返回默认合并时间为 `debounce` 并应用 `opts` 后的设置
+func newWatchOptions(debounce time.Duration, opts []WatchOption) watchOptions {
+	options := watchOptions{debounce: debounce}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WatchKey 注册配置键 `key` 的值变更的回调，参数为变更前后的值，配置键不存在时为 nil，
+// 例如 `WatchKey("log.level", fn)` 只在日志级别变更时调用 `fn`。变更来自 Watch、Set 等，见 OnChange。
+// 返回的函数注销该回调，可以多次调用。
+func (c *Config) WatchKey(key string, fn func(old, new any), opts ...WatchOption) (cancel func()) {
+	var (
+		options = newWatchOptions(0, opts)
+		path    = splitKey(strings.ToLower(c.foldKey(key)))
+		mu      sync.Mutex
+		timer   *time.Timer
+		first   any // 合并的第一次变更前的值
+		last    any // 合并的最后一次变更后的值
+	)
+	remove := c.addOnChange(func(old, new map[string]any) {
+		oldValue, _ := lookupPath(old, path)
+		newValue, _ := lookupPath(new, path)
+		if reflect.DeepEqual(oldValue, newValue) {
+			return
+		}
+		if options.debounce <= 0 {
+			fn(oldValue, newValue)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if timer == nil {
+			first = oldValue
+		} else {
+			timer.Stop()
+		}
+		last = newValue
+		timer = time.AfterFunc(options.debounce, func() {
+			mu.Lock()
+			oldValue, newValue := first, last
+			timer, first, last = nil, nil, nil
+			mu.Unlock()
+			if !reflect.DeepEqual(oldValue, newValue) {
+				safeCall(func() { fn(oldValue, newValue) })
+			}
+		})
+	})
+	return func() {
+		remove()
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+			timer, first, last = nil, nil, nil
+		}
+	}
+}
+
+// WatchFile 监听文件 `path` 并在其内容变更时以新的内容调用 `fn`，用于配置以外的文件，例如证书和密钥文件。
+// 监听文件所在的目录，因此支持原子替换和 Kubernetes 挂载的 ConfigMap 和 Secret 的符号链接替换；
+// 读取文件失败时调用 OnChangeError 注册的回调。返回的函数停止监听，可以多次调用。
+func (c *Config) WatchFile(path string, fn func(content []byte), opts ...WatchOption) (cancel func(), err error) {
+	options := newWatchOptions(watchDebounce, opts)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid file path "%s"`, path)
+	}
+	path = abs
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeOperationFailed, `read file "%s" failed`, path)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, merror.WrapCode(err, mcode.CodeOperationFailed, `create file watcher failed`)
+	}
+	if err = watchDirs(watcher, []string{path}); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go watchEvents(ctx, watcher, options.debounce, nil, func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			c.notify(nil, nil, merror.WrapCodef(err, mcode.CodeOperationFailed, `read file "%s" failed`, path))
+			return
+		}
+		if bytes.Equal(data, content) {
+			return
+		}
+		content = data
+		safeCall(func() { fn(data) })
+	}, func(err error) {
+		c.notify(nil, nil, merror.WrapCodef(err, mcode.CodeOperationFailed, `watch file "%s" failed`, path))
+	})
+	return cancel, nil
+}
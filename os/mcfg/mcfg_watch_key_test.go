@@ -0,0 +1,139 @@
+package mcfg_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+func TestConfig_WatchKey(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", `
+log:
+  level: info
+server:
+  addr: ":8000"
+`)
+	var changes [][2]any
+	cancel := c.WatchKey("Log.Level", func(old, new any) {
+		changes = append(changes, [2]any{old, new})
+	})
+	if err := c.Set(ctx, "server.addr", ":9000"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("WatchKey() expected no call for other keys, got %v", changes)
+	}
+	if err := c.Set(ctx, "log.level", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0] != [2]any{"info", "debug"} {
+		t.Errorf("WatchKey() expected [info debug], got %v", changes)
+	}
+
+	cancel()
+	cancel()
+	if err := c.Set(ctx, "log.level", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Errorf("WatchKey() expected no call after cancel, got %v", changes)
+	}
+}
+
+func TestConfig_WatchKey_Debounce(t *testing.T) {
+	c := newFileConfig(t, "config.yaml", "log:\n  level: info\n")
+	changes := make(chan [2]any, 10)
+	cancel := c.WatchKey("log.level", func(old, new any) {
+		changes <- [2]any{old, new}
+	}, mcfg.WithDebounce(50*time.Millisecond))
+	defer cancel()
+
+	for _, level := range []string{"debug", "warn", "error"} {
+		if err := c.Set(ctx, "log.level", level); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case change := <-changes:
+		if change != [2]any{"info", "error"} {
+			t.Errorf("WatchKey() expected [info error], got %v", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WatchKey() expected a debounced call")
+	}
+	select {
+	case change := <-changes:
+		t.Errorf("WatchKey() expected a single call, got %v", change)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestConfig_WatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tls.crt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := mcfg.NewWithAdapter(newMockAdapter())
+	var (
+		mu       sync.Mutex
+		contents []string
+	)
+	cancel, err := c.WatchFile(path, func(content []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		contents = append(contents, string(content))
+	}, mcfg.WithDebounce(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("WatchFile() error = %v", err)
+	}
+
+	// 原子替换
+	tmp := filepath.Join(dir, "tls.crt.tmp")
+	if err = os.WriteFile(tmp, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]string(nil), contents...)
+		mu.Unlock()
+		if len(got) > 0 {
+			if len(got) != 1 || got[0] != "v2" {
+				t.Errorf("WatchFile() expected [v2], got %v", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("WatchFile() expected a call after the file changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if err = os.WriteFile(path, []byte("v3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(contents) != 1 {
+		t.Errorf("WatchFile() expected no call after cancel, got %v", contents)
+	}
+}
+
+func TestConfig_WatchFile_NotFound(t *testing.T) {
+	_, err := mcfg.NewWithAdapter(newMockAdapter()).WatchFile(filepath.Join(t.TempDir(), "missing"), func([]byte) {})
+	if err == nil {
+		t.Error("WatchFile() expected an error for a missing file, got nil")
+	}
+}
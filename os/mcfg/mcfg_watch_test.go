@@ -0,0 +1,115 @@
+package mcfg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+)
+
+// chdirTemp 切换到临时目录并创建配置文件 `config/{name}`，返回配置文件路径
+func chdirTemp(t *testing.T, name, content string) string {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	path := filepath.Join(dir, "config", name)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfig_Watch(t *testing.T) {
+	path := chdirTemp(t, "watch.yaml", fixtureConfigTest)
+	adapter, err := mcfg.NewAdapterFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter.SetFileName("watch")
+	c := mcfg.NewWithAdapter(adapter)
+
+	var (
+		changes = make(chan map[string]any, 10)
+		errs    = make(chan error, 10)
+	)
+	c.OnChange(func(old, new map[string]any) {
+		panic("recovered")
+	})
+	c.OnChange(func(old, new map[string]any) {
+		changes <- new
+	})
+	c.OnChangeError(func(err error) {
+		errs <- err
+	})
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err = c.Watch(watchCtx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// 连续的写入合并为一次重新加载
+	for _, version := range []string{"3.0.0", "4.0.0", "5.0.0"} {
+		if err = os.WriteFile(path, []byte("app:\n  version: \""+version+"\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case data := <-changes:
+		if version := data["app"].(map[string]any)["version"]; version != "5.0.0" {
+			t.Errorf("OnChange() expected version 5.0.0, got %v", version)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChange() not called")
+	}
+	select {
+	case <-changes:
+		t.Error("OnChange() expected one call for successive writes")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// 无效的配置文件保留上次有效的配置
+	if err = os.WriteFile(path, []byte("app: [invalid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnChangeError() not called")
+	}
+	val, err := c.Get(ctx, "app.version")
+	if err != nil || val.String() != "5.0.0" {
+		t.Errorf("Get() expected 5.0.0, got %v, %v", val, err)
+	}
+
+	// 取消后不再重新加载
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if err = os.WriteFile(path, []byte("app:\n  version: \"6.0.0\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Error("OnChange() called after the watch is canceled")
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestConfig_WatchNotSupported(t *testing.T) {
+	c := mcfg.NewWithAdapter(newMockAdapter())
+	if err := c.Watch(ctx); err == nil {
+		t.Error("Watch() expected error for an adapter without watching")
+	}
+}
@@ -20,6 +20,11 @@ type ILogger interface {
 	Fatalf(ctx context.Context, format string, v ...any) // Fatalf logs a message at level Fatal.
 	Panic(ctx context.Context, v ...any)                 // Panic logs a message at level Panic.
 	Panicf(ctx context.Context, format string, v ...any) // Panicf logs a message at level Panic.
+
+	Debugw(ctx context.Context, msg string, keysAndValues ...any) // Debugw logs a message with fields at level Debug.
+	Infow(ctx context.Context, msg string, keysAndValues ...any)  // Infow logs a message with fields at level Info.
+	Warnw(ctx context.Context, msg string, keysAndValues ...any)  // Warnw logs a message with fields at level Warn.
+	Errorw(ctx context.Context, msg string, keysAndValues ...any) // Errorw logs a message with fields at level Error.
 }
 
 var (
@@ -28,6 +33,10 @@ var (
 
 	// defaultLogger is the default logger.
 	defaultLogger = New()
+
+	// apiLogger is the default logger used by the package functions,
+	// skipping the package function when reporting the caller.
+	apiLogger = defaultLogger.AddCallerSkip(1)
 )
 
 // DefaultLogger returns the default logger.
@@ -40,4 +49,5 @@ func DefaultLogger() *Logger {
 // in different goroutines.
 func SetDefaultLogger(l *Logger) {
 	defaultLogger = l
+	apiLogger = l.AddCallerSkip(1)
 }
@@ -5,75 +5,111 @@ import "context"
 // Print prints `v` with newline using fmt.Sprintln.
 // The parameter `v` can be multiple variables.
 func Print(ctx context.Context, v ...interface{}) {
-	defaultLogger.Print(ctx, v...)
+	apiLogger.Print(ctx, v...)
 }
 
 // Printf prints `v` with format `format` using fmt.Sprintf.
 // The parameter `v` can be multiple variables.
 func Printf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Printf(ctx, format, v...)
+	apiLogger.Printf(ctx, format, v...)
+}
+
+// Check returns a CheckedEntry of the default logger if the entries at `level` are logged,
+// or nil otherwise, see Logger.Check.
+func Check(level Level) *CheckedEntry {
+	return defaultLogger.Check(level)
 }
 
 // Fatal prints the logging content with [FATA] header and newline, then exit the current process.
 func Fatal(ctx context.Context, v ...interface{}) {
-	defaultLogger.Fatal(ctx, v...)
+	apiLogger.Fatal(ctx, v...)
 }
 
 // Fatalf prints the logging content with [FATA] header, custom format and newline, then exit the current process.
 func Fatalf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Fatalf(ctx, format, v...)
+	apiLogger.Fatalf(ctx, format, v...)
+}
+
+// FatalWithCode prints the logging content with [FATA] header and newline, then exit the current process with status `code`.
+func FatalWithCode(ctx context.Context, code int, v ...any) {
+	apiLogger.FatalWithCode(ctx, code, v...)
 }
 
 // Panic prints the logging content with [PANI] header and newline, then panics.
 func Panic(ctx context.Context, v ...interface{}) {
-	defaultLogger.Panic(ctx, v...)
+	apiLogger.Panic(ctx, v...)
 }
 
 // Panicf prints the logging content with [PANI] header, custom format and newline, then panics.
 func Panicf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Panicf(ctx, format, v...)
+	apiLogger.Panicf(ctx, format, v...)
 }
 
 // Info prints the logging content with [INFO] header and newline.
 func Info(ctx context.Context, v ...interface{}) {
-	defaultLogger.Info(ctx, v...)
+	apiLogger.Info(ctx, v...)
 }
 
 // Infof prints the logging content with [INFO] header, custom format and newline.
 func Infof(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Infof(ctx, format, v...)
+	apiLogger.Infof(ctx, format, v...)
 }
 
 // Debug prints the logging content with [DEBU] header and newline.
 func Debug(ctx context.Context, v ...interface{}) {
-	defaultLogger.Debug(ctx, v...)
+	apiLogger.Debug(ctx, v...)
 }
 
 // Debugf prints the logging content with [DEBU] header, custom format and newline.
 func Debugf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Debugf(ctx, format, v...)
+	apiLogger.Debugf(ctx, format, v...)
 }
 
 // Warn prints the logging content with [WARN] header and newline.
 // It also prints caller stack info if stack feature is enabled.
 func Warn(ctx context.Context, v ...interface{}) {
-	defaultLogger.Warn(ctx, v...)
+	apiLogger.Warn(ctx, v...)
 }
 
 // Warnf prints the logging content with [WARN] header, custom format and newline.
 // It also prints caller stack info if stack feature is enabled.
 func Warnf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Warnf(ctx, format, v...)
+	apiLogger.Warnf(ctx, format, v...)
 }
 
 // Error prints the logging content with [ERRO] header and newline.
 // It also prints caller stack info if stack feature is enabled.
 func Error(ctx context.Context, v ...interface{}) {
-	defaultLogger.Error(ctx, v...)
+	apiLogger.Error(ctx, v...)
 }
 
 // Errorf prints the logging content with [ERRO] header, custom format and newline.
 // It also prints caller stack info if stack feature is enabled.
 func Errorf(ctx context.Context, format string, v ...interface{}) {
-	defaultLogger.Errorf(ctx, format, v...)
+	apiLogger.Errorf(ctx, format, v...)
+}
+
+// With returns a child logger of the default logger with `fields` attached.
+func With(fields ...Field) *Logger {
+	return defaultLogger.With(fields...)
+}
+
+// Debugw prints `msg` with alternating keys and values at level Debug.
+func Debugw(ctx context.Context, msg string, keysAndValues ...any) {
+	apiLogger.Debugw(ctx, msg, keysAndValues...)
+}
+
+// Infow prints `msg` with alternating keys and values at level Info.
+func Infow(ctx context.Context, msg string, keysAndValues ...any) {
+	apiLogger.Infow(ctx, msg, keysAndValues...)
+}
+
+// Warnw prints `msg` with alternating keys and values at level Warn.
+func Warnw(ctx context.Context, msg string, keysAndValues ...any) {
+	apiLogger.Warnw(ctx, msg, keysAndValues...)
+}
+
+// Errorw prints `msg` with alternating keys and values at level Error.
+func Errorw(ctx context.Context, msg string, keysAndValues ...any) {
+	apiLogger.Errorw(ctx, msg, keysAndValues...)
 }
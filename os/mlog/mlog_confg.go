@@ -1,10 +1,22 @@
 package mlog
 
+import "time"
+
 // SetConfig sets the logger configuration.
 func SetConfig(config Config) error {
 	return defaultLogger.SetConfig(config)
 }
 
+// SetLevel sets the logging level of the default logger.
+func SetLevel(level Level) {
+	defaultLogger.SetLevel(level)
+}
+
+// GetLevel returns the logging level of the default logger.
+func GetLevel() Level {
+	return defaultLogger.GetLevel()
+}
+
 // SetPath sets the log file path.
 func SetPath(path string) {
 	defaultLogger.SetPath(path)
@@ -15,6 +27,11 @@ func SetTimeFormat(timeFormat string) {
 	defaultLogger.SetTimeFormat(timeFormat)
 }
 
+// SetTimeZone sets the location of the log times, nil for the local time.
+func SetTimeZone(loc *time.Location) {
+	defaultLogger.SetTimeZone(loc)
+}
+
 // SetFormat sets the log format.
 func SetFormat(format string) {
 	defaultLogger.SetFormat(format)
@@ -36,6 +53,11 @@ func SetAutoClean(days int) {
 }
 
 // SetCtxKeys sets the context keys to extract values from.
-func SetCtxKeys(keys []string) {
-	defaultLogger.SetCtxKeys(keys)
+func SetCtxKeys(keys ...any) {
+	defaultLogger.SetCtxKeys(keys...)
+}
+
+// SetCaller enables or disables reporting the caller of the default logger.
+func SetCaller(enabled bool, skip int) {
+	defaultLogger.SetCaller(enabled, skip)
 }
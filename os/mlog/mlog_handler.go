@@ -0,0 +1,67 @@
+package mlog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// levelPayload is the body of the requests and responses of LevelHandler.
+type levelPayload struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an HTTP handler to get and change the level of a logger at runtime,
+// for example to enable debug logging during an incident without restarting:
+//
+//	GET /log/level?name=access
+//	PUT /log/level?name=access  {"level": "debug"}
+//
+// The query parameter "name" selects the logger instance, the default logger if empty.
+// The level of a PUT request may also be given by the query parameter "level".
+// The handler should only be exposed on internal endpoints.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+// serveLevel serves the requests of LevelHandler.
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	logger, ok := lookupInstance(name)
+	if !ok {
+		writeLevelError(w, http.StatusNotFound, "logger not found: "+name)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		payload := levelPayload{Level: r.URL.Query().Get("level")}
+		if payload.Level == "" {
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				writeLevelError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+				return
+			}
+		}
+		level, err := ParseLevel(payload.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.SetLevel(level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, "method not allowed: "+r.Method)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levelPayload{Name: name, Level: logger.GetLevel().String()})
+}
+
+// writeLevelError writes the error `message` with status `status`.
+func writeLevelError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
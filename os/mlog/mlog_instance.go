@@ -1,25 +1,221 @@
 package mlog
 
 import (
+	"context"
+	"reflect"
+	"sync"
+
 	"github.com/graingo/maltose/container/minstance"
+	"github.com/graingo/maltose/internal/intlog"
+	"github.com/graingo/maltose/os/mcfg"
 )
 
 const (
 	DefaultName = "default"
+
+	// configNodeName is the config node of the loggers, the config of a named logger
+	// is read from the node "logger.{name}", falling back to "logger".
+	configNodeName = "logger"
 )
 
 var (
 	instances = minstance.New()
+
+	// instanceConfigs holds the configs of the named loggers, guarded by instanceMu.
+	instanceConfigs = map[string]*instanceConfig{}
+	instanceMu      sync.Mutex
+
+	// watchConfigOnce registers the reload of the instances on the changes of the default config
+	watchConfigOnce sync.Once
 )
 
+// instanceConfig is the config of a named logger.
+type instanceConfig struct {
+	custom  map[string]any // config set by SetConfigWithMap, taking precedence over mcfg
+	applied map[string]any // config last applied to the logger
+	created bool           // whether the logger is created by Instance
+}
+
 // Instance returns the logger instance with the specified name.
+// On creation, the config node "logger.{name}" of the default mcfg config,
+// or "logger" if it does not exist, is applied to the logger, followed by
+// the config set by SetConfigWithMap.
+//
+// The dot-separated names form a hierarchy: Instance("app.db") creates Instance("app") first,
+// uses the config node "logger.app" if it has no node "logger.app.db", and inherits the level
+// of "app" unless it has a level of its own, set by its node, by SetConfigWithMap, by SetLevel
+// or by the map of the levels of the instances "logger.levels", like {"app.db": "debug"}.
+//
+// The changes of the node "logger" of the default config, like those of a config file
+// watched by mcfg.Config.Watch, are applied to the created instances, see ReloadConfig.
 func Instance(name ...string) *Logger {
 	key := DefaultName
 	if len(name) > 0 && name[0] != "" {
 		key = name[0]
 	}
 
-	return instances.GetOrSetFunc(key, func() any {
-		return New()
-	}).(*Logger)
+	// the parent is created first, outside of the creation lock of the instances
+	var parent *Logger
+	if parentKey := parentName(key); parentKey != "" {
+		parent = Instance(parentKey)
+	}
+	watchConfigOnce.Do(watchConfig)
+	return minstance.GetOrSet(instances, key, func() *Logger {
+		l := New()
+		l.node = &loggerNode{}
+		if parent != nil {
+			parent.addChild(l)
+		}
+		if err := applyInstanceConfig(context.Background(), key, l); err != nil {
+			intlog.Errorf(context.Background(), "failed to configure logger %s: %v", key, err)
+		}
+		return l
+	})
+}
+
+// SetConfigWithMap sets the config of the logger instance `name`, applied over its mcfg config.
+// It is applied immediately if the instance exists, otherwise when Instance creates it.
+func SetConfigWithMap(name string, m map[string]any) error {
+	if name == "" {
+		name = DefaultName
+	}
+	instanceMu.Lock()
+	ic := getInstanceConfig(name)
+	ic.custom = m
+	created := ic.created
+	instanceMu.Unlock()
+
+	if !created {
+		return nil
+	}
+	return applyInstanceConfig(context.Background(), name, Instance(name))
+}
+
+// ReloadConfig re-reads the config of the created logger instances from mcfg, and applies it
+// to the instances whose config changed. It is called on the changes of the node "logger" of
+// the default config, see Instance, and is only needed for the changes which are not notified,
+// like a new adapter set by mcfg.Config.SetAdapter.
+func ReloadConfig(ctx context.Context) error {
+	for _, name := range instanceNames() {
+		if err := applyInstanceConfig(ctx, name, Instance(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchConfig reloads the config of the instances on the changes of the node "logger" of the default config.
+func watchConfig() {
+	mcfg.Instance().OnKeyChange(configNodeName, func(_ []mcfg.Change) {
+		ctx := context.Background()
+		if err := ReloadConfig(ctx); err != nil {
+			intlog.Errorf(ctx, "failed to reload the config of the loggers: %v", err)
+		}
+	})
+}
+
+// applyInstanceConfig applies the config of the instance `name` to `l`, if it changed.
+func applyInstanceConfig(ctx context.Context, name string, l *Logger) error {
+	config := loadInstanceConfig(ctx, name)
+
+	instanceMu.Lock()
+	defer instanceMu.Unlock()
+	ic := getInstanceConfig(name)
+	ic.created = true
+	for k, v := range ic.custom {
+		config[k] = v
+	}
+	if len(config) == 0 || reflect.DeepEqual(config, ic.applied) {
+		return nil
+	}
+	if err := l.SetConfigWithMap(config); err != nil {
+		return err
+	}
+	ic.applied = config
+	return nil
+}
+
+// loadInstanceConfig reads the config of the instance `name` from mcfg: the node "logger.{name}",
+// or the node of its nearest parent in the hierarchy, or "logger". The level of the node of a
+// parent is left out, as it is inherited from the parent logger, and the level of `name` in
+// "logger.levels" takes precedence over the one of the node.
+func loadInstanceConfig(ctx context.Context, name string) map[string]any {
+	var (
+		cfg    = mcfg.Instance()
+		config = make(map[string]any)
+		found  bool
+	)
+	for node := name; node != "" && !found; node = parentName(node) {
+		if v, err := cfg.Get(ctx, configNodeName+"."+node); err == nil && !v.IsEmpty() {
+			for k, v := range v.Map() {
+				config[k] = v
+			}
+			if node != name {
+				delete(config, "level")
+			}
+			found = true
+		}
+	}
+	if !found {
+		if v, err := cfg.Get(ctx, configNodeName); err == nil && !v.IsEmpty() {
+			for k, v := range v.Map() {
+				config[k] = v
+			}
+			if parentName(name) != "" {
+				delete(config, "level")
+			}
+		}
+	}
+	delete(config, levelsConfigKey)
+
+	if v, err := cfg.Get(ctx, configNodeName+"."+levelsConfigKey); err == nil && !v.IsEmpty() {
+		if level, ok := flattenLevels("", v.Map(), nil)[name]; ok {
+			config["level"] = level
+		}
+	}
+	return config
+}
+
+// getInstanceConfig returns the config of the instance `name`, it must be called with instanceMu held.
+func getInstanceConfig(name string) *instanceConfig {
+	ic, ok := instanceConfigs[name]
+	if !ok {
+		ic = &instanceConfig{}
+		instanceConfigs[name] = ic
+	}
+	return ic
+}
+
+// SetLevelAll sets the logging level of the default logger and all the logger instances.
+// The instances below others in the hierarchy, like "app.db", lose their own level and
+// inherit the level again, see Instance.
+func SetLevelAll(level Level) {
+	defaultLogger.SetLevel(level)
+	// the children are reset first, so that they inherit the level set to the roots
+	instances.Range(func(name string, instance any) bool {
+		if parentName(name) != "" {
+			instance.(*Logger).resetLevel()
+		}
+		return true
+	})
+	instances.Range(func(name string, instance any) bool {
+		if parentName(name) == "" {
+			instance.(*Logger).SetLevel(level)
+		}
+		return true
+	})
+}
+
+// instanceNames returns the names of the created logger instances, sorted so that the parents
+// in the hierarchy come before their children.
+func instanceNames() []string {
+	return instances.Keys()
+}
+
+// lookupInstance returns the created logger instance `name`, or the default logger for an empty name.
+func lookupInstance(name string) (*Logger, bool) {
+	if name == "" {
+		return defaultLogger, true
+	}
+	return minstance.Get[*Logger](instances, name)
 }
@@ -0,0 +1,75 @@
+package mlog
+
+import (
+	"strings"
+	"sync"
+)
+
+// levelsConfigKey is the key of the levels of the instances in the config node "logger",
+// like {"app.db": "debug"}.
+const levelsConfigKey = "levels"
+
+// hierarchyMu guards the nodes of the named loggers.
+var hierarchyMu sync.Mutex
+
+// loggerNode is the position of a named logger in the hierarchy of the dot-separated names,
+// like "app.db" below "app".
+type loggerNode struct {
+	children []*Logger // loggers directly below, like "app.db" for "app"
+	levelSet bool      // whether the level is set explicitly, instead of inherited from the parent
+}
+
+// parentName returns the name of the parent of the instance `name`, like "app" for "app.db",
+// or an empty string for the top-level names.
+func parentName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// addChild adds the logger `child` below `l` in the hierarchy, inheriting the level of `l`.
+func (l *Logger) addChild(child *Logger) {
+	hierarchyMu.Lock()
+	defer hierarchyMu.Unlock()
+	l.node.children = append(l.node.children, child)
+	child.level.Store(l.level.Load())
+}
+
+// resetLevel makes the named logger `l` inherit the level of its parent again, on the next change
+// of the level of its parent.
+func (l *Logger) resetLevel() {
+	if l.node == nil {
+		return
+	}
+	hierarchyMu.Lock()
+	defer hierarchyMu.Unlock()
+	l.node.levelSet = false
+}
+
+// inheritLevel sets `level` to the loggers below `n` which have no level of their own, it must be
+// called with hierarchyMu held.
+func (n *loggerNode) inheritLevel(level Level) {
+	for _, child := range n.children {
+		if !child.node.levelSet {
+			child.level.Store(int32(level))
+			child.node.inheritLevel(level)
+		}
+	}
+}
+
+// flattenLevels adds the levels of `m` to `levels` by dot-separated name, as the config sources
+// may split the names like "app.db" into nested maps.
+func flattenLevels(prefix string, m map[string]any, levels map[string]any) map[string]any {
+	if levels == nil {
+		levels = make(map[string]any, len(m))
+	}
+	for k, v := range m {
+		if nested, ok := v.(map[string]any); ok {
+			flattenLevels(prefix+k+".", nested, levels)
+			continue
+		}
+		levels[prefix+k] = v
+	}
+	return levels
+}
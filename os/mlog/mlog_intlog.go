@@ -0,0 +1,56 @@
+package mlog
+
+import (
+	"context"
+
+	"github.com/graingo/maltose/internal/intlog"
+)
+
+const (
+	// InternalInstanceName is the logger instance writing the framework internal logs, see CaptureInternal.
+	InternalInstanceName = "maltose.internal"
+	// FieldComponent is the field of the framework package writing an internal log, like "mclient".
+	FieldComponent = "component"
+)
+
+// internalCallerSkip is the number of stack frames between the internal logging call and the
+// logger: the logging function of intlog, its print function and the handler.
+const internalCallerSkip = 3
+
+// CaptureInternal sets whether the framework internal logs, like the retries of mclient, are
+// written by the logger instance InternalInstanceName, with the trace ids of their context and
+// the package writing them as the field "component", instead of being printed to stdout.
+// They are written at debug level, and the errors at error level, so the instance must log
+// the debug level to write all of them, like with the config "logger.levels":
+//
+//	logger:
+//	  levels:
+//	    maltose.internal: debug
+//
+// The internal logs of mlog are still printed, as they report the failures of the loggers.
+func CaptureInternal(enabled bool) {
+	if !enabled {
+		intlog.SetHandler(nil)
+		return
+	}
+	intlog.SetHandler(internalHandler(Instance(InternalInstanceName).AddCallerSkip(internalCallerSkip)))
+}
+
+// internalHandler returns the intlog handler writing the internal logs to `logger`.
+func internalHandler(logger *Logger) intlog.Handler {
+	return func(ctx context.Context, component string, content string, isError bool) bool {
+		// the failing logger may be the one writing the internal logs
+		if component == "mlog" {
+			return false
+		}
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if isError {
+			logger.Errorw(ctx, content, String(FieldComponent, component))
+		} else {
+			logger.Debugw(ctx, content, String(FieldComponent, component))
+		}
+		return true
+	}
+}
@@ -2,22 +2,63 @@ package mlog
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Logger is the struct for logging management.
+//
+// A Logger is safe for concurrent use, including its configuration methods. The child loggers
+// created by With and AddCallerSkip share the configuration, level, outputs and hooks of their
+// logger, so configuring a child configures its logger; only their fields and caller skip are
+// their own. Use Clone for a logger diverging from the configuration of another.
 type Logger struct {
-	parent *logrus.Logger
-	config Config
+	parent   *logrus.Logger
+	config   *loggerConfig                  // config shared with the child loggers
+	level    *atomic.Int32                  // level shared with the child loggers
+	fields   []Field                        // fields attached by With
+	out      *loggerOutput                  // outputs shared with the child loggers
+	dispatch *dispatchFormatter             // formatter dispatching entries to the added writers
+	ctxHook  *ctxHook                       // hook extracting the context keys
+	callers  *atomic.Pointer[callerOptions] // caller reporting options shared with the child loggers
+	sampler  *atomic.Pointer[sampler]       // sampler shared with the child loggers, nil if disabled
+	dedup    *atomic.Pointer[deduper]       // deduper shared with the child loggers, nil if disabled
+	// configFields are the fields of the config shared with the child loggers, see SetDefaultFields
+	configFields *atomic.Pointer[[]Field]
+	// exitFunc is the exit function of Fatal shared with the child loggers, nil for os.Exit
+	exitFunc *atomic.Pointer[func(int)]
+	// stackDepth is the depth of the error stacks shared with the child loggers, 0 if disabled
+	stackDepth *atomic.Int32
+	// hooks are the hooks shared with the child loggers
+	hooks *loggerHooks
+	// failures reports the failed writes of the outputs shared with the child loggers
+	failures *writeFailures
+	// node is the position of a named logger in the hierarchy shared with the child loggers, nil if not an instance
+	node *loggerNode
+	// callerSkip is the number of stack frames skipped by AddCallerSkip
+	callerSkip int
+}
+
+// loggerOutput holds the closable outputs of a logger.
+type loggerOutput struct {
+	mu    sync.Mutex
+	file  *fileWriter  // file output, nil if disabled
+	async *asyncWriter // async writer wrapping the outputs, nil if disabled
+	// fallbacks wrap the outputs, reporting and retrying their failed writes
+	fallbacks []*fallbackWriter
+	closed    atomic.Bool // whether the logger is closed, see Logger.Close
 }
 
 const (
-	defaultPath       = "/logs"
+	defaultPath       = "" // file output is disabled by default
 	defaultFile       = "{Y}-{m}-{d}.log"
 	defaultTimeFormat = time.DateTime
-	defaultFormat     = "text"
+	defaultFormat     = "" // the console format in development, the text format otherwise
 	defaultLevel      = InfoLevel
 )
 
@@ -25,88 +66,349 @@ const (
 func New() *Logger {
 	config := DefaultConfig()
 	l := &Logger{
-		parent: logrus.New(),
-		config: config,
+		parent:       logrus.New(),
+		config:       &loggerConfig{Config: config},
+		hooks:        &loggerHooks{},
+		level:        &atomic.Int32{},
+		out:          &loggerOutput{},
+		ctxHook:      &ctxHook{},
+		callers:      &atomic.Pointer[callerOptions]{},
+		sampler:      &atomic.Pointer[sampler]{},
+		dedup:        &atomic.Pointer[deduper]{},
+		stackDepth:   &atomic.Int32{},
+		configFields: &atomic.Pointer[[]Field]{},
+		exitFunc:     &atomic.Pointer[func(int)]{},
+		failures:     newWriteFailures(),
+		dispatch:     newDispatchFormatter(newFormatter(FormatText, timeOptions{format: config.TimeFormat})),
+	}
+	l.dispatch.failures = l.failures
+	l.parent.SetFormatter(l.dispatch)
+	// the level is checked by the logger before creating the entries
+	l.parent.SetLevel(logrus.TraceLevel)
+	// write the queued entries to disk before Fatal exits the process
+	l.parent.ExitFunc = func(code int) {
+		_ = l.Sync()
+		if exit := l.exitFunc.Load(); exit != nil {
+			(*exit)(code)
+			return
+		}
+		os.Exit(code)
 	}
 	l.SetConfig(config)
 
 	// Add default hooks
 	l.AddHook(&traceHook{})
-
-	// Add context hook if there are keys configured
-	if len(config.CtxKeys) > 0 {
-		l.AddHook(&ctxHook{keys: config.CtxKeys})
-	}
+	l.AddHook(l.ctxHook)
 
 	return l
 }
 
 // Print prints `v` with newline using fmt.Sprintln.
+// The Field arguments are attached to the entry, see Info.
 func (l *Logger) Print(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Print(v...)
+	if !l.IsLevelEnabled(InfoLevel) || l.dropSampledArgs(InfoLevel, v) {
+		return
+	}
+	args, fields := splitFields(v)
+	entry := l.entryWith(ctx, InfoLevel, fields)
+	if l.dropDuplicate(entry, InfoLevel, args) {
+		return
+	}
+	entry.Print(args...)
 }
 
 // Printf prints `v` with format `format` using fmt.Sprintf.
 func (l *Logger) Printf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Printf(format, v...)
+	if !l.IsLevelEnabled(InfoLevel) || l.dropSampled(InfoLevel, format) {
+		return
+	}
+	entry := l.entry(ctx, InfoLevel)
+	if l.dropDuplicatef(entry, InfoLevel, format, v) {
+		return
+	}
+	entry.Printf(format, v...)
 }
 
 // Debug prints the logging content with [DEBUG] header and newline.
+// The Field arguments are attached to the entry, see Info.
 func (l *Logger) Debug(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Debug(v...)
+	if !l.IsLevelEnabled(DebugLevel) || l.dropSampledArgs(DebugLevel, v) {
+		return
+	}
+	args, fields := splitFields(v)
+	entry := l.entryWith(ctx, DebugLevel, fields)
+	if l.dropDuplicate(entry, DebugLevel, args) {
+		return
+	}
+	entry.Debug(args...)
 }
 
 // Debugf prints the logging content with [DEBUG] header and format `format`.
 func (l *Logger) Debugf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Debugf(format, v...)
+	if !l.IsLevelEnabled(DebugLevel) || l.dropSampled(DebugLevel, format) {
+		return
+	}
+	entry := l.entry(ctx, DebugLevel)
+	if l.dropDuplicatef(entry, DebugLevel, format, v) {
+		return
+	}
+	entry.Debugf(format, v...)
 }
 
 // Info prints the logging content with [INFO] header and newline.
+// The Field arguments are attached to the entry instead of being part of the message,
+// so that the message and fields styles can be mixed:
+//
+//	logger.Info(ctx, "request done", mlog.Int("status", 200), mlog.String("path", path))
 func (l *Logger) Info(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Info(v...)
+	if !l.IsLevelEnabled(InfoLevel) || l.dropSampledArgs(InfoLevel, v) {
+		return
+	}
+	args, fields := splitFields(v)
+	entry := l.entryWith(ctx, InfoLevel, fields)
+	if l.dropDuplicate(entry, InfoLevel, args) {
+		return
+	}
+	entry.Info(args...)
 }
 
 // Infof prints the logging content with [INFO] header and format `format`.
 func (l *Logger) Infof(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Infof(format, v...)
+	if !l.IsLevelEnabled(InfoLevel) || l.dropSampled(InfoLevel, format) {
+		return
+	}
+	entry := l.entry(ctx, InfoLevel)
+	if l.dropDuplicatef(entry, InfoLevel, format, v) {
+		return
+	}
+	entry.Infof(format, v...)
 }
 
 // Warn prints the logging content with [WARN] header and newline.
+// The Field arguments are attached to the entry, see Info.
 func (l *Logger) Warn(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Warn(v...)
+	if !l.IsLevelEnabled(WarnLevel) || l.dropSampledArgs(WarnLevel, v) {
+		return
+	}
+	args, fields := splitFields(v)
+	entry := l.entryWith(ctx, WarnLevel, fields)
+	if l.dropDuplicate(entry, WarnLevel, args) {
+		return
+	}
+	entry.Warn(args...)
 }
 
 // Warnf prints the logging content with [WARN] header and format `format`.
 func (l *Logger) Warnf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Warnf(format, v...)
+	if !l.IsLevelEnabled(WarnLevel) || l.dropSampled(WarnLevel, format) {
+		return
+	}
+	entry := l.entry(ctx, WarnLevel)
+	if l.dropDuplicatef(entry, WarnLevel, format, v) {
+		return
+	}
+	entry.Warnf(format, v...)
 }
 
 // Error prints the logging content with [ERROR] header and newline.
+// The Field arguments are attached to the entry, see Info.
 func (l *Logger) Error(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Error(v...)
+	if !l.IsLevelEnabled(ErrorLevel) || l.dropSampledArgs(ErrorLevel, v) {
+		return
+	}
+	args, fields := splitFields(v)
+	entry := l.entryWith(ctx, ErrorLevel, fields)
+	if l.dropDuplicate(entry, ErrorLevel, args) {
+		return
+	}
+	entry.Error(args...)
 }
 
 // Errorf prints the logging content with [ERROR] header and format `format`.
 func (l *Logger) Errorf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Errorf(format, v...)
+	if !l.IsLevelEnabled(ErrorLevel) || l.dropSampled(ErrorLevel, format) {
+		return
+	}
+	entry := l.entry(ctx, ErrorLevel)
+	if l.dropDuplicatef(entry, ErrorLevel, format, v) {
+		return
+	}
+	entry.Errorf(format, v...)
 }
 
-// Fatal prints the logging content with [FATAL] header and newline.
+// Fatal logs `v` at level Fatal like Info, then exits the process with status 1 through
+// the exit function, see SetExitFunc. It exits even if the level is disabled.
+//
+// Fatal is meant for the initialization in the main path of a program, like a missing
+// config: library code should return errors instead, as it cannot know whether the
+// program is able to recover and Fatal skips the deferred functions.
 func (l *Logger) Fatal(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Fatal(v...)
+	if l.IsLevelEnabled(FatalLevel) {
+		args, fields := splitFields(v)
+		l.entryWith(ctx, FatalLevel, fields).Log(logrus.FatalLevel, args...)
+	}
+	l.exit(1)
 }
 
-// Fatalf prints the logging content with [FATAL] header and format `format`.
+// Fatalf logs at level Fatal with format `format` like Infof, then exits the process, see Fatal.
 func (l *Logger) Fatalf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Fatalf(format, v...)
+	if l.IsLevelEnabled(FatalLevel) {
+		l.entry(ctx, FatalLevel).Logf(logrus.FatalLevel, format, v...)
+	}
+	l.exit(1)
+}
+
+// FatalWithCode logs `v` at level Fatal, then exits the process with status `code`, see Fatal.
+func (l *Logger) FatalWithCode(ctx context.Context, code int, v ...any) {
+	if l.IsLevelEnabled(FatalLevel) {
+		args, fields := splitFields(v)
+		l.entryWith(ctx, FatalLevel, fields).Log(logrus.FatalLevel, args...)
+	}
+	l.exit(code)
 }
 
-// Panic prints the logging content with [PANIC] header and newline.
+// Panic logs `v` at level Panic like Info, then panics with the message as a string.
+// It panics even if the level is disabled.
 func (l *Logger) Panic(ctx context.Context, v ...any) {
-	l.parent.WithContext(ctx).Panic(v...)
+	args, fields := splitFields(v)
+	msg := fmt.Sprint(args...)
+	if l.IsLevelEnabled(PanicLevel) {
+		writePanic(l.entryWith(ctx, PanicLevel, fields), msg)
+	}
+	panic(msg)
 }
 
-// Panicf prints the logging content with [PANIC] header and format `format`.
+// Panicf logs at level Panic with format `format` like Infof, then panics with the message, see Panic.
 func (l *Logger) Panicf(ctx context.Context, format string, v ...any) {
-	l.parent.WithContext(ctx).Panicf(format, v...)
+	msg := fmt.Sprintf(format, v...)
+	if l.IsLevelEnabled(PanicLevel) {
+		writePanic(l.entry(ctx, PanicLevel), msg)
+	}
+	panic(msg)
+}
+
+// Debugw logs `msg` at level Debug with alternating keys and values, or fields, like
+// Debugw(ctx, "request done", "path", path, mlog.Int("status", 200)).
+// Nothing is converted nor allocated by Debugw if the level is disabled, but the arguments are
+// boxed into interfaces by the caller, use Check for the hot paths.
+func (l *Logger) Debugw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !l.IsLevelEnabled(DebugLevel) || l.dropSampled(DebugLevel, msg) {
+		return
+	}
+	entry := l.entryWith(ctx, DebugLevel, keysAndValuesToFields(keysAndValues))
+	if l.dropDuplicateMsg(entry, DebugLevel, msg) {
+		return
+	}
+	entry.Debug(msg)
+}
+
+// Infow logs `msg` at level Info with alternating keys and values, see Debugw.
+func (l *Logger) Infow(ctx context.Context, msg string, keysAndValues ...any) {
+	if !l.IsLevelEnabled(InfoLevel) || l.dropSampled(InfoLevel, msg) {
+		return
+	}
+	entry := l.entryWith(ctx, InfoLevel, keysAndValuesToFields(keysAndValues))
+	if l.dropDuplicateMsg(entry, InfoLevel, msg) {
+		return
+	}
+	entry.Info(msg)
+}
+
+// Warnw logs `msg` at level Warn with alternating keys and values, see Debugw.
+func (l *Logger) Warnw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !l.IsLevelEnabled(WarnLevel) || l.dropSampled(WarnLevel, msg) {
+		return
+	}
+	entry := l.entryWith(ctx, WarnLevel, keysAndValuesToFields(keysAndValues))
+	if l.dropDuplicateMsg(entry, WarnLevel, msg) {
+		return
+	}
+	entry.Warn(msg)
+}
+
+// Errorw logs `msg` at level Error with alternating keys and values, see Debugw.
+func (l *Logger) Errorw(ctx context.Context, msg string, keysAndValues ...any) {
+	if !l.IsLevelEnabled(ErrorLevel) || l.dropSampled(ErrorLevel, msg) {
+		return
+	}
+	entry := l.entryWith(ctx, ErrorLevel, keysAndValuesToFields(keysAndValues))
+	if l.dropDuplicateMsg(entry, ErrorLevel, msg) {
+		return
+	}
+	entry.Error(msg)
+}
+
+// Fatalw logs `msg` at level Fatal with alternating keys and values, see Debugw,
+// then exits the process, see Fatal.
+func (l *Logger) Fatalw(ctx context.Context, msg string, keysAndValues ...any) {
+	if l.IsLevelEnabled(FatalLevel) {
+		l.entryWith(ctx, FatalLevel, keysAndValuesToFields(keysAndValues)).Log(logrus.FatalLevel, msg)
+	}
+	l.exit(1)
+}
+
+// Panicw logs `msg` at level Panic with alternating keys and values, see Debugw,
+// then panics with `msg`, see Panic.
+func (l *Logger) Panicw(ctx context.Context, msg string, keysAndValues ...any) {
+	if l.IsLevelEnabled(PanicLevel) {
+		writePanic(l.entryWith(ctx, PanicLevel, keysAndValuesToFields(keysAndValues)), msg)
+	}
+	panic(msg)
+}
+
+// SetExitFunc sets the function called by the Fatal methods to exit the process, os.Exit by
+// default, or if `fn` is nil. The entries are flushed and synced to disk before it is called.
+// Tests can use it to assert that Fatal is called without exiting, in which case Fatal returns:
+//
+//	var code int
+//	logger.SetExitFunc(func(c int) { code = c })
+func (l *Logger) SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		l.exitFunc.Store(nil)
+		return
+	}
+	l.exitFunc.Store(&fn)
+}
+
+// exit exits the process with status `code` through the exit handlers of logrus and the exit function.
+func (l *Logger) exit(code int) {
+	l.parent.Exit(code)
+}
+
+// writePanic writes `entry` at level Panic with message `msg`, without the panic of logrus.
+func writePanic(entry *logrus.Entry, msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*logrus.Entry); !ok {
+				panic(r)
+			}
+		}
+	}()
+	entry.Log(logrus.PanicLevel, msg)
+}
+
+// entry creates a logrus entry at `level` with the context, the fields of the logger,
+// the caller and the error stack.
+func (l *Logger) entry(ctx context.Context, level Level) *logrus.Entry {
+	data := l.fieldsData(0)
+	if len(l.fields) > 0 {
+		data = l.errorStack(data, level, l.fields, nil)
+	}
+	data = l.caller(data)
+	entry := l.parent.WithContext(ctx)
+	if len(data) == 0 {
+		return entry
+	}
+	return entry.WithFields(data)
+}
+
+// entryWith creates a logrus entry at `level` with the context, the fields of the logger,
+// the caller, the error stack and `fields`, which take precedence.
+func (l *Logger) entryWith(ctx context.Context, level Level, fields []Field) *logrus.Entry {
+	data := l.fieldsData(len(fields))
+	for _, field := range fields {
+		data[field.Key] = field.Value()
+	}
+	data = l.errorStack(data, level, l.fields, fields)
+	return l.parent.WithContext(ctx).WithFields(l.caller(data))
 }
@@ -0,0 +1,144 @@
+package mlog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// AsyncPolicyBlock makes logging calls wait for free buffer space, no entry is lost.
+	AsyncPolicyBlock = "block"
+	// AsyncPolicyDrop makes logging calls drop the entry if the buffer is full,
+	// the number of dropped entries is reported by Logger.Dropped.
+	AsyncPolicyDrop = "drop"
+
+	// maxAsyncBatchSize is the size at which a batch of entries is written.
+	maxAsyncBatchSize = 256 * 1024
+)
+
+// asyncWriter queues the written entries and writes them to the underlying writer
+// in batches from a background goroutine. Entries are written in the order they are queued,
+// so the ordering within a goroutine is preserved.
+type asyncWriter struct {
+	writer   io.Writer
+	queue    chan []byte
+	drop     bool
+	mu       sync.RWMutex // guards closed against in-flight writes
+	closed   bool
+	flushReq chan chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	dropped  atomic.Int64
+}
+
+// newAsyncWriter creates an asyncWriter with a queue of `bufferSize` entries writing to `writer`.
+func newAsyncWriter(writer io.Writer, bufferSize int, policy string) *asyncWriter {
+	w := &asyncWriter{
+		writer:   writer,
+		queue:    make(chan []byte, bufferSize),
+		drop:     policy == AsyncPolicyDrop,
+		flushReq: make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write implements the io.Writer interface, it queues a copy of `p`.
+// After the writer is closed, `p` is written synchronously.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return w.writer.Write(p)
+	}
+	entry := make([]byte, len(p))
+	copy(entry, p)
+	if w.drop {
+		select {
+		case w.queue <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+	w.queue <- entry
+	return len(p), nil
+}
+
+// Flush writes all queued entries synchronously.
+func (w *asyncWriter) Flush() {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return
+	}
+	done := make(chan struct{})
+	w.flushReq <- done
+	<-done
+}
+
+// Close writes all queued entries and stops the background goroutine.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (w *asyncWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// run writes the queued entries in batches until the writer is closed.
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	batch := make([]byte, 0, 4096)
+	for {
+		select {
+		case entry := <-w.queue:
+			batch = w.drain(append(batch[:0], entry...))
+		case done := <-w.flushReq:
+			batch = w.drain(batch[:0])
+			close(done)
+		case <-w.stop:
+			w.drain(batch[:0])
+			return
+		}
+	}
+}
+
+// drain writes `batch` and all the currently queued entries, returning the batch buffer for reuse.
+func (w *asyncWriter) drain(batch []byte) []byte {
+	for {
+		select {
+		case entry := <-w.queue:
+			batch = append(batch, entry...)
+			if len(batch) < maxAsyncBatchSize {
+				continue
+			}
+		default:
+		}
+		if len(batch) == 0 {
+			return batch
+		}
+		_, _ = w.writer.Write(batch)
+		if len(w.queue) == 0 {
+			return batch[:0]
+		}
+		batch = batch[:0]
+	}
+}
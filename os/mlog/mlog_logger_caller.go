@@ -0,0 +1,117 @@
+package mlog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FieldCaller is the field of the caller file and line, like "mhttp/mhttp_server.go:42".
+	FieldCaller = "caller"
+	// FieldFunc is the field of the caller function, like "github.com/graingo/maltose/net/mhttp.(*Server).Run".
+	FieldFunc = "func"
+
+	// callerBaseSkip skips runtime.Callers, Logger.caller, Logger.entry and the logging method.
+	callerBaseSkip = 4
+)
+
+// callerOptions are the caller reporting options shared by a logger and its children.
+type callerOptions struct {
+	enabled  bool
+	skip     int
+	function bool
+	fullPath bool
+}
+
+// SetCaller enables or disables reporting the file and line of the logging call as the
+// field "caller". The `skip` is the number of additional stack frames to skip,
+// for loggers called through wrapper functions, see also AddCallerSkip.
+// The caller is only looked up for the entries whose level is enabled.
+func (l *Logger) SetCaller(enabled bool, skip int) {
+	l.SetConfigWithMap(map[string]any{
+		"caller":      enabled,
+		"caller_skip": skip,
+	})
+}
+
+// SetCallerFunc sets whether to report the function of the logging call as the field "func".
+func (l *Logger) SetCallerFunc(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"caller_func": enabled,
+	})
+}
+
+// SetCallerFullPath sets whether to report the full file path of the logging call,
+// by default it is trimmed to the package directory and file name.
+func (l *Logger) SetCallerFullPath(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"caller_full_path": enabled,
+	})
+}
+
+// AddCallerSkip returns a child logger skipping `n` additional stack frames
+// when reporting the caller, for use in logging wrapper functions:
+//
+//	var logger = mlog.Instance().AddCallerSkip(1)
+//
+//	func logRequest(ctx context.Context, path string) {
+//		logger.Infow(ctx, "request", "path", path) // reports the caller of logRequest
+//	}
+func (l *Logger) AddCallerSkip(n int) *Logger {
+	child := *l
+	child.callerSkip += n
+	return &child
+}
+
+// caller appends the caller fields of the logging call to `data`, if enabled.
+// It must be called by Logger.entry or Logger.entryWith directly.
+func (l *Logger) caller(data map[string]any) map[string]any {
+	options := l.callers.Load()
+	if options == nil || !options.enabled {
+		return data
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(callerBaseSkip+options.skip+l.callerSkip, pcs[:]) == 0 {
+		return data
+	}
+	return addCallerFields(data, pcs[0], options)
+}
+
+// callerPC appends the caller fields of the program counter `pc` to `data`, if enabled.
+func (l *Logger) callerPC(data map[string]any, pc uintptr) map[string]any {
+	options := l.callers.Load()
+	if options == nil || !options.enabled || pc == 0 {
+		return data
+	}
+	return addCallerFields(data, pc, options)
+}
+
+// addCallerFields appends the caller fields of the program counter `pc` to `data`.
+func addCallerFields(data map[string]any, pc uintptr, options *callerOptions) map[string]any {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if data == nil {
+		data = make(map[string]any, 2)
+	}
+	file := frame.File
+	if !options.fullPath {
+		file = trimCallerPath(file)
+	}
+	data[FieldCaller] = file + ":" + strconv.Itoa(frame.Line)
+	if options.function {
+		data[FieldFunc] = frame.Function
+	}
+	return data
+}
+
+// trimCallerPath trims the file path `file` to the package directory and file name.
+func trimCallerPath(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i == -1 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j != -1 {
+		return file[j+1:]
+	}
+	return file
+}
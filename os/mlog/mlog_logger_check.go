@@ -0,0 +1,56 @@
+package mlog
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CheckedEntry is a logging call at a level checked as enabled, see Logger.Check.
+type CheckedEntry struct {
+	logger *Logger
+	level  Level
+}
+
+// Check returns a CheckedEntry if the entries at `level` are logged, or nil otherwise, so that the
+// arguments of the entry are only evaluated if it is logged:
+//
+//	if ce := logger.Check(mlog.DebugLevel); ce != nil {
+//		ce.Write(ctx, "cache miss", mlog.String("key", key), mlog.Any("stats", cache.Stats()))
+//	}
+//
+// The disabled levels do not allocate, whereas the methods like Debugw box their arguments into
+// interfaces before checking the level. Check never returns nil for FatalLevel and PanicLevel,
+// as Write exits or panics even if they are disabled, like Fatal and Panic.
+func (l *Logger) Check(level Level) *CheckedEntry {
+	if level < FatalLevel && !l.IsLevelEnabled(level) {
+		return nil
+	}
+	return &CheckedEntry{logger: l, level: level}
+}
+
+// Write logs `msg` with `fields` at the checked level, like Infow with fields.
+func (ce *CheckedEntry) Write(ctx context.Context, msg string, fields ...Field) {
+	l := ce.logger
+	switch ce.level {
+	case FatalLevel:
+		if l.IsLevelEnabled(FatalLevel) {
+			l.entryWith(ctx, FatalLevel, fields).Log(logrus.FatalLevel, msg)
+		}
+		l.exit(1)
+	case PanicLevel:
+		if l.IsLevelEnabled(PanicLevel) {
+			writePanic(l.entryWith(ctx, PanicLevel, fields), msg)
+		}
+		panic(msg)
+	default:
+		if !l.IsLevelEnabled(ce.level) || l.dropSampled(ce.level, msg) {
+			return
+		}
+		entry := l.entryWith(ctx, ce.level, fields)
+		if l.dropDuplicateMsg(entry, ce.level, msg) {
+			return
+		}
+		entry.Log(ce.level.toLogrusLevel(), msg)
+	}
+}
@@ -0,0 +1,51 @@
+package mlog
+
+import "maps"
+
+// Clone returns a logger independent of the logger, created like New with its configuration,
+// level, hooks, fields, caller skip and exit function. Unlike the child loggers of With, the
+// configuration of the clone does not affect the logger, and conversely:
+//
+//	audit := logger.Clone()
+//	audit.SetLevel(mlog.DebugLevel) // the level of logger is unchanged
+//
+// The clone opens its own outputs from the configuration, so it is closed separately, and it
+// should not write to the same rotated files as the logger. The writers added by AddWriter
+// are not copied, as the writers are not safe for concurrent writes by two loggers.
+func (l *Logger) Clone() *Logger {
+	l.config.mu.Lock()
+	config := l.config.Config
+	location := l.config.timeLocation
+	l.config.mu.Unlock()
+	config.CtxKeys = append([]string(nil), config.CtxKeys...)
+	config.SamplingLevels = append([]Level(nil), config.SamplingLevels...)
+	config.DedupLevels = append([]Level(nil), config.DedupLevels...)
+	config.Fields = maps.Clone(config.Fields)
+	// the location is set as is, it may not have an IANA name like the ones of time.FixedZone
+	config.TimeZone = ""
+
+	c := New()
+	hooks := l.hooks.list()
+	for i, hook := range hooks {
+		if hook == Hook(l.ctxHook) {
+			hooks[i] = c.ctxHook
+		}
+		c.failures.addHook(hook)
+	}
+	c.hooks.mu.Lock()
+	c.hooks.set(c.parent, hooks)
+	c.hooks.mu.Unlock()
+
+	// the configuration of the logger is valid
+	_ = c.SetConfig(config)
+	if location != nil {
+		c.SetTimeZone(location)
+	}
+	c.level.Store(l.level.Load())
+	c.fields = append([]Field(nil), l.fields...)
+	c.callerSkip = l.callerSkip
+	c.exitFunc.Store(l.exitFunc.Load())
+	c.failures.errorOutput.Store(l.failures.errorOutput.Load())
+	c.dispatch.errorHandler.Store(l.dispatch.errorHandler.Load())
+	return c
+}
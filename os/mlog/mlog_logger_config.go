@@ -3,141 +3,552 @@ package mlog
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/graingo/maltose/errors/mcode"
 	"github.com/graingo/maltose/errors/merror"
 	"github.com/graingo/mconv"
-	"github.com/sirupsen/logrus"
 )
 
 type Config struct {
-	Level      Level    `json:"level"`
-	Path       string   `json:"path"`
-	File       string   `json:"file"`
-	TimeFormat string   `json:"time_format"`
-	Format     string   `json:"format"`
-	Stdout     bool     `json:"stdout"`
-	AutoClean  int      `json:"auto_clean"`
+	Level      Level  `json:"level"`
+	Path       string `json:"path"`        // directory of the log files, empty disables file output
+	File       string `json:"file"`        // file name pattern, supporting date placeholders like "app-{Y-m-d}.log"
+	MaxSize    int    `json:"max_size"`    // maximum size in megabytes of a log file before it is rotated, 0 disables size rotation
+	MaxBackups int    `json:"max_backups"` // maximum number of rotated log files to keep, 0 keeps all
+	MaxAge     int    `json:"max_age"`     // maximum days to keep rotated log files, 0 keeps all
+	Compress   bool   `json:"compress"`    // whether to gzip the rotated log files
+	// TimeFormat is the layout of the entry times, or TimeFormatUnix, TimeFormatUnixMilli or TimeFormatUnixNano
+	TimeFormat string `json:"time_format"`
+	TimeZone   string `json:"time_zone"` // IANA name of the location of the entry times, empty for the local time
+	TimeKey    string `json:"time_key"`  // key of the entry time in the JSON format, "time" by default
+	// Format is FormatText, FormatJSON or FormatConsole,
+	// empty for the console format in development and the text format otherwise
+	Format string `json:"format"`
+	// Development enables the defaults for development, like the colored console format
+	Development bool `json:"development"`
+	Stdout      bool `json:"stdout"`
+	// StdoutSplit writes the entries at error level and above to stderr instead of stdout,
+	// it is enabled by the config value "split" of "stdout"
+	StdoutSplit bool `json:"stdout_split"`
+	// AsyncBuffer is the number of entries buffered for asynchronous writing, 0 writes synchronously
+	AsyncBuffer int `json:"async_buffer"`
+	// AsyncPolicy is the policy when the async buffer is full, AsyncPolicyBlock or AsyncPolicyDrop
+	AsyncPolicy string `json:"async_policy"`
+	// WriteRetry is the number of entries buffered per output after a failed write and retried
+	// on the next writes, 0 drops them, see Logger.SetWriteRetry
+	WriteRetry int      `json:"write_retry"`
+	AutoClean  int      `json:"auto_clean"` // Deprecated: use MaxAge instead
 	CtxKeys    []string `json:"ctx_keys"`
+	// Caller enables reporting the file and line of the logging call
+	Caller         bool `json:"caller"`
+	CallerSkip     int  `json:"caller_skip"`      // number of additional stack frames to skip
+	CallerFunc     bool `json:"caller_func"`      // whether to report the function of the logging call
+	CallerFullPath bool `json:"caller_full_path"` // whether to report the full file path instead of the package-relative one
+	// SamplingInitial is the number of entries with the same message logged per tick, 0 disables sampling
+	SamplingInitial    int           `json:"sampling_initial"`
+	SamplingThereafter int           `json:"sampling_thereafter"` // log every nth entry after the initial ones, 0 drops them
+	SamplingTick       time.Duration `json:"sampling_tick"`       // interval of the sampling counters and summaries
+	SamplingLevels     []Level       `json:"sampling_levels"`     // sampled levels, debug, info and warn by default
+	// DedupWindow is the window collapsing the identical entries into a summary, 0 disables it
+	DedupWindow time.Duration `json:"dedup_window"`
+	DedupLevels []Level       `json:"dedup_levels"` // deduplicated levels, debug to error by default
+	// ErrorStack adds the stack of the merror of the "error" field to the entries at error level and above
+	ErrorStack      bool `json:"error_stack"`
+	ErrorStackDepth int  `json:"error_stack_depth"` // maximum number of frames of the error stacks, 32 by default
+	// Fields are attached to every entry, see SetDefaultFields for their precedence
+	Fields   map[string]any `json:"fields"`
+	Hostname bool           `json:"hostname"` // whether to add the host name as the field "hostname"
+	Pid      bool           `json:"pid"`      // whether to add the process id as the field "pid"
+	// Otel exports the entries through the global OpenTelemetry logger provider, see NewOtelHook
+	Otel bool `json:"otel"`
+}
+
+// loggerConfig is the config of a logger shared with its child loggers.
+type loggerConfig struct {
+	Config
+	mu           sync.Mutex     // serializes the configuration, see Logger.SetConfigWithMap
+	timeLocation *time.Location // location of the entry times, nil for the local time
+}
+
+// presetConsole is the kind of the writers of the split console.
+const presetConsole = "console"
+
+// stdoutSplit is the config value of "stdout" enabling the split console, see Config.StdoutSplit.
+const stdoutSplit = "split"
+
+// outputConfigKeys are the config keys affecting the outputs of the logger.
+var outputConfigKeys = []string{
+	"path", "file", "max_size", "max_backups", "max_age", "compress", "auto_clean", "stdout", "stdout_split",
+	"async_buffer", "async_policy", "write_retry",
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Level:      InfoLevel,
-		Path:       defaultPath,
-		File:       defaultFile,
-		TimeFormat: defaultTimeFormat,
-		Format:     defaultFormat,
-		Stdout:     true,
-		CtxKeys:    []string{},
+		Level:       InfoLevel,
+		Path:        defaultPath,
+		File:        defaultFile,
+		TimeFormat:  defaultTimeFormat,
+		Format:      defaultFormat,
+		Stdout:      true,
+		AsyncPolicy: AsyncPolicyBlock,
+		CtxKeys:     []string{},
+		// errors are never sampled by default
+		SamplingLevels:  append([]Level(nil), defaultSamplingLevels...),
+		DedupLevels:     append([]Level(nil), defaultDedupLevels...),
+		ErrorStackDepth: defaultErrorStackDepth,
 	}
 }
 
 func (l *Logger) SetConfig(config Config) error {
 	return l.SetConfigWithMap(map[string]any{
-		"level":       config.Level,
-		"path":        config.Path,
-		"file":        config.File,
-		"time_format": config.TimeFormat,
-		"format":      config.Format,
-		"stdout":      config.Stdout,
-		"auto_clean":  config.AutoClean,
-		"ctx_keys":    config.CtxKeys,
+		"level":               config.Level,
+		"path":                config.Path,
+		"file":                config.File,
+		"max_size":            config.MaxSize,
+		"max_backups":         config.MaxBackups,
+		"max_age":             config.MaxAge,
+		"compress":            config.Compress,
+		"time_format":         config.TimeFormat,
+		"time_zone":           config.TimeZone,
+		"time_key":            config.TimeKey,
+		"format":              config.Format,
+		"development":         config.Development,
+		"stdout":              config.Stdout,
+		"async_buffer":        config.AsyncBuffer,
+		"async_policy":        config.AsyncPolicy,
+		"write_retry":         config.WriteRetry,
+		"auto_clean":          config.AutoClean,
+		"ctx_keys":            config.CtxKeys,
+		"caller":              config.Caller,
+		"caller_skip":         config.CallerSkip,
+		"caller_func":         config.CallerFunc,
+		"caller_full_path":    config.CallerFullPath,
+		"sampling_initial":    config.SamplingInitial,
+		"sampling_thereafter": config.SamplingThereafter,
+		"sampling_tick":       config.SamplingTick,
+		"sampling_levels":     config.SamplingLevels,
+		"dedup_window":        config.DedupWindow,
+		"dedup_levels":        config.DedupLevels,
+		"error_stack":         config.ErrorStack,
+		"error_stack_depth":   config.ErrorStackDepth,
+		"otel":                config.Otel,
+		"fields":              config.Fields,
+		"hostname":            config.Hostname,
+		"pid":                 config.Pid,
 	})
 }
 
 // SetConfigWithMap sets the logger configuration using a map.
+// The keys are the json names of the Config fields, like "max_size".
+// It is safe for concurrent use, the configurations are applied one at a time.
 func (l *Logger) SetConfigWithMap(config map[string]any) error {
+	l.config.mu.Lock()
+	defer l.config.mu.Unlock()
+
 	// Set log level
 	if v, ok := config["level"]; ok {
-		if lvl, err := logrus.ParseLevel(mconv.ToString(v)); err == nil {
-			l.parent.SetLevel(lvl)
-			l.config.Level = Level(lvl)
+		level, err := toLevel(v)
+		if err != nil {
+			return err
 		}
+		l.SetLevel(level)
+		l.config.Level = level
 	}
 
 	// Update config values
 	if v, ok := config["path"]; ok {
 		l.config.Path = mconv.ToString(v)
 	}
-
 	if v, ok := config["file"]; ok {
 		l.config.File = mconv.ToString(v)
 	}
-
+	if v, ok := config["max_size"]; ok {
+		l.config.MaxSize = mconv.ToInt(v)
+	}
+	if v, ok := config["max_backups"]; ok {
+		l.config.MaxBackups = mconv.ToInt(v)
+	}
+	if v, ok := config["max_age"]; ok {
+		l.config.MaxAge = mconv.ToInt(v)
+	}
+	if v, ok := config["compress"]; ok {
+		l.config.Compress = mconv.ToBool(v)
+	}
 	if v, ok := config["auto_clean"]; ok {
 		l.config.AutoClean = mconv.ToInt(v)
 	}
+	if v, ok := config["stdout"]; ok {
+		if s, isString := v.(string); isString && strings.EqualFold(s, stdoutSplit) {
+			l.config.Stdout, l.config.StdoutSplit = true, true
+		} else {
+			l.config.Stdout, l.config.StdoutSplit = mconv.ToBool(v), false
+		}
+	}
+	if v, ok := config["stdout_split"]; ok {
+		l.config.StdoutSplit = mconv.ToBool(v)
+	}
+	if v, ok := config["async_buffer"]; ok {
+		l.config.AsyncBuffer = mconv.ToInt(v)
+	}
+	if v, ok := config["async_policy"]; ok {
+		policy := mconv.ToString(v)
+		switch policy {
+		case "":
+			policy = AsyncPolicyBlock
+		case AsyncPolicyBlock, AsyncPolicyDrop:
+		default:
+			return merror.NewCodef(mcode.CodeInvalidParameter, `invalid async policy: %s`, policy)
+		}
+		l.config.AsyncPolicy = policy
+	}
+	if v, ok := config["write_retry"]; ok {
+		l.config.WriteRetry = mconv.ToInt(v)
+	}
 
 	if v, ok := config["ctx_keys"]; ok {
-		if keys, ok := v.([]string); ok {
-			l.RemoveHookByType(&ctxHook{})
-			l.config.CtxKeys = keys
-			l.AddHook(&ctxHook{keys: keys})
+		var keys []any
+		for _, key := range mconv.ToStringSlice(v) {
+			keys = append(keys, key)
 		}
+		l.SetCtxKeys(keys...)
 	}
 
-	// Set output
-	var outputs []io.Writer
-	// Set stdout
-	stdout := true // default enable stdout
-	if v, ok := config["stdout"]; ok {
-		stdout = mconv.ToBool(v)
-		l.config.Stdout = stdout
+	if v, ok := config["otel"]; ok && mconv.ToBool(v) != l.config.Otel {
+		l.config.Otel = !l.config.Otel
+		l.setOtelHook()
+	}
+
+	if err := l.setFieldsConfig(config); err != nil {
+		return err
 	}
-	if stdout {
+
+	_, hasErrorStack := config["error_stack"]
+	if hasErrorStack {
+		l.config.ErrorStack = mconv.ToBool(config["error_stack"])
+	}
+	_, hasErrorStackDepth := config["error_stack_depth"]
+	if hasErrorStackDepth {
+		l.config.ErrorStackDepth = mconv.ToInt(config["error_stack_depth"])
+	}
+	if hasErrorStack || hasErrorStackDepth {
+		l.setErrorStackDepth()
+	}
+
+	l.setCallerConfig(config)
+	if err := l.setSamplingConfig(config); err != nil {
+		return err
+	}
+	if err := l.setDedupConfig(config); err != nil {
+		return err
+	}
+
+	// Set outputs only if they are affected, so that the log file is not reopened
+	var outputsChanged bool
+	for _, key := range outputConfigKeys {
+		if _, ok := config[key]; ok {
+			if err := l.setOutputs(); err != nil {
+				return err
+			}
+			outputsChanged = true
+			break
+		}
+	}
+
+	// Set log format, the colors of the console format depend on the outputs
+	hasTimeConfig, err := l.setTimeConfig(config)
+	if err != nil {
+		return err
+	}
+	_, hasDevelopment := config["development"]
+	if hasDevelopment {
+		l.config.Development = mconv.ToBool(config["development"])
+	}
+	if format, ok := config["format"]; ok || hasTimeConfig || hasDevelopment || outputsChanged {
+		formatStr := l.config.Format
+		if ok {
+			formatStr = mconv.ToString(format)
+		}
+
+		formatter := newFormatter(l.effectiveFormat(formatStr), l.timeOptions())
+		if formatter == nil {
+			return merror.NewCodef(mcode.CodeInvalidParameter, `invalid format: %s`, formatStr)
+		}
+		if console, ok := formatter.(*consoleFormatter); ok {
+			console.color = colorEnabled(l.consoleFiles()...)
+		}
+		l.dispatch.setFormatter(formatter)
+		l.config.Format = formatStr
+	}
+
+	return nil
+}
+
+// setCallerConfig sets the caller reporting options present in `config`.
+func (l *Logger) setCallerConfig(config map[string]any) {
+	var changed bool
+	if v, ok := config["caller"]; ok {
+		l.config.Caller, changed = mconv.ToBool(v), true
+	}
+	if v, ok := config["caller_skip"]; ok {
+		l.config.CallerSkip, changed = mconv.ToInt(v), true
+	}
+	if v, ok := config["caller_func"]; ok {
+		l.config.CallerFunc, changed = mconv.ToBool(v), true
+	}
+	if v, ok := config["caller_full_path"]; ok {
+		l.config.CallerFullPath, changed = mconv.ToBool(v), true
+	}
+	if !changed {
+		return
+	}
+	l.callers.Store(&callerOptions{
+		enabled:  l.config.Caller,
+		skip:     l.config.CallerSkip,
+		function: l.config.CallerFunc,
+		fullPath: l.config.CallerFullPath,
+	})
+}
+
+// setFieldsConfig sets the fields options present in `config`.
+func (l *Logger) setFieldsConfig(config map[string]any) error {
+	var changed bool
+	if v, ok := config["fields"]; ok {
+		var fields map[string]any
+		if v != nil {
+			var err error
+			if fields, err = mconv.ToMapE(v); err != nil {
+				return merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid fields: %v`, v)
+			}
+		}
+		l.config.Fields, changed = fields, true
+	}
+	if v, ok := config["hostname"]; ok {
+		l.config.Hostname, changed = mconv.ToBool(v), true
+	}
+	if v, ok := config["pid"]; ok {
+		l.config.Pid, changed = mconv.ToBool(v), true
+	}
+	if changed {
+		l.setConfigFields()
+	}
+	return nil
+}
+
+// setSamplingConfig sets the sampling options present in `config`.
+func (l *Logger) setSamplingConfig(config map[string]any) error {
+	var changed bool
+	if v, ok := config["sampling_initial"]; ok {
+		l.config.SamplingInitial, changed = mconv.ToInt(v), true
+	}
+	if v, ok := config["sampling_thereafter"]; ok {
+		l.config.SamplingThereafter, changed = mconv.ToInt(v), true
+	}
+	if v, ok := config["sampling_tick"]; ok {
+		tick, err := mconv.ToDurationE(v)
+		if err != nil {
+			return merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid sampling tick: %v`, v)
+		}
+		l.config.SamplingTick, changed = tick, true
+	}
+	if v, ok := config["sampling_levels"]; ok {
+		levels, err := toLevels(v)
+		if err != nil {
+			return err
+		}
+		l.config.SamplingLevels, changed = levels, true
+	}
+	if changed {
+		l.setSampler()
+	}
+	return nil
+}
+
+// setDedupConfig sets the deduplication options present in `config`.
+func (l *Logger) setDedupConfig(config map[string]any) error {
+	var changed bool
+	if v, ok := config["dedup_window"]; ok {
+		window, err := mconv.ToDurationE(v)
+		if err != nil {
+			return merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid dedup window: %v`, v)
+		}
+		l.config.DedupWindow, changed = window, true
+	}
+	if v, ok := config["dedup_levels"]; ok {
+		levels, err := toLevels(v)
+		if err != nil {
+			return err
+		}
+		l.config.DedupLevels, changed = levels, true
+	}
+	if changed {
+		l.setDeduper()
+	}
+	return nil
+}
+
+// toLevels converts `v` to a list of levels, like []string{"debug", "info"}.
+func toLevels(v any) ([]Level, error) {
+	if levels, ok := v.([]Level); ok {
+		return levels, nil
+	}
+	var levels []Level
+	for _, item := range mconv.ToSlice(v) {
+		level, err := toLevel(item)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// setOutputs sets the stdout and file outputs according to the config,
+// closing the previous file output.
+func (l *Logger) setOutputs() error {
+	var outputs []io.Writer
+	if l.config.Stdout && !l.config.StdoutSplit {
 		outputs = append(outputs, os.Stdout)
 	}
+	l.setConsoleSplit(l.config.Stdout && l.config.StdoutSplit)
 
 	// Set file output
+	var file *fileWriter
 	if l.config.Path != "" && l.config.File != "" {
-		fileWriter, err := newFileWriter(l.config.Path, l.config.File, l.config.AutoClean)
+		maxAge := l.config.MaxAge
+		if maxAge <= 0 {
+			maxAge = l.config.AutoClean
+		}
+		var err error
+		file, err = newFileWriter(filepath.Clean(l.config.Path), l.config.File, fileRotation{
+			MaxSize:    int64(l.config.MaxSize) * megabyte,
+			MaxBackups: l.config.MaxBackups,
+			MaxAge:     time.Duration(maxAge) * 24 * time.Hour,
+			Compress:   l.config.Compress,
+		})
 		if err != nil {
 			return err
 		}
-		outputs = append(outputs, fileWriter)
+		outputs = append(outputs, file)
+	}
+
+	// Report and retry the failed writes of each output, so that one failing output does
+	// not stop the others
+	fallbacks := make([]*fallbackWriter, len(outputs))
+	for i, w := range outputs {
+		fallbacks[i] = newFallbackWriter(w, l.failures, l.config.WriteRetry)
+		outputs[i] = fallbacks[i]
 	}
 
 	// Set output
-	if len(outputs) > 0 {
-		if len(outputs) == 1 {
-			l.parent.SetOutput(outputs[0])
-		} else {
-			l.parent.SetOutput(io.MultiWriter(outputs...))
-		}
-	} else {
+	var output io.Writer
+	switch len(outputs) {
+	case 0:
 		// If there is no output, set to io.Discard
-		l.parent.SetOutput(io.Discard)
+		output = io.Discard
+	case 1:
+		output = outputs[0]
+	default:
+		output = io.MultiWriter(outputs...)
+	}
+	var async *asyncWriter
+	if l.config.AsyncBuffer > 0 && len(outputs) > 0 {
+		async = newAsyncWriter(output, l.config.AsyncBuffer, l.config.AsyncPolicy)
+		output = async
 	}
+	l.parent.SetOutput(output)
 
-	// Set time format
-	timeFormat := defaultTimeFormat
-	if v, ok := config["time_format"]; ok {
-		timeFormat = mconv.ToString(v)
-		l.config.TimeFormat = timeFormat
+	// Close the previous outputs after the switch, the queued entries are written first
+	l.out.mu.Lock()
+	prevFile, prevAsync, prevFallbacks := l.out.file, l.out.async, l.out.fallbacks
+	l.out.file, l.out.async, l.out.fallbacks = file, async, fallbacks
+	l.out.mu.Unlock()
+	if prevAsync != nil {
+		_ = prevAsync.Close()
 	}
+	for _, fb := range prevFallbacks {
+		_ = fb.Close()
+	}
+	if prevFile != nil {
+		_ = prevFile.Close()
+	}
+	return nil
+}
 
-	// Set log format
-	if format, ok := config["format"]; ok {
-		formatStr := mconv.ToString(format)
-		l.config.Format = formatStr
+// SetAsync enables asynchronous writing with a buffer of `bufferSize` entries,
+// which are written in batches by a background goroutine. A size of 0 disables it.
+// When the buffer is full, logging calls block or drop the entry according to
+// the async policy, see SetAsyncPolicy.
+//
+// Call Flush or Close before the process exits, so that no queued entry is lost.
+func (l *Logger) SetAsync(bufferSize int) {
+	l.SetConfigWithMap(map[string]any{
+		"async_buffer": bufferSize,
+	})
+}
 
-		switch formatStr {
-		case "json":
-			l.parent.SetFormatter(&logrus.JSONFormatter{
-				TimestampFormat: timeFormat,
-			})
-		case "text":
-			l.parent.SetFormatter(&logrus.TextFormatter{
-				TimestampFormat: timeFormat,
-				FullTimestamp:   true,
-			})
-		default:
-			return merror.NewCodef(mcode.CodeInvalidParameter, `invalid format: %s`, format)
-		}
+// SetAsyncPolicy sets the policy when the async buffer is full, AsyncPolicyBlock or AsyncPolicyDrop.
+func (l *Logger) SetAsyncPolicy(policy string) error {
+	return l.SetConfigWithMap(map[string]any{
+		"async_policy": policy,
+	})
+}
+
+// Dropped returns the number of entries dropped because the async buffer was full.
+func (l *Logger) Dropped() int64 {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	if l.out.async == nil {
+		return 0
 	}
+	return l.out.async.Dropped()
+}
 
-	return nil
+// RetentionPlan returns the rotated log files that the next cleanup removes and compresses
+// according to the max backups, max age and compress config, without changing them.
+// The cleanup runs when the file output is opened and on each rotation. The plan is empty
+// if the file output is disabled.
+func (l *Logger) RetentionPlan() (RetentionPlan, error) {
+	l.out.mu.Lock()
+	file := l.out.file
+	l.out.mu.Unlock()
+	if file == nil {
+		return RetentionPlan{}, nil
+	}
+	return file.retentionPlan()
+}
+
+// setConsoleSplit sets the writers of the split console, entries at warn level and below
+// to stdout and at error level and above to stderr, if `split` is true.
+func (l *Logger) setConsoleSplit(split bool) {
+	if !split {
+		l.dispatch.setPresetWriters(presetConsole)
+		return
+	}
+	l.dispatch.setPresetWriters(presetConsole,
+		&levelWriter{writer: os.Stdout, minLevel: DebugLevel, maxLevel: WarnLevel},
+		&levelWriter{writer: os.Stderr, minLevel: ErrorLevel, maxLevel: PanicLevel},
+	)
+}
+
+// SetStdoutSplit sets whether to write the entries at error level and above to stderr
+// instead of stdout, as expected by container platforms. It enables the stdout output.
+func (l *Logger) SetStdoutSplit(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"stdout":       true,
+		"stdout_split": enabled,
+	})
+}
+
+// SetDevelopment sets whether to use the defaults for development,
+// like the colored console format if no format is set.
+func (l *Logger) SetDevelopment(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"development": enabled,
+	})
 }
 
 // SetStdoutPrint sets the stdout print.
@@ -147,21 +558,52 @@ func (l *Logger) SetStdoutPrint(enabled bool) {
 	})
 }
 
-// SetPath sets the log file path.
+// SetPath sets the log file directory, an empty path disables the file output.
 func (l *Logger) SetPath(path string) {
 	l.SetConfigWithMap(map[string]any{
 		"path": path,
 	})
 }
 
-// SetFile sets the log file name.
+// SetFile sets the log file name pattern, supporting date placeholders like
+// "app-{Y-m-d}.log" and "{Y}{m}{d}/{H}.log". A new file is opened when the date changes.
 func (l *Logger) SetFile(file string) {
 	l.SetConfigWithMap(map[string]any{
 		"file": file,
 	})
 }
 
+// SetMaxSize sets the maximum size in megabytes of a log file before it is rotated.
+func (l *Logger) SetMaxSize(maxSize int) {
+	l.SetConfigWithMap(map[string]any{
+		"max_size": maxSize,
+	})
+}
+
+// SetMaxBackups sets the maximum number of rotated log files to keep.
+func (l *Logger) SetMaxBackups(maxBackups int) {
+	l.SetConfigWithMap(map[string]any{
+		"max_backups": maxBackups,
+	})
+}
+
+// SetMaxAge sets the maximum days to keep rotated log files.
+func (l *Logger) SetMaxAge(maxAge int) {
+	l.SetConfigWithMap(map[string]any{
+		"max_age": maxAge,
+	})
+}
+
+// SetCompress sets whether to gzip the rotated log files.
+func (l *Logger) SetCompress(compress bool) {
+	l.SetConfigWithMap(map[string]any{
+		"compress": compress,
+	})
+}
+
 // SetAutoClean sets the auto clean days.
+//
+// Deprecated: use SetMaxAge instead.
 func (l *Logger) SetAutoClean(autoClean int) {
 	l.SetConfigWithMap(map[string]any{
 		"auto_clean": autoClean,
@@ -175,16 +617,21 @@ func (l *Logger) SetTimeFormat(timeFormat string) {
 	})
 }
 
-// SetFormat sets the log format.
+// SetFormat sets the log format, FormatText, FormatJSON or FormatConsole.
 func (l *Logger) SetFormat(format string) {
 	l.SetConfigWithMap(map[string]any{
 		"format": format,
 	})
 }
 
-// SetCtxKeys sets the context keys to extract.
-func (l *Logger) SetCtxKeys(keys []string) {
-	l.SetConfigWithMap(map[string]any{
-		"ctx_keys": keys,
-	})
+// SetCtxKeys sets the context keys whose values are added as fields to every entry,
+// missing keys are omitted. The field names are the keys for string keys, and their
+// string forms for other key types. The trace id, span id and request id are always added.
+func (l *Logger) SetCtxKeys(keys ...any) {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, ctxKeyName(key))
+	}
+	l.config.CtxKeys = names
+	l.ctxHook.keys.Store(&keys)
 }
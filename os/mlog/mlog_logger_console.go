@@ -0,0 +1,163 @@
+package mlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FormatConsole is the human-friendly format with colored levels, for development.
+	FormatConsole = "console"
+	// FormatText is the logfmt-like text format.
+	FormatText = "text"
+	// FormatJSON is the JSON format, one object per line.
+	FormatJSON = "json"
+)
+
+// ANSI escape codes of the console format.
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorBold   = "\x1b[1m"
+)
+
+// consoleLevelColors are the colors of the levels in the console format.
+var consoleLevelColors = map[Level]string{
+	DebugLevel: colorBlue,
+	InfoLevel:  colorGreen,
+	WarnLevel:  colorYellow,
+	ErrorLevel: colorRed,
+	FatalLevel: colorBold + colorRed,
+	PanicLevel: colorBold + colorRed,
+}
+
+// consoleFormatter formats the entries for reading in a terminal:
+//
+//	2025-01-02 15:04:05 INFO  server started port=8000 caller=mhttp/mhttp_server.go:42
+//
+// The fields follow the message as key=value pairs sorted by key, and the caller is
+// written last, dimmed if colored.
+type consoleFormatter struct {
+	times timeOptions
+	color bool
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *consoleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	level := fromLogrusLevel(entry.Level)
+
+	b.WriteString(f.times.formatTime(entry.Time))
+	b.WriteByte(' ')
+	f.writeColored(b, consoleLevelColors[level], fmt.Sprintf("%-5s", strings.ToUpper(level.String())))
+	b.WriteByte(' ')
+	b.WriteString(strings.TrimRight(entry.Message, "\n"))
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		if key != FieldCaller && key != FieldFunc {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(consoleValue(entry.Data[key]))
+	}
+
+	if caller, ok := entry.Data[FieldCaller]; ok {
+		text := FieldCaller + "=" + consoleValue(caller)
+		if function, ok := entry.Data[FieldFunc]; ok {
+			text += " " + FieldFunc + "=" + consoleValue(function)
+		}
+		b.WriteByte(' ')
+		f.writeColored(b, colorDim, text)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writeColored writes `s` with the color `color`, if colored.
+func (f *consoleFormatter) writeColored(b *bytes.Buffer, color string, s string) {
+	if !f.color || color == "" {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(color)
+	b.WriteString(s)
+	b.WriteString(colorReset)
+}
+
+// consoleValue formats the field value `v`, quoting the strings with spaces or quotes.
+func consoleValue(v any) string {
+	var s string
+	switch value := v.(type) {
+	case string:
+		s = value
+	case error:
+		s = value.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// colorEnabled reports whether the entries written to `files` are colored:
+// they must all be terminals supporting colors, and the NO_COLOR variable must be unset.
+func colorEnabled(files ...*os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if !isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()) {
+			return false
+		}
+		if !enableColor(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// consoleFiles returns the files of the console outputs, or nil if the entries are also
+// written to other outputs, which must not receive the escape codes.
+func (l *Logger) consoleFiles() []*os.File {
+	switch {
+	case !l.config.Stdout || (l.config.Path != "" && l.config.File != ""):
+		return nil
+	case l.config.StdoutSplit:
+		return []*os.File{os.Stdout, os.Stderr}
+	}
+	return []*os.File{os.Stdout}
+}
+
+// effectiveFormat returns the format used for `format`, resolving the empty format
+// to the console format in development and to the text format otherwise.
+func (l *Logger) effectiveFormat(format string) string {
+	if format != "" {
+		return format
+	}
+	if l.config.Development {
+		return FormatConsole
+	}
+	return FormatText
+}
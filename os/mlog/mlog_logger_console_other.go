@@ -0,0 +1,10 @@
+//go:build !windows
+
+package mlog
+
+import "os"
+
+// enableColor reports whether the terminal `f` supports the escape codes, which they all do.
+func enableColor(f *os.File) bool {
+	return true
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package mlog
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableColor enables the processing of the escape codes by the console `f`,
+// it returns false if the console does not support it, like before Windows 10.
+func enableColor(f *os.File) bool {
+	var mode uint32
+	handle := windows.Handle(f.Fd())
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -0,0 +1,274 @@
+package mlog
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FieldRepeatCount is the field of the number of suppressed repeats in the summary of a duplicate entry.
+	FieldRepeatCount = "repeat_count"
+
+	// dedupMaxKeys is the number of entries tracked for duplicates, the least recently repeated ones are
+	// evicted beyond it.
+	dedupMaxKeys = 4096
+)
+
+// defaultDedupLevels are the levels deduplicated by default, including errors as only the identical
+// entries are collapsed.
+var defaultDedupLevels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// dedupEntry is an entry tracked for duplicates within its window.
+type dedupEntry struct {
+	key     uint64
+	level   Level
+	message string
+	data    logrus.Fields // fields of the first occurrence
+	start   time.Time     // time of the first occurrence, starting the window
+	repeats uint64        // suppressed repeats within the window
+}
+
+// deduper collapses the identical entries within a window: the first occurrence is logged, the
+// repeats are counted and reported by a single summary entry at the end of the window. The entries
+// are identified by the hash of their level, message, call site and fields, and the tracked ones
+// are bounded by dedupMaxKeys in least recently used order.
+type deduper struct {
+	window   time.Duration
+	levels   [PanicLevel + 1]bool
+	seed     maphash.Seed
+	logger   *logrus.Logger
+	mu       sync.Mutex
+	entries  map[uint64]*list.Element
+	lru      *list.List // elements of *dedupEntry, the most recently repeated first
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newDeduper creates a deduper and starts the goroutine writing the summaries to `logger`.
+func newDeduper(window time.Duration, levels []Level, logger *logrus.Logger) *deduper {
+	d := &deduper{
+		window:  window,
+		seed:    maphash.MakeSeed(),
+		logger:  logger,
+		entries: make(map[uint64]*list.Element),
+		lru:     list.New(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, level := range levels {
+		// fatal and panic entries are never deduplicated, as they terminate the call
+		if level >= DebugLevel && level <= ErrorLevel {
+			d.levels[level] = true
+		}
+	}
+	go d.run()
+	return d
+}
+
+// key returns the hash of the entry at `level` with message `msg`, logged at `pc` with the fields `data`.
+// The hashes of the fields are summed, so that the key does not depend on their order.
+func (d *deduper) key(level Level, msg string, pc uintptr, data map[string]any) uint64 {
+	var h maphash.Hash
+	h.SetSeed(d.seed)
+	h.WriteByte(byte(level))
+	h.WriteString(msg)
+	var pcBytes [8]byte
+	binary.LittleEndian.PutUint64(pcBytes[:], uint64(pc))
+	h.Write(pcBytes[:])
+	key := h.Sum64()
+	for k, v := range data {
+		h.Reset()
+		h.WriteString(k)
+		h.WriteByte(0)
+		fmt.Fprint(&h, v)
+		key += h.Sum64()
+	}
+	return key
+}
+
+// drop reports whether the entry is a repeat within the window of its first occurrence.
+// The summary of the previous window, or of an evicted entry, is written before the entry.
+func (d *deduper) drop(level Level, msg string, pc uintptr, data map[string]any) bool {
+	var (
+		key     = d.key(level, msg, pc, data)
+		now     = time.Now()
+		summary *dedupEntry
+	)
+	d.mu.Lock()
+	if e, ok := d.entries[key]; ok {
+		item := e.Value.(*dedupEntry)
+		if now.Sub(item.start) < d.window {
+			item.repeats++
+			d.lru.MoveToFront(e)
+			d.mu.Unlock()
+			return true
+		}
+		// the window ended before its summary was written, a new one starts with this entry
+		d.remove(e)
+		summary = item
+	} else if d.lru.Len() >= dedupMaxKeys {
+		back := d.lru.Back()
+		d.remove(back)
+		summary = back.Value.(*dedupEntry)
+	}
+	d.entries[key] = d.lru.PushFront(&dedupEntry{
+		key:     key,
+		level:   level,
+		message: msg,
+		data:    maps.Clone(data),
+		start:   now,
+	})
+	d.mu.Unlock()
+
+	if summary != nil {
+		d.summarize(summary)
+	}
+	return false
+}
+
+// remove removes the element `e` from the tracked entries, under the lock.
+func (d *deduper) remove(e *list.Element) {
+	d.lru.Remove(e)
+	delete(d.entries, e.Value.(*dedupEntry).key)
+}
+
+// run writes the summaries of the ended windows, until the deduper is stopped.
+func (d *deduper) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(max(d.window/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			d.flush(func(item *dedupEntry) bool { return now.Sub(item.start) >= d.window })
+		case <-d.stop:
+			d.flush(func(*dedupEntry) bool { return true })
+			return
+		}
+	}
+}
+
+// flush removes the tracked entries matching `ended` and writes their summaries.
+func (d *deduper) flush(ended func(item *dedupEntry) bool) {
+	var summaries []*dedupEntry
+	d.mu.Lock()
+	for e := d.lru.Back(); e != nil; {
+		prev := e.Prev()
+		if item := e.Value.(*dedupEntry); ended(item) {
+			d.remove(e)
+			summaries = append(summaries, item)
+		}
+		e = prev
+	}
+	d.mu.Unlock()
+
+	for _, item := range summaries {
+		d.summarize(item)
+	}
+}
+
+// summarize writes the summary of the entry `item`, with its message and fields and the number
+// of suppressed repeats, if any.
+func (d *deduper) summarize(item *dedupEntry) {
+	if item.repeats == 0 {
+		return
+	}
+	data := make(logrus.Fields, len(item.data)+1)
+	for k, v := range item.data {
+		data[k] = v
+	}
+	data[FieldRepeatCount] = item.repeats
+	d.logger.WithContext(context.Background()).WithFields(data).Log(item.level.toLogrusLevel(), item.message)
+}
+
+// close stops the deduper, writing the summaries of the pending windows.
+func (d *deduper) close() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+		<-d.done
+	})
+}
+
+// SetDedup collapses the identical entries repeated within `window`, like the errors of a
+// reconnection loop: the first occurrence is logged immediately, and the repeats with the same
+// level, message, call site and fields are suppressed until the end of the window, which writes
+// a single summary entry with the field "repeat_count". A `window` of 0 disables it.
+//
+// Unlike sampling, which limits the volume of the entries by message, it only drops exact
+// repeats, so errors are deduplicated by default, see SetDedupLevels.
+func (l *Logger) SetDedup(window time.Duration) {
+	l.SetConfigWithMap(map[string]any{
+		"dedup_window": window,
+	})
+}
+
+// SetDedupLevels sets the levels deduplicated by SetDedup, fatal and panic are never deduplicated.
+func (l *Logger) SetDedupLevels(levels ...Level) {
+	l.SetConfigWithMap(map[string]any{
+		"dedup_levels": levels,
+	})
+}
+
+// setDeduper replaces the deduper according to the config, stopping the previous one.
+func (l *Logger) setDeduper() {
+	var d *deduper
+	if l.config.DedupWindow > 0 {
+		d = newDeduper(l.config.DedupWindow, l.config.DedupLevels, l.parent)
+	}
+	if prev := l.dedup.Swap(d); prev != nil {
+		prev.close()
+	}
+}
+
+// dropDuplicate reports whether the entry at `level` with message fmt.Sprint(args...) is a suppressed repeat.
+// It must be called by the logging method directly, like dropDuplicateMsg.
+func (l *Logger) dropDuplicate(entry *logrus.Entry, level Level, args []any) bool {
+	d := l.dedup.Load()
+	if d == nil || !d.levels[level] {
+		return false
+	}
+	return l.dropDuplicateEntry(d, entry, level, fmt.Sprint(args...))
+}
+
+// dropDuplicatef reports whether the entry at `level` with message fmt.Sprintf(format, args...) is a
+// suppressed repeat, see dropDuplicate.
+func (l *Logger) dropDuplicatef(entry *logrus.Entry, level Level, format string, args []any) bool {
+	d := l.dedup.Load()
+	if d == nil || !d.levels[level] {
+		return false
+	}
+	return l.dropDuplicateEntry(d, entry, level, fmt.Sprintf(format, args...))
+}
+
+// dropDuplicateMsg reports whether the entry at `level` with message `msg` is a suppressed repeat.
+// It must be called by the logging method directly, so that the call site is identified.
+func (l *Logger) dropDuplicateMsg(entry *logrus.Entry, level Level, msg string) bool {
+	d := l.dedup.Load()
+	if d == nil || !d.levels[level] {
+		return false
+	}
+	return l.dropDuplicateEntry(d, entry, level, msg)
+}
+
+// dropDuplicateEntry checks the entry with the call site of the logging method calling dropDuplicate.
+func (l *Logger) dropDuplicateEntry(d *deduper, entry *logrus.Entry, level Level, msg string) bool {
+	// skip runtime.Callers, dropDuplicateEntry, dropDuplicate and the logging method
+	skip := callerBaseSkip + l.callerSkip
+	if options := l.callers.Load(); options != nil {
+		skip += options.skip
+	}
+	var pcs [1]uintptr
+	runtime.Callers(skip, pcs[:])
+	return d.drop(level, msg, pcs[0], entry.Data)
+}
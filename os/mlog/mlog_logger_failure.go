@@ -0,0 +1,241 @@
+package mlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// failureReportInterval is the minimum interval between two reports of the failed writes to the error output.
+const failureReportInterval = time.Second
+
+// Stats are the counters of the outputs of a logger, see Logger.Stats.
+type Stats struct {
+	WriteErrors  int64 // failed writes of the outputs and of the writers added by AddWriter
+	Dropped      int64 // entries lost by a failed write, see SetWriteRetry
+	Pending      int   // entries buffered after a failed write, retried on the next writes
+	AsyncDropped int64 // entries dropped because the async buffer was full, see Dropped
+}
+
+// WriteFailure is a failed write of an output of the logger, see WriteFailureHook.
+type WriteFailure struct {
+	Writer  io.Writer // failing writer, like the log file or a writer added by AddWriter
+	Err     error     // error of the write
+	Dropped bool      // whether the entry is lost, or buffered to be retried
+}
+
+// WriteFailureHook is implemented by the hooks which also observe the failed writes of the
+// outputs, for alerting that the logging is broken, which the entries cannot report.
+// WriteFailed is called for each failed write, from the logging call or the async goroutine;
+// like Fire, it must be fast, and it must not log to the failing logger.
+type WriteFailureHook interface {
+	Hook
+	WriteFailed(failure WriteFailure)
+}
+
+// writeFailures reports the failed writes of a logger and its child loggers.
+type writeFailures struct {
+	errorOutput atomic.Pointer[io.Writer] // nil for stderr
+	hooks       atomic.Pointer[[]WriteFailureHook]
+	hooksMu     sync.Mutex // serializes updates of hooks
+	writeErrors atomic.Int64
+	dropped     atomic.Int64
+	lastReport  atomic.Int64 // unix nanoseconds of the last report to the error output
+	suppressed  atomic.Int64 // failures not reported since the last report
+}
+
+// newWriteFailures creates a writeFailures reporting to stderr.
+func newWriteFailures() *writeFailures {
+	f := &writeFailures{}
+	f.hooks.Store(&[]WriteFailureHook{})
+	return f
+}
+
+// report counts the failed write of `w`, reports it to the error output at most once per
+// failureReportInterval, and to the hooks.
+func (f *writeFailures) report(w io.Writer, err error, dropped bool) {
+	f.observe(w, err, dropped)
+
+	now := time.Now().UnixNano()
+	if last := f.lastReport.Load(); now-last >= int64(failureReportInterval) && f.lastReport.CompareAndSwap(last, now) {
+		output := io.Writer(os.Stderr)
+		if o := f.errorOutput.Load(); o != nil {
+			output = *o
+		}
+		msg := fmt.Sprintf("mlog: failed to write to %T: %v", w, err)
+		if n := f.suppressed.Swap(0); n > 0 {
+			msg += fmt.Sprintf(" (%d more failures since the last report)", n)
+		}
+		_, _ = fmt.Fprintln(output, msg)
+	} else {
+		f.suppressed.Add(1)
+	}
+}
+
+// observe counts the failed write of `w` and reports it to the hooks.
+func (f *writeFailures) observe(w io.Writer, err error, dropped bool) {
+	f.writeErrors.Add(1)
+	if dropped {
+		f.dropped.Add(1)
+	}
+	failure := WriteFailure{Writer: w, Err: err, Dropped: dropped}
+	for _, hook := range *f.hooks.Load() {
+		hook.WriteFailed(failure)
+	}
+}
+
+// addHook adds `hook` if it observes the failed writes.
+func (f *writeFailures) addHook(hook Hook) {
+	fh, ok := hook.(WriteFailureHook)
+	if !ok {
+		return
+	}
+	f.hooksMu.Lock()
+	defer f.hooksMu.Unlock()
+	hooks := append([]WriteFailureHook{}, *f.hooks.Load()...)
+	hooks = append(hooks, fh)
+	f.hooks.Store(&hooks)
+}
+
+// removeHooksByType removes the hooks of type `hookType`.
+func (f *writeFailures) removeHooksByType(hookType reflect.Type) {
+	f.hooksMu.Lock()
+	defer f.hooksMu.Unlock()
+	var hooks []WriteFailureHook
+	for _, hook := range *f.hooks.Load() {
+		if reflect.TypeOf(hook) != hookType {
+			hooks = append(hooks, hook)
+		}
+	}
+	f.hooks.Store(&hooks)
+}
+
+// fallbackWriter wraps an output of the logger, reporting its failed writes instead of
+// returning them to logrus. The entries of the failed writes are buffered, up to `retry`
+// entries, and written again before the next entries, so their order is preserved;
+// the entries beyond are dropped.
+type fallbackWriter struct {
+	writer   io.Writer
+	failures *writeFailures
+	retry    int
+	mu       sync.Mutex
+	pending  [][]byte // entries of the failed writes, in order
+}
+
+// newFallbackWriter creates a fallbackWriter writing to `writer`, buffering up to `retry` failed entries.
+func newFallbackWriter(writer io.Writer, failures *writeFailures, retry int) *fallbackWriter {
+	return &fallbackWriter{writer: writer, failures: failures, retry: retry}
+}
+
+// Write implements the io.Writer interface, it never fails.
+func (w *fallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.writePending()
+	if err == nil {
+		if _, err = w.writer.Write(p); err == nil {
+			return len(p), nil
+		}
+	}
+	if len(w.pending) < w.retry {
+		w.pending = append(w.pending, append([]byte(nil), p...))
+		w.failures.report(w.writer, err, false)
+	} else {
+		w.failures.report(w.writer, err, true)
+	}
+	return len(p), nil
+}
+
+// writePending writes the buffered entries, stopping at the first failure.
+func (w *fallbackWriter) writePending() error {
+	for len(w.pending) > 0 {
+		if _, err := w.writer.Write(w.pending[0]); err != nil {
+			return err
+		}
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+	return nil
+}
+
+// Flush writes the buffered entries, it returns the error of the output if it still fails.
+func (w *fallbackWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writePending()
+}
+
+// Close writes the buffered entries a last time, the ones which still fail are dropped.
+func (w *fallbackWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.writePending()
+	if err != nil {
+		w.failures.dropped.Add(int64(len(w.pending)))
+		w.pending = nil
+	}
+	return err
+}
+
+// pendingCount returns the number of buffered entries.
+func (w *fallbackWriter) pendingCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// SetErrorOutput sets the writer reporting the failed writes of the outputs, like a full disk,
+// stderr by default or if `w` is nil. The reports are limited to one per second, the following
+// failures are counted in the next report and in Stats. The hooks implementing WriteFailureHook
+// observe all of them.
+func (l *Logger) SetErrorOutput(w io.Writer) {
+	if w == nil {
+		l.failures.errorOutput.Store(nil)
+		return
+	}
+	l.failures.errorOutput.Store(&w)
+}
+
+// SetWriteRetry sets the number of entries buffered per output after a failed write, which
+// are written again before the next entries. A size of 0, the default, drops the entries
+// of the failed writes. The lost entries are counted by Stats.
+func (l *Logger) SetWriteRetry(size int) {
+	l.SetConfigWithMap(map[string]any{
+		"write_retry": size,
+	})
+}
+
+// Stats returns the counters of the failed writes and the dropped entries of the logger,
+// which are shared with its child loggers.
+func (l *Logger) Stats() Stats {
+	l.out.mu.Lock()
+	fallbacks, async := l.out.fallbacks, l.out.async
+	l.out.mu.Unlock()
+
+	stats := Stats{
+		WriteErrors: l.failures.writeErrors.Load(),
+		Dropped:     l.failures.dropped.Load(),
+	}
+	for _, fb := range fallbacks {
+		stats.Pending += fb.pendingCount()
+	}
+	if async != nil {
+		stats.AsyncDropped = async.Dropped()
+	}
+	return stats
+}
+
+// flushFallbacks writes the entries buffered by the failed writes of `fallbacks`.
+func flushFallbacks(fallbacks []*fallbackWriter) error {
+	var errs []error
+	for _, fb := range fallbacks {
+		errs = append(errs, fb.Flush())
+	}
+	return errors.Join(errs...)
+}
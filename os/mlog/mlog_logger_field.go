@@ -0,0 +1,341 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FieldHostname is the field of the host name, added by the config "hostname".
+	FieldHostname = "hostname"
+	// FieldPid is the field of the process id, added by the config "pid".
+	FieldPid = "pid"
+)
+
+var (
+	// defaultFields are the fields set by SetDefaultFields.
+	defaultFields atomic.Pointer[[]Field]
+
+	// processHostname returns the host name, looked up once.
+	processHostname = sync.OnceValue(func() string {
+		hostname, _ := os.Hostname()
+		return hostname
+	})
+)
+
+// fieldType is the value type of a Field.
+type fieldType uint8
+
+const (
+	fieldTypeAny fieldType = iota
+	fieldTypeString
+	fieldTypeInt
+	fieldTypeFloat
+	fieldTypeBool
+	fieldTypeTime
+	fieldTypeDuration
+	fieldTypeError
+	fieldTypeLazy
+)
+
+// badKey is the key of values without a key in keysAndValues.
+const badKey = "!BADKEY"
+
+// FieldLogError is the field reporting the invalid keys and values of the logging call, like a value without a key.
+const FieldLogError = "log_error"
+
+// Field is a key-value pair attached to a log entry.
+// Fields are created by the typed constructors like String and Int.
+type Field struct {
+	Key     string
+	typ     fieldType
+	integer int64
+	str     string
+	float   float64
+	iface   any
+}
+
+// Fields is a map of fields, see Logger.WithFields.
+type Fields map[string]any
+
+// String creates a field with a string value.
+func String(key string, value string) Field {
+	return Field{Key: key, typ: fieldTypeString, str: value}
+}
+
+// Int creates a field with an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, typ: fieldTypeInt, integer: int64(value)}
+}
+
+// Int64 creates a field with an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, typ: fieldTypeInt, integer: value}
+}
+
+// Float64 creates a field with a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, typ: fieldTypeFloat, float: value}
+}
+
+// Bool creates a field with a bool value.
+func Bool(key string, value bool) Field {
+	var integer int64
+	if value {
+		integer = 1
+	}
+	return Field{Key: key, typ: fieldTypeBool, integer: integer}
+}
+
+// Time creates a field with a time value.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, typ: fieldTypeTime, iface: value}
+}
+
+// Duration creates a field with a duration value, it is rendered like "1.5s".
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, typ: fieldTypeDuration, integer: int64(value)}
+}
+
+// Err creates a field with key "error" and the error message as value.
+func Err(err error) Field {
+	return NamedErr(FieldError, err)
+}
+
+// NamedErr creates a field with the error message as value.
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, typ: fieldTypeError, iface: err}
+}
+
+// Lazy creates a field whose value is computed by `fn` only when the entry is written, that is
+// after the level and sampling checks, for values that are expensive to compute:
+//
+//	logger.Debugw(ctx, "state", mlog.Lazy("diff", func() any { return computeDiff(a, b) }))
+//
+// The function is called once per entry, whatever the number of writers. A panic of the
+// function is recovered and its value is rendered like "!PANIC: message".
+func Lazy(key string, fn func() any) Field {
+	return Field{Key: key, typ: fieldTypeLazy, iface: fn}
+}
+
+// LazyString creates a field whose string value is computed by `fn` only when the entry is written, see Lazy.
+func LazyString(key string, fn func() string) Field {
+	return Lazy(key, func() any { return fn() })
+}
+
+// Any creates a field with an arbitrary value, which is marshaled only when the entry is written.
+func Any(key string, value any) Field {
+	switch v := value.(type) {
+	case Field:
+		return v
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Time:
+		return Time(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case error:
+		return NamedErr(key, v)
+	}
+	return Field{Key: key, typ: fieldTypeAny, iface: value}
+}
+
+// Value returns the value of the field as written to the log entry.
+func (f Field) Value() any {
+	switch f.typ {
+	case fieldTypeString:
+		return f.str
+	case fieldTypeInt:
+		return f.integer
+	case fieldTypeFloat:
+		return f.float
+	case fieldTypeBool:
+		return f.integer == 1
+	case fieldTypeTime:
+		return f.iface
+	case fieldTypeDuration:
+		return time.Duration(f.integer).String()
+	case fieldTypeError:
+		if err, ok := f.iface.(error); ok && err != nil {
+			return err.Error()
+		}
+		return nil
+	case fieldTypeLazy:
+		if fn, ok := f.iface.(func() any); ok && fn != nil {
+			return lazyValue(fn)
+		}
+		return nil
+	}
+	return f.iface
+}
+
+// lazyValue returns the value computed by `fn`, or the rendering of its panic.
+func lazyValue(fn func() any) (value any) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("!PANIC: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// With returns a child logger with `fields` attached to every entry it writes.
+// The child shares the level, outputs and hooks of the logger, and its fields
+// take precedence over the fields of the logger with the same key.
+// It is cheap to create and safe for concurrent use.
+func (l *Logger) With(fields ...Field) *Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	child := *l
+	child.fields = make([]Field, 0, len(l.fields)+len(fields))
+	child.fields = append(child.fields, l.fields...)
+	child.fields = append(child.fields, fields...)
+	return &child
+}
+
+// WithFields returns a child logger with the map `fields` attached, see With.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	list := make([]Field, 0, len(fields))
+	for key, value := range fields {
+		list = append(list, Any(key, value))
+	}
+	return l.With(list...)
+}
+
+// SetDefaultFields sets the fields attached to the entries of all the loggers, including the
+// ones created later, like the service name, environment or version:
+//
+//	mlog.SetDefaultFields(mlog.String("service", "checkout"), mlog.String("env", "prod"))
+//
+// They have the lowest precedence: the fields of the logging call take precedence over
+// the fields of With, which take precedence over the fields of the logger config,
+// like "fields", "hostname" and "pid", which take precedence over the default fields.
+func SetDefaultFields(fields ...Field) {
+	fields = append([]Field(nil), fields...)
+	defaultFields.Store(&fields)
+}
+
+// DefaultFields returns the fields set by SetDefaultFields.
+func DefaultFields() []Field {
+	if fields := defaultFields.Load(); fields != nil {
+		return *fields
+	}
+	return nil
+}
+
+// setConfigFields stores the fields of the config "fields", "hostname" and "pid".
+func (l *Logger) setConfigFields() {
+	fields := make([]Field, 0, len(l.config.Fields)+2)
+	if l.config.Hostname {
+		fields = append(fields, String(FieldHostname, processHostname()))
+	}
+	if l.config.Pid {
+		fields = append(fields, Int(FieldPid, os.Getpid()))
+	}
+	for key, value := range l.config.Fields {
+		fields = append(fields, Any(key, value))
+	}
+	l.configFields.Store(&fields)
+}
+
+// fieldsData returns the map of the default fields, the config fields and the fields of the
+// logger, in the order of their precedence, with room for `n` more fields.
+// It returns nil if there are no fields and `n` is 0.
+func (l *Logger) fieldsData(n int) logrus.Fields {
+	var defaults, config []Field
+	if fields := defaultFields.Load(); fields != nil {
+		defaults = *fields
+	}
+	if fields := l.configFields.Load(); fields != nil {
+		config = *fields
+	}
+	size := len(defaults) + len(config) + len(l.fields) + n
+	if size == 0 {
+		return nil
+	}
+	// room for the caller fields
+	data := make(logrus.Fields, size+2)
+	for _, list := range [][]Field{defaults, config, l.fields} {
+		for _, field := range list {
+			data[field.Key] = field.Value()
+		}
+	}
+	return data
+}
+
+// Fields returns the fields attached to the logger.
+func (l *Logger) Fields() []Field {
+	return l.fields
+}
+
+// keysAndValuesToFields converts alternating keys and values to fields.
+// Field values are taken as they are, and a value without a key gets the key "!BADKEY".
+// The invalid keys and values are reported in the field "log_error" rather than failing.
+func keysAndValuesToFields(keysAndValues []any) []Field {
+	var (
+		fields   = make([]Field, 0, (len(keysAndValues)+1)/2)
+		problems []string
+	)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch v := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case string:
+			if i+1 < len(keysAndValues) {
+				fields = append(fields, Any(v, keysAndValues[i+1]))
+				i++
+			} else {
+				fields = append(fields, String(badKey, v))
+				problems = append(problems, fmt.Sprintf("odd number of keys and values, %q has no key", v))
+			}
+		default:
+			fields = append(fields, Any(badKey, fmt.Sprint(v)))
+			problems = append(problems, fmt.Sprintf("non-string key %v of type %T", v, v))
+		}
+	}
+	if len(problems) > 0 {
+		fields = append(fields, String(FieldLogError, strings.Join(problems, "; ")))
+	}
+	return fields
+}
+
+// splitFields separates the Field arguments of `v` from the arguments of the message.
+// It returns `v` as it is if it holds no fields.
+func splitFields(v []any) ([]any, []Field) {
+	var n int
+	for _, arg := range v {
+		if _, ok := arg.(Field); ok {
+			n++
+		}
+	}
+	if n == 0 {
+		return v, nil
+	}
+	var (
+		args   = make([]any, 0, len(v)-n)
+		fields = make([]Field, 0, n)
+	)
+	for _, arg := range v {
+		if field, ok := arg.(Field); ok {
+			fields = append(fields, field)
+		} else {
+			args = append(args, arg)
+		}
+	}
+	return args, fields
+}
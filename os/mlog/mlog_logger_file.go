@@ -1,114 +1,179 @@
 package mlog
 
 import (
-	"fmt"
+	"compress/gzip"
+	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/maltose/internal/intlog"
 )
 
-// fileWriter is a writer that writes to files based on date patterns or fixed file names.
+const (
+	// backupTimeFormat is the time format of the suffix of the files rotated by size.
+	backupTimeFormat = "20060102T150405.000"
+	// compressSuffix is the suffix of the compressed rotated files.
+	compressSuffix = ".gz"
+	megabyte       = 1024 * 1024
+)
+
+var (
+	// datePlaceholderRegex matches the date placeholders like "{Y}" and "{Y-m-d}".
+	datePlaceholderRegex = regexp.MustCompile(`\{[YymdHis][^{}]*\}`)
+	// dateLayouts maps the date placeholder letters to the time layouts and their regex.
+	dateLayouts = map[rune][2]string{
+		'Y': {"2006", `\d{4}`},
+		'y': {"06", `\d{2}`},
+		'm': {"01", `\d{2}`},
+		'd': {"02", `\d{2}`},
+		'H': {"15", `\d{2}`},
+		'i': {"04", `\d{2}`},
+		's': {"05", `\d{2}`},
+	}
+)
+
+// fileRotation is the rotation configuration of the file writer.
+type fileRotation struct {
+	MaxSize    int64         // Maximum size in bytes before rotating, 0 disables size rotation
+	MaxBackups int           // Maximum number of rotated files to keep, 0 keeps all
+	MaxAge     time.Duration // Maximum age of rotated files to keep, 0 keeps all
+	Compress   bool          // Whether to gzip the rotated files
+}
+
+// fileWriter is a writer that writes to files based on date patterns or fixed file names,
+// rotating them by date and size.
 type fileWriter struct {
-	basePath    string        // Base directory path
-	filePattern string        // File name pattern
-	autoClean   int           // Auto cleanup days
+	dir         string        // Directory of the log files
+	filePattern string        // File name pattern, relative to dir
+	rotation    fileRotation  // Rotation configuration
 	mu          sync.Mutex    // Mutex for concurrency safety
 	file        *os.File      // Current open file
+	size        int64         // Size of the current file
 	currentPath string        // Current file path
-	lastCheck   time.Time     // Last file check time
-	lastCleanup time.Time     // Last cleanup check time
-	stopChan    chan struct{} // Stop channel for cleanup goroutine
+	lastCheck   int64         // Unix second of the last date check
+	millChan    chan struct{} // Signal channel for compression and cleanup
+	stopChan    chan struct{} // Stop channel for the mill goroutine
+	millDone    chan struct{} // Closed when the mill goroutine exits
+	closed      bool          // Whether the writer is closed
 	isDateMode  bool          // Whether using date pattern mode
 }
 
-// newFileWriter creates a new DateWriter.
-func newFileWriter(basePath string, filePattern string, autoClean int) (*fileWriter, error) {
-	// Ensure directory exists
-	dir := filepath.Dir(basePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, merror.Wrapf(err, "failed to create log directory: %s", dir)
-	}
-
+// newFileWriter creates a new fileWriter writing to `filePattern` in directory `dir`.
+func newFileWriter(dir string, filePattern string, rotation fileRotation) (*fileWriter, error) {
 	w := &fileWriter{
-		basePath:    basePath,
+		dir:         dir,
 		filePattern: filePattern,
-		autoClean:   autoClean,
-		lastCheck:   time.Now(),
-		lastCleanup: time.Now(),
+		rotation:    rotation,
+		millChan:    make(chan struct{}, 1),
 		stopChan:    make(chan struct{}),
+		millDone:    make(chan struct{}),
 		isDateMode:  IsDatePattern(filePattern),
 	}
 
 	// Open initial file
-	if err := w.checkAndRotate(); err != nil {
+	if err := w.openFile(time.Now()); err != nil {
 		return nil, err
 	}
 
-	// Start cleanup goroutine if auto cleanup is enabled and using date mode
-	if autoClean > 0 && w.isDateMode {
-		go w.cleanupRoutine()
-	}
+	go w.millRoutine()
+	w.mill()
 
 	return w, nil
 }
 
 // Write implements the io.Writer interface.
+// The rotation happens under the lock, so no entry is lost or split during the swap.
 func (w *fileWriter) Write(p []byte) (n int, err error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// Check if we need to switch to a new file based on current date
-	// Only check periodically and only if using date mode
-	if w.isDateMode && time.Since(w.lastCheck) >= time.Minute {
-		if err := w.checkAndRotate(); err != nil {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	// Reopen the file if a previous rotation failed to open it
+	if w.file == nil {
+		if err = w.openFile(now); err != nil {
+			return 0, err
+		}
+	}
+	// Check at most once a second if we need to switch to a new file based on the date
+	if w.isDateMode && now.Unix() != w.lastCheck {
+		w.lastCheck = now.Unix()
+		if w.formatFilePath(now) != w.currentPath {
+			if err = w.openFile(now); err != nil {
+				return 0, err
+			}
+			w.mill()
+		}
+	}
+
+	// Rotate by size, an entry larger than the max size is written to an empty file
+	if w.rotation.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.rotation.MaxSize {
+		if err = w.rotate(now); err != nil {
 			return 0, err
 		}
-		w.lastCheck = time.Now()
 	}
 
-	return w.file.Write(p)
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
 }
 
-// Close closes the current file and stops the cleanup goroutine.
-func (w *fileWriter) Close() error {
+// Sync commits the content of the current file to stable storage.
+func (w *fileWriter) Sync() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-
-	// Stop cleanup goroutine if running
-	close(w.stopChan)
-
-	// Close file
-	if w.file != nil {
-		return w.file.Close()
+	if w.file == nil {
+		return nil
 	}
-	return nil
+	return w.file.Sync()
 }
 
-// checkAndRotate checks if the file needs to be rotated based on the current date.
-func (w *fileWriter) checkAndRotate() error {
-	// Generate file path based on current date or fixed pattern
-	var filePath string
-	if w.isDateMode {
-		filePath = w.formatFilePath(time.Now())
-	} else {
-		filePath = filepath.Join(filepath.Dir(w.basePath), w.filePattern)
+// Reopen opens the file at its configured path again, closing the current one, for the
+// external rotation tools which rename the file, like logrotate. It happens under the lock,
+// so each write goes entirely either to the previous file or to the new one.
+func (w *fileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
 	}
+	return w.openFile(time.Now())
+}
 
-	// If the path is the same, no need to rotate
-	if filePath == w.currentPath && w.file != nil {
+// Close closes the current file and waits for the mill goroutine to stop.
+func (w *fileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
 		return nil
 	}
+	w.closed = true
+	close(w.stopChan)
 
-	// Close current file if open
+	var err error
 	if w.file != nil {
-		w.file.Close()
+		err = w.file.Close()
 		w.file = nil
 	}
+	w.mu.Unlock()
+
+	<-w.millDone
+	return err
+}
+
+// openFile opens the file for time `t`, closing the current one.
+func (w *fileWriter) openFile(t time.Time) error {
+	filePath := w.formatFilePath(t)
 
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
@@ -116,127 +181,268 @@ func (w *fileWriter) checkAndRotate() error {
 		return merror.Wrapf(err, "failed to create log directory: %s", dir)
 	}
 
-	// Open new file
 	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return merror.Wrapf(err, "failed to open log file: %s", filePath)
 	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return merror.Wrapf(err, "failed to stat log file: %s", filePath)
+	}
+
+	// Close current file if open
+	if w.file != nil {
+		_ = w.file.Close()
+	}
 
-	// Update state
 	w.file = file
+	w.size = info.Size()
 	w.currentPath = filePath
+	return nil
+}
 
+// rotate renames the current file with a time suffix and opens a new one.
+func (w *fileWriter) rotate(t time.Time) error {
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+	var (
+		ext    = filepath.Ext(w.currentPath)
+		backup string
+	)
+	// rotations within the same millisecond get the following free milliseconds
+	for {
+		backup = strings.TrimSuffix(w.currentPath, ext) + "-" + t.Format(backupTimeFormat) + ext
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			break
+		}
+		t = t.Add(time.Millisecond)
+	}
+	if err := os.Rename(w.currentPath, backup); err != nil {
+		intlog.Errorf(context.Background(), "failed to rotate log file %s: %v", w.currentPath, err)
+	}
+	if err := w.openFile(t); err != nil {
+		return err
+	}
+	w.mill()
 	return nil
 }
 
-// formatFilePath formats the file path based on the current date.
+// formatFilePath formats the file path based on time `t`.
 func (w *fileWriter) formatFilePath(t time.Time) string {
-	// Replace date placeholders
-	pattern := w.filePattern
-	pattern = strings.ReplaceAll(pattern, "{Y}", t.Format("2006"))
-	pattern = strings.ReplaceAll(pattern, "{y}", t.Format("06"))
-	pattern = strings.ReplaceAll(pattern, "{m}", t.Format("01"))
-	pattern = strings.ReplaceAll(pattern, "{d}", t.Format("02"))
-	pattern = strings.ReplaceAll(pattern, "{H}", t.Format("15"))
-	pattern = strings.ReplaceAll(pattern, "{i}", t.Format("04"))
-	pattern = strings.ReplaceAll(pattern, "{s}", t.Format("05"))
-
-	// Combine with base path
-	return filepath.Join(filepath.Dir(w.basePath), pattern)
-}
-
-// cleanupRoutine periodically cleans up old log files.
-func (w *fileWriter) cleanupRoutine() {
+	return filepath.Join(w.dir, formatDatePattern(w.filePattern, t))
+}
+
+// mill signals the mill goroutine to compress and clean up the rotated files.
+func (w *fileWriter) mill() {
+	if !w.rotation.Compress && w.rotation.MaxBackups <= 0 && w.rotation.MaxAge <= 0 {
+		return
+	}
+	select {
+	case w.millChan <- struct{}{}:
+	default:
+	}
+}
+
+// millRoutine compresses and cleans up the rotated files in background,
+// and periodically removes the files exceeding the max age.
+func (w *fileWriter) millRoutine() {
+	defer close(w.millDone)
 	ticker := time.NewTicker(time.Hour) // Check every hour
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-w.millChan:
+			w.millRun()
 		case <-ticker.C:
-			w.cleanup()
+			if w.rotation.MaxAge > 0 {
+				w.millRun()
+			}
 		case <-w.stopChan:
 			return
 		}
 	}
 }
 
-// cleanup removes old log files based on autoClean setting.
-func (w *fileWriter) cleanup() {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+// RetentionPlan lists the rotated log files that the cleanup removes and compresses.
+type RetentionPlan struct {
+	Remove   []string // files exceeding the max backups or age
+	Compress []string // kept files to gzip
+}
 
-	// Skip if auto cleanup is not enabled or not in date mode
-	if w.autoClean <= 0 || !w.isDateMode {
+// millRun compresses the rotated files and removes the ones exceeding the max backups or age.
+func (w *fileWriter) millRun() {
+	plan, err := w.retentionPlan()
+	if err != nil {
+		intlog.Errorf(context.Background(), "failed to list rotated log files: %v", err)
 		return
 	}
 
-	// Convert date pattern to regex pattern for matching
-	regexPattern := convertDatePatternToRegex(w.filePattern)
-	re, err := regexp.Compile(regexPattern)
-	if err != nil {
-		// Log error but continue
-		fmt.Printf("Invalid pattern for cleanup: %s, error: %v\n", w.filePattern, err)
-		return
+	for _, path := range plan.Remove {
+		// the active file may have changed since the plan
+		if w.isActive(path) {
+			continue
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			intlog.Errorf(context.Background(), "failed to remove log file %s: %v", path, err)
+		}
+	}
+	for _, path := range plan.Compress {
+		if w.isActive(path) {
+			continue
+		}
+		if err = compressFile(path); err != nil {
+			intlog.Errorf(context.Background(), "failed to compress log file %s: %v", path, err)
+		}
 	}
+}
+
+// retentionPlan returns the rotated files exceeding the max backups or age, and the
+// kept ones to compress. The active file is never part of it.
+func (w *fileWriter) retentionPlan() (RetentionPlan, error) {
+	w.mu.Lock()
+	currentPath := w.currentPath
+	w.mu.Unlock()
 
-	// Get all files in directory
-	dir := filepath.Dir(w.basePath)
-	files, err := os.ReadDir(dir)
+	var plan RetentionPlan
+	backups, err := w.backupFiles(currentPath)
 	if err != nil {
-		// Log error but continue
-		fmt.Printf("Failed to read directory for cleanup: %s, error: %v\n", dir, err)
-		return
+		return plan, err
 	}
-
 	now := time.Now()
-	maxAge := time.Duration(w.autoClean) * 24 * time.Hour // Convert to days
+	for i, backup := range backups {
+		switch {
+		case w.rotation.MaxBackups > 0 && i >= w.rotation.MaxBackups,
+			w.rotation.MaxAge > 0 && now.Sub(backup.modTime) > w.rotation.MaxAge:
+			plan.Remove = append(plan.Remove, backup.path)
+		case w.rotation.Compress && !strings.HasSuffix(backup.path, compressSuffix):
+			plan.Compress = append(plan.Compress, backup.path)
+		}
+	}
+	return plan, nil
+}
+
+// isActive reports whether `path` is the file currently written.
+func (w *fileWriter) isActive(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return path == w.currentPath
+}
+
+// logBackup is a rotated log file.
+type logBackup struct {
+	path    string
+	modTime time.Time
+}
 
-	for _, file := range files {
-		if file.IsDir() {
+// backupFiles returns the rotated log files in the directory of `currentPath`,
+// sorted by modification time from newest to oldest.
+func (w *fileWriter) backupFiles(currentPath string) ([]logBackup, error) {
+	dir := filepath.Dir(currentPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(convertDatePatternToRegex(filepath.Base(w.filePattern)))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []logBackup
+	for _, entry := range entries {
+		if entry.IsDir() || !re.MatchString(entry.Name()) {
 			continue
 		}
-
-		// Check if file matches pattern
-		if !re.MatchString(file.Name()) {
+		path := filepath.Join(dir, entry.Name())
+		if path == currentPath {
 			continue
 		}
-
-		// Check file age
-		info, err := file.Info()
+		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
+		backups = append(backups, logBackup{path: path, modTime: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+	return backups, nil
+}
 
-		if now.Sub(info.ModTime()) > maxAge {
-			filePath := filepath.Join(dir, file.Name())
-			// Skip current file
-			if filePath == w.currentPath {
-				continue
-			}
-			if err := os.Remove(filePath); err != nil {
-				// Log error but continue
-				fmt.Printf("Failed to remove file %s: %v\n", filePath, err)
-			}
-		}
+// compressFile compresses the file `path` to "path.gz" and removes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
 	}
+	defer src.Close()
 
-	w.lastCleanup = now
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	_ = os.Chtimes(path+compressSuffix, info.ModTime(), info.ModTime())
+	return os.Remove(path)
 }
 
-// convertDatePatternToRegex converts a date pattern to a regex pattern.
+// formatDatePattern replaces the date placeholders like "{Y}" and "{Y-m-d}" in `pattern` with time `t`.
+func formatDatePattern(pattern string, t time.Time) string {
+	return datePlaceholderRegex.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		var b strings.Builder
+		for _, c := range placeholder[1 : len(placeholder)-1] {
+			if layout, ok := dateLayouts[c]; ok {
+				b.WriteString(t.Format(layout[0]))
+			} else {
+				b.WriteRune(c)
+			}
+		}
+		return b.String()
+	})
+}
+
+// convertDatePatternToRegex converts a file name pattern to a regex pattern matching
+// the files of the pattern and their rotated, possibly compressed, backups.
 func convertDatePatternToRegex(pattern string) string {
-	pattern = regexp.QuoteMeta(pattern)
-	pattern = strings.ReplaceAll(pattern, "\\{Y\\}", "\\d{4}")
-	pattern = strings.ReplaceAll(pattern, "\\{y\\}", "\\d{2}")
-	pattern = strings.ReplaceAll(pattern, "\\{m\\}", "\\d{2}")
-	pattern = strings.ReplaceAll(pattern, "\\{d\\}", "\\d{2}")
-	pattern = strings.ReplaceAll(pattern, "\\{H\\}", "\\d{2}")
-	pattern = strings.ReplaceAll(pattern, "\\{i\\}", "\\d{2}")
-	pattern = strings.ReplaceAll(pattern, "\\{s\\}", "\\d{2}")
-	return "^" + pattern + "$"
+	var (
+		ext  = filepath.Ext(datePlaceholderRegex.ReplaceAllString(pattern, ""))
+		stem = strings.TrimSuffix(pattern, ext)
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range datePlaceholderRegex.FindAllStringIndex(stem, -1) {
+		b.WriteString(regexp.QuoteMeta(stem[last:loc[0]]))
+		for _, c := range stem[loc[0]+1 : loc[1]-1] {
+			if layout, ok := dateLayouts[c]; ok {
+				b.WriteString(layout[1])
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(stem[last:]))
+	return "^" + b.String() + `(-\d{8}T\d{6}\.\d{3})?` + regexp.QuoteMeta(ext) + `(\.gz)?$`
 }
 
 // IsDatePattern checks if a file pattern contains date placeholders.
 func IsDatePattern(pattern string) bool {
-	return strings.Contains(pattern, "{") && strings.Contains(pattern, "}")
+	return datePlaceholderRegex.MatchString(pattern)
 }
@@ -1,29 +1,58 @@
 package mlog
 
 import (
+	"context"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Hook defines the log hook interface.
+//
+// Hooks run synchronously before the entry is written, in the order they are added,
+// and they may modify the entry. They must be fast and must not block, as they delay
+// the logging call; offload slow work like network calls to a goroutine.
+// The error returned by Fire is reported to stderr and does not prevent the entry from being written.
+// The entry is only valid during Fire, as the following hooks and the formatters still use it;
+// the hooks processing it later, like in a goroutine, must retain a copy made by Entry.Clone.
 type Hook interface {
 	// Levels returns the log levels that the hook applies to.
 	Levels() []Level
-	// Fire executes the hook when a log entry is written.
-	Fire(entry *Entry) error
+	// Fire executes the hook before a log entry is written.
+	Fire(ctx context.Context, entry *Entry) error
 }
 
 // Entry represents a log entry.
 type Entry struct {
+	// log time
+	Time time.Time
 	// log level
 	Level Level
 	// log message
 	Message string
-	// log fields
-	Data map[string]interface{}
-	// raw logrus entry (not exposed to users)
-	raw *logrus.Entry
+	// log fields, modifications are written; they include the caller fields
+	// FieldCaller and FieldFunc if caller reporting is enabled
+	Data Fields
+}
+
+// Clone returns a copy of the entry which is safe to retain after Fire returns.
+// The values of the fields are not copied.
+func (e *Entry) Clone() *Entry {
+	clone := *e
+	if e.Data != nil {
+		clone.Data = make(Fields, len(e.Data))
+		for key, value := range e.Data {
+			clone.Data[key] = value
+		}
+	}
+	return &clone
+}
+
+// AllLevels returns all the log levels, for hooks applying to all levels.
+func AllLevels() []Level {
+	return []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
 }
 
 // logrusHook is an adapter for logrus.Hook.
@@ -36,33 +65,72 @@ func (h *logrusHook) Levels() []logrus.Level {
 	levels := h.hook.Levels()
 	logrusLevels := make([]logrus.Level, len(levels))
 	for i, level := range levels {
-		logrusLevels[i] = logrus.Level(level)
+		logrusLevels[i] = level.toLogrusLevel()
 	}
 	return logrusLevels
 }
 
 // Fire implements the logrus.Hook interface.
 func (h *logrusHook) Fire(entry *logrus.Entry) error {
-	// create mlog.Entry
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if entry.Data == nil {
+		entry.Data = make(logrus.Fields)
+	}
+
+	// create mlog.Entry sharing the data, so that the modifications are written
 	e := &Entry{
-		Level:   Level(entry.Level),
+		Time:    entry.Time,
+		Level:   fromLogrusLevel(entry.Level),
 		Message: entry.Message,
-		Data:    make(map[string]interface{}),
-		raw:     entry,
+		Data:    Fields(entry.Data),
 	}
 
-	// copy data
-	for k, v := range entry.Data {
-		e.Data[k] = v
+	// call user's hook
+	err := h.hook.Fire(ctx, e)
+
+	entry.Time = e.Time
+	entry.Message = e.Message
+	if e.Data == nil {
+		e.Data = Fields{}
 	}
+	entry.Data = logrus.Fields(e.Data)
+	return err
+}
 
-	// call user's hook
-	return h.hook.Fire(e)
+// loggerHooks are the hooks of a logger, which are set on the logrus logger as a whole
+// so that they are never modified while the entries fire them.
+type loggerHooks struct {
+	mu    sync.Mutex
+	hooks []Hook
 }
 
-// AddHook adds a log hook.
+// set replaces the hooks with `hooks` and sets them on `parent`.
+func (h *loggerHooks) set(parent *logrus.Logger, hooks []Hook) {
+	levelHooks := make(logrus.LevelHooks)
+	for _, hook := range hooks {
+		levelHooks.Add(&logrusHook{hook: hook})
+	}
+	h.hooks = hooks
+	parent.ReplaceHooks(levelHooks)
+}
+
+// list returns the hooks, in the order they were added.
+func (h *loggerHooks) list() []Hook {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Hook(nil), h.hooks...)
+}
+
+// AddHook adds a log hook. The hooks implementing WriteFailureHook also observe the failed
+// writes of the outputs.
 func (l *Logger) AddHook(hook Hook) {
-	l.parent.AddHook(&logrusHook{hook: hook})
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+	l.hooks.set(l.parent, append(append([]Hook(nil), l.hooks.hooks...), hook))
+	l.failures.addHook(hook)
 }
 
 // RemoveHookByType removes hooks of a specific type.
@@ -70,24 +138,14 @@ func (l *Logger) AddHook(hook Hook) {
 func (l *Logger) RemoveHookByType(hookType Hook) {
 	typeToRemove := reflect.TypeOf(hookType)
 
-	// Iterate through all levels and hooks
-	for level, hooks := range l.parent.Hooks {
-		var newHooks []logrus.Hook
-
-		// Keep only hooks that don't match the type to remove
-		for _, hook := range hooks {
-			if lh, ok := hook.(*logrusHook); ok {
-				// Check if the underlying hook is of the type we want to remove
-				if reflect.TypeOf(lh.hook) != typeToRemove {
-					newHooks = append(newHooks, hook)
-				}
-			} else {
-				// Keep hooks that are not logrusHook
-				newHooks = append(newHooks, hook)
-			}
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+	var hooks []Hook
+	for _, hook := range l.hooks.hooks {
+		if reflect.TypeOf(hook) != typeToRemove {
+			hooks = append(hooks, hook)
 		}
-
-		// Update hooks for this level
-		l.parent.Hooks[level] = newHooks
 	}
+	l.hooks.set(l.parent, hooks)
+	l.failures.removeHooksByType(typeToRemove)
 }
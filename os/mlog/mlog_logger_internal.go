@@ -1,61 +1,77 @@
 package mlog
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
 	"github.com/graingo/maltose/net/mtrace"
 )
 
-// traceHook is a hook that automatically adds TraceID.
+const (
+	// FieldTraceID is the field of the trace id of the otel span context.
+	FieldTraceID = "trace_id"
+	// FieldSpanID is the field of the span id of the otel span context.
+	FieldSpanID = "span_id"
+	// FieldRequestID is the field of the request id set by mtrace.WithRequestID,
+	// for example by the mhttp request id middleware.
+	FieldRequestID = "request_id"
+)
+
+// traceHook is a hook that automatically adds the trace id, span id and request id.
 type traceHook struct{}
 
 // Levels implements the Hook interface.
 func (h *traceHook) Levels() []Level {
-	return []Level{
-		DebugLevel,
-		InfoLevel,
-		WarnLevel,
-		ErrorLevel,
-		FatalLevel,
-		PanicLevel,
-	}
+	return AllLevels()
 }
 
 // Fire implements the Hook interface.
-func (h *traceHook) Fire(entry *Entry) error {
-	entry.Data["trace_id"] = mtrace.GetTraceID(entry.raw.Context)
-	entry.Data["span_id"] = mtrace.GetSpanID(entry.raw.Context)
+func (h *traceHook) Fire(ctx context.Context, entry *Entry) error {
+	if traceID := mtrace.GetTraceID(ctx); traceID != "" {
+		entry.Data[FieldTraceID] = traceID
+	}
+	if spanID := mtrace.GetSpanID(ctx); spanID != "" {
+		entry.Data[FieldSpanID] = spanID
+	}
+	if requestID := mtrace.GetRequestID(ctx); requestID != "" {
+		entry.Data[FieldRequestID] = requestID
+	}
 	return nil
 }
 
 // ctxHook is a hook that extracts values from context.
 type ctxHook struct {
-	keys []string
+	keys atomic.Pointer[[]any]
 }
 
 // Levels implements the Hook interface.
 func (h *ctxHook) Levels() []Level {
-	return []Level{
-		DebugLevel,
-		InfoLevel,
-		WarnLevel,
-		ErrorLevel,
-		FatalLevel,
-		PanicLevel,
-	}
+	return AllLevels()
 }
 
 // Fire implements the Hook interface.
-func (h *ctxHook) Fire(entry *Entry) error {
-	ctx := entry.raw.Context
-	if ctx == nil {
+func (h *ctxHook) Fire(ctx context.Context, entry *Entry) error {
+	keys := h.keys.Load()
+	if keys == nil {
 		return nil
 	}
-
-	// Extract values from context for each key
-	for _, key := range h.keys {
+	// Extract values from context for each key, missing keys are omitted
+	for _, key := range *keys {
 		if value := ctx.Value(key); value != nil {
-			entry.Data[key] = value
+			entry.Data[ctxKeyName(key)] = value
 		}
 	}
-
 	return nil
 }
+
+// ctxKeyName returns the field name of the context key `key`.
+func ctxKeyName(key any) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	}
+	return fmt.Sprint(key)
+}
@@ -1,6 +1,11 @@
 package mlog
 
 import (
+	"strings"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/mconv"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,12 +20,135 @@ const (
 	PanicLevel
 )
 
-// SetLevel sets the logging level.
+// levelNames maps the levels to their names.
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+	FatalLevel: "fatal",
+	PanicLevel: "panic",
+}
+
+// ParseLevel parses the level name like "debug", "info", "warn", "error", "fatal"
+// and "panic", case-insensitively. "warning" is accepted as an alias of "warn".
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return WarnLevel, nil
+	}
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return InfoLevel, merror.NewCodef(mcode.CodeInvalidParameter, `invalid level: %s`, s)
+}
+
+// String returns the name of the level.
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (level Level) MarshalText() ([]byte, error) {
+	if _, ok := levelNames[level]; !ok {
+		return nil, merror.NewCodef(mcode.CodeInvalidParameter, `invalid level: %d`, int(level))
+	}
+	return []byte(level.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (level *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*level = parsed
+	return nil
+}
+
+// toLogrusLevel converts the level to the logrus level, whose ordering is reversed.
+func (level Level) toLogrusLevel() logrus.Level {
+	switch level {
+	case DebugLevel:
+		return logrus.DebugLevel
+	case InfoLevel:
+		return logrus.InfoLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	case ErrorLevel:
+		return logrus.ErrorLevel
+	case FatalLevel:
+		return logrus.FatalLevel
+	case PanicLevel:
+		return logrus.PanicLevel
+	}
+	if level < DebugLevel {
+		return logrus.TraceLevel
+	}
+	return logrus.PanicLevel
+}
+
+// fromLogrusLevel converts the logrus level to the level.
+func fromLogrusLevel(level logrus.Level) Level {
+	switch level {
+	case logrus.PanicLevel:
+		return PanicLevel
+	case logrus.FatalLevel:
+		return FatalLevel
+	case logrus.ErrorLevel:
+		return ErrorLevel
+	case logrus.WarnLevel:
+		return WarnLevel
+	case logrus.InfoLevel:
+		return InfoLevel
+	}
+	return DebugLevel
+}
+
+// toLevel converts the config value `v` to a level, it accepts levels, level names and integers.
+func toLevel(v any) (Level, error) {
+	switch value := v.(type) {
+	case Level:
+		return value, nil
+	case string:
+		return ParseLevel(value)
+	case []byte:
+		return ParseLevel(string(value))
+	case int:
+		return Level(value), nil
+	}
+	return ParseLevel(mconv.ToString(v))
+}
+
+// SetLevel sets the logging level, messages below the level are dropped before they are formatted.
+// It is safe to call concurrently with logging, and applies to the child loggers created by With.
+//
+// For a named logger, like Instance("app"), it also applies to the loggers below it in the
+// hierarchy which have no level of their own, like Instance("app.db"), see Instance.
 func (l *Logger) SetLevel(level Level) {
-	l.parent.SetLevel(logrus.Level(level))
+	if l.node == nil {
+		l.level.Store(int32(level))
+		return
+	}
+	hierarchyMu.Lock()
+	defer hierarchyMu.Unlock()
+	l.node.levelSet = true
+	l.level.Store(int32(level))
+	l.node.inheritLevel(level)
 }
 
 // GetLevel returns the logging level value.
 func (l *Logger) GetLevel() Level {
-	return Level(l.parent.GetLevel())
+	return Level(l.level.Load())
+}
+
+// IsLevelEnabled checks whether messages at `level` are logged, it is false for all
+// the levels once the logger is closed. It is safe for concurrent use and does not allocate.
+func (l *Logger) IsLevelEnabled(level Level) bool {
+	return level >= Level(l.level.Load()) && !l.out.closed.Load()
 }
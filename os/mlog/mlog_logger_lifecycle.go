@@ -0,0 +1,162 @@
+package mlog
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// flusher is implemented by the writers buffering their content, like bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// syncer is implemented by the writers committing their content to stable storage, like os.File.
+type syncer interface {
+	Sync() error
+}
+
+// reopener is implemented by the writers reopening their files, like the file output of the logger.
+type reopener interface {
+	Reopen() error
+}
+
+// Flush writes all the entries queued by the async mode synchronously, retries the entries
+// buffered after a failed write, see SetWriteRetry, and flushes the writers added by AddWriter
+// which buffer their content, that is which have a method Flush() error, like bufio.Writer.
+func (l *Logger) Flush() error {
+	l.out.mu.Lock()
+	async, fallbacks := l.out.async, l.out.fallbacks
+	l.out.mu.Unlock()
+	if async != nil {
+		async.Flush()
+	}
+
+	errs := []error{flushFallbacks(fallbacks)}
+	for _, w := range l.dispatch.addedWriters() {
+		if f, ok := w.(flusher); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync flushes the logger like Flush, then commits the log file and the writers added by
+// AddWriter which have a method Sync() error, like os.File, to stable storage.
+// Call it before the process exits to make sure that the entries are on disk.
+func (l *Logger) Sync() error {
+	errs := []error{l.Flush()}
+
+	l.out.mu.Lock()
+	file := l.out.file
+	l.out.mu.Unlock()
+	if file != nil {
+		errs = append(errs, file.Sync())
+	}
+	for _, w := range l.dispatch.addedWriters() {
+		if s, ok := w.(syncer); ok && !isStdStream(w) {
+			errs = append(errs, s.Sync())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the sampling and the deduplication, flushes the queued entries, closes the file
+// output and the writers added by AddWriter which implement io.Closer, except stdout and stderr.
+//
+// The logger and its child loggers are unusable once closed: the following entries are
+// discarded, IsLevelEnabled reports false for all the levels, and closing it again does nothing.
+func (l *Logger) Close() error {
+	if !l.out.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	l.out.mu.Lock()
+	file, async, fallbacks := l.out.file, l.out.async, l.out.fallbacks
+	l.out.file, l.out.async, l.out.fallbacks = nil, nil, nil
+	l.out.mu.Unlock()
+
+	// stop the sampler and the deduper first, so that their last summaries are written
+	if s := l.sampler.Swap(nil); s != nil {
+		s.close()
+	}
+	if d := l.dedup.Swap(nil); d != nil {
+		d.close()
+	}
+
+	var errs []error
+	if async != nil {
+		errs = append(errs, async.Close())
+	}
+	l.parent.SetOutput(io.Discard)
+	for _, fb := range fallbacks {
+		errs = append(errs, fb.Close())
+	}
+	if file != nil {
+		errs = append(errs, file.Close())
+	}
+	for _, w := range l.dispatch.addedWriters() {
+		if f, ok := w.(flusher); ok {
+			errs = append(errs, f.Flush())
+		}
+		if c, ok := w.(io.Closer); ok && !isStdStream(w) {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CloseAll closes the default logger and all the logger instances, see Logger.Close.
+// It is meant for the shutdown of the process, as the closed loggers discard the following entries.
+func CloseAll() error {
+	errs := []error{defaultLogger.Close()}
+	for _, name := range instanceNames() {
+		errs = append(errs, Instance(name).Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ReopenFiles reopens the log file at its configured path, and the writers added by AddWriter
+// which have a method Reopen() error, for the external rotation tools like logrotate which
+// rename the file and expect the process to reopen it. The concurrent entries are written
+// entirely either to the previous file or to the new one, see also HandleSIGHUP.
+func (l *Logger) ReopenFiles() error {
+	l.out.mu.Lock()
+	file := l.out.file
+	l.out.mu.Unlock()
+
+	var errs []error
+	if file != nil {
+		errs = append(errs, file.Reopen())
+	}
+	for _, w := range l.dispatch.addedWriters() {
+		if r, ok := w.(reopener); ok {
+			errs = append(errs, r.Reopen())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReopenAll reopens the files of the default logger and all the logger instances, see Logger.ReopenFiles.
+func ReopenAll() error {
+	errs := []error{defaultLogger.ReopenFiles()}
+	for _, name := range instanceNames() {
+		errs = append(errs, Instance(name).ReopenFiles())
+	}
+	return errors.Join(errs...)
+}
+
+// addedWriters returns the writers added by Logger.AddWriter.
+func (f *dispatchFormatter) addedWriters() []io.Writer {
+	var writers []io.Writer
+	for _, lw := range *f.writers.Load() {
+		if lw.preset == "" {
+			writers = append(writers, lw.writer)
+		}
+	}
+	return writers
+}
+
+// isStdStream reports whether `w` is stdout or stderr, which are never synced nor closed by the logger.
+func isStdStream(w io.Writer) bool {
+	return w == os.Stdout || w == os.Stderr
+}
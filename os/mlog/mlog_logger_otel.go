@@ -0,0 +1,154 @@
+package mlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelScopeName is the instrumentation scope of the records emitted by OtelHook.
+const otelScopeName = "github.com/graingo/maltose/os/mlog"
+
+// otelSeverities maps the levels to the OpenTelemetry severity numbers:
+//
+//	DebugLevel -> SeverityDebug  (5)
+//	InfoLevel  -> SeverityInfo   (9)
+//	WarnLevel  -> SeverityWarn   (13)
+//	ErrorLevel -> SeverityError  (17)
+//	FatalLevel -> SeverityFatal  (21)
+//	PanicLevel -> SeverityFatal4 (24)
+var otelSeverities = map[Level]otellog.Severity{
+	DebugLevel: otellog.SeverityDebug,
+	InfoLevel:  otellog.SeverityInfo,
+	WarnLevel:  otellog.SeverityWarn,
+	ErrorLevel: otellog.SeverityError,
+	FatalLevel: otellog.SeverityFatal,
+	PanicLevel: otellog.SeverityFatal4,
+}
+
+// OtelHook is a hook exporting the entries as OpenTelemetry log records, see NewOtelHook.
+type OtelHook struct {
+	logger otellog.Logger
+}
+
+// NewOtelHook creates a hook emitting the entries through the OpenTelemetry logs API of
+// `provider`, or of the global logger provider if nil:
+//
+//	logger.AddHook(mlog.NewOtelHook(provider))
+//
+// The records carry the span context of the logging context, the severity mapped from the
+// level (see otelSeverities), the message as body and the fields as attributes, except the
+// trace and span ids which are already in the span context. The entries at error level and
+// above are also recorded as "log" events of the recording span of the context.
+//
+// It is added with the global provider by the config "otel".
+func NewOtelHook(provider otellog.LoggerProvider) *OtelHook {
+	if provider == nil {
+		provider = global.GetLoggerProvider()
+	}
+	return &OtelHook{logger: provider.Logger(otelScopeName)}
+}
+
+// Levels implements the Hook interface.
+func (h *OtelHook) Levels() []Level {
+	return AllLevels()
+}
+
+// Fire implements the Hook interface.
+func (h *OtelHook) Fire(ctx context.Context, entry *Entry) error {
+	severity := otelSeverities[entry.Level]
+	if !h.logger.Enabled(ctx, otellog.EnabledParameters{Severity: severity}) {
+		h.addSpanEvent(ctx, entry)
+		return nil
+	}
+
+	var record otellog.Record
+	record.SetTimestamp(entry.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(severity)
+	record.SetSeverityText(entry.Level.String())
+	record.SetBody(otellog.StringValue(entry.Message))
+	for key, value := range entry.Data {
+		if key == FieldTraceID || key == FieldSpanID {
+			continue
+		}
+		record.AddAttributes(otellog.KeyValue{Key: key, Value: otelValue(value)})
+	}
+	h.logger.Emit(ctx, record)
+	h.addSpanEvent(ctx, entry)
+	return nil
+}
+
+// addSpanEvent records the entries at error level and above as events of the recording span of `ctx`.
+func (h *OtelHook) addSpanEvent(ctx context.Context, entry *Entry) {
+	if entry.Level < ErrorLevel {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.AddEvent("log", trace.WithTimestamp(entry.Time), trace.WithAttributes(
+		attribute.String("log.severity", entry.Level.String()),
+		attribute.String("log.message", entry.Message),
+	))
+}
+
+// otelValue converts the field value `v` to a log value, the unsupported types are formatted with fmt.
+func otelValue(v any) otellog.Value {
+	switch value := v.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(value)
+	case bool:
+		return otellog.BoolValue(value)
+	case int:
+		return otellog.IntValue(value)
+	case int8:
+		return otellog.Int64Value(int64(value))
+	case int16:
+		return otellog.Int64Value(int64(value))
+	case int32:
+		return otellog.Int64Value(int64(value))
+	case int64:
+		return otellog.Int64Value(value)
+	case uint8:
+		return otellog.Int64Value(int64(value))
+	case uint16:
+		return otellog.Int64Value(int64(value))
+	case uint32:
+		return otellog.Int64Value(int64(value))
+	case float32:
+		return otellog.Float64Value(float64(value))
+	case float64:
+		return otellog.Float64Value(value)
+	case []byte:
+		return otellog.BytesValue(value)
+	case error:
+		return otellog.StringValue(value.Error())
+	case fmt.Stringer:
+		return otellog.StringValue(value.String())
+	}
+	return otellog.StringValue(fmt.Sprint(v))
+}
+
+// SetOtel enables exporting the entries through the global OpenTelemetry logger provider, see NewOtelHook.
+func (l *Logger) SetOtel(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"otel": enabled,
+	})
+}
+
+// setOtelHook adds or removes the OtelHook of the config "otel".
+func (l *Logger) setOtelHook() {
+	l.RemoveHookByType(&OtelHook{})
+	if l.config.Otel {
+		l.AddHook(NewOtelHook(nil))
+	}
+}
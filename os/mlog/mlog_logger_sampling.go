@@ -0,0 +1,212 @@
+package mlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FieldSampledMessage is the field of the sampled message in the summary of the suppressed entries.
+	FieldSampledMessage = "sampled_message"
+	// FieldSuppressed is the field of the number of suppressed entries in the summary.
+	FieldSuppressed = "suppressed"
+
+	// samplerSlots is the number of counters per level, messages with the same hash share a counter.
+	samplerSlots = 1024
+)
+
+// defaultSamplingLevels are the levels sampled by default, errors are never sampled by default.
+var defaultSamplingLevels = []Level{DebugLevel, InfoLevel, WarnLevel}
+
+// samplerSlot counts the entries of a message within the current tick.
+type samplerSlot struct {
+	resetAt atomic.Int64  // end of the current tick in unix nanoseconds
+	count   atomic.Uint64 // entries within the current tick
+	dropped atomic.Uint64 // entries dropped since the last summary
+	message atomic.Pointer[string]
+}
+
+// sampler limits the entries per message and level: within each tick, the first `initial`
+// entries are logged, then every `thereafter`th one. The counters are atomic, so the
+// concurrent logging calls do not contend on a lock.
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	tick       time.Duration
+	levels     [PanicLevel + 1]bool
+	slots      [PanicLevel + 1][]samplerSlot
+	stop       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// newSampler creates a sampler and starts the goroutine writing the summaries to `logger`.
+func newSampler(initial, thereafter int, tick time.Duration, levels []Level, logger *logrus.Logger) *sampler {
+	s := &sampler{
+		initial:    uint64(initial),
+		thereafter: uint64(max(thereafter, 0)),
+		tick:       tick,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, level := range levels {
+		// fatal and panic entries are never sampled, as they terminate the call
+		if level >= DebugLevel && level <= ErrorLevel {
+			s.levels[level] = true
+			s.slots[level] = make([]samplerSlot, samplerSlots)
+		}
+	}
+	go s.run(logger)
+	return s
+}
+
+// allow reports whether the entry at `level` with message `msg` is logged.
+func (s *sampler) allow(level Level, msg string) bool {
+	if level < DebugLevel || level > PanicLevel || !s.levels[level] {
+		return true
+	}
+	slot := &s.slots[level][hashMessage(msg)%samplerSlots]
+
+	now := time.Now().UnixNano()
+	if resetAt := slot.resetAt.Load(); now > resetAt && slot.resetAt.CompareAndSwap(resetAt, now+int64(s.tick)) {
+		slot.count.Store(1)
+		return true
+	}
+	n := slot.count.Add(1)
+	if n <= s.initial || (s.thereafter > 0 && (n-s.initial)%s.thereafter == 0) {
+		return true
+	}
+	if slot.dropped.Add(1) == 1 {
+		slot.message.Store(&msg)
+	}
+	return false
+}
+
+// run writes the summaries of the suppressed entries every tick, until the sampler is stopped.
+func (s *sampler) run(logger *logrus.Logger) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.summarize(logger)
+		case <-s.stop:
+			s.summarize(logger)
+			return
+		}
+	}
+}
+
+// summarize writes a summary entry for each message with suppressed entries.
+func (s *sampler) summarize(logger *logrus.Logger) {
+	for level, slots := range s.slots {
+		for i := range slots {
+			slot := &slots[i]
+			if slot.dropped.Load() == 0 {
+				continue
+			}
+			var message string
+			if msg := slot.message.Swap(nil); msg != nil {
+				message = *msg
+			}
+			dropped := slot.dropped.Swap(0)
+			logger.WithContext(context.Background()).WithFields(logrus.Fields{
+				FieldSampledMessage: message,
+				FieldSuppressed:     dropped,
+			}).Log(Level(level).toLogrusLevel(), fmt.Sprintf("suppressed %d similar entries", dropped))
+		}
+	}
+}
+
+// close stops the sampler, writing the last summaries.
+func (s *sampler) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+}
+
+// hashMessage returns the FNV-1a hash of `msg`.
+func hashMessage(msg string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= 16777619
+	}
+	return h
+}
+
+// SetSampling limits the volume of repeated entries: within each `tick`, the first `initial`
+// entries with the same message and level are logged, then every `thereafter`th one,
+// and the others are dropped. The number of dropped entries is reported every tick by
+// a summary entry "suppressed N similar entries". The message of the formatting methods
+// like Infof is their format. An `initial` or `tick` of 0 disables sampling.
+//
+// Only the debug, info and warn levels are sampled by default, see SetSamplingLevels.
+func (l *Logger) SetSampling(initial, thereafter int, tick time.Duration) {
+	l.SetConfigWithMap(map[string]any{
+		"sampling_initial":    initial,
+		"sampling_thereafter": thereafter,
+		"sampling_tick":       tick,
+	})
+}
+
+// SetSamplingLevels sets the levels sampled by SetSampling, fatal and panic are never sampled.
+func (l *Logger) SetSamplingLevels(levels ...Level) {
+	l.SetConfigWithMap(map[string]any{
+		"sampling_levels": levels,
+	})
+}
+
+// setSampler replaces the sampler according to the config, stopping the previous one.
+func (l *Logger) setSampler() {
+	var s *sampler
+	if l.config.SamplingInitial > 0 && l.config.SamplingTick > 0 {
+		s = newSampler(
+			l.config.SamplingInitial, l.config.SamplingThereafter, l.config.SamplingTick,
+			l.config.SamplingLevels, l.parent,
+		)
+	}
+	if prev := l.sampler.Swap(s); prev != nil {
+		prev.close()
+	}
+}
+
+// dropSampled reports whether the entry at `level` with message `msg` is dropped by sampling.
+func (l *Logger) dropSampled(level Level, msg string) bool {
+	s := l.sampler.Load()
+	return s != nil && !s.allow(level, msg)
+}
+
+// dropSampledArgs reports whether the entry at `level` with message `v` is dropped by sampling.
+func (l *Logger) dropSampledArgs(level Level, v []any) bool {
+	s := l.sampler.Load()
+	if s == nil || !s.levels[level] {
+		return false
+	}
+	// the message is the first argument if it is a string followed by fields only
+	if len(v) == 0 {
+		return !s.allow(level, "")
+	}
+	if msg, ok := v[0].(string); ok {
+		messageOnly := true
+		for _, arg := range v[1:] {
+			if _, isField := arg.(Field); !isField {
+				messageOnly = false
+				break
+			}
+		}
+		if messageOnly {
+			return !s.allow(level, msg)
+		}
+	}
+	args, _ := splitFields(v)
+	return !s.allow(level, fmt.Sprint(args...))
+}
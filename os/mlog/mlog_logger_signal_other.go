@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package mlog
+
+// HandleSIGHUP does nothing, as there is no SIGHUP on this system: call ReopenAll explicitly
+// to reopen the log files after they are renamed.
+func HandleSIGHUP() (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !plan9
+
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// HandleSIGHUP reopens the files of the default logger and all the logger instances on SIGHUP,
+// see ReopenAll, which is the signal sent by logrotate after renaming the files. The errors are
+// written to stderr. It returns a function stopping the handling.
+//
+// On the systems without SIGHUP, like Windows, it does nothing and ReopenAll is called explicitly.
+func HandleSIGHUP() (stop func()) {
+	var (
+		signals = make(chan os.Signal, 1)
+		done    = make(chan struct{})
+		once    sync.Once
+	)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if err := ReopenAll(); err != nil {
+					fmt.Fprintf(os.Stderr, "mlog: failed to reopen the log files: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
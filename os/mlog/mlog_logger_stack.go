@@ -0,0 +1,132 @@
+package mlog
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graingo/maltose/errors/merror"
+)
+
+const (
+	// FieldError is the field of the error, see Err.
+	FieldError = "error"
+	// FieldStack is the field of the stack of the error, see StackField and SetErrorStack.
+	FieldStack = "stack"
+	// FieldErrorCaller is the field of the location where the error was created, see SetErrorStack.
+	FieldErrorCaller = "error_caller"
+
+	// defaultErrorStackDepth is the default maximum number of frames of the error stacks.
+	defaultErrorStackDepth = 32
+)
+
+// StackField creates a field with key "stack" and the stacks of the chain of `err` as value, formatted as
+// one "function\n\tfile:line" pair per frame followed by the errors wrapped in the frame, see merror.Format.
+// The first stack is the one captured where the error originated, each stack is limited to 32 frames.
+// Errors without stack just give their message.
+func StackField(err error) Field {
+	if stack := formatErrorStack(err, defaultErrorStackDepth); stack != "" {
+		return String(FieldStack, stack)
+	}
+	return NamedErr(FieldStack, err)
+}
+
+// SetErrorStack enables or disables adding the fields "stack" and "error_caller", the "file:line"
+// where the error was created, to the entries at error level and above whose "error" field, see Err,
+// is an merror carrying a stack. Plain errors just log their message. See also SetErrorStackDepth.
+func (l *Logger) SetErrorStack(enabled bool) {
+	l.SetConfigWithMap(map[string]any{
+		"error_stack": enabled,
+	})
+}
+
+// SetErrorStackDepth sets the maximum number of frames of the error stacks, 32 by default.
+func (l *Logger) SetErrorStackDepth(depth int) {
+	l.SetConfigWithMap(map[string]any{
+		"error_stack_depth": depth,
+	})
+}
+
+// setErrorStackDepth stores the depth of the error stacks according to the config, 0 if disabled.
+func (l *Logger) setErrorStackDepth() {
+	var depth int32
+	if l.config.ErrorStack {
+		depth = int32(l.config.ErrorStackDepth)
+		if depth <= 0 {
+			depth = defaultErrorStackDepth
+		}
+	}
+	l.stackDepth.Store(depth)
+}
+
+// errorStack adds the stack and caller of the "error" field of `fields` or `overrides` to `data`,
+// if enabled and `level` is error or above. The stack field set explicitly is kept.
+func (l *Logger) errorStack(data map[string]any, level Level, fields, overrides []Field) map[string]any {
+	if level < ErrorLevel {
+		return data
+	}
+	depth := l.stackDepth.Load()
+	if depth == 0 {
+		return data
+	}
+	if _, ok := data[FieldStack]; ok {
+		return data
+	}
+	var err error
+	for _, list := range [][]Field{fields, overrides} {
+		for _, field := range list {
+			if field.Key == FieldError {
+				err, _ = field.iface.(error)
+			}
+		}
+	}
+	if stack := formatErrorStack(err, int(depth)); stack != "" {
+		data[FieldStack] = stack
+	}
+	if _, ok := data[FieldErrorCaller]; !ok && err != nil {
+		if file, line, _, ok := merror.Caller(err); ok {
+			data[FieldErrorCaller] = file + ":" + strconv.Itoa(line)
+		}
+	}
+	return data
+}
+
+// formatErrorStack formats at most `depth` frames of each stack of the chain of `err`, see merror.Format,
+// it returns an empty string if `err` carries no stack.
+func formatErrorStack(err error, depth int) string {
+	chain := merror.Format(err, merror.FormatOptions{MaxFrames: depth})
+	if chain == nil || len(chain.Stacks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, stack := range chain.Stacks {
+		if i > 0 {
+			// the stacks other than the origin are those of errors wrapping it elsewhere, like in another goroutine
+			b.WriteString("\n\nerror: ")
+			b.WriteString(stack.Error)
+		}
+		for j, frame := range stack.Frames {
+			if i > 0 || j > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+			for _, wrap := range frame.Wraps {
+				b.WriteString("\n\twrapped ")
+				b.WriteString(strconv.Quote(wrap.Message))
+				b.WriteString(" at ")
+				b.WriteString(wrap.File)
+				b.WriteByte(':')
+				b.WriteString(strconv.Itoa(wrap.Line))
+			}
+		}
+		if stack.More > 0 {
+			b.WriteString("\n... ")
+			b.WriteString(strconv.Itoa(stack.More))
+			b.WriteString(" more frames")
+		}
+	}
+	return b.String()
+}
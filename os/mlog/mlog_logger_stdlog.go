@@ -0,0 +1,47 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+)
+
+// stdLoggerCallerSkip skips log.Logger.output and the printing method of log.Logger.
+const stdLoggerCallerSkip = 2
+
+// logWriter is the io.Writer returned by Logger.Writer.
+type logWriter struct {
+	logger *Logger
+	level  Level
+}
+
+// Writer returns an io.Writer converting each Write into entries at `level`, one per line,
+// for the libraries writing their logs to an io.Writer. The trailing newline is trimmed
+// and empty lines are skipped. Levels above ErrorLevel are lowered to ErrorLevel,
+// as the writes must not exit or panic. It is safe for concurrent use.
+func (l *Logger) Writer(level Level) io.Writer {
+	return &logWriter{logger: l, level: min(level, ErrorLevel)}
+}
+
+// StdLogger returns a *log.Logger writing entries at `level` through Writer,
+// for example as the ErrorLog of http.Server. Its caller is reported as the caller
+// of the log.Logger methods.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(l.AddCallerSkip(stdLoggerCallerSkip).Writer(level), "", 0)
+}
+
+// Write implements the io.Writer interface.
+func (w *logWriter) Write(p []byte) (int, error) {
+	if !w.logger.IsLevelEnabled(w.level) {
+		return len(p), nil
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\r\n"), []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 || w.logger.dropSampled(w.level, string(line)) {
+			continue
+		}
+		w.logger.entry(context.Background(), w.level).Log(w.level.toLogrusLevel(), string(line))
+	}
+	return len(p), nil
+}
@@ -0,0 +1,309 @@
+package mlog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+const (
+	// syslogFacilityUser is the syslog facility "user-level messages".
+	syslogFacilityUser = 1
+	// syslogSDID is the id of the RFC 5424 structured data element holding the entry fields.
+	syslogSDID = "mlog@32473"
+	// syslogTimeFormat is the RFC 5424 timestamp format.
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	defaultSyslogMaxPending = 1000
+	minSyslogBackoff        = 100 * time.Millisecond
+	maxSyslogBackoff        = 30 * time.Second
+)
+
+// syslogSeverities maps the levels to the syslog severities.
+var syslogSeverities = map[Level]int{
+	DebugLevel: 7, // debug
+	InfoLevel:  6, // informational
+	WarnLevel:  4, // warning
+	ErrorLevel: 3, // error
+	FatalLevel: 2, // critical
+	PanicLevel: 1, // alert
+}
+
+// SyslogWriter is a writer sending the entries to a syslog server in the RFC 5424 format,
+// with the entry fields as structured data. Add it to a logger with Logger.AddWriter:
+//
+//	w, err := mlog.NewSyslogWriter("udp", "localhost:514", "myapp")
+//	if err != nil {
+//		return err
+//	}
+//	logger.AddWriter(w, mlog.WithMinLevel(mlog.InfoLevel))
+//
+// When the connection is broken, the entries are buffered up to a bounded number,
+// dropping the oldest ones, and the writer reconnects with an exponential backoff
+// on the following writes.
+type SyslogWriter struct {
+	network  string
+	addr     string
+	tag      string
+	hostname string
+	pid      string
+
+	mu         sync.Mutex
+	conn       net.Conn
+	stream     bool     // whether the connection is a stream, whose messages are newline-terminated
+	pending    [][]byte // messages buffered while disconnected
+	maxPending int
+	backoff    time.Duration
+	retryAt    time.Time
+	closed     bool
+	dropped    atomic.Int64
+}
+
+// NewSyslogWriter creates a SyslogWriter connected to the syslog server at `addr` over `network`,
+// like "udp", "tcp" or "unix". An empty network and address connect to the local syslog socket,
+// which is not supported on Windows. The `tag` is the application name of the messages,
+// the program name if empty.
+func NewSyslogWriter(network, addr, tag string) (*SyslogWriter, error) {
+	if tag == "" && len(os.Args) > 0 {
+		tag = filepathBase(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+	w := &SyslogWriter{
+		network:    network,
+		addr:       addr,
+		tag:        syslogHeaderField(tag, 48),
+		hostname:   syslogHeaderField(hostname, 255),
+		pid:        strconv.Itoa(os.Getpid()),
+		maxPending: defaultSyslogMaxPending,
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// SetMaxPending sets the maximum number of entries buffered while disconnected, 1000 by default.
+func (w *SyslogWriter) SetMaxPending(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.maxPending = n
+}
+
+// Dropped returns the number of entries dropped because the buffer was full while disconnected.
+func (w *SyslogWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Write implements the io.Writer interface, it sends `p` with the severity of InfoLevel.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	if err := w.send(time.Now(), InfoLevel, strings.TrimRight(string(p), "\n"), nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements the EntryWriter interface, it sends the message of the entry
+// with the severity of its level and its fields as structured data.
+func (w *SyslogWriter) WriteEntry(entry *Entry, p []byte) error {
+	return w.send(entry.Time, entry.Level, entry.Message, entry.Data)
+}
+
+// Close sends the buffered entries if connected, and closes the connection.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	_ = w.flushPending()
+	return w.conn.Close()
+}
+
+// send sends the message, buffering it if disconnected.
+// It returns the error breaking the connection, the following writes are buffered silently.
+func (w *SyslogWriter) send(t time.Time, level Level, message string, data Fields) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return merror.NewCode(mcode.CodeInvalidOperation, `syslog writer is closed`)
+	}
+
+	w.pending = append(w.pending, w.format(t, level, message, data))
+	if len(w.pending) > w.maxPending {
+		dropped := len(w.pending) - w.maxPending
+		w.pending = append(w.pending[:0], w.pending[dropped:]...)
+		w.dropped.Add(int64(dropped))
+	}
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			return nil
+		}
+		if err := w.connect(); err != nil {
+			w.retryLater()
+			return nil
+		}
+	}
+	if err := w.flushPending(); err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		w.retryLater()
+		return merror.WrapCodef(err, mcode.CodeOperationFailed, `failed to write to syslog %s`, w.addr)
+	}
+	w.backoff = 0
+	return nil
+}
+
+// flushPending sends the buffered messages, keeping the unsent ones.
+func (w *SyslogWriter) flushPending() error {
+	for len(w.pending) > 0 {
+		if _, err := w.conn.Write(w.pending[0]); err != nil {
+			return err
+		}
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+	return nil
+}
+
+// retryLater schedules the next connection attempt with an exponential backoff.
+func (w *SyslogWriter) retryLater() {
+	w.backoff *= 2
+	if w.backoff < minSyslogBackoff {
+		w.backoff = minSyslogBackoff
+	}
+	if w.backoff > maxSyslogBackoff {
+		w.backoff = maxSyslogBackoff
+	}
+	w.retryAt = time.Now().Add(w.backoff)
+}
+
+// connect connects to the syslog server.
+func (w *SyslogWriter) connect() error {
+	var (
+		conn net.Conn
+		err  error
+	)
+	if w.network == "" && w.addr == "" {
+		conn, err = dialLocalSyslog()
+	} else {
+		conn, err = net.DialTimeout(w.network, w.addr, 5*time.Second)
+	}
+	if err != nil {
+		return merror.WrapCodef(err, mcode.CodeOperationFailed, `failed to connect to syslog %s`, w.addr)
+	}
+	w.conn = conn
+	switch conn.LocalAddr().Network() {
+	case "udp", "udp4", "udp6", "unixgram":
+		w.stream = false
+	default:
+		w.stream = true
+	}
+	return nil
+}
+
+// format formats the message in the RFC 5424 format, it must be called with the lock held.
+func (w *SyslogWriter) format(t time.Time, level Level, message string, data Fields) []byte {
+	severity, ok := syslogSeverities[level]
+	if !ok {
+		severity = syslogSeverities[InfoLevel]
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ",
+		syslogFacilityUser*8+severity, t.Format(syslogTimeFormat), w.hostname, w.tag, w.pid,
+	)
+	writeSyslogData(&b, data)
+	b.WriteByte(' ')
+	b.WriteString(message)
+	if w.stream && !strings.HasSuffix(message, "\n") {
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// writeSyslogData writes `data` as a RFC 5424 structured data element, or "-" if empty.
+func writeSyslogData(b *strings.Builder, data Fields) {
+	if len(data) == 0 {
+		b.WriteByte('-')
+		return
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("[" + syslogSDID)
+	for _, key := range keys {
+		b.WriteByte(' ')
+		b.WriteString(syslogParamName(key))
+		b.WriteString(`="`)
+		for _, r := range fmt.Sprint(data[key]) {
+			if r == '"' || r == '\\' || r == ']' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+}
+
+// syslogParamName converts `key` to a valid structured data parameter name,
+// replacing the invalid characters with "_" and truncating it to 32 characters.
+func syslogParamName(key string) string {
+	name := []byte(key)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	if len(name) == 0 {
+		return "_"
+	}
+	for i, c := range name {
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// syslogHeaderField converts `s` to a valid header field of at most `maxLength` printable characters.
+func syslogHeaderField(s string, maxLength int) string {
+	if s == "" {
+		return "-"
+	}
+	field := []byte(s)
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	for i, c := range field {
+		if c <= ' ' || c > '~' {
+			field[i] = '_'
+		}
+	}
+	return string(field)
+}
+
+// filepathBase returns the last element of the slash or backslash separated `path`.
+func filepathBase(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i != -1 {
+		return path[i+1:]
+	}
+	return path
+}
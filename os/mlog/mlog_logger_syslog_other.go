@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package mlog
+
+import (
+	"net"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// dialLocalSyslog returns an error, as there is no local syslog socket on this system.
+func dialLocalSyslog() (net.Conn, error) {
+	return nil, merror.NewCode(mcode.CodeNotSupported, `local syslog is not supported on this system, use a network address`)
+}
@@ -0,0 +1,25 @@
+//go:build !windows && !plan9
+
+package mlog
+
+import (
+	"net"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+)
+
+// localSyslogPaths are the paths of the local syslog socket on the different systems.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// dialLocalSyslog connects to the local syslog socket.
+func dialLocalSyslog() (net.Conn, error) {
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range localSyslogPaths {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, merror.NewCode(mcode.CodeOperationFailed, `local syslog socket not found`)
+}
@@ -0,0 +1,174 @@
+package mlog
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/graingo/maltose/errors/mcode"
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/graingo/mconv"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TimeFormatUnix is the time format writing the entry times as unix seconds.
+	TimeFormatUnix = "unix"
+	// TimeFormatUnixMilli is the time format writing the entry times as unix milliseconds.
+	TimeFormatUnixMilli = "unixMilli"
+	// TimeFormatUnixNano is the time format writing the entry times as unix nanoseconds.
+	TimeFormatUnixNano = "unixNano"
+)
+
+// timeOptions are the options of the entry times of the formatters.
+type timeOptions struct {
+	format   string         // layout of time.Format, or one of the unix time formats
+	location *time.Location // location of the times, nil for the local time
+	key      string         // key of the time in the JSON format, empty for "time"
+}
+
+// isUnix reports whether the times are written as unix timestamps.
+func (o timeOptions) isUnix() bool {
+	switch o.format {
+	case TimeFormatUnix, TimeFormatUnixMilli, TimeFormatUnixNano:
+		return true
+	}
+	return false
+}
+
+// formatTime formats `t` in the location and format of the options.
+func (o timeOptions) formatTime(t time.Time) string {
+	switch o.format {
+	case TimeFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeFormatUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TimeFormatUnixNano:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	if o.location != nil {
+		t = t.In(o.location)
+	}
+	return t.Format(o.format)
+}
+
+// timeFormatter wraps a logrus formatter to write the entry times in a location or as unix
+// timestamps, which the logrus formatters do not support. The unix timestamps are written in
+// place of the time disabled in the wrapped formatter, as the first field of the text format,
+// and as a number in the JSON format.
+type timeFormatter struct {
+	formatter logrus.Formatter
+	options   timeOptions
+	json      bool
+}
+
+// wrapTimeFormatter wraps `formatter` in a timeFormatter if the times are in a location or
+// unix timestamps, the formatters of the default options are returned unchanged.
+func wrapTimeFormatter(formatter logrus.Formatter, times timeOptions, json bool) logrus.Formatter {
+	if times.location == nil && !times.isUnix() {
+		return formatter
+	}
+	return &timeFormatter{formatter: formatter, options: times, json: json}
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *timeFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.options.isUnix() {
+		e := *entry
+		e.Time = e.Time.In(f.options.location)
+		return f.formatter.Format(&e)
+	}
+
+	data, err := f.formatter.Format(entry)
+	if err != nil || len(data) == 0 {
+		return data, err
+	}
+	timestamp := f.options.formatTime(entry.Time)
+	out := make([]byte, 0, len(data)+len(f.options.key)+len(timestamp)+4)
+	if f.json {
+		key := f.options.key
+		if key == "" {
+			key = logrus.FieldKeyTime
+		}
+		// the JSON objects always have the level and the message
+		out = append(out, '{')
+		out = strconv.AppendQuote(out, key)
+		out = append(out, ':')
+		out = append(out, timestamp...)
+		out = append(out, ',')
+		return append(out, data[1:]...), nil
+	}
+	out = append(out, logrus.FieldKeyTime+"="...)
+	out = append(out, timestamp...)
+	out = append(out, ' ')
+	return append(out, data...), nil
+}
+
+// SetTimeZone sets the location of the entry times, like time.UTC, applied by all the formats.
+// The times are in the local time by default or if `loc` is nil. The config "time_zone" takes
+// the IANA name of the location, like "UTC" or "Asia/Shanghai".
+func (l *Logger) SetTimeZone(loc *time.Location) {
+	l.SetConfigWithMap(map[string]any{
+		"time_zone": loc,
+	})
+}
+
+// SetTimeKey sets the key of the entry time in the JSON format, "time" by default.
+func (l *Logger) SetTimeKey(key string) {
+	l.SetConfigWithMap(map[string]any{
+		"time_key": key,
+	})
+}
+
+// timeOptions returns the time options of the config.
+func (l *Logger) timeOptions() timeOptions {
+	return timeOptions{
+		format:   l.config.TimeFormat,
+		location: l.config.timeLocation,
+		key:      l.config.TimeKey,
+	}
+}
+
+// setTimeConfig sets the time options present in `config`, it returns whether one of them is present.
+func (l *Logger) setTimeConfig(config map[string]any) (bool, error) {
+	var changed bool
+	if v, ok := config["time_zone"]; ok {
+		loc, err := toLocation(v)
+		if err != nil {
+			return false, err
+		}
+		l.config.timeLocation = loc
+		l.config.TimeZone = ""
+		if loc != nil {
+			l.config.TimeZone = loc.String()
+		}
+		changed = true
+	}
+	if v, ok := config["time_format"]; ok {
+		l.config.TimeFormat = mconv.ToString(v)
+		changed = true
+	}
+	if v, ok := config["time_key"]; ok {
+		l.config.TimeKey = mconv.ToString(v)
+		changed = true
+	}
+	return changed, nil
+}
+
+// toLocation converts `v` to a location, a *time.Location or an IANA name, nil for the local time.
+func toLocation(v any) (*time.Location, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case *time.Location:
+		return value, nil
+	}
+	name := mconv.ToString(v)
+	if name == "" || name == "Local" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, merror.WrapCodef(err, mcode.CodeInvalidParameter, `invalid time zone: %v`, v)
+	}
+	return loc, nil
+}
@@ -0,0 +1,257 @@
+package mlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WriterOption configures a writer added by Logger.AddWriter.
+type WriterOption func(w *levelWriter)
+
+// WriterErrorHandler handles the error of a writer added by Logger.AddWriter.
+type WriterErrorHandler func(w io.Writer, err error)
+
+// EntryWriter is implemented by the writers added by Logger.AddWriter which need
+// the entry, like its level or fields, in addition to its formatted content `p`.
+// WriteEntry is called instead of Write.
+type EntryWriter interface {
+	WriteEntry(entry *Entry, p []byte) error
+}
+
+// ctxEntryWriter is an EntryWriter also receiving the context of the logging call.
+type ctxEntryWriter interface {
+	writeEntryContext(ctx context.Context, entry *Entry) error
+}
+
+// WithMinLevel sets the minimum level of the entries written to the writer.
+func WithMinLevel(level Level) WriterOption {
+	return func(w *levelWriter) {
+		w.minLevel = level
+	}
+}
+
+// WithMaxLevel sets the maximum level of the entries written to the writer.
+func WithMaxLevel(level Level) WriterOption {
+	return func(w *levelWriter) {
+		w.maxLevel = level
+	}
+}
+
+// WithFormat sets the format of the entries written to the writer, FormatText, FormatJSON or FormatConsole.
+// The console format is colored if the writer is a terminal.
+// By default, the format of the logger is used.
+func WithFormat(format string) WriterOption {
+	return func(w *levelWriter) {
+		w.format = format
+	}
+}
+
+// levelWriter is a writer added by Logger.AddWriter.
+type levelWriter struct {
+	writer    io.Writer
+	minLevel  Level
+	maxLevel  Level
+	format    string
+	formatter logrus.Formatter // nil to use the formatter of the logger
+	preset    string           // kind of the writer set by the logger, like the split console, empty if added
+}
+
+// dispatchFormatter is the formatter of the logger. It formats entries for the main output,
+// and writes them to the writers added by Logger.AddWriter whose level range matches.
+// It is called after the hooks fired and under the lock of the logger, so the writers
+// receive the same entries in the same order as the main output.
+type dispatchFormatter struct {
+	formatter    atomic.Pointer[logrus.Formatter]
+	writers      atomic.Pointer[[]*levelWriter]
+	errorHandler atomic.Pointer[WriterErrorHandler]
+	failures     *writeFailures // failures of the logger, counting the errors of the writers
+	mu           sync.Mutex     // serializes updates of writers
+}
+
+// newDispatchFormatter creates a dispatchFormatter with the formatter `formatter`.
+func newDispatchFormatter(formatter logrus.Formatter) *dispatchFormatter {
+	f := &dispatchFormatter{}
+	f.formatter.Store(&formatter)
+	f.writers.Store(&[]*levelWriter{})
+	return f
+}
+
+// Format implements the logrus.Formatter interface.
+func (f *dispatchFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data, err := (*f.formatter.Load()).Format(entry)
+	writers := *f.writers.Load()
+	if err != nil || len(writers) == 0 {
+		return data, err
+	}
+
+	// the formatters write to the entry buffer if set, which holds the data of the main output
+	buffer := entry.Buffer
+	entry.Buffer = nil
+	defer func() { entry.Buffer = buffer }()
+
+	level := fromLogrusLevel(entry.Level)
+	for _, w := range writers {
+		if level < w.minLevel || level > w.maxLevel {
+			continue
+		}
+		var (
+			content = data
+			err     error
+		)
+		if w.formatter != nil {
+			content, err = w.formatter.Format(entry)
+		}
+		if err == nil {
+			switch ew := w.writer.(type) {
+			case ctxEntryWriter:
+				err = ew.writeEntryContext(entry.Context, &Entry{
+					Time:    entry.Time,
+					Level:   level,
+					Message: entry.Message,
+					Data:    Fields(entry.Data),
+				})
+			case EntryWriter:
+				err = ew.WriteEntry(&Entry{
+					Time:    entry.Time,
+					Level:   level,
+					Message: entry.Message,
+					Data:    Fields(entry.Data),
+				}, content)
+			default:
+				_, err = w.writer.Write(content)
+			}
+		}
+		if err != nil {
+			f.handleError(w.writer, err)
+		}
+	}
+	return data, nil
+}
+
+// handleError reports the error of writer `w` to the error handler, or to the error output
+// of the logger, see SetErrorOutput. It is counted by Stats and observed by the hooks.
+func (f *dispatchFormatter) handleError(w io.Writer, err error) {
+	if handler := f.errorHandler.Load(); handler != nil {
+		(*handler)(w, err)
+		f.failures.observe(w, err, true)
+		return
+	}
+	f.failures.report(w, err, true)
+}
+
+// setPresetWriters replaces the writers of kind `kind` set by the logger with `writers`.
+func (f *dispatchFormatter) setPresetWriters(kind string, writers ...*levelWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []*levelWriter
+	for _, lw := range *f.writers.Load() {
+		if lw.preset != kind {
+			list = append(list, lw)
+		}
+	}
+	for _, lw := range writers {
+		lw.preset = kind
+		list = append(list, lw)
+	}
+	f.writers.Store(&list)
+}
+
+// setFormatter sets the formatter of the main output.
+func (f *dispatchFormatter) setFormatter(formatter logrus.Formatter) {
+	f.formatter.Store(&formatter)
+}
+
+// AddWriter adds the writer `w` receiving the entries of the logger in addition to
+// the configured outputs, for example the errors to stderr:
+//
+//	logger.AddWriter(os.Stderr, mlog.WithMinLevel(mlog.ErrorLevel))
+//
+// A failing writer does not affect the other outputs, its errors are reported to
+// the handler set by SetWriterErrorHandler, or to the error output, see SetErrorOutput.
+// Writes are serialized by the logger.
+func (l *Logger) AddWriter(w io.Writer, opts ...WriterOption) {
+	lw := &levelWriter{
+		writer:   w,
+		minLevel: DebugLevel,
+		maxLevel: PanicLevel,
+	}
+	for _, opt := range opts {
+		opt(lw)
+	}
+	l.config.mu.Lock()
+	lw.formatter = newFormatter(lw.format, l.timeOptions())
+	l.config.mu.Unlock()
+	if console, ok := lw.formatter.(*consoleFormatter); ok {
+		if f, isFile := w.(*os.File); isFile {
+			console.color = colorEnabled(f)
+		}
+	}
+
+	l.dispatch.mu.Lock()
+	defer l.dispatch.mu.Unlock()
+	writers := append([]*levelWriter{}, *l.dispatch.writers.Load()...)
+	writers = append(writers, lw)
+	l.dispatch.writers.Store(&writers)
+}
+
+// RemoveWriter removes the writer `w` added by AddWriter, it returns whether it was found.
+// The writer is compared by identity, so it should be of a pointer type like *os.File.
+func (l *Logger) RemoveWriter(w io.Writer) bool {
+	l.dispatch.mu.Lock()
+	defer l.dispatch.mu.Unlock()
+	var (
+		found   bool
+		writers []*levelWriter
+	)
+	for _, lw := range *l.dispatch.writers.Load() {
+		if lw.writer == w && lw.preset == "" {
+			found = true
+			continue
+		}
+		writers = append(writers, lw)
+	}
+	l.dispatch.writers.Store(&writers)
+	return found
+}
+
+// SetWriterErrorHandler sets the handler of the errors of the writers added by AddWriter.
+// By default, the errors are reported to the error output, see SetErrorOutput.
+func (l *Logger) SetWriterErrorHandler(handler WriterErrorHandler) {
+	if handler == nil {
+		l.dispatch.errorHandler.Store(nil)
+		return
+	}
+	l.dispatch.errorHandler.Store(&handler)
+}
+
+// newFormatter creates the logrus formatter of `format` with the time options `times`,
+// it returns nil for an empty or unknown format.
+func newFormatter(format string, times timeOptions) logrus.Formatter {
+	switch format {
+	case FormatConsole:
+		return &consoleFormatter{
+			times: times,
+		}
+	case FormatJSON:
+		formatter := &logrus.JSONFormatter{
+			TimestampFormat:  times.format,
+			DisableTimestamp: times.isUnix(),
+		}
+		if times.key != "" {
+			formatter.FieldMap = logrus.FieldMap{logrus.FieldKeyTime: times.key}
+		}
+		return wrapTimeFormatter(formatter, times, true)
+	case FormatText:
+		return wrapTimeFormatter(&logrus.TextFormatter{
+			TimestampFormat:  times.format,
+			FullTimestamp:    true,
+			DisableTimestamp: times.isUnix(),
+		}, times, false)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package mlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// presetSlog is the kind of the writer of FromSlog.
+const presetSlog = "slog"
+
+// The levels are mapped to the slog levels as follows, the slog levels between
+// two mapped ones are rounded down:
+//
+//	DebugLevel <-> slog.LevelDebug (-4)
+//	InfoLevel  <-> slog.LevelInfo  (0)
+//	WarnLevel  <-> slog.LevelWarn  (4)
+//	ErrorLevel <-> slog.LevelError (8)
+//	FatalLevel  -> slog.LevelError + 4 (12)
+//	PanicLevel  -> slog.LevelError + 8 (16)
+//
+// The slog levels above slog.LevelError are mapped to ErrorLevel, as slog
+// records must not exit or panic.
+
+// toSlogLevel converts the level to the slog level.
+func toSlogLevel(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return slog.LevelError + 4
+	case PanicLevel:
+		return slog.LevelError + 8
+	}
+	return slog.LevelInfo
+}
+
+// fromSlogLevel converts the slog level to the level.
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level >= slog.LevelError:
+		return ErrorLevel
+	case level >= slog.LevelWarn:
+		return WarnLevel
+	case level >= slog.LevelInfo:
+		return InfoLevel
+	}
+	return DebugLevel
+}
+
+// slogHandler is the slog.Handler returned by SlogHandler.
+type slogHandler struct {
+	logger *Logger
+	attrs  []Field // fields of WithAttrs, with their group prefix
+	prefix string  // prefix of the groups of WithGroup, like "request."
+}
+
+// SlogHandler returns a slog.Handler writing the records to `logger`, so that the
+// libraries using log/slog go through its levels, fields, hooks and writers:
+//
+//	slog.SetDefault(slog.New(mlog.SlogHandler(mlog.DefaultLogger())))
+//
+// The attributes of groups are written as fields prefixed by the group names,
+// like "request.method", and the caller is reported from the record if enabled.
+func SlogHandler(logger *Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(fromSlogLevel(level))
+}
+
+// Handle implements the slog.Handler interface.
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	level := fromSlogLevel(record.Level)
+	if !h.logger.IsLevelEnabled(level) || h.logger.dropSampled(level, record.Message) {
+		return nil
+	}
+	data := h.logger.fieldsData(len(h.attrs) + record.NumAttrs())
+	for _, field := range h.attrs {
+		data[field.Key] = field.Value()
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		for _, field := range attrToFields(h.prefix, attr, nil) {
+			data[field.Key] = field.Value()
+		}
+		return true
+	})
+	data = h.logger.callerPC(data, record.PC)
+	if d := h.logger.dedup.Load(); d != nil && d.levels[level] && d.drop(level, record.Message, record.PC, data) {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	entry := h.logger.parent.WithContext(ctx).WithFields(data)
+	if !record.Time.IsZero() {
+		entry = entry.WithTime(record.Time)
+	}
+	entry.Log(level.toLogrusLevel(), record.Message)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	child := *h
+	child.attrs = append([]Field{}, h.attrs...)
+	for _, attr := range attrs {
+		child.attrs = attrToFields(h.prefix, attr, child.attrs)
+	}
+	return &child
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+// attrToFields appends the fields of `attr` to `fields`, prefixing their keys with `prefix`.
+// The attributes of a group are prefixed by the group name, or inlined if it is empty.
+func attrToFields(prefix string, attr slog.Attr, fields []Field) []Field {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			fields = attrToFields(groupPrefix, groupAttr, fields)
+		}
+		return fields
+	}
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	return append(fields, Any(prefix+attr.Key, value.Any()))
+}
+
+// slogWriter is the writer of FromSlog, writing the entries to a slog.Handler.
+type slogWriter struct {
+	handler slog.Handler
+}
+
+// Write implements the io.Writer interface, the entries are written by writeEntryContext.
+func (w *slogWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// writeEntryContext implements the ctxEntryWriter interface.
+func (w *slogWriter) writeEntryContext(ctx context.Context, entry *Entry) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := toSlogLevel(entry.Level)
+	if !w.handler.Enabled(ctx, level) {
+		return nil
+	}
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, entry.Data[key]))
+	}
+	return w.handler.Handle(ctx, record)
+}
+
+// discardFormatter is the main formatter of the loggers without main output, like FromSlog.
+type discardFormatter struct{}
+
+// Format implements the logrus.Formatter interface.
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// FromSlog creates a Logger writing its entries to the slog handler `h`, for using the API
+// of mlog on top of an existing slog backend. The level of the logger is DebugLevel, the
+// handler decides which entries it writes. Hooks, fields and added writers apply as usual,
+// and the fields are passed as attributes sorted by key.
+func FromSlog(h slog.Handler) *Logger {
+	l := New()
+	_ = l.SetConfigWithMap(map[string]any{
+		"stdout": false,
+		"path":   "",
+		"level":  DebugLevel,
+	})
+	l.parent.SetOutput(io.Discard)
+	l.dispatch.setFormatter(discardFormatter{})
+	l.dispatch.setPresetWriters(presetSlog, &levelWriter{
+		writer:   &slogWriter{handler: h},
+		minLevel: DebugLevel,
+		maxLevel: PanicLevel,
+	})
+	return l
+}
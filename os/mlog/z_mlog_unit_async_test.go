@@ -0,0 +1,176 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowWriter is a writer that blocks until released.
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter_Order(t *testing.T) {
+	var buf bytes.Buffer
+	w := newAsyncWriter(&buf, 16, AsyncPolicyBlock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = fmt.Fprintf(w, "%d:%d\n", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	w.Flush()
+
+	// the entries of each goroutine keep their order
+	next := map[string]int{}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 400)
+	for _, line := range lines {
+		var i, j int
+		_, err := fmt.Sscanf(line, "%d:%d", &i, &j)
+		assert.NoError(t, err)
+		key := fmt.Sprint(i)
+		assert.Equal(t, next[key], j, line)
+		next[key] = j + 1
+	}
+	assert.NoError(t, w.Close())
+
+	// writes after close are synchronous
+	_, _ = w.Write([]byte("late\n"))
+	assert.True(t, strings.HasSuffix(buf.String(), "late\n"))
+}
+
+func TestAsyncWriter_DropPolicy(t *testing.T) {
+	sw := &slowWriter{release: make(chan struct{})}
+	w := newAsyncWriter(sw, 2, AsyncPolicyDrop)
+
+	for i := 0; i < 10; i++ {
+		n, err := fmt.Fprintf(w, "entry %d\n", i)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, n)
+	}
+	assert.Greater(t, w.Dropped(), int64(0))
+
+	close(sw.release)
+	assert.NoError(t, w.Close())
+	lines := strings.Split(strings.TrimSpace(sw.String()), "\n")
+	assert.Equal(t, int64(10), int64(len(lines))+w.Dropped())
+	assert.Equal(t, "entry 0", lines[0])
+}
+
+func TestAsyncWriter_BlockPolicy(t *testing.T) {
+	sw := &slowWriter{release: make(chan struct{})}
+	w := newAsyncWriter(sw, 1, AsyncPolicyBlock)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			_, _ = fmt.Fprintf(w, "entry %d\n", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("writes should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sw.release)
+	<-done
+	assert.NoError(t, w.Close())
+	assert.Equal(t, int64(0), w.Dropped())
+	assert.Len(t, strings.Split(strings.TrimSpace(sw.String()), "\n"), 5)
+}
+
+func TestLogger_Async(t *testing.T) {
+	dir := t.TempDir()
+	l := New()
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":         dir,
+		"file":         "app.log",
+		"stdout":       false,
+		"async_buffer": 128,
+	}))
+	assert.Error(t, l.SetAsyncPolicy("wait"))
+
+	ctx := context.Background()
+	for i := 0; i < 50; i++ {
+		l.Infof(ctx, "entry %d", i)
+	}
+	l.Flush()
+	content, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 50, strings.Count(string(content), "entry"))
+
+	// children flush the shared outputs
+	l.With(String("k", "v")).Info(ctx, "child")
+	l.With(String("k", "v")).Flush()
+	content, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Contains(t, string(content), "child")
+
+	l.Info(ctx, "last")
+	assert.NoError(t, l.Close())
+	content, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Contains(t, string(content), "last")
+	assert.Nil(t, l.out.file)
+	assert.Nil(t, l.out.async)
+}
+
+func benchmarkLoggerFile(b *testing.B, async int) {
+	l := New()
+	_ = l.SetConfigWithMap(map[string]any{
+		"path":         b.TempDir(),
+		"file":         "bench.log",
+		"stdout":       false,
+		"async_buffer": async,
+	})
+	defer l.Close()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Infow(ctx, "request done", "path", "/users", "status", 200)
+		}
+	})
+	l.Flush()
+}
+
+func BenchmarkLogger_FileSync(b *testing.B) {
+	benchmarkLoggerFile(b, 0)
+}
+
+func BenchmarkLogger_FileAsync(b *testing.B) {
+	benchmarkLoggerFile(b, 4096)
+}
@@ -0,0 +1,120 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// callerLine returns the file and line of its caller, trimmed like the caller field.
+func callerLine(offset int) string {
+	_, file, line, _ := runtime.Caller(1)
+	return trimCallerPath(file) + ":" + strconv.Itoa(line+offset)
+}
+
+// logWrapped is a logging wrapper function, whose callers should be reported.
+func logWrapped(ctx context.Context, l *Logger, msg string) {
+	l.AddCallerSkip(1).Infow(ctx, msg)
+}
+
+func TestLogger_Caller(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	ctx := context.Background()
+
+	l.Info(ctx, "disabled")
+	l.SetCaller(true, 0)
+	want := callerLine(1)
+	l.Info(ctx, "info")
+	wantWith := callerLine(1)
+	l.With(String("k", "v")).Errorw(ctx, "errorw", "a", 1)
+	wantWrapped := callerLine(1)
+	logWrapped(ctx, l, "wrapped")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 4)
+	assert.NotContains(t, entries[0], FieldCaller)
+	assert.Equal(t, "mlog/z_mlog_unit_caller_test.go", strings.Split(want, ":")[0])
+	assert.Equal(t, want, entries[1][FieldCaller])
+	assert.NotContains(t, entries[1], FieldFunc)
+	assert.Equal(t, wantWith, entries[2][FieldCaller])
+	assert.Equal(t, wantWrapped, entries[3][FieldCaller])
+}
+
+func TestLogger_CallerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	ctx := context.Background()
+
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"caller":           true,
+		"caller_func":      true,
+		"caller_full_path": true,
+	}))
+	_, file, line, _ := runtime.Caller(0)
+	l.Warn(ctx, "full")
+
+	entries := decodeLines(t, &buf)
+	assert.Equal(t, file+":"+strconv.Itoa(line+1), entries[0][FieldCaller])
+	assert.Equal(t, "github.com/graingo/maltose/os/mlog.TestLogger_CallerOptions", entries[0][FieldFunc])
+}
+
+func TestLogger_CallerText(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetCaller(true, 0)
+	want := callerLine(1)
+	l.Info(context.Background(), "text")
+	assert.Contains(t, buf.String(), "caller=\""+want+"\"")
+}
+
+func TestLogger_CallerPackageFunctions(t *testing.T) {
+	var buf bytes.Buffer
+	prev := DefaultLogger()
+	defer SetDefaultLogger(prev)
+	l := newJSONTestLogger(&buf)
+	SetDefaultLogger(l)
+
+	l.SetCaller(true, 0)
+	want := callerLine(1)
+	Infof(context.Background(), "package %s", "function")
+	entries := decodeLines(t, &buf)
+	assert.Equal(t, want, entries[0][FieldCaller])
+}
+
+func TestTrimCallerPath(t *testing.T) {
+	assert.Equal(t, "mhttp/server.go", trimCallerPath("/go/src/net/mhttp/server.go"))
+	assert.Equal(t, "/server.go", trimCallerPath("/server.go"))
+	assert.Equal(t, "server.go", trimCallerPath("server.go"))
+}
+
+func BenchmarkLogger_Caller(b *testing.B) {
+	l := newTestLogger(io.Discard)
+	ctx := context.Background()
+	b.Run("Disabled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "message")
+		}
+	})
+	l.SetCaller(true, 0)
+	b.Run("Enabled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "message")
+		}
+	})
+	l.SetLevel(ErrorLevel)
+	b.Run("EnabledLevelDisabled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info(ctx, "message")
+		}
+	})
+}
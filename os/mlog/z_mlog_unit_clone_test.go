@@ -0,0 +1,141 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// retainHook retains the clones of the entries it fires on.
+type retainHook struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func (h *retainHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *retainHook) Fire(ctx context.Context, entry *Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry.Clone())
+	return nil
+}
+
+func TestEntry_Clone(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	retain := &retainHook{}
+	l.AddHook(retain)
+	l.AddHook(&hostnameHook{hostname: "host"})
+	l.Infow(context.Background(), "message", "k", "v")
+
+	// the later hooks do not modify the retained entry
+	if assert.Len(t, retain.entries, 1) {
+		entry := retain.entries[0]
+		assert.Equal(t, "message", entry.Message)
+		assert.Equal(t, "v", entry.Data["k"])
+		assert.NotContains(t, entry.Data, "hostname")
+	}
+	assert.Contains(t, buf.String(), "[host] message")
+	assert.Nil(t, (&Entry{}).Clone().Data)
+}
+
+func TestLogger_Clone(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		cloneBuf bytes.Buffer
+		ctx      = context.Background()
+		hook     = &levelCountHook{counts: map[Level]int{}}
+	)
+	l := newTestLogger(&buf).With(String("service", "api"))
+	l.AddHook(hook)
+	l.SetTimeZone(time.FixedZone("UTC+9", 9*3600))
+	l.SetLevel(WarnLevel)
+
+	c := l.Clone()
+	defer c.Close()
+	c.parent.SetOutput(&cloneBuf)
+	assert.Equal(t, WarnLevel, c.GetLevel())
+	assert.Equal(t, "UTC+9", c.config.TimeZone)
+
+	// the configuration of the clone does not affect the logger
+	c.SetLevel(DebugLevel)
+	c.SetFormat(FormatJSON)
+	c.RemoveHookByType(&levelCountHook{})
+	assert.Equal(t, WarnLevel, l.GetLevel())
+	assert.Empty(t, l.config.Format)
+
+	c.Debug(ctx, "clone debug")
+	c.Warn(ctx, "clone warn")
+	l.Debug(ctx, "logger debug")
+	l.Warn(ctx, "logger warn")
+	entries := decodeLines(t, &cloneBuf)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "api", entries[0]["service"])
+	}
+	assert.NotContains(t, buf.String(), "clone")
+	assert.NotContains(t, buf.String(), "logger debug")
+	assert.Equal(t, 1, hook.counts[WarnLevel])
+
+	// the child loggers share the configuration of their logger
+	l.With(String("k", "v")).SetLevel(ErrorLevel)
+	assert.Equal(t, ErrorLevel, l.GetLevel())
+	assert.Equal(t, DebugLevel, c.GetLevel())
+}
+
+func TestLogger_ConcurrentConfig(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ctx = context.Background()
+		wg  sync.WaitGroup
+	)
+	l := newTestLogger(lockedWriter{w: io.Discard, mu: &mu})
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := l.With(Int("goroutine", i))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				child.Infow(ctx, "concurrent", "i", i)
+				child.With(String("k", "v")).Warn(ctx, "child")
+				if ce := child.Check(ErrorLevel); ce != nil {
+					ce.Write(ctx, "checked")
+				}
+			}
+		}(i)
+	}
+
+	for i := 0; i < 50; i++ {
+		child := l.With(Int("config", i))
+		assert.NoError(t, child.SetConfigWithMap(map[string]any{
+			"level":       []string{"debug", "info"}[i%2],
+			"format":      []string{FormatText, FormatJSON, FormatConsole}[i%3],
+			"time_format": []string{time.DateTime, TimeFormatUnixMilli}[i%2],
+			"caller":      i%2 == 0,
+			"ctx_keys":    []string{"request_id"},
+		}))
+		hook := &retainHook{}
+		child.AddHook(hook)
+		w := &bytes.Buffer{}
+		l.AddWriter(lockedWriter{w: w, mu: &mu}, WithFormat(FormatJSON))
+		l.RemoveWriter(lockedWriter{w: w, mu: &mu})
+		l.Clone().Close()
+		child.RemoveHookByType(hook)
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,136 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureConsole replaces stdout and stderr with files while `fn` runs, returning their contents.
+func captureConsole(t *testing.T, fn func()) (stdout string, stderr string) {
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	assert.NoError(t, err)
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	assert.NoError(t, err)
+
+	prevOut, prevErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	defer func() { os.Stdout, os.Stderr = prevOut, prevErr }()
+	fn()
+
+	_ = outFile.Close()
+	_ = errFile.Close()
+	outData, _ := os.ReadFile(outFile.Name())
+	errData, _ := os.ReadFile(errFile.Name())
+	return string(outData), string(errData)
+}
+
+func TestLogger_StdoutSplit(t *testing.T) {
+	ctx := context.Background()
+	stdout, stderr := captureConsole(t, func() {
+		l := New()
+		assert.NoError(t, l.SetConfigWithMap(map[string]any{"stdout": "split", "level": "debug"}))
+		assert.True(t, l.config.Stdout)
+		assert.True(t, l.config.StdoutSplit)
+		l.Debug(ctx, "debug line")
+		l.Warn(ctx, "warn line")
+		l.Error(ctx, "error line")
+		l.With(String("k", "v")).Errorw(ctx, "child error")
+
+		// the split console is not removable as an added writer
+		assert.False(t, l.RemoveWriter(os.Stderr))
+		l.Error(ctx, "still split")
+
+		l.SetStdoutPrint(true)
+		l.Error(ctx, "not split")
+	})
+	assert.Contains(t, stdout, "debug line")
+	assert.Contains(t, stdout, "warn line")
+	assert.NotContains(t, stdout, "error line")
+	assert.Contains(t, stderr, "error line")
+	assert.Contains(t, stderr, "child error")
+	assert.Contains(t, stderr, "still split")
+	assert.NotContains(t, stderr, "warn line")
+	assert.Contains(t, stdout, "not split")
+	assert.NotContains(t, stderr, "not split")
+}
+
+func TestLogger_SetStdoutSplit(t *testing.T) {
+	ctx := context.Background()
+	stdout, stderr := captureConsole(t, func() {
+		l := New()
+		l.SetStdoutSplit(true)
+		l.Info(ctx, "info line")
+		l.Error(ctx, "error line")
+		l.SetStdoutSplit(false)
+		l.Error(ctx, "after")
+	})
+	assert.Contains(t, stdout, "info line")
+	assert.Contains(t, stderr, "error line")
+	assert.Contains(t, stdout, "after")
+	assert.NotContains(t, stderr, "after")
+}
+
+func TestLogger_ConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"format":      FormatConsole,
+		"time_format": "15:04:05",
+		"caller":      true,
+	}))
+	l.parent.SetOutput(&buf)
+	l.Warnw(context.Background(), "disk almost full", "path", "/data", "note", "two words")
+
+	line := buf.String()
+	assert.Regexp(t, `^\d{2}:\d{2}:\d{2} WARN  disk almost full note="two words" path=/data caller=mlog/z_mlog_unit_console_test.go:\d+\n$`, line)
+	assert.NotContains(t, line, "\x1b[")
+}
+
+func TestConsoleFormatter_Color(t *testing.T) {
+	f := &consoleFormatter{times: timeOptions{format: time.DateTime}, color: true}
+	entry := &logrus.Entry{
+		Time:    time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:   logrus.ErrorLevel,
+		Message: "failed",
+		Data:    logrus.Fields{"k": "v", FieldCaller: "mlog/a.go:1", FieldFunc: "main.run"},
+	}
+	data, err := f.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-02 15:04:05 \x1b[31mERROR\x1b[0m failed k=v \x1b[2mcaller=mlog/a.go:1 func=main.run\x1b[0m\n", string(data))
+}
+
+func TestLogger_Development(t *testing.T) {
+	l := newTestLogger(io.Discard)
+	_, ok := (*l.dispatch.formatter.Load()).(*logrus.TextFormatter)
+	assert.True(t, ok)
+
+	l.SetDevelopment(true)
+	_, ok = (*l.dispatch.formatter.Load()).(*consoleFormatter)
+	assert.True(t, ok)
+
+	// an explicit format takes precedence
+	l.SetFormat(FormatJSON)
+	_, ok = (*l.dispatch.formatter.Load()).(*logrus.JSONFormatter)
+	assert.True(t, ok)
+	assert.Error(t, l.SetConfigWithMap(map[string]any{"format": "xml"}))
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.False(t, colorEnabled(f))
+	assert.False(t, colorEnabled())
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, colorEnabled(os.Stdout))
+}
@@ -0,0 +1,63 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/graingo/maltose/net/mtrace"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tenantCtxKey struct{}
+
+func (tenantCtxKey) String() string {
+	return "tenant"
+}
+
+func TestLogger_CtxKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetCtxKeys("user_id", tenantCtxKey{}, "missing")
+	assert.Equal(t, []string{"user_id", "tenant", "missing"}, l.config.CtxKeys)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = mtrace.WithRequestID(ctx, "req-1")
+	ctx = context.WithValue(ctx, "user_id", 42)
+	ctx = context.WithValue(ctx, tenantCtxKey{}, "acme")
+
+	l.Infof(ctx, "with context %d", 1)
+	l.With(String("k", "v")).Errorw(ctx, "child")
+	l.Info(context.Background(), "without context values")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	for _, entry := range entries[:2] {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry[FieldTraceID])
+		assert.Equal(t, "00f067aa0ba902b7", entry[FieldSpanID])
+		assert.Equal(t, "req-1", entry[FieldRequestID])
+		assert.Equal(t, float64(42), entry["user_id"])
+		assert.Equal(t, "acme", entry["tenant"])
+		assert.NotContains(t, entry, "missing")
+	}
+	for _, key := range []string{FieldTraceID, FieldSpanID, FieldRequestID, "user_id", "tenant"} {
+		assert.NotContains(t, entries[2], key)
+	}
+}
+
+func TestLogger_CtxKeysConfig(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{"ctx_keys": []any{"user_id"}}))
+
+	ctx := context.WithValue(context.Background(), "user_id", "u1")
+	l.Info(ctx, "message")
+	entries := decodeLines(t, &buf)
+	assert.Equal(t, "u1", entries[0]["user_id"])
+}
@@ -0,0 +1,145 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Dedup(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetDedup(time.Hour)
+	defer l.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		l.Errorw(ctx, "connection refused", "addr", "127.0.0.1:6379")
+		l.Warnf(ctx, "retry %d", 1)
+		l.Info(ctx, "attempt", Int("i", i))
+	}
+	l.Errorw(ctx, "connection refused", "addr", "127.0.0.1:6379")
+
+	var refused, retries, attempts int
+	for _, entry := range decodeLines(t, &buf) {
+		switch entry["msg"] {
+		case "connection refused":
+			refused++
+		case "retry 1":
+			retries++
+		case "attempt":
+			attempts++
+		}
+		assert.NotContains(t, entry, FieldRepeatCount)
+	}
+	// the same message logged at another call site is not a repeat
+	assert.Equal(t, 2, refused)
+	assert.Equal(t, 1, retries)
+	// the entries with other fields are not repeats
+	assert.Equal(t, 5, attempts)
+
+	// the summaries are written when the deduper stops
+	buf.Reset()
+	assert.NoError(t, l.Close())
+	summaries := map[string]float64{}
+	for _, entry := range decodeLines(t, &buf) {
+		summaries[entry["msg"].(string)] = entry[FieldRepeatCount].(float64)
+		if entry["msg"] == "connection refused" {
+			assert.Equal(t, "error", entry["level"])
+			assert.Equal(t, "127.0.0.1:6379", entry["addr"])
+		}
+	}
+	assert.Equal(t, map[string]float64{"connection refused": 4, "retry 1": 4}, summaries)
+}
+
+func TestLogger_DedupWindow(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		mu  sync.Mutex
+	)
+	l := newJSONTestLogger(&buf)
+	l.parent.SetOutput(lockedWriter{&buf, &mu})
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"dedup_window": "50ms",
+		"dedup_levels": []string{"error"},
+	}))
+	defer l.Close()
+	ctx := context.Background()
+
+	logFailed := func() { l.Errorw(ctx, "failed") }
+	for i := 0; i < 3; i++ {
+		logFailed()
+		l.Warnw(ctx, "warn is not deduplicated")
+	}
+	time.Sleep(120 * time.Millisecond)
+	logFailed()
+
+	mu.Lock()
+	entries := decodeLines(t, &buf)
+	mu.Unlock()
+	var failed, warns, summaries int
+	for _, entry := range entries {
+		switch {
+		case entry[FieldRepeatCount] != nil:
+			summaries++
+			assert.Equal(t, "failed", entry["msg"])
+			assert.Equal(t, float64(2), entry[FieldRepeatCount])
+		case entry["msg"] == "failed":
+			failed++
+		case entry["msg"] == "warn is not deduplicated":
+			warns++
+		}
+	}
+	// the first occurrence of each window is logged
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, 3, warns)
+	assert.Equal(t, 1, summaries)
+
+	assert.Error(t, l.SetConfigWithMap(map[string]any{"dedup_window": "soon"}))
+}
+
+func TestLogger_DedupEviction(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetDedup(time.Hour)
+	defer l.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		l.Infow(ctx, "first")
+	}
+	for i := 0; i < dedupMaxKeys; i++ {
+		l.Infow(ctx, fmt.Sprintf("message %d", i))
+	}
+
+	// the least recently repeated entry is evicted with its summary
+	var summary map[string]any
+	for _, entry := range decodeLines(t, &buf) {
+		if entry[FieldRepeatCount] != nil {
+			summary = entry
+		}
+	}
+	if assert.NotNil(t, summary) {
+		assert.Equal(t, "first", summary["msg"])
+		assert.Equal(t, float64(2), summary[FieldRepeatCount])
+	}
+}
+
+func BenchmarkLogger_Dedup(b *testing.B) {
+	l := newTestLogger(io.Discard)
+	l.SetDedup(time.Second)
+	defer l.Close()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Errorw(ctx, "connection refused", "addr", "127.0.0.1:6379")
+		}
+	})
+}
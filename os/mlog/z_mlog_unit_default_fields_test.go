@@ -0,0 +1,84 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultFields(t *testing.T) {
+	defer SetDefaultFields()
+
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	SetDefaultFields(String("service", "global"), String("env", "test"), String("version", "1.0"))
+	assert.Len(t, DefaultFields(), 3)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"fields": map[string]any{"service": "checkout", "region": "eu"},
+	}))
+
+	ctx := context.Background()
+	child := l.With(String("region", "us"), String("component", "cart"))
+	l.Info(ctx, "instance")
+	child.Info(ctx, "child")
+	child.Infow(ctx, "call site", "component", "payment")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	for _, entry := range entries {
+		assert.Equal(t, "test", entry["env"])
+		assert.Equal(t, "1.0", entry["version"])
+		assert.Equal(t, "checkout", entry["service"])
+	}
+	assert.Equal(t, "eu", entries[0]["region"])
+	assert.NotContains(t, entries[0], "component")
+	assert.Equal(t, "us", entries[1]["region"])
+	assert.Equal(t, "cart", entries[1]["component"])
+	assert.Equal(t, "payment", entries[2]["component"])
+
+	// the defaults apply to the loggers created later
+	buf.Reset()
+	later := newJSONTestLogger(&buf)
+	later.Info(ctx, "later")
+	assert.Equal(t, "global", decodeLines(t, &buf)[0]["service"])
+}
+
+func TestConfigFields_Auto(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	child := l.With(String("component", "cart"))
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"hostname": true,
+		"pid":      true,
+	}))
+	hostname, _ := os.Hostname()
+
+	// the child loggers see the config changes of their parent
+	child.Info(context.Background(), "auto fields")
+	entry := decodeLines(t, &buf)[0]
+	assert.Equal(t, hostname, entry[FieldHostname])
+	assert.Equal(t, float64(os.Getpid()), entry[FieldPid])
+	assert.Equal(t, "cart", entry["component"])
+
+	buf.Reset()
+	l.SetConfigWithMap(map[string]any{"hostname": false, "pid": false})
+	l.Info(context.Background(), "no auto fields")
+	entry = decodeLines(t, &buf)[0]
+	assert.NotContains(t, entry, FieldHostname)
+	assert.NotContains(t, entry, FieldPid)
+}
+
+func TestConfigFields_Instance(t *testing.T) {
+	useTestConfig(t, map[string]any{
+		"logger": map[string]any{
+			"fields":   map[string]any{"service": "checkout"},
+			"hostname": true,
+		},
+	})
+	l := Instance("fields_test")
+	assert.Equal(t, map[string]any{"service": "checkout"}, l.config.Fields)
+	assert.True(t, l.config.Hostname)
+}
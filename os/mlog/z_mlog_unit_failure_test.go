@@ -0,0 +1,146 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenWriter is a writer failing while it is broken.
+type brokenWriter struct {
+	bytes.Buffer
+	broken bool
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if w.broken {
+		return 0, errors.New("no space left on device")
+	}
+	return w.Buffer.Write(p)
+}
+
+// failureHook records the failed writes.
+type failureHook struct {
+	mu       sync.Mutex
+	failures []WriteFailure
+}
+
+func (h *failureHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *failureHook) Fire(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (h *failureHook) WriteFailed(failure WriteFailure) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = append(h.failures, failure)
+}
+
+// newFailureTestLogger creates a logger whose main output is `w` wrapped in a fallbackWriter.
+func newFailureTestLogger(w *brokenWriter, retry int) *Logger {
+	l := newTestLogger(w)
+	fallback := newFallbackWriter(w, l.failures, retry)
+	l.out.fallbacks = []*fallbackWriter{fallback}
+	l.parent.SetOutput(fallback)
+	return l
+}
+
+func TestLogger_WriteFailureDrop(t *testing.T) {
+	var (
+		w      = &brokenWriter{broken: true}
+		errOut bytes.Buffer
+		hook   = &failureHook{}
+		ctx    = context.Background()
+	)
+	l := newFailureTestLogger(w, 0)
+	l.SetErrorOutput(&errOut)
+	l.AddHook(hook)
+
+	for i := 0; i < 3; i++ {
+		l.Infof(ctx, "lost %d", i)
+	}
+	w.broken = false
+	l.Info(ctx, "written")
+
+	assert.NotContains(t, w.String(), "lost")
+	assert.Contains(t, w.String(), "written")
+	assert.Equal(t, Stats{WriteErrors: 3, Dropped: 3}, l.Stats())
+	// the reports to the error output are rate limited
+	assert.Equal(t, 1, strings.Count(errOut.String(), "\n"))
+	assert.Contains(t, errOut.String(), "mlog: failed to write to *mlog.brokenWriter: no space left on device")
+	// the hooks observe all the failures
+	if assert.Len(t, hook.failures, 3) {
+		assert.Same(t, w, hook.failures[0].Writer)
+		assert.True(t, hook.failures[0].Dropped)
+		assert.EqualError(t, hook.failures[0].Err, "no space left on device")
+	}
+
+	l.RemoveHookByType(&failureHook{})
+	w.broken = true
+	l.Info(ctx, "lost again")
+	assert.Len(t, hook.failures, 3)
+}
+
+func TestLogger_WriteFailureRetry(t *testing.T) {
+	w := &brokenWriter{broken: true}
+	l := newFailureTestLogger(w, 2)
+	ctx := context.Background()
+	l.SetErrorOutput(&bytes.Buffer{})
+
+	l.Info(ctx, "first")
+	l.Info(ctx, "second")
+	l.Info(ctx, "dropped")
+	assert.Equal(t, Stats{WriteErrors: 3, Dropped: 1, Pending: 2}, l.Stats())
+
+	// the buffered entries are written first, in order
+	w.broken = false
+	l.Info(ctx, "third")
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], "first")
+		assert.Contains(t, lines[1], "second")
+		assert.Contains(t, lines[2], "third")
+	}
+	assert.Equal(t, 0, l.Stats().Pending)
+
+	// the entries still failing on close are dropped
+	w.broken = true
+	l.Info(ctx, "pending")
+	assert.Error(t, l.Flush())
+	assert.Error(t, l.Close())
+	assert.Equal(t, int64(2), l.Stats().Dropped)
+}
+
+func TestLogger_WriteFailureAddedWriter(t *testing.T) {
+	var (
+		main   bytes.Buffer
+		errOut bytes.Buffer
+		hook   = &failureHook{}
+	)
+	l := newTestLogger(&main)
+	l.SetErrorOutput(&errOut)
+	l.AddHook(hook)
+	l.AddWriter(&failingWriter{})
+
+	l.Info(context.Background(), "message")
+	assert.Contains(t, main.String(), "message")
+	assert.Contains(t, errOut.String(), "disk full")
+	assert.Equal(t, int64(1), l.Stats().WriteErrors)
+	assert.Len(t, hook.failures, 1)
+
+	// the errors handled by the writer error handler are still counted
+	errOut.Reset()
+	l.SetWriterErrorHandler(func(w io.Writer, err error) {})
+	l.Info(context.Background(), "message")
+	assert.Empty(t, errOut.String())
+	assert.Equal(t, int64(2), l.Stats().WriteErrors)
+}
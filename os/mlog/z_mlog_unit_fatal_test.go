@@ -0,0 +1,83 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Fatal(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	var fired []Level
+	l.AddHook(&levelsHook{fired: &fired})
+	var codes []int
+	l.SetExitFunc(func(code int) { codes = append(codes, code) })
+	ctx := context.Background()
+
+	l.Fatal(ctx, "fatal ", 1)
+	l.Fatalf(ctx, "fatal %d", 2)
+	l.With(String("k", "v")).Fatalw(ctx, "fatal 3", "n", 3)
+	l.FatalWithCode(ctx, 3, "fatal 4")
+
+	assert.Equal(t, []int{1, 1, 1, 3}, codes)
+	assert.Equal(t, []Level{FatalLevel, FatalLevel, FatalLevel, FatalLevel}, fired)
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 4)
+	for i, entry := range entries {
+		assert.Equal(t, "fatal", entry["level"])
+		assert.Equal(t, "fatal "+string(rune('1'+i)), entry["msg"])
+	}
+	assert.Equal(t, "v", entries[2]["k"])
+	assert.Equal(t, float64(3), entries[2]["n"])
+
+	// exits even if the level is disabled, or the logger closed
+	buf.Reset()
+	l.SetLevel(PanicLevel)
+	l.Fatal(ctx, "disabled")
+	assert.NoError(t, l.Close())
+	l.FatalWithCode(ctx, 2, "closed")
+	assert.Equal(t, []int{1, 1, 1, 3, 1, 2}, codes)
+	assert.Empty(t, buf.String())
+}
+
+func TestLogger_FatalFlushes(t *testing.T) {
+	dir := t.TempDir()
+	l := New()
+	defer l.Close()
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":         dir,
+		"file":         "app.log",
+		"stdout":       false,
+		"async_buffer": 128,
+	}))
+
+	var content []byte
+	l.SetExitFunc(func(int) {
+		content, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	})
+	l.Fatal(context.Background(), "flushed before exit")
+	assert.Contains(t, string(content), "flushed before exit")
+}
+
+func TestLogger_Panic(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	ctx := context.Background()
+
+	assert.PanicsWithValue(t, "panic 1", func() { l.Panic(ctx, "panic ", 1) })
+	assert.PanicsWithValue(t, "panic 2", func() { l.Panicf(ctx, "panic %d", 2) })
+	assert.PanicsWithValue(t, "panic 3", func() { l.Panicw(ctx, "panic 3", "n", 3) })
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "panic", entries[2]["level"])
+	assert.Equal(t, float64(3), entries[2]["n"])
+
+	// panics even if the logger is closed
+	assert.NoError(t, l.Close())
+	assert.PanicsWithValue(t, "closed", func() { l.Panic(ctx, "closed") })
+}
@@ -0,0 +1,218 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeLines decodes the JSON entries written to `buf`.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		entry := map[string]any{}
+		assert.NoError(t, json.Unmarshal(line, &entry), string(line))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newJSONTestLogger(buf *bytes.Buffer) *Logger {
+	l := newTestLogger(buf)
+	_ = l.SetConfigWithMap(map[string]any{"format": "json"})
+	l.parent.SetOutput(buf)
+	return l
+}
+
+func TestField_Value(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		field Field
+		value any
+	}{
+		{String("k", "v"), "v"},
+		{Int("k", 1), int64(1)},
+		{Int64("k", 2), int64(2)},
+		{Float64("k", 1.5), 1.5},
+		{Bool("k", true), true},
+		{Bool("k", false), false},
+		{Time("k", now), now},
+		{Duration("k", 1500*time.Millisecond), "1.5s"},
+		{Err(errors.New("failed")), "failed"},
+		{Err(nil), nil},
+		{Any("k", []int{1}), []int{1}},
+		{Any("k", 3), int64(3)},
+		{Any("k", errors.New("e")), "e"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.value, tt.field.Value(), tt.field.Key)
+	}
+	assert.Equal(t, "error", Err(nil).Key)
+}
+
+func TestLogger_With(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	ctx := context.Background()
+
+	parent := l.With(String("service", "api"), String("env", "dev"))
+	child := parent.With(String("env", "prod"), Int("shard", 3))
+	assert.Len(t, parent.Fields(), 2)
+	assert.Len(t, child.Fields(), 4)
+
+	parent.Info(ctx, "parent")
+	child.Infof(ctx, "child %d", 1)
+	l.Info(ctx, "root")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "api", entries[0]["service"])
+	assert.Equal(t, "dev", entries[0]["env"])
+	assert.Nil(t, entries[0]["shard"])
+	assert.Equal(t, "child 1", entries[1]["msg"])
+	assert.Equal(t, "prod", entries[1]["env"])
+	assert.Equal(t, float64(3), entries[1]["shard"])
+	assert.Nil(t, entries[2]["service"])
+
+	// child loggers share the level of the logger
+	l.SetLevel(ErrorLevel)
+	assert.False(t, child.IsLevelEnabled(InfoLevel))
+}
+
+func TestLogger_WithFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.WithFields(Fields{"user": "u1", "elapsed": time.Second}).Warn(context.Background(), "slow")
+
+	entries := decodeLines(t, &buf)
+	assert.Equal(t, "u1", entries[0]["user"])
+	assert.Equal(t, "1s", entries[0]["elapsed"])
+	assert.Equal(t, "warning", entries[0]["level"])
+}
+
+func TestLogger_Infow(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf).With(String("service", "api"), Int("attempt", 1))
+	ctx := context.Background()
+
+	l.Infow(ctx, "done", "path", "/users", "attempt", 2, Bool("cached", true), Err(errors.New("e")), "dangling")
+	l.Debugw(ctx, "dropped", "k", "v")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "done", entries[0]["msg"])
+	assert.Equal(t, "api", entries[0]["service"])
+	assert.Equal(t, "/users", entries[0]["path"])
+	assert.Equal(t, float64(2), entries[0]["attempt"])
+	assert.Equal(t, true, entries[0]["cached"])
+	assert.Equal(t, "e", entries[0]["error"])
+	assert.Equal(t, "dangling", entries[0][badKey])
+	assert.Contains(t, entries[0][FieldLogError], "odd number")
+}
+
+func TestLogger_InfowInvalidKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+
+	assert.NotPanics(t, func() {
+		l.Infow(context.Background(), "done", 42, "k", "v", "dangling")
+		l.Infow(context.Background(), "valid", "k", "v")
+	})
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "dangling", entries[0][badKey])
+	assert.Equal(t, "v", entries[0]["k"])
+	assert.Equal(t, `non-string key 42 of type int; odd number of keys and values, "dangling" has no key`, entries[0][FieldLogError])
+	assert.NotContains(t, entries[1], FieldLogError)
+}
+
+func TestLogger_InfoFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetSampling(1, 0, time.Hour)
+	ctx := context.Background()
+
+	l.Info(ctx, "done", Int("status", 200), String("path", "/users"))
+	l.Info(ctx, "done", Int("status", 500))
+	l.Warn(ctx, "retry ", 2, Int("attempt", 2))
+	l.Infof(ctx, "done %d", 3)
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "done", entries[0]["msg"])
+	assert.Equal(t, float64(200), entries[0]["status"])
+	assert.Equal(t, "/users", entries[0]["path"])
+	assert.Equal(t, "retry 2", entries[1]["msg"])
+	assert.Equal(t, float64(2), entries[1]["attempt"])
+	assert.Equal(t, "done 3", entries[2]["msg"])
+}
+
+func TestLogger_WithConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf).With(String("base", "1"))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := l.With(Int("worker", i))
+			child.With(Int("step", i)).Info(context.Background(), "work")
+		}(i)
+	}
+	wg.Wait()
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 10)
+	for _, entry := range entries {
+		assert.Equal(t, "1", entry["base"])
+		assert.Equal(t, entry["worker"], entry["step"])
+	}
+}
+
+func TestLazy(t *testing.T) {
+	var buf, extra bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.AddWriter(&extra)
+	ctx := context.Background()
+
+	var calls int
+	lazy := Lazy("state", func() any {
+		calls++
+		return map[string]int{"n": calls}
+	})
+
+	// not evaluated for disabled levels or sampled entries
+	l.Debugw(ctx, "disabled", lazy)
+	l.SetSampling(1, 0, time.Minute)
+	l.Infow(ctx, "sampled", lazy)
+	l.Infow(ctx, "sampled", lazy)
+	assert.Equal(t, 1, calls)
+	l.SetSampling(0, 0, 0)
+
+	// evaluated once with multiple writers
+	buf.Reset()
+	extra.Reset()
+	calls = 0
+	l.With(lazy).Info(ctx, "written")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]any{"n": float64(1)}, decodeLines(t, &buf)[0]["state"])
+	assert.Contains(t, extra.String(), "state")
+
+	buf.Reset()
+	l.Infow(ctx, "strings",
+		LazyString("name", func() string { return "computed" }),
+		Lazy("broken", func() any { panic("boom") }),
+	)
+	entry := decodeLines(t, &buf)[0]
+	assert.Equal(t, "computed", entry["name"])
+	assert.Equal(t, "!PANIC: boom", entry["broken"])
+}
@@ -0,0 +1,266 @@
+package mlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDatePattern(t *testing.T) {
+	tm := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]string{
+		"app.log":               "app.log",
+		"{Y}-{m}-{d}.log":       "2026-01-02.log",
+		"app-{Y-m-d}.log":       "app-2026-01-02.log",
+		"app-{Ymd}-{H:i:s}.log": "app-20260102-03:04:05.log",
+		"{y}{m}/{d}.log":        "2601/02.log",
+		"{app}.log":             "{app}.log",
+	}
+	for pattern, want := range tests {
+		assert.Equal(t, want, formatDatePattern(pattern, tm), pattern)
+	}
+	assert.True(t, IsDatePattern("app-{Y-m-d}.log"))
+	assert.False(t, IsDatePattern("app.log"))
+}
+
+func TestConvertDatePatternToRegex(t *testing.T) {
+	re := regexp.MustCompile(convertDatePatternToRegex("app-{Y-m-d}.log"))
+	for _, name := range []string{
+		"app-2026-01-02.log",
+		"app-2026-01-02-20260102T030405.000.log",
+		"app-2026-01-02-20260102T030405.000.log.gz",
+		"app-2026-01-02.log.gz",
+	} {
+		assert.True(t, re.MatchString(name), name)
+	}
+	for _, name := range []string{"app-2026-01-02.txt", "other-2026-01-02.log", "app-2026-1-2.log"} {
+		assert.False(t, re.MatchString(name), name)
+	}
+
+	re = regexp.MustCompile(convertDatePatternToRegex("access.log"))
+	assert.True(t, re.MatchString("access.log"))
+	assert.True(t, re.MatchString("access-20260102T030405.000.log.gz"))
+	assert.False(t, re.MatchString("access2.log"))
+}
+
+// readLogLines reads the lines of all log files in `dir`, decompressing gzip files.
+func readLogLines(t *testing.T, dir string) []string {
+	var lines []string
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		f, err := os.Open(filepath.Join(dir, entry.Name()))
+		assert.NoError(t, err)
+		var r io.Reader = f
+		if strings.HasSuffix(entry.Name(), compressSuffix) {
+			gz, err := gzip.NewReader(f)
+			assert.NoError(t, err)
+			r = gz
+		}
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		_ = f.Close()
+	}
+	return lines
+}
+
+func TestFileWriter_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newFileWriter(dir, "app.log", fileRotation{MaxSize: 1024})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := fmt.Fprintf(w, "goroutine %d entry %02d\n", i, j)
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.NoError(t, w.Close())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Greater(t, len(entries), 5)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1024), entry.Name())
+	}
+
+	// no entry is lost or split
+	lines := readLogLines(t, dir)
+	assert.Len(t, lines, 500)
+	for _, line := range lines {
+		assert.Regexp(t, `^goroutine \d entry \d{2}$`, line)
+	}
+}
+
+func TestFileWriter_MaxBackupsAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newFileWriter(dir, "app-{Y-m-d}.log", fileRotation{MaxSize: 10, MaxBackups: 2, Compress: true})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err = fmt.Fprintf(w, "entry %d\n", i)
+		assert.NoError(t, err)
+		// make the rotated file names and modification times distinct
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.millRun()
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var compressed, plain int
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), compressSuffix) {
+			compressed++
+		} else {
+			plain++
+		}
+	}
+	assert.Equal(t, 2, compressed)
+	assert.Equal(t, 1, plain)
+	assert.ElementsMatch(t, []string{"entry 2", "entry 3", "entry 4"}, readLogLines(t, dir))
+}
+
+func TestFileWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "app-20200101T000000.000.log")
+	assert.NoError(t, os.WriteFile(old, []byte("old\n"), 0644))
+	past := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(old, past, past))
+	recent := filepath.Join(dir, "app-20200102T000000.000.log")
+	assert.NoError(t, os.WriteFile(recent, []byte("recent\n"), 0644))
+
+	w, err := newFileWriter(dir, "app.log", fileRotation{MaxAge: 24 * time.Hour})
+	assert.NoError(t, err)
+	defer w.Close()
+	w.millRun()
+
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	assert.NoError(t, err)
+}
+
+func TestLogger_FileConfig(t *testing.T) {
+	dir := t.TempDir()
+	l := New()
+	assert.Nil(t, l.out.file)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":        dir,
+		"file":        "access-{Y-m-d}.log",
+		"max_size":    1,
+		"max_backups": 3,
+		"max_age":     7,
+		"compress":    true,
+		"stdout":      false,
+	}))
+	defer l.SetPath("")
+
+	assert.NotNil(t, l.out.file)
+	assert.Equal(t, int64(1)*megabyte, l.out.file.rotation.MaxSize)
+	assert.Equal(t, 3, l.out.file.rotation.MaxBackups)
+	assert.Equal(t, 7*24*time.Hour, l.out.file.rotation.MaxAge)
+	assert.True(t, l.out.file.rotation.Compress)
+
+	// config keys not affecting the outputs keep the file open
+	file := l.out.file
+	l.SetLevel(DebugLevel)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{"format": "json"}))
+	assert.Same(t, file, l.out.file)
+
+	l.Info(context.Background(), "to file")
+	content, err := os.ReadFile(filepath.Join(dir, formatDatePattern("access-{Y-m-d}.log", time.Now())))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "to file")
+}
+
+func TestLogger_RetentionPlan(t *testing.T) {
+	dir := t.TempDir()
+	today := formatDatePattern("app-{Y-m-d}.log", time.Now())
+	files := map[string]time.Duration{
+		"app-2020-01-01.log":                     -72 * time.Hour,
+		"app-2020-01-02.log":                     -48 * time.Hour,
+		"app-2020-01-03-20200103T100000.000.log": -2 * time.Hour,
+		"app-2020-01-03.log.gz":                  -time.Hour,
+		"other-2020-01-01.log":                   -72 * time.Hour,
+		// the active file is never removed, even if it is old
+		today: -96 * time.Hour,
+	}
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(name+"\n"), 0644))
+		mtime := time.Now().Add(age)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	l := New()
+	plan, err := l.RetentionPlan()
+	assert.NoError(t, err)
+	assert.Empty(t, plan)
+
+	// a writer that is not opened, as opening starts the cleanup
+	w := &fileWriter{
+		dir:         dir,
+		filePattern: "app-{Y-m-d}.log",
+		rotation:    fileRotation{MaxAge: 24 * time.Hour, Compress: true},
+		currentPath: filepath.Join(dir, today),
+	}
+	plan, err = w.retentionPlan()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "app-2020-01-01.log"),
+		filepath.Join(dir, "app-2020-01-02.log"),
+	}, plan.Remove)
+	assert.Equal(t, []string{filepath.Join(dir, "app-2020-01-03-20200103T100000.000.log")}, plan.Compress)
+
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":     dir,
+		"file":     "app-{Y-m-d}.log",
+		"max_age":  1,
+		"compress": true,
+		"stdout":   false,
+	}))
+
+	// wait for the cleanup started on opening
+	file := l.out.file
+	assert.NoError(t, l.Close())
+	file.millRun()
+	plan, err = file.retentionPlan()
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Remove)
+	assert.Empty(t, plan.Compress)
+
+	var names []string
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"app-2020-01-03-20200103T100000.000.log.gz",
+		"app-2020-01-03.log.gz",
+		"other-2020-01-01.log",
+		today,
+	}, names)
+}
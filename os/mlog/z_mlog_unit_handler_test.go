@@ -0,0 +1,52 @@
+package mlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveLevelRequest(method string, target string, body string) (*httptest.ResponseRecorder, map[string]string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	LevelHandler().ServeHTTP(w, req)
+	res := map[string]string{}
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	return w, res
+}
+
+func TestLevelHandler(t *testing.T) {
+	l := Instance("level-handler")
+	l.SetLevel(InfoLevel)
+
+	w, res := serveLevelRequest(http.MethodGet, "/?name=level-handler", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]string{"name": "level-handler", "level": "info"}, res)
+
+	w, res = serveLevelRequest(http.MethodPut, "/?name=level-handler", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "debug", res["level"])
+	assert.Equal(t, DebugLevel, l.GetLevel())
+
+	w, _ = serveLevelRequest(http.MethodPut, "/?name=level-handler&level=warn", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, WarnLevel, l.GetLevel())
+
+	w, _ = serveLevelRequest(http.MethodPut, "/?name=level-handler", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, WarnLevel, l.GetLevel())
+
+	w, _ = serveLevelRequest(http.MethodGet, "/?name=level-handler-missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = serveLevelRequest(http.MethodDelete, "/?name=level-handler", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w, res = serveLevelRequest(http.MethodGet, "/", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, GetLevel().String(), res["level"])
+}
@@ -0,0 +1,129 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hostnameHook adds the hostname to every entry.
+type hostnameHook struct {
+	hostname string
+}
+
+func (h *hostnameHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *hostnameHook) Fire(ctx context.Context, entry *Entry) error {
+	entry.Data["hostname"] = h.hostname
+	entry.Message = "[" + h.hostname + "] " + entry.Message
+	return nil
+}
+
+// levelCountHook counts the entries by level.
+type levelCountHook struct {
+	mu     sync.Mutex
+	counts map[Level]int
+}
+
+func (h *levelCountHook) Levels() []Level {
+	return []Level{WarnLevel, ErrorLevel}
+}
+
+func (h *levelCountHook) Fire(ctx context.Context, entry *Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[entry.Level]++
+	return nil
+}
+
+// failingHook always fails.
+type failingHook struct{}
+
+func (h *failingHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *failingHook) Fire(ctx context.Context, entry *Entry) error {
+	return errors.New("hook failed")
+}
+
+type hookCtxKey struct{}
+
+// ctxCheckHook records the context value and the entry fields it sees.
+type ctxCheckHook struct {
+	value any
+	entry Entry
+}
+
+func (h *ctxCheckHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *ctxCheckHook) Fire(ctx context.Context, entry *Entry) error {
+	h.value = ctx.Value(hookCtxKey{})
+	h.entry = *entry
+	return nil
+}
+
+func TestLogger_HookMutation(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	hostname, _ := os.Hostname()
+	l.AddHook(&hostnameHook{hostname: hostname})
+
+	l.With(String("service", "api")).Info(context.Background(), "started")
+
+	entries := decodeLines(t, &buf)
+	assert.Equal(t, hostname, entries[0]["hostname"])
+	assert.Equal(t, "api", entries[0]["service"])
+	assert.Equal(t, "["+hostname+"] started", entries[0]["msg"])
+}
+
+func TestLogger_HookLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	hook := &levelCountHook{counts: map[Level]int{}}
+	l.AddHook(hook)
+
+	ctx := context.Background()
+	l.Info(ctx, "info")
+	l.Warn(ctx, "warn")
+	l.Error(ctx, "error 1")
+	l.Errorw(ctx, "error 2", "k", "v")
+
+	assert.Equal(t, map[Level]int{WarnLevel: 1, ErrorLevel: 2}, hook.counts)
+}
+
+func TestLogger_HookContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	hook := &ctxCheckHook{}
+	l.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), hookCtxKey{}, "value")
+	l.Warnw(ctx, "message", "k", "v")
+	assert.Equal(t, "value", hook.value)
+	assert.Equal(t, WarnLevel, hook.entry.Level)
+	assert.Equal(t, "message", hook.entry.Message)
+	assert.Equal(t, "v", hook.entry.Data["k"])
+	assert.False(t, hook.entry.Time.IsZero())
+
+	// a nil context is replaced by the background context
+	l.Info(nil, "nil context")
+	assert.Nil(t, hook.value)
+}
+
+func TestLogger_HookFailure(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.AddHook(&failingHook{})
+	l.Info(context.Background(), "written anyway")
+	assert.Contains(t, buf.String(), "written anyway")
+}
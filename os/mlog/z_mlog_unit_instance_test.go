@@ -0,0 +1,231 @@
+package mlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graingo/maltose/os/mcfg"
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfigAdapter is a mcfg adapter serving a map.
+type testConfigAdapter struct {
+	mu   sync.Mutex
+	data map[string]any
+}
+
+func (a *testConfigAdapter) Get(ctx context.Context, pattern string) (any, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var value any = a.data
+	for _, key := range strings.Split(pattern, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		value = m[key]
+	}
+	return value, nil
+}
+
+func (a *testConfigAdapter) Data(ctx context.Context) (map[string]any, error) {
+	return a.data, nil
+}
+
+func (a *testConfigAdapter) Available(ctx context.Context, resource ...string) bool {
+	return true
+}
+
+func (a *testConfigAdapter) set(data map[string]any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.data = data
+}
+
+func useTestConfig(t *testing.T, data map[string]any) *testConfigAdapter {
+	adapter := &testConfigAdapter{data: data}
+	prev := mcfg.Instance().GetAdapter()
+	mcfg.Instance().SetAdapter(adapter)
+	t.Cleanup(func() { mcfg.Instance().SetAdapter(prev) })
+	return adapter
+}
+
+func TestInstance_Config(t *testing.T) {
+	adapter := useTestConfig(t, map[string]any{
+		"logger": map[string]any{
+			"level":  "warn",
+			"stdout": false,
+			"test_access": map[string]any{
+				"level":       "debug",
+				"stdout":      false,
+				"format":      "json",
+				"caller":      true,
+				"ctx_keys":    []any{"user_id"},
+				"max_backups": 3,
+			},
+		},
+	})
+
+	access := Instance("test_access")
+	assert.Equal(t, DebugLevel, access.GetLevel())
+	assert.Equal(t, "json", access.config.Format)
+	assert.True(t, access.config.Caller)
+	assert.Equal(t, []string{"user_id"}, access.config.CtxKeys)
+	assert.Equal(t, 3, access.config.MaxBackups)
+	assert.Same(t, access, Instance("test_access"))
+
+	// fall back to the logger node
+	other := Instance("test_other")
+	assert.Equal(t, WarnLevel, other.GetLevel())
+	assert.Empty(t, other.config.Format)
+
+	// config changes are re-applied
+	adapter.set(map[string]any{
+		"logger": map[string]any{
+			"level":  "error",
+			"stdout": false,
+		},
+	})
+	assert.NoError(t, ReloadConfig(context.Background()))
+	assert.Equal(t, ErrorLevel, access.GetLevel())
+	assert.Equal(t, ErrorLevel, other.GetLevel())
+}
+
+func TestInstance_WatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(level string) {
+		content := "logger:\n  test_watched:\n    level: " + level + "\n    stdout: false\n"
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("warn")
+	adapter, err := mcfg.NewAdapterFile()
+	assert.NoError(t, err)
+	assert.NoError(t, adapter.SetConfigFile(path))
+	prev := mcfg.Instance().GetAdapter()
+	mcfg.Instance().SetAdapter(adapter)
+	t.Cleanup(func() { mcfg.Instance().SetAdapter(prev) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, mcfg.Instance().Watch(ctx))
+
+	l := Instance("test_watched")
+	t.Cleanup(func() {
+		instanceMu.Lock()
+		defer instanceMu.Unlock()
+		instances.Remove("test_watched")
+		delete(instanceConfigs, "test_watched")
+	})
+	assert.Equal(t, WarnLevel, l.GetLevel())
+
+	// the changes of the watched file are applied to the existing instance
+	write("debug")
+	assert.Eventually(t, func() bool {
+		return l.GetLevel() == DebugLevel
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSetConfigWithMap(t *testing.T) {
+	useTestConfig(t, map[string]any{
+		"logger": map[string]any{
+			"level":  "warn",
+			"stdout": false,
+		},
+	})
+
+	// applied on creation, over the mcfg config
+	assert.NoError(t, SetConfigWithMap("test_custom", map[string]any{"level": "debug"}))
+	l := Instance("test_custom")
+	assert.Equal(t, DebugLevel, l.GetLevel())
+	assert.False(t, l.config.Stdout)
+
+	// applied immediately to an existing instance
+	assert.NoError(t, SetConfigWithMap("test_custom", map[string]any{"level": "error"}))
+	assert.Equal(t, ErrorLevel, l.GetLevel())
+	assert.NoError(t, ReloadConfig(context.Background()))
+	assert.Equal(t, ErrorLevel, l.GetLevel())
+
+	assert.Error(t, SetConfigWithMap("test_custom", map[string]any{"format": "xml"}))
+}
+
+func TestInstance_Hierarchy(t *testing.T) {
+	adapter := useTestConfig(t, map[string]any{
+		"logger": map[string]any{
+			"level":  "warn",
+			"stdout": false,
+			"test_app": map[string]any{
+				"level":  "info",
+				"stdout": false,
+				"format": "json",
+			},
+			"levels": map[string]any{
+				"test_app.db": "debug",
+			},
+		},
+	})
+	names := []string{"test_app", "test_app.db", "test_app.cache", "test_app.cache.redis"}
+	t.Cleanup(func() {
+		instanceMu.Lock()
+		defer instanceMu.Unlock()
+		for _, name := range names {
+			instances.Remove(name)
+			delete(instanceConfigs, name)
+		}
+	})
+	prevLevel := DefaultLogger().GetLevel()
+	defer DefaultLogger().SetLevel(prevLevel)
+
+	// the parent is created first, the config of its node is used
+	db := Instance("test_app.db")
+	app := Instance("test_app")
+	assert.Equal(t, InfoLevel, app.GetLevel())
+	assert.Equal(t, DebugLevel, db.GetLevel())
+	assert.Equal(t, "json", db.config.Format)
+
+	cache := Instance("test_app.cache")
+	assert.Equal(t, InfoLevel, cache.GetLevel())
+
+	// the level of the parent applies to the children without a level of their own
+	app.SetLevel(ErrorLevel)
+	redis := Instance("test_app.cache.redis")
+	assert.Equal(t, ErrorLevel, cache.GetLevel())
+	assert.Equal(t, ErrorLevel, redis.GetLevel())
+	assert.Equal(t, DebugLevel, db.GetLevel())
+
+	cache.SetLevel(DebugLevel)
+	app.SetLevel(WarnLevel)
+	assert.Equal(t, DebugLevel, cache.GetLevel())
+	assert.Equal(t, DebugLevel, redis.GetLevel())
+
+	// the children inherit the level again after SetLevelAll
+	SetLevelAll(InfoLevel)
+	assert.Equal(t, InfoLevel, db.GetLevel())
+	assert.Equal(t, InfoLevel, redis.GetLevel())
+	app.SetLevel(ErrorLevel)
+	assert.Equal(t, ErrorLevel, db.GetLevel())
+	assert.Equal(t, ErrorLevel, cache.GetLevel())
+	assert.Equal(t, ErrorLevel, redis.GetLevel())
+
+	// the levels split into nested maps by the config source are supported
+	adapter.set(map[string]any{
+		"logger": map[string]any{
+			"level":  "warn",
+			"stdout": false,
+			"levels": map[string]any{
+				"test_app": map[string]any{"cache": "debug"},
+			},
+		},
+	})
+	for _, name := range names {
+		assert.NoError(t, applyInstanceConfig(context.Background(), name, Instance(name)))
+	}
+	assert.Equal(t, WarnLevel, app.GetLevel())
+	assert.Equal(t, DebugLevel, cache.GetLevel())
+	assert.Equal(t, DebugLevel, redis.GetLevel())
+	assert.Equal(t, WarnLevel, db.GetLevel())
+}
@@ -0,0 +1,35 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCaptureInternal(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetLevel(DebugLevel)
+	handler := internalHandler(l)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+	}))
+	assert.True(t, handler(ctx, "mclient", "Retrying request (attempt 1/3)", false))
+	assert.True(t, handler(nil, "mclient", "ReadAll error", true))
+	// the internal logs of mlog are printed
+	assert.False(t, handler(ctx, "mlog", "failed to rotate log file", true))
+
+	entries := decodeLines(t, &buf)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "debug", entries[0]["level"])
+		assert.Equal(t, "mclient", entries[0][FieldComponent])
+		assert.Equal(t, traceID.String(), entries[0][FieldTraceID])
+		assert.Equal(t, "error", entries[1]["level"])
+		assert.Equal(t, "ReadAll error", entries[1]["msg"])
+	}
+}
@@ -0,0 +1,238 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLogger creates a logger writing text entries without time to `w`.
+func newTestLogger(w io.Writer) *Logger {
+	l := New()
+	_ = l.SetConfigWithMap(map[string]any{"path": "", "stdout": false})
+	l.parent.SetOutput(w)
+	return l
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"debug":   DebugLevel,
+		"INFO":    InfoLevel,
+		"warn":    WarnLevel,
+		"warning": WarnLevel,
+		" error ": ErrorLevel,
+		"fatal":   FatalLevel,
+		"panic":   PanicLevel,
+	}
+	for s, want := range tests {
+		level, err := ParseLevel(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, level, s)
+	}
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+
+	for level := DebugLevel; level <= PanicLevel; level++ {
+		text, err := level.MarshalText()
+		assert.NoError(t, err)
+		var parsed Level
+		assert.NoError(t, parsed.UnmarshalText(text))
+		assert.Equal(t, level, parsed)
+	}
+}
+
+func TestLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	ctx := context.Background()
+
+	assert.Equal(t, InfoLevel, l.GetLevel())
+	l.Debug(ctx, "debug message")
+	l.Info(ctx, "info message")
+	assert.NotContains(t, buf.String(), "debug message")
+	assert.Contains(t, buf.String(), "info message")
+
+	for level := DebugLevel; level <= PanicLevel; level++ {
+		l.SetLevel(level)
+		assert.Equal(t, level, l.GetLevel())
+		for check := DebugLevel; check <= PanicLevel; check++ {
+			assert.Equal(t, check >= level, l.IsLevelEnabled(check), "level %s check %s", level, check)
+		}
+	}
+
+	buf.Reset()
+	l.SetLevel(ErrorLevel)
+	l.Warnf(ctx, "warn %d", 1)
+	l.Errorf(ctx, "error %d", 2)
+	assert.NotContains(t, buf.String(), "warn 1")
+	assert.Contains(t, buf.String(), "error 2")
+
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{"level": "debug"}))
+	assert.Equal(t, DebugLevel, l.GetLevel())
+	assert.Error(t, l.SetConfigWithMap(map[string]any{"level": "verbose"}))
+}
+
+func TestInstance_Level(t *testing.T) {
+	a, b := Instance("level-a"), Instance("level-b")
+	a.SetLevel(ErrorLevel)
+	b.SetLevel(DebugLevel)
+	assert.Equal(t, ErrorLevel, Instance("level-a").GetLevel())
+	assert.Equal(t, DebugLevel, Instance("level-b").GetLevel())
+}
+
+func TestLogger_LevelConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	child := l.With(String("k", "v"))
+	ctx := context.Background()
+
+	l.SetLevel(ErrorLevel)
+	child.Info(ctx, "dropped")
+	l.SetLevel(DebugLevel)
+	child.Debug(ctx, "child debug")
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "child debug")
+	assert.Equal(t, DebugLevel, child.GetLevel())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				child.Debugf(ctx, "message %d", j)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.SetLevel(Level((i + j) % 3))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSetLevelAll(t *testing.T) {
+	prev := GetLevel()
+	defer SetLevel(prev)
+	a, b := Instance("level-all-a"), Instance("level-all-b")
+	a.SetLevel(InfoLevel)
+	b.SetLevel(ErrorLevel)
+
+	SetLevelAll(DebugLevel)
+	assert.Equal(t, DebugLevel, a.GetLevel())
+	assert.Equal(t, DebugLevel, b.GetLevel())
+	assert.Equal(t, DebugLevel, GetLevel())
+}
+
+func BenchmarkDisabledLevel(b *testing.B) {
+	l := newTestLogger(io.Discard)
+	l.SetLevel(ErrorLevel)
+	ctx := context.Background()
+
+	b.Run("Debug", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Debug(ctx, "message")
+		}
+	})
+	b.Run("Infof", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Infof(ctx, "message %s", "value")
+		}
+	})
+	b.Run("Debugw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Debugw(ctx, "message", "key", "value", "attempt", 1)
+		}
+	})
+	b.Run("Check", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if ce := l.Check(DebugLevel); ce != nil {
+				ce.Write(ctx, "message", String("key", "value"), Int("attempt", i))
+			}
+		}
+	})
+}
+
+func BenchmarkEnabledJSON(b *testing.B) {
+	l := newTestLogger(io.Discard)
+	_ = l.SetConfigWithMap(map[string]any{"format": "json"})
+	l.parent.SetOutput(io.Discard)
+	ctx := context.Background()
+
+	b.Run("Infow", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Infow(ctx, "request done", "path", "/users", "status", 200)
+		}
+	})
+	b.Run("Check", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if ce := l.Check(InfoLevel); ce != nil {
+				ce.Write(ctx, "request done", String("path", "/users"), Int("status", 200))
+			}
+		}
+	})
+}
+
+func TestLogger_DisabledLevelAllocs(t *testing.T) {
+	l := newTestLogger(io.Discard)
+	l.SetLevel(ErrorLevel)
+	ctx := context.Background()
+	key, attempt := "user:42", 1000
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Debug(ctx, "message")
+		l.Infof(ctx, "message %s", "value")
+		l.Warn(ctx, "message")
+		l.Debugw(ctx, "message", "key", "value", "attempt", 1)
+		if ce := l.Check(DebugLevel); ce != nil {
+			ce.Write(ctx, "message", String("key", key), Int("attempt", attempt))
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestLogger_Check(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetCaller(true, 0)
+	ctx := context.Background()
+
+	assert.Nil(t, l.Check(DebugLevel))
+	assert.NotNil(t, l.Check(FatalLevel))
+	assert.NotNil(t, l.Check(PanicLevel))
+	if ce := l.Check(WarnLevel); assert.NotNil(t, ce) {
+		ce.Write(ctx, "checked", String("key", "value"))
+	}
+
+	entries := decodeLines(t, &buf)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "checked", entries[0]["msg"])
+		assert.Equal(t, "warning", entries[0]["level"])
+		assert.Equal(t, "value", entries[0]["key"])
+		assert.Contains(t, entries[0][FieldCaller], "z_mlog_unit_level_test.go")
+	}
+
+	var code int
+	l.SetExitFunc(func(c int) { code = c })
+	l.Check(FatalLevel).Write(ctx, "fatal")
+	assert.Equal(t, 1, code)
+	assert.PanicsWithValue(t, "panic", func() { l.Check(PanicLevel).Write(ctx, "panic") })
+
+	// a closed logger discards the checked entries
+	buf.Reset()
+	ce := l.Check(InfoLevel)
+	assert.NoError(t, l.Close())
+	ce.Write(ctx, "after close")
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,114 @@
+package mlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closeWriter records the calls of the lifecycle methods.
+type closeWriter struct {
+	bytes.Buffer
+	synced, closed int
+	closeErr       error
+}
+
+func (w *closeWriter) Sync() error {
+	w.synced++
+	return nil
+}
+
+func (w *closeWriter) Close() error {
+	w.closed++
+	return w.closeErr
+}
+
+func TestLogger_FlushAndSync(t *testing.T) {
+	dir := t.TempDir()
+	l := New()
+	defer l.Close()
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":         dir,
+		"file":         "app.log",
+		"stdout":       false,
+		"async_buffer": 100,
+	}))
+	var buf bytes.Buffer
+	buffered := bufio.NewWriter(&buf)
+	extra := &closeWriter{}
+	l.AddWriter(buffered)
+	l.AddWriter(extra)
+
+	l.Info(context.Background(), "flushed")
+	assert.Empty(t, buf.String())
+	assert.NoError(t, l.Flush())
+	assert.Contains(t, buf.String(), "flushed")
+	content, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "flushed")
+
+	assert.NoError(t, l.Sync())
+	assert.Equal(t, 1, extra.synced)
+}
+
+func TestLogger_Close(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	child := l.With(String("k", "v"))
+	extra := &closeWriter{closeErr: errors.New("close failed")}
+	l.AddWriter(extra)
+	l.AddWriter(os.Stderr, WithMinLevel(PanicLevel))
+	l.SetSampling(1, 0, time.Minute)
+
+	l.Info(context.Background(), "before close")
+	err := l.Close()
+	assert.ErrorContains(t, err, "close failed")
+	assert.Equal(t, 1, extra.closed)
+
+	// the following entries are discarded, for the child loggers too
+	buf.Reset()
+	extra.Reset()
+	l.Error(context.Background(), "after close")
+	child.Errorw(context.Background(), "after close")
+	assert.False(t, child.IsLevelEnabled(PanicLevel))
+	assert.Empty(t, buf.String())
+	assert.Empty(t, extra.String())
+
+	// closing again is safe
+	assert.NoError(t, l.Close())
+	assert.NoError(t, child.Close())
+	assert.Equal(t, 1, extra.closed)
+	assert.NoError(t, l.Flush())
+	assert.NoError(t, l.Sync())
+}
+
+func TestCloseAll(t *testing.T) {
+	useTestConfig(t, map[string]any{})
+	prev := defaultLogger
+	defer SetDefaultLogger(prev)
+	SetDefaultLogger(New())
+
+	// recreate the closed instances for the following tests
+	names := instanceNames()
+	t.Cleanup(func() {
+		instanceMu.Lock()
+		defer instanceMu.Unlock()
+		for _, name := range append(names, "close_all_test") {
+			instances.Remove(name)
+			delete(instanceConfigs, name)
+		}
+	})
+
+	l := Instance("close_all_test")
+	assert.NoError(t, CloseAll())
+	assert.False(t, l.IsLevelEnabled(PanicLevel))
+	assert.False(t, DefaultLogger().IsLevelEnabled(PanicLevel))
+	assert.NoError(t, CloseAll())
+}
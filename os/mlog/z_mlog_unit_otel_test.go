@@ -0,0 +1,100 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/log/logtest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOtelHook(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+
+	recorder := logtest.NewRecorder()
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.AddHook(NewOtelHook(recorder))
+
+	l.Infow(ctx, "request done", "status", 200, "path", "/users", "ok", true)
+	l.Errorw(ctx, "request failed", Err(errors.New("boom")))
+	span.End()
+
+	records := recorder.Result()[0].Records
+	assert.Equal(t, otelScopeName, recorder.Result()[0].Name)
+	assert.Len(t, records, 2)
+
+	info := records[0]
+	assert.Equal(t, otellog.SeverityInfo, info.Severity())
+	assert.Equal(t, "info", info.SeverityText())
+	assert.Equal(t, "request done", info.Body().AsString())
+	assert.False(t, info.Timestamp().IsZero())
+	assert.Equal(t, span.SpanContext(), trace.SpanContextFromContext(info.Context()))
+	attrs := map[string]otellog.Value{}
+	info.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, int64(200), attrs["status"].AsInt64())
+	assert.Equal(t, "/users", attrs["path"].AsString())
+	assert.True(t, attrs["ok"].AsBool())
+	assert.NotContains(t, attrs, FieldTraceID)
+	assert.NotContains(t, attrs, FieldSpanID)
+
+	assert.Equal(t, otellog.SeverityError, records[1].Severity())
+
+	// error entries are recorded as span events
+	events := spans.Ended()[0].Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, "log", events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.String("log.message", "request failed"))
+	assert.Contains(t, events[0].Attributes, attribute.String("log.severity", "error"))
+
+	// the entries are still written
+	assert.Len(t, decodeLines(t, &buf), 2)
+}
+
+func TestOtelSeverities(t *testing.T) {
+	want := map[Level]int{
+		DebugLevel: 5,
+		InfoLevel:  9,
+		WarnLevel:  13,
+		ErrorLevel: 17,
+		FatalLevel: 21,
+		PanicLevel: 24,
+	}
+	for _, level := range AllLevels() {
+		assert.Equal(t, want[level], int(otelSeverities[level]), level.String())
+	}
+}
+
+func TestOtelHook_Config(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	prev := global.GetLoggerProvider()
+	global.SetLoggerProvider(recorder)
+	defer global.SetLoggerProvider(prev)
+
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{"otel": true}))
+	// enabling twice does not duplicate the hook
+	l.SetOtel(true)
+	l.Warn(context.Background(), "exported")
+	assert.Len(t, recorder.Result(), 1)
+	assert.Len(t, recorder.Result()[0].Records, 1)
+	assert.Equal(t, otellog.SeverityWarn, recorder.Result()[0].Records[0].Severity())
+
+	l.SetOtel(false)
+	l.Warn(context.Background(), "not exported")
+	assert.Len(t, recorder.Result()[0].Records, 1)
+}
@@ -0,0 +1,124 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFileTestLogger(t *testing.T, dir string) *Logger {
+	l := New()
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"path":   dir,
+		"file":   "app.log",
+		"stdout": false,
+		"format": "json",
+	}))
+	return l
+}
+
+func TestLogger_ReopenFiles(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileTestLogger(t, dir)
+	defer l.Close()
+	ctx := context.Background()
+
+	l.Info(ctx, "before")
+	// the rotation tool renames the file, the entries still go to the renamed file until reopened
+	assert.NoError(t, os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")))
+	l.Info(ctx, "renamed")
+	assert.NoError(t, l.ReopenFiles())
+	l.Info(ctx, "after")
+
+	rotated, err := os.ReadFile(filepath.Join(dir, "app.log.1"))
+	assert.NoError(t, err)
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Len(t, decodeLines(t, bytes.NewBuffer(rotated)), 2)
+	entries := decodeLines(t, bytes.NewBuffer(current))
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "after", entries[0]["msg"])
+	}
+
+	assert.NoError(t, l.Close())
+	assert.NoError(t, l.ReopenFiles())
+	assert.NoError(t, New().ReopenFiles())
+}
+
+func TestLogger_ReopenFilesConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileTestLogger(t, dir)
+	defer l.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.Infow(ctx, "concurrent entry", "j", j)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		_ = os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log."+strconv.Itoa(i)))
+		assert.NoError(t, l.ReopenFiles())
+	}
+	wg.Wait()
+	assert.NoError(t, l.Close())
+
+	// no entry is lost or corrupted by the swaps
+	files, err := filepath.Glob(filepath.Join(dir, "app.log*"))
+	assert.NoError(t, err)
+	var total int
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		total += len(decodeLines(t, bytes.NewBuffer(content)))
+	}
+	assert.Equal(t, 800, total)
+}
+
+func TestHandleSIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("SIGHUP is not supported on this system")
+	}
+	useTestConfig(t, map[string]any{})
+	t.Cleanup(func() {
+		instanceMu.Lock()
+		defer instanceMu.Unlock()
+		instances.Remove("sighup_test")
+		delete(instanceConfigs, "sighup_test")
+	})
+
+	dir := t.TempDir()
+	l := Instance("sighup_test")
+	defer l.Close()
+	assert.NoError(t, SetConfigWithMap("sighup_test", map[string]any{
+		"path":   dir,
+		"file":   "app.log",
+		"stdout": false,
+	}))
+	l.Info(context.Background(), "before")
+	assert.NoError(t, os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1")))
+
+	stop := HandleSIGHUP()
+	defer stop()
+	process, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+	assert.NoError(t, process.Signal(syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "app.log"))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
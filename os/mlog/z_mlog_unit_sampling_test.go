@@ -0,0 +1,127 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetSampling(2, 3, time.Hour)
+	defer l.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		l.Warnf(ctx, "disk almost full: %d", i)
+		l.Info(ctx, "other message")
+		l.Error(ctx, "errors are not sampled")
+	}
+	l.With(String("k", "v")).Warnw(ctx, "child", "i", 1)
+
+	var warns, infos, errs, child int
+	for _, entry := range decodeLines(t, &buf) {
+		switch entry["msg"] {
+		case "disk almost full: 0", "disk almost full: 1", "disk almost full: 4", "disk almost full: 7":
+			warns++
+		case "other message":
+			infos++
+		case "errors are not sampled":
+			errs++
+		case "child":
+			child++
+		default:
+			t.Errorf("unexpected entry %v", entry)
+		}
+	}
+	// the first 2, then every 3rd: 1, 2, 5, 8
+	assert.Equal(t, 4, warns)
+	assert.Equal(t, 4, infos)
+	assert.Equal(t, 10, errs)
+	assert.Equal(t, 1, child)
+
+	// the summaries are written when the sampler stops
+	buf.Reset()
+	assert.NoError(t, l.Close())
+	summaries := map[string]float64{}
+	for _, entry := range decodeLines(t, &buf) {
+		summaries[entry[FieldSampledMessage].(string)] = entry[FieldSuppressed].(float64)
+		assert.Contains(t, entry["msg"], "similar entries")
+	}
+	assert.Equal(t, map[string]float64{"disk almost full: %d": 6, "other message": 6}, summaries)
+}
+
+func TestLogger_SamplingTick(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		mu  sync.Mutex
+	)
+	l := newJSONTestLogger(&buf)
+	l.parent.SetOutput(lockedWriter{&buf, &mu})
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"sampling_initial":    1,
+		"sampling_thereafter": 0,
+		"sampling_tick":       "50ms",
+		"sampling_levels":     []string{"error"},
+	}))
+	defer l.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		l.Errorw(ctx, "failed")
+		l.Warnw(ctx, "warn is not sampled")
+	}
+	time.Sleep(120 * time.Millisecond)
+	l.Errorw(ctx, "failed")
+
+	mu.Lock()
+	entries := decodeLines(t, &buf)
+	mu.Unlock()
+	var failed, warns, summaries int
+	for _, entry := range entries {
+		switch entry["msg"] {
+		case "failed":
+			failed++
+		case "warn is not sampled":
+			warns++
+		case "suppressed 4 similar entries":
+			summaries++
+			assert.Equal(t, "error", entry["level"])
+		}
+	}
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, 5, warns)
+	assert.Equal(t, 1, summaries)
+
+	assert.Error(t, l.SetConfigWithMap(map[string]any{"sampling_tick": "soon"}))
+}
+
+type lockedWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func BenchmarkLogger_Sampling(b *testing.B) {
+	l := newTestLogger(io.Discard)
+	l.SetSampling(10, 100, time.Second)
+	defer l.Close()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Warnf(ctx, "hot loop warning %d", 1)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetLevel(DebugLevel)
+	var fired []Level
+	l.AddHook(&levelsHook{fired: &fired})
+
+	logger := slog.New(SlogHandler(l.With(String("service", "api"))))
+	logger.Debug("debug message", "n", 1)
+	logger.With("user", "u1").WithGroup("request").Info("request done",
+		slog.String("method", "GET"),
+		slog.Group("response", slog.Int("status", 200)),
+		slog.Duration("elapsed", 1500*time.Millisecond),
+	)
+	logger.Warn("warn message", slog.Group("", slog.String("inline", "yes")))
+	logger.Error("error message", "error", errors.New("boom"))
+	logger.Log(context.Background(), slog.LevelError+4, "above error")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 5)
+	assert.Equal(t, "debug", entries[0]["level"])
+	assert.Equal(t, float64(1), entries[0]["n"])
+	assert.Equal(t, "api", entries[0]["service"])
+
+	assert.Equal(t, "info", entries[1]["level"])
+	assert.Equal(t, "u1", entries[1]["user"])
+	assert.Equal(t, "GET", entries[1]["request.method"])
+	assert.Equal(t, float64(200), entries[1]["request.response.status"])
+	assert.Equal(t, "1.5s", entries[1]["request.elapsed"])
+
+	assert.Equal(t, "warning", entries[2]["level"])
+	assert.Equal(t, "yes", entries[2]["inline"])
+	assert.Equal(t, "boom", entries[3]["error"])
+	assert.Equal(t, "error", entries[4]["level"])
+	assert.Equal(t, []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, ErrorLevel}, fired)
+
+	// disabled levels are not handled
+	buf.Reset()
+	l.SetLevel(WarnLevel)
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelInfo))
+	logger.Info("dropped")
+	assert.Empty(t, buf.String())
+}
+
+func TestSlogHandler_Caller(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetCaller(true, 0)
+	want := callerLine(1)
+	slog.New(SlogHandler(l)).Info("with caller")
+	assert.Equal(t, want, decodeLines(t, &buf)[0][FieldCaller])
+}
+
+func TestFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	l := FromSlog(h)
+	ctx := context.Background()
+
+	l.Debug(ctx, "dropped by the handler")
+	l.With(String("service", "api")).Infow(ctx, "request done", "status", 200)
+	l.Warnf(ctx, "warn %d", 1)
+	l.Errorw(ctx, "failed", Err(errors.New("boom")))
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		record := map[string]any{}
+		assert.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	assert.Len(t, records, 3)
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "request done", records[0]["msg"])
+	assert.Equal(t, "api", records[0]["service"])
+	assert.Equal(t, float64(200), records[0]["status"])
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, "ERROR", records[2]["level"])
+	assert.Equal(t, "boom", records[2]["error"])
+
+	// output config changes keep the slog backend
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{"stdout": false}))
+	l.Info(ctx, "still bridged")
+	assert.Contains(t, buf.String(), "still bridged")
+}
+
+func TestSlog_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.SetLevel(DebugLevel)
+	bridged := FromSlog(SlogHandler(l))
+	ctx := context.Background()
+
+	for level := DebugLevel; level <= ErrorLevel; level++ {
+		bridged.entry(ctx, level).WithField("n", int(level)).Log(level.toLogrusLevel(), "round trip")
+	}
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 4)
+	for i, entry := range entries {
+		level := Level(i)
+		assert.Equal(t, level.toLogrusLevel().String(), entry["level"])
+		assert.Equal(t, float64(i), entry["n"])
+	}
+
+	for level := DebugLevel; level <= PanicLevel; level++ {
+		want := min(level, ErrorLevel)
+		assert.Equal(t, want, fromSlogLevel(toSlogLevel(level)))
+	}
+}
+
+type levelsHook struct {
+	fired *[]Level
+}
+
+func (h *levelsHook) Levels() []Level {
+	return AllLevels()
+}
+
+func (h *levelsHook) Fire(ctx context.Context, entry *Entry) error {
+	*h.fired = append(*h.fired, entry.Level)
+	return nil
+}
@@ -0,0 +1,85 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graingo/maltose/errors/merror"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStackError() error {
+	return merror.New("stack error")
+}
+
+func TestErrorStack(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	ctx := context.Background()
+	err := merror.Wrap(newStackError(), "wrapped")
+	file, line, _, ok := merror.Caller(err)
+	assert.True(t, ok)
+	assert.True(t, strings.HasSuffix(file, "/z_mlog_unit_stack_test.go"), file)
+
+	// disabled by default
+	l.Errorw(ctx, "failed", Err(err))
+	assert.NotContains(t, decodeLines(t, &buf)[0], FieldStack)
+
+	l.SetErrorStack(true)
+	buf.Reset()
+	l.Errorw(ctx, "failed", Err(err))
+	l.With(Err(err)).Error(ctx, "failed")
+	l.Warnw(ctx, "not an error level", Err(err))
+	l.Errorw(ctx, "plain error", Err(errors.New("plain")))
+	l.Errorw(ctx, "explicit stack", Err(err), String(FieldStack, "kept"))
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 5)
+	for _, entry := range entries[:2] {
+		stack, _ := entry[FieldStack].(string)
+		// the stack is the one of the deepest error, where it originated
+		assert.True(t, strings.HasPrefix(stack, "github.com/graingo/maltose/os/mlog.newStackError\n\t"), stack)
+		assert.Contains(t, stack, "z_mlog_unit_stack_test.go:")
+		// the wrap points are annotated in the stack instead of repeating the stack of each error
+		assert.Contains(t, stack, "\n\twrapped \"wrapped\" at ")
+		assert.Equal(t, "wrapped: stack error", entry[FieldError])
+		assert.Equal(t, fmt.Sprintf("%s:%d", file, line), entry[FieldErrorCaller])
+	}
+	assert.NotContains(t, entries[2], FieldStack)
+	assert.NotContains(t, entries[3], FieldStack)
+	assert.NotContains(t, entries[3], FieldErrorCaller)
+	assert.Equal(t, "plain", entries[3][FieldError])
+	assert.Equal(t, "kept", entries[4][FieldStack])
+}
+
+func TestErrorStack_Depth(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"error_stack":       true,
+		"error_stack_depth": 1,
+	}))
+	err := newStackError()
+	l.Errorw(context.Background(), "failed", Err(err))
+
+	frames := merror.Frames(err)
+	assert.Greater(t, len(frames), 1)
+	stack := decodeLines(t, &buf)[0][FieldStack].(string)
+	lines := strings.Split(stack, "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "github.com/graingo/maltose/os/mlog.newStackError", lines[0])
+	assert.Equal(t, fmt.Sprintf("... %d more frames", len(frames)-1), lines[2])
+}
+
+func TestStackField(t *testing.T) {
+	field := StackField(newStackError())
+	assert.Equal(t, FieldStack, field.Key)
+	assert.Contains(t, field.Value(), "mlog.newStackError\n\t")
+
+	assert.Equal(t, "plain", StackField(errors.New("plain")).Value())
+	assert.Nil(t, StackField(nil).Value())
+}
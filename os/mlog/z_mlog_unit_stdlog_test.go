@@ -0,0 +1,66 @@
+package mlog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Writer(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	w := l.Writer(WarnLevel)
+
+	n, err := w.Write([]byte("first line\r\nsecond line\n\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 25, n)
+	_, _ = fmt.Fprintf(w, "third line\n")
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 3)
+	for i, msg := range []string{"first line", "second line", "third line"} {
+		assert.Equal(t, msg, entries[i]["msg"])
+		assert.Equal(t, "warning", entries[i]["level"])
+	}
+
+	// disabled level
+	buf.Reset()
+	_, _ = l.Writer(DebugLevel).Write([]byte("debug line\n"))
+	assert.Empty(t, buf.String())
+
+	// fatal is lowered to error, without exiting
+	_, _ = l.Writer(FatalLevel).Write([]byte("fatal line\n"))
+	assert.Equal(t, "error", decodeLines(t, &buf)[0]["level"])
+}
+
+func TestLogger_StdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.parent.SetOutput(lockedWriter{&buf, &sync.Mutex{}})
+	l.SetCaller(true, 0)
+	std := l.StdLogger(ErrorLevel)
+
+	_, file, line, _ := runtime.Caller(0)
+	std.Printf("http: TLS handshake error from %s", "10.0.0.1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			std.Println("concurrent", i)
+		}(i)
+	}
+	wg.Wait()
+
+	entries := decodeLines(t, &buf)
+	assert.Len(t, entries, 11)
+	assert.Equal(t, "http: TLS handshake error from 10.0.0.1", entries[0]["msg"])
+	assert.Equal(t, "error", entries[0]["level"])
+	assert.Equal(t, trimCallerPath(file)+":"+strconv.Itoa(line+1), entries[0][FieldCaller])
+}
@@ -0,0 +1,120 @@
+package mlog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogWriter_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String(), "my app")
+	assert.NoError(t, err)
+	defer w.Close()
+
+	l := newTestLogger(&strings.Builder{})
+	l.AddWriter(w)
+	l.Warnw(context.Background(), "disk almost full", "path", "/data", "note", `a "quoted" ]`)
+	_, _ = w.Write([]byte("plain line\n"))
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<12>1 "), msg)
+	assert.Contains(t, msg, " my_app ")
+	assert.True(t, strings.HasSuffix(msg, `[mlog@32473 note="a \"quoted\" \]" path="/data"] disk almost full`), msg)
+
+	n, _, err = conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	msg = string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<14>1 "), msg)
+	assert.True(t, strings.HasSuffix(msg, " - plain line"), msg)
+}
+
+func TestSyslogWriter_Reconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+
+	w, err := NewSyslogWriter("tcp", addr, "app")
+	assert.NoError(t, err)
+	defer w.Close()
+	server, err := ln.Accept()
+	assert.NoError(t, err)
+
+	// break the connection, until a write reports it
+	_ = server.Close()
+	_ = ln.Close()
+	var writeErr error
+	for i := 0; i < 100 && writeErr == nil; i++ {
+		_, writeErr = w.Write([]byte("lost"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Error(t, writeErr)
+
+	// buffered while disconnected, oldest dropped beyond the limit
+	w.SetMaxPending(3)
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		_, err = w.Write([]byte(msg))
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, w.Dropped(), int64(1))
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	w.mu.Lock()
+	w.retryAt = time.Time{}
+	w.mu.Unlock()
+	_, err = w.Write([]byte("five"))
+	assert.NoError(t, err)
+
+	server, err = ln.Accept()
+	assert.NoError(t, err)
+	defer server.Close()
+	_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var lines []string
+	scanner := bufio.NewScanner(server)
+	for len(lines) < 3 && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Len(t, lines, 3)
+	for i, msg := range []string{"three", "four", "five"} {
+		if i < len(lines) {
+			assert.True(t, strings.HasSuffix(lines[i], " - "+msg), lines[i])
+		}
+	}
+}
+
+func TestSyslogWriter_Closed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String(), "")
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	_, err = w.Write([]byte("closed"))
+	assert.Error(t, err)
+
+	_, err = NewSyslogWriter("tcp", "127.0.0.1:1", "app")
+	assert.Error(t, err)
+}
+
+func TestSyslogParamName(t *testing.T) {
+	assert.Equal(t, "user_id", syslogParamName("user_id"))
+	assert.Equal(t, "a_b_c_", syslogParamName(`a=b c"`))
+	assert.Equal(t, "_", syslogParamName(""))
+	assert.Len(t, syslogParamName(strings.Repeat("k", 40)), 32)
+}
@@ -0,0 +1,102 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_TimeFormatDefault(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONTestLogger(&buf)
+	l.Info(context.Background(), "message")
+
+	entries := decodeLines(t, &buf)
+	if assert.Len(t, entries, 1) {
+		_, err := time.ParseInLocation(time.DateTime, entries[0]["time"].(string), time.Local)
+		assert.NoError(t, err)
+	}
+	_, ok := (*l.dispatch.formatter.Load()).(*timeFormatter)
+	assert.False(t, ok)
+}
+
+func TestLogger_TimeFormatUnix(t *testing.T) {
+	tests := map[string]time.Duration{
+		TimeFormatUnix:      time.Second,
+		TimeFormatUnixMilli: time.Millisecond,
+		TimeFormatUnixNano:  time.Nanosecond,
+	}
+	for format, unit := range tests {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := newJSONTestLogger(&buf)
+			l.SetTimeFormat(format)
+			l.SetTimeKey("ts")
+			before := time.Now()
+			l.Infow(context.Background(), "message", "time", "field")
+			after := time.Now()
+
+			var entry map[string]any
+			decoder := json.NewDecoder(&buf)
+			decoder.UseNumber()
+			assert.NoError(t, decoder.Decode(&entry))
+			n, err := entry["ts"].(json.Number).Int64()
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, n, before.UnixNano()/int64(unit))
+			assert.LessOrEqual(t, n, after.UnixNano()/int64(unit))
+			assert.Equal(t, "message", entry["msg"])
+			assert.Equal(t, "field", entry["time"])
+		})
+	}
+}
+
+func TestLogger_TimeFormatUnixText(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetTimeFormat(TimeFormatUnix)
+	l.Info(context.Background(), "message")
+
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "time="+strconv.FormatInt(time.Now().Unix(), 10)[:8]), line)
+	assert.Contains(t, line, " level=info msg=message")
+}
+
+func TestLogger_TimeZone(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		ctx = context.Background()
+	)
+	l := newJSONTestLogger(&buf)
+	assert.NoError(t, l.SetConfigWithMap(map[string]any{
+		"time_zone":   "Asia/Tokyo",
+		"time_format": time.RFC3339,
+	}))
+	assert.Equal(t, "Asia/Tokyo", l.config.TimeZone)
+	l.Info(ctx, "json")
+	entries := decodeLines(t, &buf)
+	if assert.Len(t, entries, 1) {
+		assert.True(t, strings.HasSuffix(entries[0]["time"].(string), "+09:00"))
+	}
+
+	// the text and console formats also use the location
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.SetTimeZone(time.UTC)
+	l.Info(ctx, "text")
+	assert.Contains(t, buf.String(), `Z" level=info`)
+
+	buf.Reset()
+	l.SetFormat(FormatConsole)
+	l.Info(ctx, "console")
+	assert.Contains(t, buf.String(), "Z INFO")
+
+	l.SetTimeZone(nil)
+	assert.Empty(t, l.config.TimeZone)
+	assert.Error(t, l.SetConfigWithMap(map[string]any{"time_zone": "Mars/Olympus"}))
+}
@@ -0,0 +1,97 @@
+package mlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter is a writer that always fails.
+type failingWriter struct{}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestLogger_AddWriter(t *testing.T) {
+	var (
+		main  bytes.Buffer
+		errW  bytes.Buffer
+		debug bytes.Buffer
+		jsonW bytes.Buffer
+		ctx   = context.Background()
+	)
+	l := newTestLogger(&main)
+	l.SetLevel(DebugLevel)
+	l.AddWriter(&errW, WithMinLevel(ErrorLevel))
+	l.AddWriter(&debug, WithMaxLevel(DebugLevel))
+	l.AddWriter(&jsonW, WithMinLevel(InfoLevel), WithMaxLevel(WarnLevel), WithFormat("json"))
+
+	l.Debug(ctx, "debug message")
+	l.Info(ctx, "info message")
+	l.Warn(ctx, "warn message")
+	l.Error(ctx, "error message")
+
+	assert.Equal(t, 4, strings.Count(main.String(), "message"))
+	assert.Equal(t, 1, strings.Count(errW.String(), "message"))
+	assert.Contains(t, errW.String(), "error message")
+	assert.Equal(t, 1, strings.Count(debug.String(), "message"))
+	assert.Contains(t, debug.String(), "debug message")
+
+	// the format override applies to the writer only
+	lines := strings.Split(strings.TrimSpace(jsonW.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+	assert.False(t, json.Valid([]byte(strings.Split(main.String(), "\n")[0])))
+	assert.NotContains(t, main.String(), `"msg"`)
+
+	// removing a writer at runtime
+	assert.True(t, l.RemoveWriter(&errW))
+	assert.False(t, l.RemoveWriter(&errW))
+	l.Error(ctx, "after removal")
+	assert.NotContains(t, errW.String(), "after removal")
+	assert.Contains(t, main.String(), "after removal")
+}
+
+func TestLogger_AddWriterFailure(t *testing.T) {
+	var (
+		main   bytes.Buffer
+		other  bytes.Buffer
+		mu     sync.Mutex
+		failed []error
+	)
+	l := newTestLogger(&main)
+	failing := &failingWriter{}
+	l.AddWriter(failing)
+	l.AddWriter(&other)
+	l.SetWriterErrorHandler(func(w io.Writer, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Same(t, failing, w)
+		failed = append(failed, err)
+	})
+
+	l.Info(context.Background(), "still written")
+	assert.Contains(t, main.String(), "still written")
+	assert.Contains(t, other.String(), "still written")
+	assert.Len(t, failed, 1)
+	assert.EqualError(t, failed[0], "disk full")
+}
+
+func TestLogger_AddWriterChild(t *testing.T) {
+	var main, extra bytes.Buffer
+	l := newTestLogger(&main)
+	child := l.With(String("k", "v"))
+	l.AddWriter(&extra)
+	child.Info(context.Background(), "from child")
+	assert.Contains(t, extra.String(), "k=v")
+}